@@ -0,0 +1,184 @@
+// Package audit provides the audit command for querying the audit log of backport attempts.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+const shaTruncateLength = 12
+
+// Command is the audit command.
+var Command = &cli.Command{
+	Name:   "audit",
+	Usage:  "query the audit log of backport attempts",
+	Action: queryAudit,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo",
+			Usage: "only list attempts in this repository (owner/repo)",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "only list attempts to backport to this target branch",
+		},
+		&cli.IntFlag{
+			Name:  "pr",
+			Usage: "only list attempts to backport this PR",
+		},
+		&cli.StringFlag{
+			Name:  "sha",
+			Usage: "only list attempts whose source or backport commit starts with this SHA",
+		},
+		&cli.StringFlag{
+			Name:  "result",
+			Usage: "only list attempts with this result: success, empty, conflict, failed, skipped, deferred or pr_created",
+		},
+		&cli.StringFlag{
+			Name:  "actor",
+			Usage: "only list attempts by this actor",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "only list attempts made at or after this date (2006-01-02) or RFC 3339 timestamp",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "only list attempts made before this date (2006-01-02) or RFC 3339 timestamp",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the entries as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: "verify that the audit log was not modified",
+		},
+	},
+}
+
+// auditFilter builds the audit log filter from the command flags.
+func auditFilter(c *cli.Command) (backport.AuditFilter, error) {
+	filter := backport.AuditFilter{
+		Repo:   c.String("repo"),
+		Branch: c.String("branch"),
+		PR:     c.Int("pr"),
+		SHA:    c.String("sha"),
+		Result: c.String("result"),
+		Actor:  c.String("actor"),
+	}
+
+	var err error
+	if since := c.String("since"); since != "" {
+		if filter.Since, err = backport.ParseHistoryTime(since); err != nil {
+			return filter, err
+		}
+	}
+	if until := c.String("until"); until != "" {
+		if filter.Until, err = backport.ParseHistoryTime(until); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+func queryAudit(_ context.Context, c *cli.Command) error {
+	cfg, err := cliconfig.GetConfig(c)
+	if err != nil {
+		return err
+	}
+
+	path := backport.NewAuditLog(cfg.Audit.Path, "").Path()
+	entries, err := backport.ReadAuditLog(path)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	if c.Bool("verify") {
+		if err := backport.VerifyAuditLog(entries); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("✓ Audit log %s is intact (%d entries)\n", path, len(entries))
+		return nil
+	}
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return err
+	}
+	entries = backport.FilterAudit(entries, filter)
+
+	if c.Bool("json") {
+		if entries == nil {
+			entries = []backport.AuditEntry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		if !cfg.Audit.Enabled {
+			fmt.Println("No backport attempts found, enable the audit log with audit.enabled")
+			return nil
+		}
+		fmt.Println("No backport attempts found in audit log")
+		return nil
+	}
+
+	fmt.Printf("%-16s %-24s %-20s %-12s %-8s %-20s %-10s %-10s %s\n",
+		"TIMESTAMP", "REPO", "ACTOR", "SOURCE", "PR", "BRANCH", "RESULT", "BACKPORT", "BACKPORT PR")
+	fmt.Println("------------------------------------------------------------------------------------------------------------------------------------")
+
+	for _, entry := range entries {
+		backportStr := "-"
+		if entry.BackportSHA != "" {
+			backportStr = safeTruncate(entry.BackportSHA, shaTruncateLength)
+		}
+
+		fmt.Printf("%-16s %-24s %-20s %-12s %-8s %-20s %-10s %-10s %s\n",
+			entry.Time.Local().Format("2006-01-02 15:04"),
+			orDash(entry.Repo),
+			orDash(entry.Actor),
+			orDash(safeTruncate(entry.SourceSHA, shaTruncateLength)),
+			prString(entry.SourcePR),
+			entry.TargetBranch,
+			entry.Result,
+			backportStr,
+			prString(entry.BackportPR),
+		)
+	}
+
+	return nil
+}
+
+// prString formats a PR number, "-" if there is none.
+func prString(number int) string {
+	if number == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", number)
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func safeTruncate(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,164 @@
+// Package auth provides commands for storing forge tokens in the OS keyring.
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
+	pkgconfig "codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/credentials"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// Command is the auth command.
+var Command = &cli.Command{
+	Name:  "auth",
+	Usage: "manage the forge token stored in the OS keyring",
+	Commands: []*cli.Command{
+		loginCmd,
+		statusCmd,
+		logoutCmd,
+	},
+}
+
+var loginCmd = &cli.Command{
+	Name:   "login",
+	Usage:  "store a token for the configured forge in the OS keyring",
+	Action: login,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "with-token",
+			Usage: "read the token from stdin instead of prompting for it",
+		},
+	},
+}
+
+var statusCmd = &cli.Command{
+	Name:   "status",
+	Usage:  "show where the token of the configured forge is read from",
+	Action: status,
+}
+
+var logoutCmd = &cli.Command{
+	Name:   "logout",
+	Usage:  "remove the token of the configured forge from the OS keyring",
+	Action: logout,
+}
+
+// forgeConfig returns the config, failing if no forge is configured.
+func forgeConfig(c *cli.Command) (*pkgconfig.Config, error) {
+	cfg, err := cliconfig.GetConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if credentials.KeyringUser(cfg.ForgeType, cfg.ForgeURL()) == "" {
+		return nil, fmt.Errorf("forge not configured, set forge_type (and forgejo_url or gerrit_url for self-hosted forges) in the config or select a --profile")
+	}
+	return cfg, nil
+}
+
+func login(_ context.Context, c *cli.Command) error {
+	cfg, err := forgeConfig(c)
+	if err != nil {
+		return err
+	}
+	forgeName := credentials.KeyringUser(cfg.ForgeType, cfg.ForgeURL())
+
+	var token string
+	if c.Bool("with-token") {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read token from stdin: %w", err)
+		}
+		token = line
+	} else {
+		err := huh.NewInput().
+			Title(fmt.Sprintf("Token for %s", forgeName)).
+			EchoMode(huh.EchoModePassword).
+			Value(&token).
+			Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("no token given")
+	}
+	if err := credentials.Store(cfg.ForgeType, cfg.ForgeURL(), token); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Stored token for %s in the OS keyring\n", forgeName)
+	if envVar := tokenEnvVar(cfg); os.Getenv(envVar) != "" {
+		fmt.Printf("  %s is set and takes precedence over the keyring\n", envVar)
+	}
+	return nil
+}
+
+func status(ctx context.Context, c *cli.Command) error {
+	cfg, err := forgeConfig(c)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Forge: %s\n", credentials.KeyringUser(cfg.ForgeType, cfg.ForgeURL()))
+
+	token, err := credentials.Lookup(ctx, internal.CredentialOptions(cfg))
+	if errors.Is(err, credentials.ErrNoToken) {
+		fmt.Printf("✗ No token found, run `backporter auth login` or set %s\n", tokenEnvVar(cfg))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	switch token.Source {
+	case credentials.SourceEnv:
+		fmt.Printf("✓ Token from environment variable %s\n", token.Location)
+	case credentials.SourceCommand:
+		fmt.Printf("✓ Token from token_command %q\n", token.Location)
+	case credentials.SourceFile:
+		fmt.Printf("✓ Token from token_file %s\n", token.Location)
+	case credentials.SourceKeyring:
+		fmt.Printf("✓ Token from the OS keyring (%s)\n", token.Location)
+	}
+	return nil
+}
+
+func logout(_ context.Context, c *cli.Command) error {
+	cfg, err := forgeConfig(c)
+	if err != nil {
+		return err
+	}
+	forgeName := credentials.KeyringUser(cfg.ForgeType, cfg.ForgeURL())
+
+	removed, err := credentials.Delete(cfg.ForgeType, cfg.ForgeURL())
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Printf("No token for %s in the OS keyring\n", forgeName)
+		return nil
+	}
+
+	fmt.Printf("✓ Removed token for %s from the OS keyring\n", forgeName)
+	return nil
+}
+
+// tokenEnvVar returns the environment variable holding the token of the configured forge.
+func tokenEnvVar(cfg *pkgconfig.Config) string {
+	if cfg.TokenEnv != "" {
+		return cfg.TokenEnv
+	}
+	return forge.TokenEnvVar(cfg.ForgeType)
+}
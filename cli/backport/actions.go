@@ -0,0 +1,156 @@
+package backport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// actionsRunner returns the Actions implementation running backporter, "forgejo", "gitea" or
+// "github", or "" outside of Actions. Forgejo and Gitea runners set GITHUB_ACTIONS as well.
+func actionsRunner() string {
+	switch {
+	case os.Getenv("FORGEJO_ACTIONS") == "true":
+		return "forgejo"
+	case os.Getenv("GITEA_ACTIONS") == "true":
+		return "gitea"
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return "github"
+	default:
+		return ""
+	}
+}
+
+// actionsFile returns the path in env of a file the Actions runner reads after the step,
+// e.g. GITHUB_STEP_SUMMARY. All runners use the GitHub names for these files.
+func actionsFile(env string) string {
+	if actionsRunner() == "" {
+		return ""
+	}
+	return os.Getenv(env)
+}
+
+// actionsResult is a CI backport result in the results output.
+type actionsResult struct {
+	TargetBranch string `json:"target_branch"`
+	Status       string `json:"status"`
+	PRNumber     int    `json:"pr,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// resultState returns the status of a CI backport result in the outputs: deferred, skipped, success or failed.
+func resultState(r backportci.Result) string {
+	switch {
+	case r.Deferred:
+		return "deferred"
+	case r.Skipped:
+		return "skipped"
+	case r.Success:
+		return "success"
+	default:
+		return "failed"
+	}
+}
+
+// formatActionsSummary returns the backport results as a Markdown table for the step summary.
+func formatActionsSummary(results []backportci.Result, title string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "### %s\n\n", title)
+	sb.WriteString("| Branch | Status | Details |\n")
+	sb.WriteString("|--------|--------|---------|\n")
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[resultState(r)]++
+		fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", r.TargetBranch, resultStatus(r), resultDetails(r))
+	}
+
+	fmt.Fprintf(&sb, "\n**Total:** %d succeeded, %d failed, %d skipped", counts["success"], counts["failed"], counts["skipped"])
+	if counts["deferred"] > 0 {
+		fmt.Fprintf(&sb, ", %d deferred", counts["deferred"])
+	}
+	sb.WriteString("\n\n")
+
+	return sb.String()
+}
+
+// formatActionsOutputs returns the step outputs of the backport results in the format of GITHUB_OUTPUT.
+func formatActionsOutputs(results []backportci.Result) (string, error) {
+	counts := make(map[string]int)
+	var prs []string
+	entries := make([]actionsResult, 0, len(results))
+	for _, r := range results {
+		state := resultState(r)
+		counts[state]++
+		if r.PRNumber > 0 && !r.Skipped {
+			prs = append(prs, strconv.Itoa(r.PRNumber))
+		}
+		entry := actionsResult{TargetBranch: r.TargetBranch, Status: state, PRNumber: r.PRNumber}
+		if r.Error != nil {
+			entry.Error = logger.RedactError(r.Error)
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal backport results: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, state := range []string{"success", "failed", "skipped", "deferred"} {
+		name := state
+		if state == "success" {
+			name = "succeeded"
+		}
+		fmt.Fprintf(&sb, "%s=%d\n", name, counts[state])
+	}
+	fmt.Fprintf(&sb, "prs=%s\n", strings.Join(prs, ","))
+	fmt.Fprintf(&sb, "results=%s\n", data)
+
+	return sb.String(), nil
+}
+
+// writeActionsSummary appends the backport results to the step summary when running in Actions.
+// Failing to write it is logged but does not fail the run.
+func writeActionsSummary(results []backportci.Result, title string) {
+	if path := actionsFile("GITHUB_STEP_SUMMARY"); path != "" {
+		appendActionsFile(path, formatActionsSummary(results, title))
+	}
+}
+
+// writeActionsOutputs sets the step outputs of the backport results when running in Actions.
+// Failing to set them is logged but does not fail the run.
+func writeActionsOutputs(results []backportci.Result) {
+	path := actionsFile("GITHUB_OUTPUT")
+	if path == "" {
+		return
+	}
+	outputs, err := formatActionsOutputs(results)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to set step outputs")
+		return
+	}
+	appendActionsFile(path, outputs)
+}
+
+// appendActionsFile appends content to a file read by the Actions runner.
+func appendActionsFile(path, content string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Warn().Err(err).Str("runner", actionsRunner()).Str("path", path).Msg("failed to open Actions file")
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		log.Warn().Err(err).Str("runner", actionsRunner()).Str("path", path).Msg("failed to write Actions file")
+	}
+}
@@ -0,0 +1,86 @@
+package backport
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+)
+
+func TestActionsRunner(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "none", want: ""},
+		{name: "github", env: map[string]string{"GITHUB_ACTIONS": "true"}, want: "github"},
+		{name: "forgejo", env: map[string]string{"GITHUB_ACTIONS": "true", "FORGEJO_ACTIONS": "true"}, want: "forgejo"},
+		{name: "gitea", env: map[string]string{"GITHUB_ACTIONS": "true", "GITEA_ACTIONS": "true"}, want: "gitea"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_ACTIONS", "FORGEJO_ACTIONS", "GITEA_ACTIONS"} {
+				t.Setenv(name, tc.env[name])
+			}
+			assert.Equal(t, tc.want, actionsRunner())
+		})
+	}
+}
+
+func TestFormatActionsOutputs(t *testing.T) {
+	outputs, err := formatActionsOutputs([]backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 12},
+		{TargetBranch: "release-2.x", Skipped: true, PRNumber: 9, Error: errors.New("already exists")},
+		{TargetBranch: "release-3.x", Conflict: true, Error: errors.New("conflict")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "succeeded=1\nfailed=1\nskipped=1\ndeferred=0\nprs=12\n"+
+		`results=[{"target_branch":"release-1.x","status":"success","pr":12},`+
+		`{"target_branch":"release-2.x","status":"skipped","pr":9,"error":"already exists"},`+
+		`{"target_branch":"release-3.x","status":"failed","error":"conflict"}]`+"\n", outputs)
+}
+
+func TestWriteActionsFiles(t *testing.T) {
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "summary.md")
+	output := filepath.Join(dir, "output")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("FORGEJO_ACTIONS", "true")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+	t.Setenv("GITHUB_OUTPUT", output)
+
+	results := []backportci.Result{{TargetBranch: "release-1.x", Success: true, PRNumber: 12}}
+	writeActionsSummary(results, "Backport Summary for PR #7")
+	writeActionsSummary(results, "Backport Summary for PR #8")
+	writeActionsOutputs(results)
+
+	data, err := os.ReadFile(summary)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "### Backport Summary for PR #7\n")
+	assert.Contains(t, string(data), "### Backport Summary for PR #8\n")
+	assert.Contains(t, string(data), "| `release-1.x` | ✓ Success | backport PR #12 |\n")
+	assert.Contains(t, string(data), "**Total:** 1 succeeded, 0 failed, 0 skipped\n")
+
+	data, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "succeeded=1\n")
+	assert.Contains(t, string(data), "prs=12\n")
+}
+
+func TestWriteActionsFilesOutsideActions(t *testing.T) {
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	for _, name := range []string{"GITHUB_ACTIONS", "FORGEJO_ACTIONS", "GITEA_ACTIONS"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	writeActionsSummary([]backportci.Result{{TargetBranch: "release-1.x", Success: true}}, "Backport Summary for PR #7")
+
+	assert.NoFileExists(t, summary)
+}
@@ -5,8 +5,95 @@ import (
 	"context"
 
 	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/git"
 )
 
+// emptyFlag controls how commits that become empty on the target are handled.
+var emptyFlag = &cli.StringFlag{
+	Name:  "empty",
+	Usage: "how to handle commits that become empty on the target: drop, keep or stop (requires git >= 2.45)",
+	Validator: func(s string) error {
+		return git.ValidateEmptyMode(s)
+	},
+}
+
+// keepRedundantCommitsFlag keeps commits that become empty on the target.
+var keepRedundantCommitsFlag = &cli.BoolFlag{
+	Name:  "keep-redundant-commits",
+	Usage: "keep commits that become empty on the target as empty commits",
+}
+
+// strategyFlag selects how PRs merged via a merge commit are backported.
+var strategyFlag = &cli.StringFlag{
+	Name:  "strategy",
+	Usage: "how to backport PRs that were not squash merged: squash (refuse), mainline (cherry-pick the merge commit with -m 1) or commits (cherry-pick the individual PR commits)",
+	Value: backport.StrategyCommits,
+	Validator: func(s string) error {
+		return backport.ValidateStrategy(s)
+	},
+}
+
+// mergeStrategyFlag selects the merge strategy of the cherry-picks.
+var mergeStrategyFlag = &cli.StringFlag{
+	Name:  "merge-strategy",
+	Usage: "merge strategy of the cherry-picks: ort, recursive or resolve (git default if unset)",
+	Validator: func(s string) error {
+		return git.ValidateMergeStrategy(s)
+	},
+}
+
+// strategyOptionFlag passes options to the merge strategy of the cherry-picks.
+var strategyOptionFlag = &cli.StringSliceFlag{
+	Name:    "strategy-option",
+	Aliases: []string{"X"},
+	Usage:   "option of the merge strategy, e.g. theirs, ours or ignore-space-change, to resolve trivial conflicts automatically (repeatable, see git cherry-pick -X)",
+}
+
+// pathsFlag limits backports to the changes of some paths.
+var pathsFlag = &cli.StringSliceFlag{
+	Name:  "paths",
+	Usage: "only backport the changes of files matching these glob patterns, e.g. 'src/server/**' (repeatable)",
+}
+
+// worktreeFlag runs backports in a temporary git worktree.
+var worktreeFlag = &cli.BoolFlag{
+	Name:  "worktree",
+	Usage: "run the backport in a temporary git worktree, leaving the current checkout and uncommitted changes untouched",
+}
+
+// forceFlag backports commits again that were already backported to the target.
+var forceFlag = &cli.BoolFlag{
+	Name:  "force",
+	Usage: "backport commits that were already backported to the target branch again, only warning about the duplicate",
+}
+
+// interactiveHunksFlag lets the user pick the hunks of a backport to keep.
+var interactiveHunksFlag = &cli.BoolFlag{
+	Name:  "interactive-hunks",
+	Usage: "select the hunks of the cherry-picked change to keep (like git add -p), discarding the rest",
+}
+
+// baseFlag overrides the ref backport branches are created from.
+var baseFlag = &cli.StringFlag{
+	Name:  "base",
+	Usage: "ref to create backport branches from instead of <remote>/<target-branch>, e.g. an integration branch; {target} is replaced by the target branch",
+}
+
+// concurrencyFlag bounds the number of target branches backported in parallel.
+var concurrencyFlag = &cli.IntFlag{
+	Name:  "concurrency",
+	Usage: "number of target branches to backport in parallel, each in its own temporary worktree (CI mode only)",
+	Value: 1,
+}
+
+// draftFlag opens backport PRs as drafts.
+var draftFlag = &cli.BoolFlag{
+	Name:  "draft",
+	Usage: "open backport PRs as drafts (Forgejo: with a \"WIP:\" title)",
+}
+
 // Command is the root backport command.
 var Command = &cli.Command{
 	Name:  "backport",
@@ -14,6 +101,13 @@ var Command = &cli.Command{
 	Commands: []*cli.Command{
 		prCmd,
 		commitCmd,
+		continueCmd,
+		statusCmd,
+		checkCmd,
+		exportCmd,
+		applyCmd,
+		undoCmd,
+		milestoneCmd,
 	},
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -24,9 +118,57 @@ var Command = &cli.Command{
 			Name:  "dry-run",
 			Usage: "show what would be done without making changes (CI mode only)",
 		},
+		&cli.BoolFlag{
+			Name:  "comment",
+			Usage: "react to a \"/backport <branch>\" PR comment instead of the latest merge (CI mode only)",
+		},
+		&cli.StringFlag{
+			Sources: cli.EnvVars("GITHUB_EVENT_PATH"),
+			Name:    "event-path",
+			Usage:   "path to the issue_comment event payload used with --comment",
+		},
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		mergeStrategyFlag,
+		strategyOptionFlag,
+		strategyFlag,
+		baseFlag,
+		concurrencyFlag,
+		draftFlag,
+		&cli.BoolFlag{
+			Name:  "deferred",
+			Usage: "process the backports deferred during quiet hours, e.g. from a scheduled job (CI mode only)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-stale-cache",
+			Usage: "fail instead of using cached PR data when the forge is unavailable (CI mode only)",
+		},
+		&cli.BoolFlag{
+			Sources: cli.EnvVars("BACKPORTER_CLONE"),
+			Name:    "clone",
+			Usage:   "clone the repository into a temporary directory (shallow, authenticated with the forge token) instead of using the checkout (CI mode only)",
+		},
+		&cli.StringFlag{
+			Sources: cli.EnvVars("BACKPORTER_CLONE_URL"),
+			Name:    "clone-url",
+			Usage:   "HTTPS URL of the repository to clone with --clone, detected from the CI environment if unset",
+		},
 	},
 	Action: func(ctx context.Context, c *cli.Command) error {
 		if c.Bool("ci") {
+			if c.Bool("clone") {
+				cleanup, err := enterCIClone(ctx, c)
+				if err != nil {
+					return err
+				}
+				defer cleanup()
+			}
+			if c.Bool("comment") {
+				return backportComment(ctx, c)
+			}
+			if c.Bool("deferred") {
+				return backportDeferred(ctx, c)
+			}
 			return backportCI(ctx, c)
 		}
 		// No --ci flag and no subcommand: show help
@@ -44,18 +186,47 @@ var prCmd = &cli.Command{
 			Name:  "dry-run",
 			Usage: "show what would be done without making changes",
 		},
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		mergeStrategyFlag,
+		strategyOptionFlag,
+		worktreeFlag,
+		interactiveHunksFlag,
+		pathsFlag,
+		strategyFlag,
+		forceFlag,
+		&cli.BoolFlag{
+			Name:  "create-pr",
+			Usage: "push the backport to its own branch and open a PR against the target branch (prompts if not set)",
+		},
+		baseFlag,
+		draftFlag,
 	},
 }
 
 var commitCmd = &cli.Command{
 	Name:      "commit",
 	Usage:     "backport a commit",
-	ArgsUsage: "<commit-sha> <target-branch>",
+	ArgsUsage: "<commit> <target-branch>",
 	Action:    backportCommit,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:  "dry-run",
 			Usage: "show what would be done without making changes",
 		},
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		mergeStrategyFlag,
+		strategyOptionFlag,
+		worktreeFlag,
+		interactiveHunksFlag,
+		pathsFlag,
+		forceFlag,
 	},
 }
+
+var continueCmd = &cli.Command{
+	Name:   "continue",
+	Usage:  "finish a backport after resolving cherry-pick conflicts",
+	Action: backportContinue,
+}
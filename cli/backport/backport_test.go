@@ -1,9 +1,13 @@
 package backport
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
 )
 
 func TestLooksLikeSHA(t *testing.T) {
@@ -35,3 +39,89 @@ func TestLooksLikeSHA(t *testing.T) {
 		})
 	}
 }
+
+func TestGuessRefKind(t *testing.T) {
+	commits := map[string]bool{"1234": true, "origin/main~2": true}
+	isCommit := func(ref string) bool { return commits[ref] }
+
+	tests := []struct {
+		input   string
+		kind    string
+		wantErr bool
+	}{
+		{"42", internal.RefPR, false},
+		{"1234567", internal.RefPR, false},
+		{"1234", "", true}, // Numeric abbreviated SHA, ambiguous.
+		{"abc1234", internal.RefCommit, false},
+		{"origin/main~2", internal.RefCommit, false},
+		{"release", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			kind, err := guessRefKind(tt.input, isCommit)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+		})
+	}
+}
+
+func TestBackportToBranches(t *testing.T) {
+	var visited []string
+	run := func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+		visited = append(visited, opts.TargetBranch)
+		if opts.TargetBranch == "broken" {
+			return nil, errors.New("boom")
+		}
+		return &backport.BackportResult{TargetBranch: opts.TargetBranch, Success: true}, nil
+	}
+
+	results, err := backportToBranches("commit abc1234", []string{"v1.x", "v2.x"}, run)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1.x", "v2.x"}, visited)
+	assert.Len(t, results, 2)
+
+	// Failures do not stop the remaining branches.
+	visited = nil
+	_, err = backportToBranches("commit abc1234", []string{"broken", "v2.x"}, run)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"broken", "v2.x"}, visited)
+}
+
+func TestNextStepOptions(t *testing.T) {
+	results := []*backport.BackportResult{
+		{TargetBranch: "v1.x", BackportSHA: "abc1234def", PRNumber: 42},
+		{TargetBranch: "v2.x", BackportSHA: "def5678abc"},
+	}
+
+	values := func(pushed, opened map[string]bool, clipboard bool) []string {
+		var v []string
+		for _, o := range nextStepOptions(results, pushed, opened, clipboard) {
+			v = append(v, o.Value)
+		}
+		return v
+	}
+
+	assert.Equal(t, []string{
+		"push:v1.x", "pr:v1.x", "copy:v1.x",
+		"push:v2.x", "copy:v2.x",
+		"another", "quit",
+	}, values(nil, nil, true))
+
+	// Pushed branches can only be copied, branches moved into a PR are gone.
+	assert.Equal(t, []string{
+		"copy:v2.x",
+		"another", "quit",
+	}, values(map[string]bool{"v2.x": true}, map[string]bool{"v1.x": true}, true))
+
+	// Copying needs stdout to be a terminal.
+	assert.Equal(t, []string{
+		"push:v1.x", "pr:v1.x",
+		"push:v2.x",
+		"another", "quit",
+	}, values(nil, nil, false))
+}
@@ -0,0 +1,169 @@
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// Badge output formats.
+const (
+	badgeFormatJSON = "json"
+	badgeFormatSVG  = "svg"
+)
+
+// BadgeCommand prints a badge with the backport health of a target branch.
+var BadgeCommand = &cli.Command{
+	Name:      "badge",
+	Usage:     "print a badge with the pending and completed backports of a target branch (shields.io endpoint JSON or SVG)",
+	ArgsUsage: "<target-branch>",
+	Action:    printBadge,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "badge format: json (shields.io endpoint) or svg",
+			Value: badgeFormatJSON,
+			Validator: func(s string) error {
+				if s != badgeFormatJSON && s != badgeFormatSVG {
+					return fmt.Errorf("invalid format: %s (must be 'json' or 'svg')", s)
+				}
+				return nil
+			},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "write the badge to a file instead of stdout",
+		},
+	},
+}
+
+// badgeEndpoint is a badge in the shields.io endpoint format.
+type badgeEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeColors maps the badge colors to their shields.io hex values for SVG rendering.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"yellow":      "#dfb317",
+	"red":         "#e05d44",
+}
+
+func printBadge(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("usage: badge <target-branch>")
+	}
+	branch := c.Args().First()
+
+	service, cfg, forgeClient, _, _, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// Backports that landed carry the backport trailers, local and CI backports alike, or the
+	// free-text signature of older versions. Prefer the published branch.
+	ref := cfg.WriteRemote() + "/" + branch
+	if !git.CommitExists(ref) {
+		ref = branch
+	}
+	completed, err := git.CountCommitsByMessage(ref, version.TrailerBackportedFrom+": ", version.SignatureMarker(""))
+	if err != nil {
+		return err
+	}
+
+	pending, conflicts := 0, 0
+	if forgeClient != nil {
+		owner, repoName := service.PushRepo()
+		prs, err := forgeClient.ListOpenPRs(ctx, owner, repoName, forge.ListPROptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list open PRs: %w", err)
+		}
+		pending, conflicts = countPendingBackports(prs, branch)
+	}
+
+	var out []byte
+	badge := newBadge(branch, pending, conflicts, completed)
+	if c.String("format") == badgeFormatSVG {
+		out = []byte(renderBadgeSVG(badge))
+	} else if out, err = json.Marshal(badge); err != nil {
+		return err
+	}
+
+	if path := c.String("output"); path != "" {
+		return os.WriteFile(path, out, 0o644)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// countPendingBackports counts the open backport PRs against a branch and those with conflicts.
+func countPendingBackports(prs []*forge.PRInfo, branch string) (int, int) {
+	pending, conflicts := 0, 0
+	for _, pr := range prs {
+		_, target, ok := parseBackportBranchName(pr.HeadBranch)
+		if !ok || target != branch {
+			continue
+		}
+		pending++
+		if slices.Contains(pr.Labels, backportci.ConflictLabel) {
+			conflicts++
+		}
+	}
+	return pending, conflicts
+}
+
+// newBadge returns the badge of a branch: green without pending backports, yellow with
+// pending ones and red if any of them has conflicts.
+func newBadge(branch string, pending, conflicts, completed int) badgeEndpoint {
+	badge := badgeEndpoint{
+		SchemaVersion: 1,
+		Label:         "backports " + branch,
+		Message:       fmt.Sprintf("%d backported", completed),
+		Color:         "brightgreen",
+	}
+	if pending > 0 {
+		badge.Message = fmt.Sprintf("%d pending, %d backported", pending, completed)
+		badge.Color = "yellow"
+	}
+	if conflicts > 0 {
+		badge.Color = "red"
+	}
+	return badge
+}
+
+// renderBadgeSVG renders a flat badge, with text widths estimated from the character count.
+func renderBadgeSVG(badge badgeEndpoint) string {
+	const charWidth, padding = 7, 10
+	labelWidth := len(badge.Label)*charWidth + padding
+	messageWidth := len(badge.Message)*charWidth + padding
+	width := labelWidth + messageWidth
+	labelX, messageX := labelWidth/2, labelWidth+messageWidth/2 //nolint:mnd
+	label, message := html.EscapeString(badge.Label), html.EscapeString(badge.Message)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, message)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="#555"/>`, labelWidth)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, messageWidth, badgeColors[badge.Color])
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, labelX, label)
+	fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, messageX, message)
+	b.WriteString(`</g></svg>`)
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package backport
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/schema"
+)
+
+func TestCountPendingBackports(t *testing.T) {
+	prs := []*forge.PRInfo{
+		{HeadBranch: backportci.BranchName(1, "release-1.x")},
+		{HeadBranch: backportci.BranchName(2, "release-1.x"), Labels: []string{backportci.ConflictLabel}},
+		{HeadBranch: backportci.BranchName(3, "release-2.x")},
+		{HeadBranch: "feature"},
+	}
+
+	pending, conflicts := countPendingBackports(prs, "release-1.x")
+	assert.Equal(t, 2, pending)
+	assert.Equal(t, 1, conflicts)
+}
+
+func TestNewBadge(t *testing.T) {
+	badge := newBadge("release-1.x", 0, 0, 12)
+	assert.Equal(t, badgeEndpoint{SchemaVersion: 1, Label: "backports release-1.x", Message: "12 backported", Color: "brightgreen"}, badge)
+
+	badge = newBadge("release-1.x", 2, 0, 12)
+	assert.Equal(t, "2 pending, 12 backported", badge.Message)
+	assert.Equal(t, "yellow", badge.Color)
+
+	assert.Equal(t, "red", newBadge("release-1.x", 2, 1, 12).Color)
+}
+
+func TestBadgeMatchesSchema(t *testing.T) {
+	for _, badge := range []badgeEndpoint{newBadge("main", 0, 0, 1), newBadge("main", 1, 0, 1), newBadge("main", 1, 1, 1)} {
+		data, err := json.Marshal(badge)
+		require.NoError(t, err)
+		assert.NoError(t, schema.Validate(schema.Badge, data))
+	}
+}
+
+func TestRenderBadgeSVG(t *testing.T) {
+	svg := renderBadgeSVG(newBadge("<main>", 1, 0, 3))
+	assert.Contains(t, svg, `fill="#dfb317"`)
+	assert.Contains(t, svg, "1 pending, 3 backported")
+	assert.Contains(t, svg, "backports &lt;main&gt;")
+}
@@ -0,0 +1,129 @@
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+var checkCmd = &cli.Command{
+	Name:      "check",
+	Usage:     "predict conflicts of backporting a commit or PR to each target branch, without changing any branch",
+	ArgsUsage: "<commit-sha|pr-number> [target-branch...]",
+	Action:    backportCheck,
+	Flags: []cli.Flag{
+		strategyFlag,
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the predicted outcomes as JSON",
+		},
+	},
+}
+
+func backportCheck(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("usage: backport check <commit-sha|pr-number> [target-branch...]")
+	}
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	sha, prNumber, err := internal.ResolveCommitOrPR(ctx, service, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	targetBranches := c.Args().Tail()
+	if len(targetBranches) == 0 {
+		targetBranches, err = service.TargetBranches()
+		if err != nil {
+			return err
+		}
+		if len(targetBranches) == 0 {
+			return fmt.Errorf("no target branches given or configured in target_branches")
+		}
+	}
+
+	var results []backport.CheckResult
+	if prNumber > 0 {
+		results, err = service.CheckPR(ctx, prNumber, c.String("strategy"), targetBranches)
+	} else {
+		results, err = service.CheckCommit(ctx, sha, targetBranches)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		subject := backport.ShortSHA(sha)
+		if prNumber > 0 {
+			subject = fmt.Sprintf("PR #%d", prNumber)
+		}
+		fmt.Printf("Predicted backports of %s:\n\n", subject)
+		fmt.Print(formatCheckMatrix(results))
+	}
+
+	return checkError(results)
+}
+
+// formatCheckMatrix renders the predicted outcome per target branch as a table.
+func formatCheckMatrix(results []backport.CheckResult) string {
+	width := len("TARGET")
+	for _, r := range results {
+		width = max(width, len(r.TargetBranch))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s  %-8s  %s\n", width, "TARGET", "OUTCOME", "DETAILS")
+	for _, r := range results {
+		details := ""
+		switch r.Outcome {
+		case backport.CheckConflict:
+			details = fmt.Sprintf("%s: %s", backport.ShortSHA(r.ConflictSHA), strings.Join(r.Conflicts, ", "))
+		case backport.CheckEmpty:
+			details = "already on the target branch"
+		case backport.CheckError:
+			details = r.Error
+		}
+		row := fmt.Sprintf("%-*s  %-8s  %s", width, r.TargetBranch, r.Outcome, details)
+		sb.WriteString(strings.TrimRight(row, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// checkError returns an error wrapping backport.ErrConflict if the change conflicts with a
+// target branch, so scripts can tell from the exit code. Errors take precedence.
+func checkError(results []backport.CheckResult) error {
+	var conflicts, errors int
+	for _, r := range results {
+		switch r.Outcome {
+		case backport.CheckConflict:
+			conflicts++
+		case backport.CheckError:
+			errors++
+		}
+	}
+	switch {
+	case errors > 0:
+		return fmt.Errorf("%d of %d target branches could not be checked", errors, len(results))
+	case conflicts > 0:
+		return fmt.Errorf("%d of %d target branches would stop on %w", conflicts, len(results), backport.ErrConflict)
+	default:
+		return nil
+	}
+}
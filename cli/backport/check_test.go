@@ -0,0 +1,25 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+func TestFormatCheckMatrix(t *testing.T) {
+	results := []backport.CheckResult{
+		{TargetBranch: "release-1.x", Outcome: backport.CheckClean},
+		{TargetBranch: "release-2.x", Outcome: backport.CheckConflict, ConflictSHA: "abc1234def", Conflicts: []string{"a.go", "b.go"}},
+		{TargetBranch: "v3", Outcome: backport.CheckEmpty},
+	}
+	assert.Equal(t, "TARGET       OUTCOME   DETAILS\n"+
+		"release-1.x  clean\n"+
+		"release-2.x  conflict  abc1234: a.go, b.go\n"+
+		"v3           empty     already on the target branch\n", formatCheckMatrix(results))
+
+	assert.ErrorIs(t, checkError(results), backport.ErrConflict)
+	assert.NoError(t, checkError(results[:1]))
+	assert.NotErrorIs(t, checkError(append(results, backport.CheckResult{Outcome: backport.CheckError})), backport.ErrConflict)
+}
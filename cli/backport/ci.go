@@ -2,88 +2,42 @@ package backport
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"os"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 
 	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
 	"codefloe.com/pat-s/backporter/pkg/forge"
 	"codefloe.com/pat-s/backporter/pkg/git"
 	"codefloe.com/pat-s/backporter/shared/logger"
 )
 
-// CIResult represents the result of a CI backport operation for a single branch.
-type CIResult struct {
-	TargetBranch string
-	Success      bool
-	PRNumber     int  // The created backport PR number
-	Skipped      bool // True if backport PR already exists
-	Error        error
-	Message      string
-}
-
-// convCommitPattern matches conventional commit prefixes.
-var convCommitPattern = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]+\))?:\s`)
-
-// prNumberPatterns match PR numbers in commit messages.
-var prNumberPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`\(#(\d+)\)`),                // Squash merge: "feat: something (#123)"
-	regexp.MustCompile(`Merge pull request #(\d+)`), // GitHub merge commit
-	regexp.MustCompile(`Merge branch.*#(\d+)`),      // Alternative merge format
-	regexp.MustCompile(`See merge request.*!(\d+)`), // GitLab style
-	regexp.MustCompile(`Reviewed-on:.*pull/(\d+)`),  // Forgejo/Gitea style
-}
-
 func backportCI(ctx context.Context, c *cli.Command) error {
-	// 1. Verify CI environment.
-	if !logger.IsCI() {
-		return fmt.Errorf("CI mode requires CI environment variable to be set")
-	}
-
 	dryRun := c.Bool("dry-run")
 
-	log.Info().Msg("running in CI mode")
-
-	// 2. Create service to get config and forge client.
-	_, cfg, forgeClient, owner, repoName, err := internal.CreateServiceWithDetails(ctx, c)
+	// 1-4. Verify the CI environment, set up git and fetch from the remote.
+	service, cfg, forgeClient, owner, repoName, err := prepareCI(ctx, c)
 	if err != nil {
 		return err
 	}
 
-	// 3. Configure git user if not already set.
-	configured, err := git.ConfigureUserForCI(cfg.ForgeType)
-	if err != nil {
-		return fmt.Errorf("failed to configure git user: %w", err)
-	}
-	if configured {
-		log.Debug().Str("forge", cfg.ForgeType).Msg("configured git user for CI")
-	}
-
-	// 4. Fetch from remote to ensure we have the latest commits.
-	log.Debug().Str("remote", cfg.Remote).Msg("fetching from remote")
-	if err := git.Fetch(cfg.Remote); err != nil {
-		return fmt.Errorf("failed to fetch from remote: %w", err)
-	}
+	runner := newRunner(c, cfg, service, forgeClient)
 
-	// 5. Get the most recent commit on the default branch from remote.
+	// 5-6. Parse the PR number from the most recent commit on the default branch of the remote.
 	defaultBranch := cfg.DefaultBranch
 	if defaultBranch == "" {
 		defaultBranch = "main"
 	}
-	remoteRef := fmt.Sprintf("%s/%s", cfg.Remote, defaultBranch)
-
-	commitMsg, err := git.GetCommitMessage(remoteRef)
+	prNumber, err := runner.DetectPR(fmt.Sprintf("%s/%s", cfg.Remote, defaultBranch))
 	if err != nil {
-		return fmt.Errorf("failed to get commit message from %s: %w", remoteRef, err)
+		return err
 	}
-
-	log.Debug().Str("ref", remoteRef).Str("message", commitMsg).Msg("default branch commit message")
-
-	// 6. Parse PR number from commit message.
-	prNumber := parsePRNumber(commitMsg)
 	if prNumber == 0 {
 		log.Info().Msg("no PR number found in commit message, skipping backport")
 		return nil
@@ -92,7 +46,7 @@ func backportCI(ctx context.Context, c *cli.Command) error {
 	log.Info().Int("pr", prNumber).Msg("found PR number in commit")
 
 	// 7. Fetch PR info including labels.
-	prInfo, err := forgeClient.GetPR(ctx, owner, repoName, prNumber)
+	prInfo, err := getPR(ctx, forgeClient, newPRCache(cfg), owner, repoName, prNumber, !c.Bool("no-stale-cache"))
 	if err != nil {
 		return fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
 	}
@@ -107,258 +61,247 @@ func backportCI(ctx context.Context, c *cli.Command) error {
 
 	log.Info().Msg("PR has backport label, proceeding with backport")
 
-	// 9. Get target branches from config.
-	targetBranches := cfg.TargetBranches
-	if len(targetBranches) == 0 {
+	// 9. Get target branches from config, expanding patterns against the fetched remote branches.
+	if len(cfg.TargetBranches) == 0 {
 		return fmt.Errorf("no target branches configured in config file")
 	}
+	targetBranches, err := service.TargetBranches()
+	if err != nil {
+		return err
+	}
 
 	log.Info().Strs("branches", targetBranches).Msg("target branches")
 
-	// 10. Extract conventional commit prefix from PR title.
-	prefix := extractConvCommitPrefix(prInfo.Title)
-	if prefix == "" {
-		prefix = cfg.CI.DefaultPrefix
-		log.Debug().Str("prefix", prefix).Msg("using default prefix")
-	} else {
-		log.Debug().Str("prefix", prefix).Msg("extracted prefix from PR title")
+	// Reverts are only backported to branches that the reverted change landed on.
+	targetBranches, skipped := skipRevertTargets(service, cfg.WriteRemote(), prInfo.MergeCommit, targetBranches)
+
+	// Nothing is pushed during quiet hours, the backports are queued for `backport --ci --deferred`.
+	if window := runner.QuietWindow(); window != nil {
+		results := append(skipped, deferBackports(cfg, owner, repoName, prNumber, targetBranches, window, dryRun)...)
+		runner.RecordAudit(prInfo, results)
+		outputCISummary(results, prNumber)
+		writeActionsOutputs(results)
+		if !dryRun {
+			notifyResults(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI.Notify)
+		}
+		return nil
 	}
 
-	// 11. Process each target branch.
-	var results []CIResult
-	for _, targetBranch := range targetBranches {
-		result := processCIBackport(ctx, forgeClient, owner, repoName, prInfo, targetBranch, prefix, cfg.Remote, dryRun)
-		results = append(results, result)
-	}
+	// 10-11. Backport to each target branch.
+	followUps := followUpFixes(backport.FindFollowUps(ctx, forgeClient, owner, repoName, prInfo))
+	runner.RecordAudit(prInfo, skipped)
+	results := append(skipped, runner.Backport(ctx, prInfo, followUps, targetBranches)...)
 
 	// 12. Output summary.
 	outputCISummary(results, prNumber)
+	writeActionsOutputs(results)
 
-	// Check if any failed.
-	for _, r := range results {
-		if r.Error != nil && !r.Skipped {
-			return fmt.Errorf("some backports failed")
-		}
+	// 13. Notify about the results on the original PR and label it.
+	if !dryRun {
+		notifyResults(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI.Notify)
+		labelOriginalPR(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI)
 	}
 
-	return nil
+	return backportci.FailedError(results)
 }
 
-// parsePRNumber extracts PR number from a commit message.
-func parsePRNumber(message string) int {
-	for _, pattern := range prNumberPatterns {
-		matches := pattern.FindStringSubmatch(message)
-		if len(matches) >= 2 { //nolint:mnd
-			var num int
-			if _, err := fmt.Sscanf(matches[1], "%d", &num); err == nil && num > 0 {
-				return num
-			}
-		}
+// prepareCI verifies the CI environment, creates the service and forge client,
+// configures the git user and fetches from the remote.
+func prepareCI(ctx context.Context, c *cli.Command) (
+	*backport.Service,
+	*config.Config,
+	forge.Forge,
+	string,
+	string,
+	error,
+) {
+	// 1. Verify CI environment.
+	if !logger.IsCI() {
+		return nil, nil, nil, "", "", fmt.Errorf("CI mode requires CI environment variable to be set")
 	}
-	return 0
-}
 
-// extractConvCommitPrefix extracts conventional commit prefix from a PR title.
-// Returns the full prefix including scope if present (e.g., "feat(api)" from "feat(api): something").
-func extractConvCommitPrefix(title string) string {
-	matches := convCommitPattern.FindStringSubmatch(title)
-	if len(matches) >= 2 { //nolint:mnd
-		// matches[1] is the type (feat, fix, etc.)
-		// matches[2] is the scope with parens (api) or empty
-		if len(matches) >= 3 && matches[2] != "" {
-			return matches[1] + matches[2]
-		}
-		return matches[1]
-	}
-	return ""
-}
+	log.Info().Msg("running in CI mode")
 
-// processCIBackport handles backporting to a single target branch.
-func processCIBackport(
-	ctx context.Context,
-	forgeClient forge.Forge,
-	owner, repoName string,
-	prInfo *forge.PRInfo,
-	targetBranch string,
-	prefix string,
-	remote string,
-	dryRun bool,
-) CIResult {
-	result := CIResult{
-		TargetBranch: targetBranch,
+	// 2. Create service to get config and forge client.
+	service, cfg, forgeClient, owner, repoName, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		return nil, nil, nil, "", "", err
 	}
 
-	branchName := fmt.Sprintf("backport-%d-to-%s", prInfo.Number, targetBranch)
-
-	log.Info().
-		Str("target", targetBranch).
-		Str("branch", branchName).
-		Msg("processing backport")
-
-	// Check if backport PR already exists.
-	existingPRs, err := forgeClient.ListOpenPRs(ctx, owner, repoName, forge.ListPROptions{
-		Head: branchName,
-	})
+	// 3. Configure git user if not already set.
+	configured, err := git.ConfigureUserForCI(cfg.ForgeType)
 	if err != nil {
-		log.Warn().Err(err).Msg("failed to check for existing backport PR")
-		// Continue anyway - we'll fail later if there's a real problem.
-	} else if len(existingPRs) > 0 {
-		result.Skipped = true
-		result.Success = true
-		result.PRNumber = existingPRs[0].Number
-		result.Message = fmt.Sprintf("backport PR #%d already exists", existingPRs[0].Number)
-		log.Info().Int("pr", existingPRs[0].Number).Msg("backport PR already exists, skipping")
-		return result
+		return nil, nil, nil, "", "", fmt.Errorf("failed to configure git user: %w", err)
 	}
-
-	if dryRun {
-		result.Success = true
-		result.Message = "would create backport PR"
-		log.Info().Msg("dry-run: would create backport branch and PR")
-		return result
+	if configured {
+		log.Debug().Str("forge", cfg.ForgeType).Msg("configured git user for CI")
 	}
 
-	// Create backport branch from target branch.
-	log.Debug().Str("branch", branchName).Str("from", targetBranch).Msg("creating backport branch")
-	if err := git.CreateBranchFrom(branchName, remote+"/"+targetBranch); err != nil {
-		result.Error = fmt.Errorf("failed to create branch: %w", err)
-		result.Message = result.Error.Error()
-		return result
+	// 4. Fetch from remote to ensure we have the latest commits.
+	log.Debug().Str("remote", cfg.Remote).Msg("fetching from remote")
+	if err := git.Fetch(cfg.Remote); err != nil {
+		return nil, nil, nil, "", "", fmt.Errorf("failed to fetch from remote: %w", err)
 	}
-
-	// Checkout the new branch.
-	if err := git.CheckoutBranch(branchName); err != nil {
-		// Clean up the branch we created.
-		_ = git.DeleteBranch(branchName)
-		result.Error = fmt.Errorf("failed to checkout branch: %w", err)
-		result.Message = result.Error.Error()
-		return result
+	if pushRemote := cfg.WriteRemote(); pushRemote != cfg.Remote {
+		log.Debug().Str("remote", pushRemote).Msg("fetching from push remote")
+		if err := git.Fetch(pushRemote); err != nil {
+			return nil, nil, nil, "", "", fmt.Errorf("failed to fetch from push remote: %w", err)
+		}
 	}
 
-	// Cherry-pick the merge commit directly since we're on a new branch.
-	cpResult, err := git.CherryPick(prInfo.MergeCommit)
-	if err != nil {
-		_ = git.AbortCherryPick()
-		_ = git.CheckoutBranch(targetBranch)
-		_ = git.DeleteBranch(branchName)
-		result.Error = fmt.Errorf("cherry-pick failed: %w", err)
-		result.Message = result.Error.Error()
-		return result
-	}
+	return service, cfg, forgeClient, owner, repoName, nil
+}
 
-	if cpResult.HasConflict {
-		_ = git.AbortCherryPick()
-		_ = git.CheckoutBranch(targetBranch)
-		_ = git.DeleteBranch(branchName)
-		result.Error = fmt.Errorf("cherry-pick has conflicts")
-		result.Message = "cherry-pick has conflicts - manual backport required"
-		return result
+// newPRCache returns the PR cache of the configuration, or nil if caching is disabled.
+func newPRCache(cfg *config.Config) *backport.PRCache {
+	if !cfg.Cache.Enabled {
+		return nil
 	}
+	return backport.NewPRCache(cfg.Cache.Path)
+}
 
-	// Push the branch.
-	log.Debug().Str("branch", branchName).Msg("pushing backport branch")
-	if err := git.Push(remote, branchName); err != nil {
-		_ = git.CheckoutBranch(targetBranch)
-		_ = git.DeleteBranch(branchName)
-		result.Error = fmt.Errorf("failed to push: %w", err)
-		result.Message = result.Error.Error()
-		return result
+// getPR fetches a PR from the forge and caches it. If the forge cannot be reached, a cached
+// copy is used instead when allowStale is set and the merge commit is available locally.
+func getPR(
+	ctx context.Context,
+	forgeClient forge.Forge,
+	prCache *backport.PRCache,
+	owner, repoName string,
+	number int,
+	allowStale bool,
+) (*forge.PRInfo, error) {
+	prInfo, err := forgeClient.GetPR(ctx, owner, repoName, number)
+	if prCache == nil {
+		return prInfo, err
 	}
 
-	// Create the PR.
-	prTitle := fmt.Sprintf("%s: backport #%d to %s", prefix, prInfo.Number, targetBranch)
-	prBody := formatBackportPRBody(prInfo, targetBranch)
-
-	log.Debug().Str("title", prTitle).Msg("creating backport PR")
-	newPRNumber, err := forgeClient.CreatePR(ctx, owner, repoName, forge.CreatePROptions{
-		Title: prTitle,
-		Body:  prBody,
-		Head:  branchName,
-		Base:  targetBranch,
-	})
-	if err != nil {
-		result.Error = fmt.Errorf("failed to create PR: %w", err)
-		result.Message = result.Error.Error()
-		return result
+	if err == nil {
+		if err := prCache.Put(owner, repoName, prInfo); err != nil {
+			log.Warn().Err(err).Int("pr", number).Msg("failed to cache PR")
+		}
+		return prInfo, nil
 	}
 
-	// Return to the target branch (optional cleanup).
-	_ = git.CheckoutBranch(targetBranch)
+	// Only fall back for failures a retry may not see, a PR does not become unmerged.
+	if !allowStale || errors.Is(err, forge.ErrNotMerged) || errors.Is(err, forge.ErrUnauthorized) {
+		return nil, err
+	}
 
-	result.Success = true
-	result.PRNumber = newPRNumber
-	result.Message = fmt.Sprintf("created backport PR #%d", newPRNumber)
+	cached, ok := prCache.Get(owner, repoName, number)
+	if !ok || !git.CommitExists(cached.PR.MergeCommit) {
+		return nil, err
+	}
 
-	log.Info().
-		Int("pr", newPRNumber).
-		Str("target", targetBranch).
-		Msg("backport PR created successfully")
+	log.Warn().Err(err).
+		Int("pr", number).
+		Time("cached_at", cached.CachedAt).
+		Msg("forge unavailable, using cached PR")
 
-	return result
+	return cached.PR, nil
 }
 
-// formatBackportPRBody creates the PR body for a backport PR.
-func formatBackportPRBody(originalPR *forge.PRInfo, targetBranch string) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("Backport of #%d to `%s`.\n\n", originalPR.Number, targetBranch))
-	sb.WriteString("## Original PR\n\n")
-	sb.WriteString(fmt.Sprintf("- **Title**: %s\n", originalPR.Title))
-	sb.WriteString(fmt.Sprintf("- **Author**: @%s\n", originalPR.Author))
-	sb.WriteString(fmt.Sprintf("- **Merged**: %s\n", originalPR.MergedAt.Format("2006-01-02 15:04:05 UTC")))
-
-	if originalPR.Body != "" {
-		sb.WriteString("\n## Original Description\n\n")
-		// Truncate very long descriptions.
-		const maxBodyLen = 2000
-		body := originalPR.Body
-		if len(body) > maxBodyLen {
-			body = body[:maxBodyLen] + "\n\n... (truncated)"
+// skipRevertTargets splits off the target branches that a revert must not be backported to,
+// because the change it reverts never landed there, and returns skipped results for them.
+func skipRevertTargets(service *backport.Service, pushRemote, sha string, targetBranches []string) ([]string, []backportci.Result) {
+	var targets []string
+	var skipped []backportci.Result
+	for _, targetBranch := range targetBranches {
+		err := service.CheckRevert(sha, targetBranch, pushRemote+"/"+targetBranch)
+		if err == nil {
+			targets = append(targets, targetBranch)
+			continue
 		}
-		sb.WriteString(body)
-		sb.WriteString("\n")
+		log.Warn().Err(err).Str("target", targetBranch).Msg("skipping backport of revert")
+		skipped = append(skipped, backportci.Result{
+			TargetBranch: targetBranch,
+			Success:      true,
+			Skipped:      true,
+			Message:      err.Error(),
+		})
 	}
+	return targets, skipped
+}
 
-	sb.WriteString("\n---\n")
-	sb.WriteString("*This PR was automatically created by [backporter](https://github.com/pat-s/backporter) in CI mode.*\n")
+// backportToTargets creates backport branches and PRs of a merged PR for each target branch.
+func backportToTargets(
+	ctx context.Context,
+	c *cli.Command,
+	cfg *config.Config,
+	service *backport.Service,
+	forgeClient forge.Forge,
+	prInfo *forge.PRInfo,
+	followUps []*forge.PRInfo,
+	targetBranches []string,
+) []backportci.Result {
+	return newRunner(c, cfg, service, forgeClient).Backport(ctx, prInfo, followUps, targetBranches)
+}
 
-	return sb.String()
+// newRunner returns the CI runner for the flags of the command. It reads the merged PRs from the
+// repository of the service and opens the backport PRs in the repository of its push remote.
+func newRunner(c *cli.Command, cfg *config.Config, service *backport.Service, forgeClient forge.Forge) *backportci.Runner {
+	pushOwner, pushRepoName := service.PushRepo()
+	runner := backportci.NewRunner(forgeClient, cfg, pushOwner, pushRepoName, backportci.Options{
+		Base: c.String("base"),
+		CherryPick: git.CherryPickOptions{
+			Empty:                c.String("empty"),
+			KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+			Rerere:               cfg.Rerere,
+			Identity:             backport.CommitIdentity(cfg),
+			Strategy:             c.String("merge-strategy"),
+			StrategyOptions:      c.StringSlice("strategy-option"),
+		},
+		Strategy:    c.String("strategy"),
+		Concurrency: c.Int("concurrency"),
+		Draft:       c.Bool("draft"),
+		DryRun:      c.Bool("dry-run"),
+	})
+	runner.SourceOwner, runner.SourceRepo = service.Repo()
+	return runner
 }
 
-const summaryLineWidth = 40
+// localBackportPROptions returns the options of the PR opened for a backport made from the
+// command line, with the branch name CI mode uses. The diffstat of the backport commits
+// from and to is included with ci.include_diffstat.
+func localBackportPROptions(cfg *config.Config, original *forge.PRInfo, targetBranch, from, to string, followUps []*forge.PRInfo) forge.CreatePROptions {
+	prefix := backportci.ConvCommitPrefix(original.Title)
+	if prefix == "" {
+		prefix = cfg.CI.DefaultPrefix
+	}
 
-// outputCISummary outputs a summary of all backport operations.
-func outputCISummary(results []CIResult, originalPR int) {
-	fmt.Println()
-	fmt.Printf("Backport Summary for PR #%d\n", originalPR)
-	fmt.Println(strings.Repeat("=", summaryLineWidth))
-
-	var succeeded, failed, skipped int
-	for _, r := range results {
-		var status string
-		switch {
-		case r.Skipped:
-			status = "⏭️  SKIPPED"
-			skipped++
-		case r.Success:
-			status = "✓  SUCCESS"
-			succeeded++
-		default:
-			status = "✗  FAILED"
-			failed++
-		}
+	opts := backportci.PRMetadata(cfg.CI, original)
+	opts.Title = backportci.PRTitle(prefix, original.Number, targetBranch)
+	var diffstat string
+	if cfg.CI.IncludeDiffstat {
+		diffstat = backportci.Diffstat(backportci.LocalGit{}, from, to)
+	}
+	opts.Body = backportci.FormatPRBody(original, targetBranch, backportci.ReviewChecklist(cfg.ReviewChecklists, targetBranch), followUps, diffstat)
+	opts.Head = backportci.BranchName(original.Number, targetBranch)
+	opts.Base = targetBranch
+	return opts
+}
 
-		fmt.Printf("%s  %s", status, r.TargetBranch)
-		if r.PRNumber > 0 {
-			fmt.Printf(" → PR #%d", r.PRNumber)
-		}
-		if r.Error != nil {
-			fmt.Printf(" (%s)", r.Error.Error())
-		}
-		fmt.Println()
+// followUpFixes warns about the follow-up fixes of a backported PR, which are not backported
+// with it, and returns them. Failing to search for them is logged, backports work without them.
+func followUpFixes(followUps []*forge.PRInfo, err error) []*forge.PRInfo {
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to search for follow-up fixes")
+		return nil
+	}
+	for _, pr := range followUps {
+		log.Warn().Int("pr", pr.Number).Str("title", pr.Title).Msg("follow-up fix of the backported PR, it may need a backport as well")
 	}
+	return followUps
+}
+
+// outputCISummary outputs a summary of all backport operations.
+// In Actions, the results are also added to the step summary.
+func outputCISummary(results []backportci.Result, originalPR int) {
+	title := fmt.Sprintf("Backport Summary for PR #%d", originalPR)
+	outputSummary(results, title)
+	writeActionsSummary(results, title)
+}
 
-	fmt.Println(strings.Repeat("-", summaryLineWidth))
-	fmt.Printf("Total: %d succeeded, %d failed, %d skipped\n", succeeded, failed, skipped)
-	fmt.Println()
+// outputSummary outputs a summary of backport operations across target branches.
+func outputSummary(results []backportci.Result, title string) {
+	backportci.WriteSummary(os.Stdout, results, title)
 }
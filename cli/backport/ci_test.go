@@ -1,275 +1,20 @@
 package backport
 
 import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"codefloe.com/pat-s/backporter/pkg/backport"
 	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
 )
 
-func TestParsePRNumber(t *testing.T) {
-	tests := []struct {
-		name     string
-		message  string
-		expected int
-	}{
-		{
-			name:     "squash merge format",
-			message:  "feat: add new feature (#123)",
-			expected: 123,
-		},
-		{
-			name:     "squash merge with scope",
-			message:  "fix(api): resolve bug (#456)",
-			expected: 456,
-		},
-		{
-			name:     "GitHub merge commit",
-			message:  "Merge pull request #789 from user/branch",
-			expected: 789,
-		},
-		{
-			name:     "GitHub merge commit multiline",
-			message:  "Merge pull request #42 from user/feature\n\nSome description here",
-			expected: 42,
-		},
-		{
-			name:     "GitLab style",
-			message:  "Merge branch 'feature' into main\n\nSee merge request owner/repo!100",
-			expected: 100,
-		},
-		{
-			name:     "Forgejo/Gitea style",
-			message:  "Some commit message\n\nReviewed-on: https://codeberg.org/owner/repo/pull/55",
-			expected: 55,
-		},
-		{
-			name:     "alternative merge format",
-			message:  "Merge branch 'feature' #200",
-			expected: 200,
-		},
-		{
-			name:     "no PR number",
-			message:  "Just a regular commit message",
-			expected: 0,
-		},
-		{
-			name:     "empty message",
-			message:  "",
-			expected: 0,
-		},
-		{
-			name:     "PR number at end without parens",
-			message:  "fix: something #999",
-			expected: 0, // Not matched by our patterns
-		},
-		{
-			name:     "multiple PR references takes first",
-			message:  "feat: feature (#111)\n\nRelated to (#222)",
-			expected: 111,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := parsePRNumber(tt.message)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestExtractConvCommitPrefix(t *testing.T) {
-	tests := []struct {
-		name     string
-		title    string
-		expected string
-	}{
-		{
-			name:     "feat prefix",
-			title:    "feat: add new feature",
-			expected: "feat",
-		},
-		{
-			name:     "fix prefix",
-			title:    "fix: resolve bug",
-			expected: "fix",
-		},
-		{
-			name:     "feat with scope",
-			title:    "feat(api): add endpoint",
-			expected: "feat(api)",
-		},
-		{
-			name:     "fix with scope",
-			title:    "fix(auth): fix login issue",
-			expected: "fix(auth)",
-		},
-		{
-			name:     "docs prefix",
-			title:    "docs: update README",
-			expected: "docs",
-		},
-		{
-			name:     "chore with scope",
-			title:    "chore(deps): update dependencies",
-			expected: "chore(deps)",
-		},
-		{
-			name:     "refactor prefix",
-			title:    "refactor: simplify code",
-			expected: "refactor",
-		},
-		{
-			name:     "test prefix",
-			title:    "test: add unit tests",
-			expected: "test",
-		},
-		{
-			name:     "ci prefix",
-			title:    "ci: update workflow",
-			expected: "ci",
-		},
-		{
-			name:     "build prefix",
-			title:    "build: update Dockerfile",
-			expected: "build",
-		},
-		{
-			name:     "perf prefix",
-			title:    "perf: optimize query",
-			expected: "perf",
-		},
-		{
-			name:     "style prefix",
-			title:    "style: format code",
-			expected: "style",
-		},
-		{
-			name:     "revert prefix",
-			title:    "revert: undo change",
-			expected: "revert",
-		},
-		{
-			name:     "no conventional commit",
-			title:    "Add new feature",
-			expected: "",
-		},
-		{
-			name:     "empty title",
-			title:    "",
-			expected: "",
-		},
-		{
-			name:     "wrong format - no colon",
-			title:    "feat add new feature",
-			expected: "",
-		},
-		{
-			name:     "wrong format - no space after colon",
-			title:    "feat:add new feature",
-			expected: "",
-		},
-		{
-			name:     "complex scope with dashes",
-			title:    "feat(my-scope): add feature",
-			expected: "feat(my-scope)",
-		},
-		{
-			name:     "complex scope with underscores",
-			title:    "fix(my_module): fix bug",
-			expected: "fix(my_module)",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractConvCommitPrefix(tt.title)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestFormatBackportPRBody(t *testing.T) {
-	mergedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
-
-	tests := []struct {
-		name         string
-		pr           *forge.PRInfo
-		targetBranch string
-		contains     []string
-		notContains  []string
-	}{
-		{
-			name: "basic PR info",
-			pr: &forge.PRInfo{
-				Number:   123,
-				Title:    "feat: add feature",
-				Body:     "This is the PR description.",
-				Author:   "testuser",
-				MergedAt: mergedAt,
-			},
-			targetBranch: "release-1.x",
-			contains: []string{
-				"Backport of #123 to `release-1.x`",
-				"**Title**: feat: add feature",
-				"**Author**: @testuser",
-				"**Merged**: 2024-01-15 10:30:00 UTC",
-				"## Original Description",
-				"This is the PR description.",
-				"automatically created by [backporter]",
-			},
-		},
-		{
-			name: "PR without body",
-			pr: &forge.PRInfo{
-				Number:   456,
-				Title:    "fix: bug fix",
-				Body:     "",
-				Author:   "anotheruser",
-				MergedAt: mergedAt,
-			},
-			targetBranch: "stable",
-			contains: []string{
-				"Backport of #456 to `stable`",
-				"**Title**: fix: bug fix",
-			},
-			notContains: []string{
-				"## Original Description",
-			},
-		},
-		{
-			name: "PR with long body gets truncated",
-			pr: &forge.PRInfo{
-				Number:   789,
-				Title:    "docs: update",
-				Body:     string(make([]byte, 2500)), // 2500 bytes, over 2000 limit
-				Author:   "user",
-				MergedAt: mergedAt,
-			},
-			targetBranch: "main",
-			contains: []string{
-				"... (truncated)",
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := formatBackportPRBody(tt.pr, tt.targetBranch)
-
-			for _, s := range tt.contains {
-				assert.Contains(t, result, s)
-			}
-
-			for _, s := range tt.notContains {
-				assert.NotContains(t, result, s)
-			}
-		})
-	}
-}
-
 func TestHasBackportLabel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -337,62 +82,56 @@ func TestHasBackportLabel(t *testing.T) {
 	}
 }
 
-func TestCIResultStates(t *testing.T) {
-	tests := []struct {
-		name    string
-		result  CIResult
-		success bool
-		skipped bool
-		hasErr  bool
-	}{
-		{
-			name: "successful backport",
-			result: CIResult{
-				TargetBranch: "release-1.x",
-				Success:      true,
-				PRNumber:     100,
-				Message:      "created backport PR #100",
-			},
-			success: true,
-			skipped: false,
-			hasErr:  false,
-		},
-		{
-			name: "skipped - already exists",
-			result: CIResult{
-				TargetBranch: "release-1.x",
-				Success:      true,
-				Skipped:      true,
-				PRNumber:     50,
-				Message:      "backport PR #50 already exists",
-			},
-			success: true,
-			skipped: true,
-			hasErr:  false,
-		},
-		{
-			name: "failed backport",
-			result: CIResult{
-				TargetBranch: "release-1.x",
-				Success:      false,
-				Error:        assert.AnError,
-				Message:      "cherry-pick failed",
-			},
-			success: false,
-			skipped: false,
-			hasErr:  true,
-		},
-	}
+// prForge returns a fixed PR or error from GetPR.
+type prForge struct {
+	forge.Forge
+	pr  *forge.PRInfo
+	err error
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.success, tt.result.Success)
-			assert.Equal(t, tt.skipped, tt.result.Skipped)
-			if tt.hasErr {
-				assert.NotNil(t, tt.result.Error)
-			} else {
-				assert.Nil(t, tt.result.Error)
-			}
-		})
+func (f *prForge) GetPR(_ context.Context, _, _ string, _ int) (*forge.PRInfo, error) {
+	return f.pr, f.err
+}
+
+func TestGetPRStaleCache(t *testing.T) {
+	repoPath := t.TempDir()
+	t.Chdir(repoPath)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "fix: bug (#42)"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
 	}
+	mergeCommit, err := git.GetCurrentCommitSHA()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	prCache := backport.NewPRCache(filepath.Join(repoPath, "cache", "history.json"))
+	pr := &forge.PRInfo{Number: 42, MergeCommit: mergeCommit, Labels: []string{"backport"}}
+
+	// A successful response is cached.
+	got, err := getPR(ctx, &prForge{pr: pr}, prCache, "owner", "repo", 42, true)
+	require.NoError(t, err)
+	assert.Equal(t, pr, got)
+
+	outage := &prForge{err: errors.New("connection refused")}
+	got, err = getPR(ctx, outage, prCache, "owner", "repo", 42, true)
+	require.NoError(t, err)
+	assert.Equal(t, mergeCommit, got.MergeCommit)
+
+	// Opted out of stale data.
+	_, err = getPR(ctx, outage, prCache, "owner", "repo", 42, false)
+	require.Error(t, err)
+
+	// Deterministic failures are not papered over.
+	_, err = getPR(ctx, &prForge{err: forge.ErrNotMerged}, prCache, "owner", "repo", 42, true)
+	require.ErrorIs(t, err, forge.ErrNotMerged)
+
+	// Without the merge commit locally the cached data is of no use.
+	require.NoError(t, prCache.Put("owner", "repo", &forge.PRInfo{Number: 43, MergeCommit: "0123456789abcdef0123456789abcdef01234567"}))
+	_, err = getPR(ctx, outage, prCache, "owner", "repo", 43, true)
+	require.Error(t, err)
 }
+
+// createPRForge records the PRs created through it.
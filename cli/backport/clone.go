@@ -0,0 +1,97 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// cloneDepth is the history fetched of each branch with --clone. The merge commit is the tip of
+// the default branch, the history needed to cherry-pick it is fetched when missing.
+const cloneDepth = 1
+
+// ciRepoURL returns the HTTPS URL of the repository the CI job runs for, from the variables
+// set by GitHub, Forgejo and Gitea Actions, Woodpecker and Crow CI and Bitbucket Pipelines.
+func ciRepoURL() string {
+	if server, repo := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"); server != "" && repo != "" {
+		return strings.TrimSuffix(server, "/") + "/" + repo + ".git"
+	}
+	if url := os.Getenv("CI_REPO_CLONE_URL"); url != "" {
+		return url
+	}
+	return os.Getenv("BITBUCKET_GIT_HTTP_ORIGIN")
+}
+
+// cloneTokenUser returns the user forges expect with a token in HTTP basic authentication.
+// Gerrit tokens include the user, they are "<username>:<http-password>".
+func cloneTokenUser(forgeType string) string {
+	switch forgeType {
+	case "github":
+		return "x-access-token"
+	case "bitbucket":
+		return "x-token-auth"
+	case "gerrit":
+		return ""
+	default:
+		return "oauth2"
+	}
+}
+
+// enterCIClone clones the repository of the CI job into a temporary directory and changes into it,
+// so CI mode needs no checkout with full history. The returned function removes the clone.
+func enterCIClone(ctx context.Context, c *cli.Command) (func(), error) {
+	url := c.String("clone-url")
+	if url == "" {
+		url = ciRepoURL()
+	}
+	if url == "" {
+		return nil, fmt.Errorf("--clone could not determine the repository URL from the CI environment, set --clone-url")
+	}
+
+	// The repo-local config is only available after cloning, the token comes from the global one.
+	cfg, err := cliconfig.Merged(c)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ForgeType == "" {
+		host, err := git.ParseRemoteHost(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse clone URL: %w", err)
+		}
+		if detected, err := forge.Detect(ctx, host); err == nil {
+			cfg.ForgeType = detected.Type
+		}
+	}
+
+	remote := c.String("remote")
+	log.Info().Str("url", url).Str("remote", remote).Msg("cloning repository")
+	sandbox, err := git.CloneRemote(url, git.CloneOptions{
+		Remote:    remote,
+		Depth:     cloneDepth,
+		Token:     internal.ForgeToken(cfg),
+		TokenUser: cloneTokenUser(cfg.ForgeType),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := sandbox.Enter(); err != nil {
+		_ = sandbox.Remove()
+		return nil, err
+	}
+	log.Debug().Str("path", sandbox.Path).Msg("running in a temporary clone")
+
+	return func() {
+		if err := sandbox.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", sandbox.Path).Msg("failed to remove temporary clone")
+		}
+	}, nil
+}
@@ -0,0 +1,35 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIRepoURL(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "actions", env: map[string]string{"GITHUB_SERVER_URL": "https://codefloe.com/", "GITHUB_REPOSITORY": "pat-s/backporter"}, want: "https://codefloe.com/pat-s/backporter.git"},
+		{name: "woodpecker", env: map[string]string{"CI_REPO_CLONE_URL": "https://codefloe.com/pat-s/backporter.git"}, want: "https://codefloe.com/pat-s/backporter.git"},
+		{name: "bitbucket", env: map[string]string{"BITBUCKET_GIT_HTTP_ORIGIN": "https://bitbucket.org/owner/repo"}, want: "https://bitbucket.org/owner/repo"},
+		{name: "unknown", env: map[string]string{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "CI_REPO_CLONE_URL", "BITBUCKET_GIT_HTTP_ORIGIN"} {
+				t.Setenv(name, tt.env[name])
+			}
+			assert.Equal(t, tt.want, ciRepoURL())
+		})
+	}
+}
+
+func TestCloneTokenUser(t *testing.T) {
+	assert.Equal(t, "x-access-token", cloneTokenUser("github"))
+	assert.Equal(t, "x-token-auth", cloneTokenUser("bitbucket"))
+	assert.Equal(t, "oauth2", cloneTokenUser("forgejo"))
+	assert.Empty(t, cloneTokenUser("gerrit"))
+}
@@ -0,0 +1,150 @@
+package backport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// commentCommand is the prefix of backport comment commands, e.g. "/backport release-1.x".
+const commentCommand = "/backport"
+
+// commentEvent is the part of an issue_comment webhook payload used by comment commands.
+type commentEvent struct {
+	Action  string `json:"action"`
+	Comment struct {
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"comment"`
+	Issue struct {
+		Number      int       `json:"number"`
+		PullRequest *struct{} `json:"pull_request"`
+	} `json:"issue"`
+}
+
+// loadCommentEvent reads an issue_comment event payload from a file.
+func loadCommentEvent(path string) (*commentEvent, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no event payload found (GITHUB_EVENT_PATH is not set)")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event payload: %w", err)
+	}
+
+	var event commentEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse event payload: %w", err)
+	}
+
+	return &event, nil
+}
+
+// parseCommentCommand returns the target branches requested by "/backport <branch>..." lines
+// of a comment, in order and without duplicates.
+func parseCommentCommand(body string) []string {
+	seen := make(map[string]bool)
+	var branches []string
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != commentCommand {
+			continue
+		}
+		for _, branch := range fields[1:] {
+			if !seen[branch] {
+				seen[branch] = true
+				branches = append(branches, branch)
+			}
+		}
+	}
+	return branches
+}
+
+// backportComment backports a merged PR to the branches requested in a "/backport" comment.
+func backportComment(ctx context.Context, c *cli.Command) error {
+	event, err := loadCommentEvent(c.String("event-path"))
+	if err != nil {
+		return err
+	}
+
+	if event.Action != "created" || event.Issue.PullRequest == nil {
+		log.Info().Msg("event is not a new PR comment, skipping")
+		return nil
+	}
+
+	targetBranches := parseCommentCommand(event.Comment.Body)
+	if len(targetBranches) == 0 {
+		log.Info().Msg("comment contains no backport command, skipping")
+		return nil
+	}
+
+	prNumber := event.Issue.Number
+	commenter := event.Comment.User.Login
+	log.Info().Int("pr", prNumber).Str("user", commenter).Strs("branches", targetBranches).Msg("found backport command")
+
+	service, cfg, forgeClient, owner, repoName, err := prepareCI(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	dryRun := c.Bool("dry-run")
+	reply := func(body string) {
+		if dryRun {
+			log.Info().Msg("dry-run: would reply to backport command")
+			return
+		}
+		if err := forgeClient.CreateComment(ctx, owner, repoName, prNumber, body); err != nil {
+			log.Warn().Err(err).Int("pr", prNumber).Msg("failed to reply to backport command")
+		}
+	}
+
+	// Only users that could push the backport themselves may trigger it.
+	allowed, err := forgeClient.HasWriteAccess(ctx, owner, repoName, commenter)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Warn().Str("user", commenter).Msg("user has no write access, ignoring backport command")
+		reply(fmt.Sprintf("@%s only users with write access can trigger backports.\n", commenter))
+		return nil
+	}
+
+	prInfo, err := getPR(ctx, forgeClient, newPRCache(cfg), owner, repoName, prNumber, !c.Bool("no-stale-cache"))
+	if err != nil {
+		reply(fmt.Sprintf("@%s cannot backport this PR: %s\n", commenter, logger.RedactError(err)))
+		return fmt.Errorf("failed to get PR #%d: %w", prNumber, err)
+	}
+
+	if window := cfg.CI.QuietWindowAt(time.Now()); window != nil {
+		results := deferBackports(cfg, owner, repoName, prNumber, targetBranches, window, dryRun)
+		outputCISummary(results, prNumber)
+		writeActionsOutputs(results)
+		reply(formatDigestComment(prInfo, results, "@"+commenter))
+		return nil
+	}
+
+	followUps := followUpFixes(backport.FindFollowUps(ctx, forgeClient, owner, repoName, prInfo))
+	results := backportToTargets(ctx, c, cfg, service, forgeClient, prInfo, followUps, targetBranches)
+	outputCISummary(results, prNumber)
+	writeActionsOutputs(results)
+
+	reply(formatDigestComment(prInfo, results, "@"+commenter))
+	if !dryRun {
+		labelOriginalPR(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI)
+	}
+
+	return backportci.FailedError(results)
+}
@@ -0,0 +1,57 @@
+package backport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommentCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"single branch", "/backport release-1.x", []string{"release-1.x"}},
+		{"multiple branches", "/backport release-1.x release-2.x", []string{"release-1.x", "release-2.x"}},
+		{"multiple lines", "Thanks!\n/backport release-1.x\r\n/backport release-2.x release-1.x", []string{"release-1.x", "release-2.x"}},
+		{"no branch", "/backport", nil},
+		{"not a command", "please /backport release-1.x", nil},
+		{"other command", "/backports release-1.x", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseCommentCommand(tt.body))
+		})
+	}
+}
+
+func TestLoadCommentEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	payload := `{
+		"action": "created",
+		"comment": {"body": "/backport release-1.x", "user": {"login": "alice"}},
+		"issue": {"number": 42, "pull_request": {"url": "https://example.com"}}
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(payload), 0o644))
+
+	event, err := loadCommentEvent(path)
+	require.NoError(t, err)
+	assert.Equal(t, "created", event.Action)
+	assert.Equal(t, "alice", event.Comment.User.Login)
+	assert.Equal(t, 42, event.Issue.Number)
+	assert.NotNil(t, event.Issue.PullRequest)
+
+	// Comments on issues have no pull_request field.
+	require.NoError(t, os.WriteFile(path, []byte(`{"action": "created", "issue": {"number": 1}}`), 0o644))
+	event, err = loadCommentEvent(path)
+	require.NoError(t, err)
+	assert.Nil(t, event.Issue.PullRequest)
+
+	_, err = loadCommentEvent("")
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package backport
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
@@ -14,10 +15,10 @@ import (
 
 func backportCommit(ctx context.Context, c *cli.Command) error {
 	if c.Args().Len() < 1 {
-		return fmt.Errorf("usage: backport commit <commit-sha> [target-branch]")
+		return fmt.Errorf("usage: backport commit <commit> [target-branch]")
 	}
 
-	sha := c.Args().Get(0)
+	sha := strings.TrimPrefix(c.Args().Get(0), internal.CommitPrefix)
 	dryRun := c.Bool("dry-run")
 
 	// Determine target branches.
@@ -32,9 +33,8 @@ func backportCommit(ctx context.Context, c *cli.Command) error {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 		if len(cfg.TargetBranches) == 0 {
-			return fmt.Errorf("usage: backport commit <commit-sha> <target-branch>\n       (or configure target_branches in .backporter.yaml)")
+			return fmt.Errorf("usage: backport commit <commit> <target-branch>\n       (or configure target_branches in .backporter.yaml)")
 		}
-		targetBranches = cfg.TargetBranches
 	}
 
 	service, err := internal.CreateService(ctx, c)
@@ -42,14 +42,30 @@ func backportCommit(ctx context.Context, c *cli.Command) error {
 		return err
 	}
 
+	// Expand configured patterns such as "release-.*" against the local and remote branches.
+	if len(targetBranches) == 0 {
+		targetBranches, err = service.TargetBranches()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Backport to each target branch.
 	var lastErr error
 	for _, targetBranch := range targetBranches {
 		log.Info().Str("branch", targetBranch).Str("sha", sha).Msg("backporting commit")
 
 		opts := backport.BackportOptions{
-			TargetBranch: targetBranch,
-			DryRun:       dryRun,
+			TargetBranch:         targetBranch,
+			DryRun:               dryRun,
+			Empty:                c.String("empty"),
+			KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+			MergeStrategy:        c.String("merge-strategy"),
+			StrategyOption:       c.StringSlice("strategy-option"),
+			Paths:                c.StringSlice("paths"),
+			UseWorktree:          c.Bool("worktree"),
+			SelectHunks:          c.Bool("interactive-hunks"),
+			Force:                c.Bool("force"),
 		}
 
 		result, err := service.BackportCommit(ctx, sha, opts)
@@ -0,0 +1,31 @@
+package backport
+
+import (
+	"context"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+)
+
+func backportContinue(ctx context.Context, c *cli.Command) error {
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	result, pending, err := service.ContinueBackport(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := handleBackportResult(result); err != nil {
+		return err
+	}
+
+	// Open the PR that was requested with --create-pr before the conflict.
+	if !pending.CreatePR || result.PRNumber == 0 {
+		return nil
+	}
+	return createLocalBackportPR(ctx, c, service, result, pending.Base)
+}
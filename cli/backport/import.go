@@ -0,0 +1,79 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+// ImportCommand seeds the backport history with the backports of another backport bot.
+var ImportCommand = &cli.Command{
+	Name:   "import",
+	Usage:  "import the backports made by another backport bot into the history, so duplicate detection and verification cover them",
+	Action: runImport,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:      "from",
+			Usage:     fmt.Sprintf("backport bot to import from: %s (sqren/backport), %s (tibdex/backport) or %s (korthout/backport-action)", backport.BotBackportBot, backport.BotTibdex, backport.BotKorthout),
+			Required:  true,
+			Validator: backport.ValidateBot,
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of recently merged PRs to scan for backport PRs",
+			Value: 100, //nolint:mnd
+		},
+		&cli.StringFlag{
+			Name:  "ledger",
+			Usage: "history file to import into instead of the cache, e.g. a ledger shared in the repository",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only print the backports that would be imported",
+		},
+	},
+}
+
+func runImport(ctx context.Context, c *cli.Command) error {
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	bot := c.String("from")
+	entries, err := service.FindBotBackports(ctx, bot, c.Int("limit"))
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No backports by %s found\n", bot)
+		return nil
+	}
+
+	if c.Bool("dry-run") {
+		fmt.Printf("Found %d backport(s) by %s:\n", len(entries), bot)
+		for _, entry := range entries {
+			printImported(entry)
+		}
+		return nil
+	}
+
+	added, err := service.ImportBackports(entries, c.String("ledger"))
+	if err != nil {
+		return fmt.Errorf("failed to import backports: %s - %w", bot, err)
+	}
+	for _, entry := range added {
+		printImported(entry)
+	}
+	fmt.Printf("✓ Imported %d of %d backport(s) by %s, %d already known\n", len(added), len(entries), bot, len(entries)-len(added))
+	return nil
+}
+
+// printImported prints an imported backport.
+func printImported(entry backport.CacheEntry) {
+	fmt.Printf("  PR #%d %s → %s (%s)\n", entry.PRNumber, backport.ShortSHA(entry.OriginalSHA), entry.TargetBranch, backport.ShortSHA(entry.BackportSHA))
+}
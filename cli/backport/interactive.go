@@ -3,7 +3,6 @@ package backport
 import (
 	"context"
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/charmbracelet/huh"
@@ -13,6 +12,7 @@ import (
 	"codefloe.com/pat-s/backporter/cli/internal"
 	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
 	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
 	"codefloe.com/pat-s/backporter/pkg/config"
 	"codefloe.com/pat-s/backporter/pkg/forge"
 	"codefloe.com/pat-s/backporter/pkg/git"
@@ -29,31 +29,35 @@ func Interactive(ctx context.Context, c *cli.Command) error {
 	}
 
 	// Verify we have commits (HEAD exists).
-	if _, err := repo.CurrentBranch(); err != nil {
+	if _, err := repo.CurrentRef(); err != nil {
 		return fmt.Errorf("git repository has no commits - please create at least one commit first")
 	}
 
-	// Check if running in CI.
+	// Direct invocation with a commit or PR and a target branch.
 	if c.Args().Len() > 0 {
-		// Not interactive mode, check if first arg looks like a SHA or number.
 		firstArg := c.Args().First()
-		if looksLikeSHA(firstArg) {
-			// Direct commit SHA provided.
-			if c.Args().Len() < 2 { //nolint:mnd
-				return fmt.Errorf("usage: backporter <commit-sha> <target-branch>")
+		kind, value := internal.SplitRefArg(firstArg)
+		if kind == internal.RefUnknown {
+			kind, err = guessRefKind(value, func(ref string) bool {
+				_, err := repo.GetCommitSHA(ref)
+				return err == nil
+			})
+			if err != nil {
+				return err
 			}
-			return backportCommit(ctx, c)
+			log.Warn().Msgf("guessing whether %s is a commit or a PR is deprecated and will be removed, use %s:%s", value, kind, value)
 		}
 
-		// Check if it's a PR number.
-		if _, err := strconv.Atoi(firstArg); err == nil {
+		if kind == internal.RefPR {
 			if c.Args().Len() < 2 { //nolint:mnd
-				return fmt.Errorf("usage: backporter <pr-number> <target-branch>")
+				return fmt.Errorf("usage: backporter pr:<pr-number> <target-branch>")
 			}
 			return backportPR(ctx, c)
 		}
-
-		return fmt.Errorf("unrecognized argument: %s", firstArg)
+		if c.Args().Len() < 2 { //nolint:mnd
+			return fmt.Errorf("usage: backporter commit:<commit> <target-branch>")
+		}
+		return backportCommit(ctx, c)
 	}
 
 	// Get branches for selection.
@@ -70,12 +74,19 @@ func Interactive(ctx context.Context, c *cli.Command) error {
 
 	// Check if configured target branches exist, offer to create if not.
 	if len(cfg.TargetBranches) > 0 {
-		branches, err = checkAndCreateTargetBranches(branches, cfg.TargetBranches)
+		branches, err = checkAndCreateTargetBranches(ctx, c, cfg, branches, cfg.TargetBranches)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Offer target branches that only exist on the remote, e.g. matched by a pattern.
+	if remoteBranches, err := repo.ListRemoteBranches(cfg.WriteRemote()); err != nil {
+		log.Debug().Err(err).Msg("failed to list remote branches")
+	} else {
+		branches = appendRemoteTargets(branches, remoteBranches, cfg.TargetBranches)
+	}
+
 	// Create options for branch selection, prioritizing configured target branches.
 	branchOptions := createBranchOptions(branches, cfg.TargetBranches)
 
@@ -93,16 +104,14 @@ func Interactive(ctx context.Context, c *cli.Command) error {
 		return err
 	}
 
-	var targetBranch string
-
 	if backportType == "pr" {
-		return interactivePR(ctx, c, branchOptions, &targetBranch)
+		return interactivePR(ctx, c, branchOptions)
 	}
 
-	return interactiveCommit(ctx, c, branchOptions, &targetBranch)
+	return interactiveCommit(ctx, c, branchOptions)
 }
 
-func interactivePR(ctx context.Context, c *cli.Command, branchOptions []huh.Option[string], targetBranch *string) error {
+func interactivePR(ctx context.Context, c *cli.Command, branchOptions []huh.Option[string]) error {
 	cfg, err := cliconfig.GetConfig(c)
 	if err != nil {
 		return err
@@ -140,18 +149,9 @@ func interactivePR(ctx context.Context, c *cli.Command, branchOptions []huh.Opti
 		return err
 	}
 
-	token := ""
-	switch cfg.ForgeType {
-	case "github":
-		token = getEnvToken("GITHUB_TOKEN")
-	case "forgejo":
-		token = getEnvToken("FORGEJO_TOKEN")
-	}
+	token := internal.ForgeToken(cfg)
 
-	forgeOpts := forge.NewOptions{
-		ForgejoURL: cfg.ForgejoURL,
-	}
-	forgeClient, err := forge.NewWithOptions(cfg.ForgeType, token, forgeOpts)
+	forgeClient, err := forge.NewWithOptions(cfg.ForgeType, token, internal.ForgeOptions(c, cfg))
 	if err != nil {
 		return err
 	}
@@ -165,7 +165,7 @@ func interactivePR(ctx context.Context, c *cli.Command, branchOptions []huh.Opti
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to fetch recent PRs")
 		// Fall back to manual input.
-		return interactivePRManualInput(ctx, service, branchOptions, targetBranch)
+		return interactivePRManualInput(ctx, c, service, branchOptions)
 	}
 
 	// Loop to allow loading more PRs.
@@ -191,30 +191,25 @@ func interactivePR(ctx context.Context, c *cli.Command, branchOptions []huh.Opti
 
 		if selectedPR == -1 {
 			// Manual input selected.
-			return interactivePRManualInput(ctx, service, branchOptions, targetBranch)
+			return interactivePRManualInput(ctx, c, service, branchOptions)
 		}
 
-		// Get target branch.
-		err = huh.NewSelect[string]().
-			Title("Select target branch to backport to:").
-			Description("⭐ indicates configured target branches").
-			Options(branchOptions...).
-			Value(targetBranch).
-			Run()
+		confirmed, err := confirmPR(ctx, service, selectedPR)
 		if err != nil {
 			return err
 		}
-
-		opts := backport.BackportOptions{
-			TargetBranch: *targetBranch,
+		if !confirmed {
+			continue
 		}
 
-		result, err := service.BackportPR(ctx, selectedPR, opts)
+		targetBranches, err := selectTargetBranches(branchOptions)
 		if err != nil {
 			return err
 		}
 
-		return handleBackportResult(result)
+		return backportWithNextSteps(ctx, c, service, fmt.Sprintf("PR #%d", selectedPR), targetBranches, branchOptions, func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+			return service.BackportPR(ctx, selectedPR, opts)
+		})
 	}
 }
 
@@ -257,58 +252,60 @@ func selectPRFromList(prs []*forge.PRInfo) (int, bool, error) {
 	return selectedPR, false, nil
 }
 
-func interactivePRManualInput(ctx context.Context, service *backport.Service, branchOptions []huh.Option[string], targetBranch *string) error {
-	var prNumberStr string
-	err := huh.NewInput().
-		Title("Enter PR number:").
-		Validate(func(s string) error {
-			_, e := strconv.Atoi(s)
-			return e
-		}).
-		Value(&prNumberStr).
-		Run()
-	if err != nil {
-		return err
-	}
+func interactivePRManualInput(ctx context.Context, c *cli.Command, service *backport.Service, branchOptions []huh.Option[string]) error {
+	var prNumber int
+	for {
+		var prNumberStr string
+		err := huh.NewInput().
+			Title("Enter PR number:").
+			Validate(func(s string) error {
+				_, e := strconv.Atoi(s)
+				return e
+			}).
+			Value(&prNumberStr).
+			Run()
+		if err != nil {
+			return err
+		}
 
-	prNumber, _ := strconv.Atoi(prNumberStr)
+		prNumber, _ = strconv.Atoi(prNumberStr)
+		confirmed, err := confirmPR(ctx, service, prNumber)
+		if err != nil {
+			return err
+		}
+		if confirmed {
+			break
+		}
+	}
 
-	// Get target branch.
-	err = huh.NewSelect[string]().
-		Title("Select target branch to backport to:").
-		Description("⭐ indicates configured target branches").
-		Options(branchOptions...).
-		Value(targetBranch).
-		Run()
+	targetBranches, err := selectTargetBranches(branchOptions)
 	if err != nil {
 		return err
 	}
 
-	opts := backport.BackportOptions{
-		TargetBranch: *targetBranch,
-	}
+	return backportWithNextSteps(ctx, c, service, fmt.Sprintf("PR #%d", prNumber), targetBranches, branchOptions, func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+		return service.BackportPR(ctx, prNumber, opts)
+	})
+}
 
-	result, err := service.BackportPR(ctx, prNumber, opts)
+func interactiveCommit(ctx context.Context, c *cli.Command, branchOptions []huh.Option[string]) error {
+	service, err := internal.CreateService(ctx, c)
 	if err != nil {
 		return err
 	}
 
-	return handleBackportResult(result)
-}
-
-func interactiveCommit(ctx context.Context, c *cli.Command, branchOptions []huh.Option[string], targetBranch *string) error {
-	service, err := internal.CreateService(ctx, c)
+	repo, err := git.OpenCurrent()
 	if err != nil {
 		return err
 	}
 
 	var sha string
 	err = huh.NewInput().
-		Title("Enter commit SHA:").
+		Title("Enter commit SHA or revision (e.g. origin/main~2):").
 		Value(&sha).
 		Validate(func(s string) error {
-			if len(s) < 7 { //nolint:mnd
-				return fmt.Errorf("SHA too short")
+			if _, err := repo.GetCommitSHA(s); err != nil {
+				return fmt.Errorf("unknown commit or revision")
 			}
 			return nil
 		}).
@@ -317,27 +314,114 @@ func interactiveCommit(ctx context.Context, c *cli.Command, branchOptions []huh.
 		return err
 	}
 
-	// Get target branch.
-	err = huh.NewSelect[string]().
-		Title("Select target branch to backport to:").
-		Description("⭐ indicates configured target branches").
+	targetBranches, err := selectTargetBranches(branchOptions)
+	if err != nil {
+		return err
+	}
+
+	return backportWithNextSteps(ctx, c, service, "commit "+sha, targetBranches, branchOptions, func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+		return service.BackportCommit(ctx, sha, opts)
+	})
+}
+
+// selectTargetBranches asks for one or more target branches to backport to.
+func selectTargetBranches(branchOptions []huh.Option[string]) ([]string, error) {
+	var targetBranches []string
+	err := huh.NewMultiSelect[string]().
+		Title("Select target branches to backport to:").
+		Description("⭐ indicates configured target branches, space to select").
 		Options(branchOptions...).
-		Value(targetBranch).
+		Value(&targetBranches).
+		Validate(func(s []string) error {
+			if len(s) == 0 {
+				return fmt.Errorf("select at least one branch")
+			}
+			return nil
+		}).
 		Run()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	opts := backport.BackportOptions{
-		TargetBranch: *targetBranch,
+	return targetBranches, nil
+}
+
+// backportToBranches runs a backport against each target branch in order and returns the
+// backports that created a commit.
+// A single branch gets the detailed result output, multiple branches a combined summary.
+// Conflicts stop the run since the repository is left in the middle of a cherry-pick.
+func backportToBranches(
+	subject string,
+	targetBranches []string,
+	run func(opts backport.BackportOptions) (*backport.BackportResult, error),
+) ([]*backport.BackportResult, error) {
+	if len(targetBranches) == 1 {
+		result, err := run(backport.BackportOptions{TargetBranch: targetBranches[0]})
+		if err != nil {
+			return nil, err
+		}
+		if err := handleBackportResult(result); err != nil {
+			return nil, err
+		}
+		if result.Empty {
+			return nil, nil
+		}
+		return []*backport.BackportResult{result}, nil
 	}
 
-	result, err := service.BackportCommit(ctx, sha, opts)
-	if err != nil {
-		return err
+	var results []backportci.Result
+	var backported []*backport.BackportResult
+	for _, targetBranch := range targetBranches {
+		log.Info().Str("branch", targetBranch).Str("source", subject).Msg("backporting")
+
+		result, err := run(backport.BackportOptions{TargetBranch: targetBranch})
+		if err != nil {
+			results = append(results, backportci.Result{TargetBranch: targetBranch, Error: err, Message: err.Error()})
+			continue
+		}
+
+		if result.HasConflict {
+			outputSummary(results, "Backport Summary for "+subject)
+			return nil, handleBackportResult(result)
+		}
+
+		results = append(results, backportci.Result{
+			TargetBranch: targetBranch,
+			Success:      result.Success,
+			Skipped:      result.Empty,
+			Message:      result.Message,
+		})
+		if result.Success && !result.Empty {
+			backported = append(backported, result)
+		}
 	}
 
-	return handleBackportResult(result)
+	outputSummary(results, "Backport Summary for "+subject)
+
+	for _, r := range results {
+		if r.Error != nil {
+			return nil, fmt.Errorf("some backports failed")
+		}
+	}
+
+	return backported, nil
+}
+
+// guessRefKind guesses whether an unprefixed argument is a commit or a PR number. Numbers that
+// also resolve to a commit, like numeric abbreviated SHAs, are ambiguous and need a prefix.
+func guessRefKind(arg string, isCommit func(string) bool) (string, error) {
+	if _, err := strconv.Atoi(arg); err == nil {
+		if isCommit(arg) {
+			return "", fmt.Errorf("%s is both a PR number and a commit, use %s%s or %s%s", arg, internal.PRPrefix, arg, internal.CommitPrefix, arg)
+		}
+		return internal.RefPR, nil
+	}
+
+	if looksLikeSHA(arg) || isCommit(arg) {
+		return internal.RefCommit, nil
+	}
+
+	return "", fmt.Errorf("unrecognized argument: %s (use %s<number> or %s<commit>)", arg, internal.PRPrefix, internal.CommitPrefix)
 }
 
 func looksLikeSHA(s string) bool {
@@ -354,11 +438,12 @@ func looksLikeSHA(s string) bool {
 	return true
 }
 
-func getEnvToken(key string) string {
-	return os.Getenv(key)
-}
-
-func checkAndCreateTargetBranches(existingBranches, targetBranches []string) ([]string, error) {
+func checkAndCreateTargetBranches(
+	ctx context.Context,
+	c *cli.Command,
+	cfg *config.Config,
+	existingBranches, targetBranches []string,
+) ([]string, error) {
 	// Build a set of existing branches for quick lookup.
 	existingSet := make(map[string]bool)
 	for _, b := range existingBranches {
@@ -369,7 +454,7 @@ func checkAndCreateTargetBranches(existingBranches, targetBranches []string) ([]
 	var missingBranches []string
 	for _, target := range targetBranches {
 		// Skip if it looks like a regex pattern.
-		if containsRegexChars(target) {
+		if backport.IsTargetPattern(target) {
 			continue
 		}
 		if !existingSet[target] {
@@ -428,19 +513,83 @@ func checkAndCreateTargetBranches(existingBranches, targetBranches []string) ([]
 	}
 
 	fmt.Printf("\nCreated %d branch(es)\n", len(missingBranches))
+
+	if err := publishTargetBranches(ctx, c, cfg, missingBranches); err != nil {
+		return nil, err
+	}
+
 	return existingBranches, nil
 }
 
-func containsRegexChars(s string) bool {
-	regexChars := []rune{'*', '+', '?', '.', '[', ']', '(', ')', '{', '}', '|', '^', '$', '\\'}
-	for _, c := range s {
-		for _, rc := range regexChars {
-			if c == rc {
-				return true
-			}
+// publishTargetBranches offers to push newly created target branches and protects them
+// on the forge according to the configured branch protection template.
+func publishTargetBranches(ctx context.Context, c *cli.Command, cfg *config.Config, branches []string) error {
+	var push bool
+	err := huh.NewConfirm().
+		Title("Would you like to push the new branches to the remote?").
+		Affirmative("Yes").
+		Negative("No").
+		Value(&push).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	if !push {
+		return nil
+	}
+
+	service, _, forgeClient, _, _, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, branchName := range branches {
+		log.Info().Str("branch", branchName).Str("remote", service.PushRemote()).Msg("pushing branch")
+		if err := git.Push(service.PushRemote(), branchName); err != nil {
+			return fmt.Errorf("failed to push branch %s: %w", branchName, err)
+		}
+	}
+	fmt.Printf("Pushed %d branch(es) to %s\n", len(branches), service.PushRemote())
+
+	if !cfg.BranchProtection.Enabled || forgeClient == nil {
+		return nil
+	}
+	if !forgeClient.Capabilities().BranchProtection {
+		log.Warn().Str("forge", forgeClient.Name()).Msg("forge does not support branch protection, branches are not protected")
+		return nil
+	}
+
+	rules := forge.BranchProtection{
+		RequiredApprovals:    cfg.BranchProtection.RequiredApprovals,
+		RequiredStatusChecks: cfg.BranchProtection.RequiredStatusChecks,
+	}
+	for _, branchName := range branches {
+		// A missing protection does not make the branch unusable as a target.
+		if err := service.ProtectBranch(ctx, branchName, rules); err != nil {
+			log.Warn().Err(err).Str("branch", branchName).Msg("failed to protect branch")
+			continue
+		}
+		fmt.Printf("Protected branch %s\n", branchName)
+	}
+
+	return nil
+}
+
+// appendRemoteTargets appends the remote branches matching a target branch that are not
+// available locally. They are created from the remote when backporting to them.
+func appendRemoteTargets(branches, remoteBranches, targetBranches []string) []string {
+	existing := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		existing[b] = true
+	}
+	for _, b := range remoteBranches {
+		if !existing[b] && backport.MatchesTargetBranch(b, targetBranches) {
+			existing[b] = true
+			branches = append(branches, b)
 		}
 	}
-	return false
+	return branches
 }
 
 // createBranchOptions creates branch selection options, prioritizing configured target branches.
@@ -454,18 +603,11 @@ func createBranchOptions(branches, targetBranches []string) []huh.Option[string]
 		return options
 	}
 
-	// Build a set of target branches for exact matching.
-	// We prioritize branches that match exactly, regardless of whether
-	// the pattern contains regex characters (e.g., "v4.4.x" is a literal branch name).
-	targetSet := make(map[string]bool)
-	for _, target := range targetBranches {
-		targetSet[target] = true
-	}
-
-	// Separate branches into targets and others.
+	// Separate branches into targets and others. Target branches match literally
+	// (e.g., "v4.4.x" is a literal branch name) or as a pattern (e.g., "release-.*").
 	var targetOpts, otherOpts []huh.Option[string]
 	for _, branch := range branches {
-		if targetSet[branch] {
+		if backport.MatchesTargetBranch(branch, targetBranches) {
 			// Mark target branches with a visual indicator.
 			targetOpts = append(targetOpts, huh.NewOption("⭐ "+branch, branch))
 		} else {
@@ -0,0 +1,92 @@
+package backport
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// originalPRLabels returns the labels of the original PR after the backports, with a
+// "backported" label per successful target branch and, once all backports succeeded, without
+// the trigger labels. The second result reports whether the labels changed.
+func originalPRLabels(ci config.CIConfig, labels []string, results []backportci.Result) ([]string, bool) {
+	var backported []string
+	allSucceeded := true
+	for _, r := range results {
+		if r.Success || r.Skipped {
+			if ci.BackportedLabel != "" {
+				backported = append(backported, strings.ReplaceAll(ci.BackportedLabel, "{target}", r.TargetBranch))
+			}
+		} else {
+			allSucceeded = false
+		}
+	}
+
+	var updated []string
+	for _, label := range labels {
+		if ci.RemoveTriggerLabel && allSucceeded && len(results) > 0 && isTriggerLabel(ci, label) {
+			continue
+		}
+		updated = append(updated, label)
+	}
+	for _, label := range backported {
+		if !slices.Contains(updated, label) {
+			updated = append(updated, label)
+		}
+	}
+
+	changed := !slices.Equal(updated, labels)
+	if updated == nil {
+		updated = []string{}
+	}
+	return updated, changed
+}
+
+// isTriggerLabel reports whether a label requests a backport, i.e. it mentions "backport"
+// and is not one of the labels marking finished backports.
+func isTriggerLabel(ci config.CIConfig, label string) bool {
+	if !strings.Contains(strings.ToLower(label), "backport") {
+		return false
+	}
+	if ci.BackportedLabel == "" {
+		return true
+	}
+	prefix, suffix, found := strings.Cut(ci.BackportedLabel, "{target}")
+	if found {
+		return !strings.HasPrefix(label, prefix) || !strings.HasSuffix(label, suffix)
+	}
+	return label != ci.BackportedLabel
+}
+
+// labelOriginalPR updates the labels of the original PR after the backports, as configured.
+// The labels are fetched again right before the update, since updating replaces all of them
+// and labels added while the backports ran would otherwise be lost.
+// Failing to label is logged but does not fail the run.
+func labelOriginalPR(ctx context.Context, forgeClient forge.Forge, owner, repoName string, prInfo *forge.PRInfo, results []backportci.Result, ci config.CIConfig) {
+	if ci.BackportedLabel == "" && !ci.RemoveTriggerLabel {
+		return
+	}
+
+	current, err := forgeClient.GetPR(ctx, owner, repoName, prInfo.Number)
+	if err != nil {
+		log.Warn().Err(err).Int("pr", prInfo.Number).Msg("failed to label original PR")
+		return
+	}
+
+	labels, changed := originalPRLabels(ci, current.Labels, results)
+	if !changed {
+		return
+	}
+
+	if err := forgeClient.UpdatePR(ctx, owner, repoName, prInfo.Number, forge.UpdatePROptions{Labels: labels}); err != nil {
+		log.Warn().Err(err).Int("pr", prInfo.Number).Msg("failed to label original PR")
+		return
+	}
+	log.Info().Int("pr", prInfo.Number).Strs("labels", labels).Msg("labeled original PR")
+}
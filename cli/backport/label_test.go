@@ -0,0 +1,100 @@
+package backport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestOriginalPRLabels(t *testing.T) {
+	succeeded := []backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 50},
+		{TargetBranch: "release-2.x", Skipped: true, Error: errors.New("exists")},
+	}
+	partly := []backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 50},
+		{TargetBranch: "release-2.x", Conflict: true, Error: errors.New("conflict")},
+	}
+	labels := []string{"bug", "backport", "backported-to/release-0.x"}
+
+	tests := []struct {
+		name    string
+		ci      config.CIConfig
+		results []backportci.Result
+		want    []string
+		changed bool
+	}{
+		{
+			name:    "backported labels",
+			ci:      config.CIConfig{BackportedLabel: "backported-to/{target}"},
+			results: partly,
+			want:    []string{"bug", "backport", "backported-to/release-0.x", "backported-to/release-1.x"},
+			changed: true,
+		},
+		{
+			name:    "remove trigger label once all succeeded",
+			ci:      config.CIConfig{BackportedLabel: "backported-to/{target}", RemoveTriggerLabel: true},
+			results: succeeded,
+			want:    []string{"bug", "backported-to/release-0.x", "backported-to/release-1.x", "backported-to/release-2.x"},
+			changed: true,
+		},
+		{
+			name:    "keep trigger label on failures",
+			ci:      config.CIConfig{RemoveTriggerLabel: true},
+			results: partly,
+			want:    labels,
+			changed: false,
+		},
+		{
+			name:    "remove all trigger labels without backported label",
+			ci:      config.CIConfig{RemoveTriggerLabel: true},
+			results: succeeded,
+			want:    []string{"bug"},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := originalPRLabels(tt.ci, labels, tt.results)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
+
+// labelForge returns the current labels of PRs and records their label updates.
+type labelForge struct {
+	forge.Forge
+	labels  map[int][]string
+	updates map[int][]string
+}
+
+func (f *labelForge) GetPR(_ context.Context, _, _ string, number int) (*forge.PRInfo, error) {
+	return &forge.PRInfo{Number: number, Labels: f.labels[number]}, nil
+}
+
+func (f *labelForge) UpdatePR(_ context.Context, _, _ string, number int, opts forge.UpdatePROptions) error {
+	f.updates[number] = opts.Labels
+	return nil
+}
+
+func TestLabelOriginalPR(t *testing.T) {
+	prInfo := &forge.PRInfo{Number: 42, Labels: []string{"backport"}}
+	results := []backportci.Result{{TargetBranch: "release-1.x", Success: true, PRNumber: 50}}
+
+	// The "bug" label was added while the backports ran, after prInfo was fetched.
+	f := &labelForge{labels: map[int][]string{42: {"backport", "bug"}}, updates: make(map[int][]string)}
+	labelOriginalPR(context.Background(), f, "owner", "repo", prInfo, results, config.CIConfig{})
+	assert.Empty(t, f.updates, "labeling is off by default")
+
+	ci := config.CIConfig{BackportedLabel: "backported-to/{target}", RemoveTriggerLabel: true}
+	labelOriginalPR(context.Background(), f, "owner", "repo", prInfo, results, ci)
+	assert.Equal(t, map[int][]string{42: {"bug", "backported-to/release-1.x"}}, f.updates)
+}
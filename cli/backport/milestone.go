@@ -0,0 +1,191 @@
+package backport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+var milestoneCmd = &cli.Command{
+	Name:      "milestone",
+	Usage:     "backport all merged PRs of a milestone that carry a backport label, in merge order",
+	ArgsUsage: "<milestone> <target-branch>",
+	Action:    backportMilestone,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "show what would be done without making changes",
+		},
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		mergeStrategyFlag,
+		strategyOptionFlag,
+		worktreeFlag,
+		strategyFlag,
+		forceFlag,
+	},
+}
+
+// milestoneOutcome is the result of backporting one PR of a milestone.
+type milestoneOutcome struct {
+	PR     *forge.PRInfo
+	Result *backport.BackportResult // nil if the backport failed or was not attempted
+	Err    error
+}
+
+// milestoneBackportPRs returns the PRs carrying a backport label, ordered by merge time.
+func milestoneBackportPRs(prs []*forge.PRInfo) []*forge.PRInfo {
+	var selected []*forge.PRInfo
+	for _, pr := range prs {
+		if pr.Merged && pr.HasBackportLabel() {
+			selected = append(selected, pr)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].MergedAt.Before(selected[j].MergedAt)
+	})
+
+	return selected
+}
+
+func backportMilestone(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 2 { //nolint:mnd
+		return fmt.Errorf("usage: backport milestone <milestone> <target-branch>")
+	}
+	milestone := c.Args().Get(0)
+	targetBranch := c.Args().Get(1)
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	prs, err := service.ListMilestonePRs(ctx, milestone)
+	if err != nil {
+		return err
+	}
+
+	selected := milestoneBackportPRs(prs)
+	if len(selected) == 0 {
+		fmt.Printf("No merged PRs with a backport label in milestone %q\n", milestone)
+		return nil
+	}
+
+	log.Info().Str("milestone", milestone).Str("branch", targetBranch).Int("prs", len(selected)).Msg("backporting milestone")
+
+	outcomes := make([]milestoneOutcome, len(selected))
+	for i, pr := range selected {
+		outcomes[i].PR = pr
+	}
+
+	for i, pr := range selected {
+		log.Info().Int("pr", pr.Number).Str("branch", targetBranch).Msg("backporting PR")
+
+		result, err := service.BackportPR(ctx, pr.Number, backport.BackportOptions{
+			TargetBranch:         targetBranch,
+			DryRun:               c.Bool("dry-run"),
+			Empty:                c.String("empty"),
+			KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+			MergeStrategy:        c.String("merge-strategy"),
+			StrategyOption:       c.StringSlice("strategy-option"),
+			UseWorktree:          c.Bool("worktree"),
+			Strategy:             c.String("strategy"),
+			Force:                c.Bool("force"),
+		})
+		outcomes[i].Result = result
+		outcomes[i].Err = err
+		if err != nil {
+			log.Error().Err(err).Int("pr", pr.Number).Msg("backport failed")
+			continue
+		}
+
+		// A conflict in the working copy has to be resolved before the next PR can be picked.
+		if result.HasConflict && !result.Aborted {
+			break
+		}
+	}
+
+	fmt.Print(formatMilestoneReport(milestone, targetBranch, outcomes))
+
+	for _, o := range outcomes {
+		if isSkipped(o.Err) {
+			continue
+		}
+		if o.Err != nil || o.Result == nil || o.Result.HasConflict {
+			return fmt.Errorf("some backports of milestone %q failed", milestone)
+		}
+	}
+
+	return nil
+}
+
+// isSkipped reports whether a backport was refused because it was done before, which is
+// expected when a milestone batch is run again, or because it reverts a change the target never got.
+func isSkipped(err error) bool {
+	var dup *backport.AlreadyBackportedError
+	var revert *backport.RevertOfMissingError
+	return errors.As(err, &dup) || errors.As(err, &revert)
+}
+
+// formatMilestoneReport summarizes the backports of a milestone, one line per PR.
+func formatMilestoneReport(milestone, targetBranch string, outcomes []milestoneOutcome) string {
+	var b strings.Builder
+	var backported, skipped, failed, pending int
+
+	fmt.Fprintf(&b, "\nMilestone %q → %s\n\n", milestone, targetBranch)
+
+	for _, o := range outcomes {
+		pr := fmt.Sprintf("#%d %s", o.PR.Number, o.PR.Title)
+		r := o.Result
+
+		var dup *backport.AlreadyBackportedError
+		var revert *backport.RevertOfMissingError
+		switch {
+		case errors.As(o.Err, &dup):
+			skipped++
+			fmt.Fprintf(&b, "  ⏭️ %s: already backported as %s\n", pr, backport.ShortSHA(dup.BackportSHA))
+		case errors.As(o.Err, &revert):
+			skipped++
+			fmt.Fprintf(&b, "  ⏭️ %s: reverts %s, which never landed on %s\n", pr, backport.ShortSHA(revert.RevertedSHA), targetBranch)
+		case o.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "  ✗ %s: %s\n", pr, o.Err)
+		case r == nil:
+			pending++
+			fmt.Fprintf(&b, "  · %s: not attempted\n", pr)
+		case r.Aborted:
+			failed++
+			fmt.Fprintf(&b, "  ✗ %s: conflicts, aborted\n", pr)
+		case r.HasConflict:
+			failed++
+			fmt.Fprintf(&b, "  ✗ %s: conflicts, resolve and run `backporter backport continue`\n", pr)
+		case r.Empty:
+			skipped++
+			fmt.Fprintf(&b, "  ⏭️ %s: already on %s\n", pr, targetBranch)
+		case r.BackportSHA == "":
+			backported++
+			fmt.Fprintf(&b, "  ✓ %s: would backport %s\n", pr, backport.ShortSHA(r.OriginalSHA))
+		default:
+			backported++
+			fmt.Fprintf(&b, "  ✓ %s: %s\n", pr, backport.ShortSHA(r.BackportSHA))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d backported, %d skipped, %d failed", backported, skipped, failed)
+	if pending > 0 {
+		fmt.Fprintf(&b, ", %d not attempted", pending)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package backport
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestMilestoneBackportPRs(t *testing.T) {
+	now := time.Now()
+	prs := []*forge.PRInfo{
+		{Number: 3, Merged: true, MergedAt: now.Add(2 * time.Hour), Labels: []string{"backport"}},
+		{Number: 1, Merged: true, MergedAt: now, Labels: []string{"needs-backport"}},
+		{Number: 2, Merged: true, MergedAt: now.Add(time.Hour), Labels: []string{"bug"}},
+		{Number: 4, Merged: false, Labels: []string{"backport"}},
+	}
+
+	selected := milestoneBackportPRs(prs)
+	if assert.Len(t, selected, 2) {
+		assert.Equal(t, 1, selected[0].Number)
+		assert.Equal(t, 3, selected[1].Number)
+	}
+}
+
+func TestFormatMilestoneReport(t *testing.T) {
+	outcomes := []milestoneOutcome{
+		{PR: &forge.PRInfo{Number: 1, Title: "Fix crash"}, Result: &backport.BackportResult{BackportSHA: "abcdef1234567", Success: true}},
+		{PR: &forge.PRInfo{Number: 2, Title: "Fix typo"}, Result: &backport.BackportResult{Empty: true}},
+		{PR: &forge.PRInfo{Number: 3, Title: "Fix leak"}, Err: errors.New("boom")},
+		{PR: &forge.PRInfo{Number: 4, Title: "Fix race"}, Result: &backport.BackportResult{HasConflict: true}},
+		{PR: &forge.PRInfo{Number: 5, Title: "Fix docs"}},
+		{PR: &forge.PRInfo{Number: 6, Title: "Fix panic"}, Err: &backport.AlreadyBackportedError{BackportSHA: "1234567890"}},
+		{PR: &forge.PRInfo{Number: 7, Title: "Revert \"Add cache\""}, Err: &backport.RevertOfMissingError{RevertedSHA: "fedcba9876"}},
+	}
+
+	report := formatMilestoneReport("v1.2", "release-1.x", outcomes)
+	assert.Contains(t, report, `Milestone "v1.2" → release-1.x`)
+	assert.Contains(t, report, "✓ #1 Fix crash: abcdef1")
+	assert.Contains(t, report, "⏭️ #2 Fix typo: already on release-1.x")
+	assert.Contains(t, report, "✗ #3 Fix leak: boom")
+	assert.Contains(t, report, "✗ #4 Fix race: conflicts")
+	assert.Contains(t, report, "· #5 Fix docs: not attempted")
+	assert.Contains(t, report, "⏭️ #6 Fix panic: already backported as 1234567")
+	assert.Contains(t, report, `⏭️ #7 Revert "Add cache": reverts fedcba9, which never landed on release-1.x`)
+	assert.Contains(t, report, "1 backported, 3 skipped, 2 failed, 1 not attempted")
+}
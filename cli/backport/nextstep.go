@@ -0,0 +1,150 @@
+package backport
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+// Next step menu values, branch specific steps are suffixed with ":<branch>".
+const (
+	nextStepPush    = "push"
+	nextStepPR      = "pr"
+	nextStepCopy    = "copy"
+	nextStepAnother = "another"
+	nextStepQuit    = "quit"
+)
+
+// backportWithNextSteps backports to the target branches, guiding the user through conflicts,
+// and then offers follow-up actions for the created backports until the user quits.
+func backportWithNextSteps(
+	ctx context.Context,
+	c *cli.Command,
+	service *backport.Service,
+	subject string,
+	targetBranches []string,
+	branchOptions []huh.Option[string],
+	run func(opts backport.BackportOptions) (*backport.BackportResult, error),
+) error {
+	run = withConflictResolution(ctx, service, run)
+	results, err := backportToBranches(subject, targetBranches, run)
+	if err != nil || len(results) == 0 {
+		return err
+	}
+
+	// Branches that were pushed or moved into a PR, the latter are no longer offered at all.
+	pushed := make(map[string]bool)
+	opened := make(map[string]bool)
+	// The SHA is copied through the terminal, which only works if stdout is one.
+	clipboard := term.IsTerminal(int(os.Stdout.Fd()))
+
+	for {
+		var step string
+		err := huh.NewSelect[string]().
+			Title("What next?").
+			Options(nextStepOptions(results, pushed, opened, clipboard)...).
+			Value(&step).
+			Run()
+		if errors.Is(err, huh.ErrUserAborted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		action, branch, _ := strings.Cut(step, ":")
+		result := findResult(results, branch)
+
+		switch action {
+		case nextStepPush:
+			if err := service.PushBackport(branch, branch); err != nil {
+				log.Error().Err(err).Str("branch", branch).Msg("failed to push backport")
+				continue
+			}
+			pushed[branch] = true
+			fmt.Printf("✓ Pushed %s to %s\n\n", branch, service.PushRemote())
+
+		case nextStepPR:
+			if err := createLocalBackportPR(ctx, c, service, result, c.String("base")); err != nil {
+				log.Error().Err(err).Str("branch", branch).Msg("failed to create backport PR")
+				continue
+			}
+			opened[branch] = true
+
+		case nextStepCopy:
+			copyToClipboard(result.BackportSHA)
+			fmt.Printf("✓ Sent %s to the terminal clipboard\n\n", result.BackportSHA)
+
+		case nextStepAnother:
+			more, err := selectTargetBranches(branchOptions)
+			if err != nil {
+				return err
+			}
+			backported, err := backportToBranches(subject, more, run)
+			if err != nil {
+				return err
+			}
+			results = append(results, backported...)
+
+		default:
+			return nil
+		}
+	}
+}
+
+// nextStepOptions returns the menu options for the follow-up actions of the given backports.
+// Pushed branches are not offered for pushing again and branches moved into a PR are skipped.
+// Copying SHAs is only offered with clipboard, i.e. when stdout is a terminal.
+func nextStepOptions(results []*backport.BackportResult, pushed, opened map[string]bool, clipboard bool) []huh.Option[string] {
+	var options []huh.Option[string]
+	for _, r := range results {
+		if opened[r.TargetBranch] {
+			continue
+		}
+		if !pushed[r.TargetBranch] {
+			options = append(options, huh.NewOption("Push "+r.TargetBranch, nextStepPush+":"+r.TargetBranch))
+			if r.PRNumber > 0 {
+				options = append(options, huh.NewOption("Open a PR against "+r.TargetBranch, nextStepPR+":"+r.TargetBranch))
+			}
+		}
+		if clipboard {
+			options = append(options, huh.NewOption(
+				fmt.Sprintf("Copy SHA of %s (%s)", r.TargetBranch, backport.ShortSHA(r.BackportSHA)),
+				nextStepCopy+":"+r.TargetBranch,
+			))
+		}
+	}
+
+	options = append(options,
+		huh.NewOption("Backport to another branch", nextStepAnother),
+		huh.NewOption("Quit", nextStepQuit),
+	)
+
+	return options
+}
+
+// findResult returns the most recent backport to a branch, or nil.
+func findResult(results []*backport.BackportResult, branch string) *backport.BackportResult {
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].TargetBranch == branch {
+			return results[i]
+		}
+	}
+	return nil
+}
+
+// copyToClipboard copies text to the clipboard through the OSC 52 terminal escape sequence,
+// which also works over SSH. Terminals without support ignore it.
+func copyToClipboard(text string) {
+	fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+}
@@ -0,0 +1,164 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// notifyResults posts the CI backport results as comments on the original PR,
+// either one comment per target branch or a single digest. Comments of earlier runs are updated.
+// Failing to comment is logged but does not fail the run.
+func notifyResults(ctx context.Context, forgeClient forge.Forge, owner, repoName string, prInfo *forge.PRInfo, results []backportci.Result, notify config.NotifyConfig) {
+	if notify.Mode == "" || notify.Mode == config.NotifyOff {
+		return
+	}
+
+	anyFailed := false
+	for _, r := range results {
+		if r.Failed() {
+			anyFailed = true
+			break
+		}
+	}
+	if notify.OnlyFailures && !anyFailed {
+		log.Debug().Msg("all backports succeeded, skipping notification")
+		return
+	}
+
+	mentions := formatMentions(notify.Mentions, prInfo.Author)
+
+	// Comments are keyed by a hidden marker, so later runs update them instead of adding more.
+	type comment struct {
+		marker string
+		body   string
+	}
+	var comments []comment
+	switch notify.Mode {
+	case config.NotifyDigest:
+		comments = append(comments, comment{forge.CommentMarker("summary"), formatDigestComment(prInfo, results, mentions)})
+	case config.NotifyBranch:
+		for _, r := range results {
+			if notify.OnlyFailures && !r.Failed() {
+				continue
+			}
+			comments = append(comments, comment{forge.CommentMarker("branch:" + r.TargetBranch), formatBranchComment(prInfo, r, mentions)})
+		}
+	}
+
+	for _, c := range comments {
+		if err := forge.UpsertComment(ctx, forgeClient, owner, repoName, prInfo.Number, c.marker, c.body); err != nil {
+			log.Warn().Err(err).Int("pr", prInfo.Number).Msg("failed to post backport notification")
+		}
+	}
+}
+
+// formatDigestComment returns a single comment summarizing the backports to all target branches.
+func formatDigestComment(prInfo *forge.PRInfo, results []backportci.Result, mentions string) string {
+	var sb strings.Builder
+
+	sb.WriteString("### Backport summary\n\n")
+	sb.WriteString("| Branch | Status | Details |\n")
+	sb.WriteString("|--------|--------|---------|\n")
+	for _, r := range results {
+		fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", r.TargetBranch, resultStatus(r), resultDetails(r))
+	}
+
+	for _, r := range results {
+		if r.Conflict && r.PRNumber == 0 {
+			sb.WriteString("\n")
+			sb.WriteString(formatManualBackport(prInfo, r.TargetBranch))
+		}
+	}
+
+	if mentions != "" {
+		fmt.Fprintf(&sb, "\ncc %s\n", mentions)
+	}
+
+	return sb.String()
+}
+
+// formatBranchComment returns a comment about the backport to a single target branch.
+func formatBranchComment(prInfo *forge.PRInfo, r backportci.Result, mentions string) string {
+	body := fmt.Sprintf("**%s**: backport to `%s` - %s\n", resultStatus(r), r.TargetBranch, resultDetails(r))
+	if r.Conflict && r.PRNumber == 0 {
+		body += "\n" + formatManualBackport(prInfo, r.TargetBranch)
+	}
+	if mentions != "" {
+		body += fmt.Sprintf("\ncc %s\n", mentions)
+	}
+	return body
+}
+
+// formatManualBackport returns the instructions to backport a PR to a target branch by hand,
+// for backports that failed with conflicts and have no draft PR to resolve them in.
+func formatManualBackport(prInfo *forge.PRInfo, targetBranch string) string {
+	branch := backportci.BranchName(prInfo.Number, targetBranch)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<details><summary>Backport to <code>%s</code> manually</summary>\n\n", targetBranch)
+	sb.WriteString("```sh\n")
+	sb.WriteString("git fetch origin\n")
+	fmt.Fprintf(&sb, "git switch -c %s origin/%s\n", branch, targetBranch)
+	fmt.Fprintf(&sb, "git cherry-pick -x %s\n", prInfo.MergeCommit)
+	sb.WriteString("# resolve the conflicts, then\n")
+	sb.WriteString("git add -A && git cherry-pick --continue\n")
+	fmt.Fprintf(&sb, "git push origin %s\n", branch)
+	sb.WriteString("```\n\n")
+	sb.WriteString("</details>\n")
+
+	return sb.String()
+}
+
+// resultStatus returns the status of a CI backport result, as shown in the CLI summary.
+func resultStatus(r backportci.Result) string {
+	switch {
+	case r.Deferred:
+		return "⏸️ Deferred"
+	case r.Skipped:
+		return "⏭️ Skipped"
+	case r.Success:
+		return "✓ Success"
+	default:
+		return "✗ Failed"
+	}
+}
+
+// resultDetails returns the message of a CI backport result, safe to use in a table cell.
+func resultDetails(r backportci.Result) string {
+	details := r.Message
+	if details == "" && r.PRNumber > 0 {
+		details = fmt.Sprintf("backport PR #%d", r.PRNumber)
+	}
+	if r.Diffstat != "" {
+		details += " (" + r.Diffstat + ")"
+	}
+	details = strings.ReplaceAll(logger.Redact(details), "\n", " ")
+	return strings.ReplaceAll(details, "|", `\|`)
+}
+
+// formatMentions returns the @-mentions for the configured users.
+// "author" is replaced by the author of the original PR.
+func formatMentions(users []string, author string) string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, user := range users {
+		if user == "author" {
+			user = author
+		}
+		user = strings.TrimPrefix(strings.TrimSpace(user), "@")
+		if user == "" || seen[user] {
+			continue
+		}
+		seen[user] = true
+		mentions = append(mentions, "@"+user)
+	}
+	return strings.Join(mentions, " ")
+}
@@ -0,0 +1,137 @@
+package backport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// commentForge records the comments posted through it.
+type commentForge struct {
+	forge.Forge
+	comments []string
+	updates  int
+}
+
+func (f *commentForge) CreateComment(_ context.Context, _, _ string, _ int, body string) error {
+	f.comments = append(f.comments, body)
+	return nil
+}
+
+func (f *commentForge) ListComments(_ context.Context, _, _ string, _ int) ([]forge.Comment, error) {
+	comments := make([]forge.Comment, 0, len(f.comments))
+	for i, body := range f.comments {
+		comments = append(comments, forge.Comment{ID: int64(i), Body: body})
+	}
+	return comments, nil
+}
+
+func (f *commentForge) UpdateComment(_ context.Context, _, _ string, _ int, id int64, body string) error {
+	f.comments[id] = body
+	f.updates++
+	return nil
+}
+
+func TestNotifyResults(t *testing.T) {
+	prInfo := &forge.PRInfo{Number: 42, Author: "alice"}
+	results := []backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 50, Message: "created backport PR #50"},
+		{TargetBranch: "release-2.x", Error: errors.New("conflict"), Message: "cherry-pick has conflicts - manual backport required"},
+	}
+	succeeded := results[:1]
+
+	tests := []struct {
+		name     string
+		notify   config.NotifyConfig
+		results  []backportci.Result
+		comments int
+	}{
+		{name: "off", notify: config.NotifyConfig{}, results: results, comments: 0},
+		{name: "branch", notify: config.NotifyConfig{Mode: config.NotifyBranch}, results: results, comments: 2},
+		{name: "branch only failures", notify: config.NotifyConfig{Mode: config.NotifyBranch, OnlyFailures: true}, results: results, comments: 1},
+		{name: "digest", notify: config.NotifyConfig{Mode: config.NotifyDigest}, results: results, comments: 1},
+		{name: "digest only failures without failure", notify: config.NotifyConfig{Mode: config.NotifyDigest, OnlyFailures: true}, results: succeeded, comments: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &commentForge{}
+			notifyResults(context.Background(), f, "owner", "repo", prInfo, tt.results, tt.notify)
+			assert.Len(t, f.comments, tt.comments)
+		})
+	}
+}
+
+func TestNotifyResultsUpdatesComments(t *testing.T) {
+	prInfo := &forge.PRInfo{Number: 42}
+	failed := []backportci.Result{
+		{TargetBranch: "release-1.x", Error: errors.New("conflict"), Message: "cherry-pick has conflicts"},
+		{TargetBranch: "release-2.x", Success: true, PRNumber: 50},
+	}
+	fixed := []backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 51},
+		{TargetBranch: "release-2.x", Success: true, PRNumber: 50},
+	}
+
+	t.Run("digest", func(t *testing.T) {
+		f := &commentForge{comments: []string{"LGTM"}}
+		notify := config.NotifyConfig{Mode: config.NotifyDigest}
+		notifyResults(context.Background(), f, "owner", "repo", prInfo, failed, notify)
+		notifyResults(context.Background(), f, "owner", "repo", prInfo, fixed, notify)
+
+		require.Len(t, f.comments, 2)
+		assert.Equal(t, "LGTM", f.comments[0])
+		assert.Contains(t, f.comments[1], "backport PR #51")
+		assert.Contains(t, f.comments[1], forge.CommentMarker("summary"))
+		assert.Equal(t, 1, f.updates)
+	})
+
+	t.Run("branch", func(t *testing.T) {
+		f := &commentForge{}
+		notify := config.NotifyConfig{Mode: config.NotifyBranch}
+		notifyResults(context.Background(), f, "owner", "repo", prInfo, failed, notify)
+		notifyResults(context.Background(), f, "owner", "repo", prInfo, fixed, notify)
+
+		require.Len(t, f.comments, 2)
+		assert.Contains(t, f.comments[0], "backport PR #51")
+		assert.Equal(t, 1, f.updates, "unchanged comments are not updated")
+	})
+}
+
+func TestFormatDigestComment(t *testing.T) {
+	results := []backportci.Result{
+		{TargetBranch: "release-1.x", Success: true, PRNumber: 50},
+		{TargetBranch: "release-2.x", Error: errors.New("failed"), Message: "failed to push: a | b"},
+		{TargetBranch: "release-3.x", Success: true, Skipped: true, Message: "changes already present on target branch"},
+	}
+	prInfo := &forge.PRInfo{Number: 42, MergeCommit: "abc123"}
+
+	comment := formatDigestComment(prInfo, results, "@alice")
+	require.Contains(t, comment, "### Backport summary")
+	assert.Contains(t, comment, "| `release-1.x` | ✓ Success | backport PR #50 |")
+	assert.Contains(t, comment, "| `release-2.x` | ✗ Failed | failed to push: a \\| b |")
+	assert.Contains(t, comment, "| `release-3.x` | ⏭️ Skipped | changes already present on target branch |")
+	assert.Contains(t, comment, "cc @alice")
+	assert.NotContains(t, comment, "manually")
+
+	// Conflicts come with the steps to backport by hand.
+	results = append(results, backportci.Result{TargetBranch: "release-4.x", Conflict: true, Error: errors.New("cherry-pick has conflicts")})
+	comment = formatDigestComment(prInfo, results, "")
+	assert.Contains(t, comment, "Backport to <code>release-4.x</code> manually")
+	assert.Contains(t, comment, "git switch -c backport-42-to-release-4.x origin/release-4.x\n")
+	assert.Contains(t, comment, "git cherry-pick -x abc123\n")
+	assert.NotContains(t, comment, "cc ")
+}
+
+func TestFormatMentions(t *testing.T) {
+	assert.Empty(t, formatMentions(nil, "alice"))
+	assert.Equal(t, "@alice @bob", formatMentions([]string{"author", "@bob", "alice"}, "alice"))
+	assert.Equal(t, "@bob", formatMentions([]string{"author", "bob"}, ""))
+}
@@ -0,0 +1,145 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+var exportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "export a commit or PR as a mailbox patch, to apply it with backport apply in another repository",
+	ArgsUsage: "<commit-sha|pr-number>",
+	Action:    backportExport,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "write the patch to this file instead of stdout",
+		},
+		strategyFlag,
+		pathsFlag,
+	},
+}
+
+var applyCmd = &cli.Command{
+	Name:      "apply",
+	Usage:     "apply a mailbox patch created by backport export onto a target branch, with the backport trailers",
+	ArgsUsage: "<patch-file> <target-branch>",
+	Action:    backportApply,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "show what would be done without making changes",
+		},
+		&cli.IntFlag{
+			Name:  "skip",
+			Usage: "skip the first patches of the mailbox, e.g. those applied before a conflict was resolved",
+		},
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		worktreeFlag,
+		forceFlag,
+	},
+}
+
+func backportExport(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("usage: backport export <commit-sha|pr-number>")
+	}
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	sha, prNumber, err := internal.ResolveCommitOrPR(ctx, service, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	var mbox []byte
+	if prNumber > 0 {
+		mbox, err = service.ExportPR(ctx, prNumber, c.String("strategy"), c.StringSlice("paths"))
+	} else {
+		mbox, err = service.ExportCommit(ctx, sha, c.StringSlice("paths"))
+	}
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	if output == "" {
+		_, err := os.Stdout.Write(mbox)
+		return err
+	}
+	if err := os.WriteFile(output, mbox, 0o644); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	patches, err := backport.ParseMailbox(mbox)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Exported %d patch(es) to %s\n", len(patches), output)
+	return nil
+}
+
+func backportApply(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 2 { //nolint:mnd
+		return fmt.Errorf("usage: backport apply <patch-file> <target-branch>")
+	}
+	path, targetBranch := c.Args().Get(0), c.Args().Get(1)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read patch: %w", err)
+	}
+	patches, err := backport.ParseMailbox(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	skip := c.Int("skip")
+	if skip < 0 || skip >= len(patches) {
+		return fmt.Errorf("--skip must be between 0 and %d, %s has %d patch(es)", len(patches)-1, path, len(patches))
+	}
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	opts := backport.BackportOptions{
+		TargetBranch:         targetBranch,
+		DryRun:               c.Bool("dry-run"),
+		Empty:                c.String("empty"),
+		KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+		UseWorktree:          c.Bool("worktree"),
+		Force:                c.Bool("force"),
+	}
+
+	// Patches build on each other, so stop at the first one that fails.
+	for i := skip; i < len(patches); i++ {
+		patch := patches[i]
+		log.Info().Str("branch", targetBranch).Str("sha", patch.OriginalSHA).Str("subject", patch.Subject).Msg("applying patch")
+
+		result, err := service.ApplyPatch(ctx, patch, opts)
+		if err == nil {
+			err = handleBackportResult(result)
+		}
+		if err != nil {
+			if remaining := len(patches) - i - 1; remaining > 0 {
+				fmt.Printf("%d patch(es) not applied yet, apply them afterwards with: backporter backport apply --skip %d %s %s\n",
+					remaining, i+1, path, targetBranch)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
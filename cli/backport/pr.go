@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
+	"github.com/charmbracelet/huh"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 
 	"codefloe.com/pat-s/backporter/cli/internal"
 	"codefloe.com/pat-s/backporter/cli/internal/config"
 	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/git"
 	"codefloe.com/pat-s/backporter/shared/logger"
 )
 
@@ -19,7 +23,7 @@ func backportPR(ctx context.Context, c *cli.Command) error {
 		return fmt.Errorf("usage: backport pr <pr-number> [target-branch]")
 	}
 
-	prNumberStr := c.Args().Get(0)
+	prNumberStr := strings.TrimPrefix(strings.TrimPrefix(c.Args().Get(0), internal.PRPrefix), "#")
 	dryRun := c.Bool("dry-run")
 
 	prNumber, err := strconv.Atoi(prNumberStr)
@@ -41,7 +45,6 @@ func backportPR(ctx context.Context, c *cli.Command) error {
 		if len(cfg.TargetBranches) == 0 {
 			return fmt.Errorf("usage: backport pr <pr-number> <target-branch>\n       (or configure target_branches in .backporter.yaml)")
 		}
-		targetBranches = cfg.TargetBranches
 	}
 
 	service, err := internal.CreateService(ctx, c)
@@ -49,14 +52,31 @@ func backportPR(ctx context.Context, c *cli.Command) error {
 		return err
 	}
 
+	// Expand configured patterns such as "release-.*" against the local and remote branches.
+	if len(targetBranches) == 0 {
+		targetBranches, err = service.TargetBranches()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Backport to each target branch.
 	var lastErr error
 	for _, targetBranch := range targetBranches {
 		log.Info().Str("branch", targetBranch).Int("pr", prNumber).Msg("backporting PR")
 
 		opts := backport.BackportOptions{
-			TargetBranch: targetBranch,
-			DryRun:       dryRun,
+			TargetBranch:         targetBranch,
+			DryRun:               dryRun,
+			Empty:                c.String("empty"),
+			KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+			MergeStrategy:        c.String("merge-strategy"),
+			StrategyOption:       c.StringSlice("strategy-option"),
+			Paths:                c.StringSlice("paths"),
+			UseWorktree:          c.Bool("worktree"),
+			SelectHunks:          c.Bool("interactive-hunks"),
+			Strategy:             c.String("strategy"),
+			Force:                c.Bool("force"),
 		}
 
 		result, err := service.BackportPR(ctx, prNumber, opts)
@@ -66,36 +86,186 @@ func backportPR(ctx context.Context, c *cli.Command) error {
 			continue
 		}
 
+		if result.HasConflict && !result.Aborted && c.Bool("create-pr") {
+			if err := backport.UpdatePending(func(p *backport.PendingBackport) {
+				p.CreatePR = true
+				p.Base = c.String("base")
+			}); err != nil {
+				log.Warn().Err(err).Msg("failed to update pending backport")
+			}
+		}
+
 		if err := handleBackportResult(result); err != nil {
 			lastErr = err
+			continue
+		}
+
+		if dryRun || result.Empty || !shouldCreatePR(c) {
+			continue
+		}
+
+		if err := createLocalBackportPR(ctx, c, service, result, c.String("base")); err != nil {
+			log.Error().Err(err).Str("branch", targetBranch).Msg("failed to create backport PR")
+			lastErr = err
 		}
 	}
 
 	return lastErr
 }
 
+// shouldCreatePR decides whether to open a PR for a local backport,
+// asking the user unless --create-pr was given explicitly.
+func shouldCreatePR(c *cli.Command) bool {
+	if c.IsSet("create-pr") || logger.IsCI() {
+		return c.Bool("create-pr")
+	}
+
+	var create bool
+	err := huh.NewConfirm().
+		Title("Push the backport branch and open a PR?").
+		Affirmative("Yes").
+		Negative("No").
+		Value(&create).
+		Run()
+	if err != nil {
+		return false
+	}
+
+	return create
+}
+
+// createLocalBackportPR moves a local backport onto its own branch, pushes it and opens a PR
+// against the target branch, like CI mode does. The local target branch is reset to its state
+// before the backport. With a base, the backport commit is cherry-picked onto a branch created
+// from base instead.
+func createLocalBackportPR(ctx context.Context, c *cli.Command, service *backport.Service, result *backport.BackportResult, base string) error {
+	cfg, err := config.GetConfig(c)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	prInfo, err := service.GetPR(ctx, result.PRNumber)
+	if err != nil {
+		return err
+	}
+
+	branchName := backportci.BranchName(result.PRNumber, result.TargetBranch)
+	remote := service.PushRemote()
+
+	if base == "" {
+		log.Debug().Str("branch", branchName).Str("from", result.BackportSHA).Msg("creating backport branch")
+		if err := git.CreateBranchFrom(branchName, result.BackportSHA); err != nil {
+			return err
+		}
+	} else {
+		base = backportci.BaseRef(base, remote, result.TargetBranch)
+		backportci.CheckBase(backportci.LocalGit{}, base, result.TargetSHA)
+		log.Debug().Str("branch", branchName).Str("from", base).Msg("creating backport branch")
+		if err := stageOnBase(branchName, base, result.BackportSHA, backport.CommitIdentity(cfg)); err != nil {
+			return err
+		}
+	}
+
+	log.Debug().Str("branch", branchName).Msg("pushing backport branch")
+	if err := service.PushBackport(branchName, result.TargetBranch); err != nil {
+		return err
+	}
+
+	prOpts := localBackportPROptions(cfg, prInfo, result.TargetBranch, result.TargetSHA, result.BackportSHA, followUpFixes(service.FollowUps(ctx, prInfo)))
+	prOpts.Draft = prOpts.Draft || c.Bool("draft")
+
+	newPRNumber, err := service.CreatePR(ctx, prOpts)
+	if err != nil && newPRNumber == 0 {
+		return fmt.Errorf("failed to create PR: %w", err)
+	}
+	if err != nil {
+		log.Warn().Err(err).Int("pr", newPRNumber).Msg("backport PR created without all metadata")
+	}
+	service.RecordBackportPR(result, newPRNumber)
+	if reviewers := backportci.OriginalReviewers(cfg.CI, prInfo); len(reviewers) > 0 {
+		if err := service.RequestReview(ctx, newPRNumber, reviewers); err != nil {
+			log.Warn().Err(err).Int("pr", newPRNumber).Strs("reviewers", reviewers).Msg("failed to request review")
+		}
+	}
+
+	// The backport is merged through the PR, keep the local target in sync with the remote.
+	if err := git.ResetBranch(result.TargetBranch, result.TargetSHA); err != nil {
+		log.Warn().Err(err).Str("branch", result.TargetBranch).Msg("failed to restore target branch, the backport commit is still on it")
+	}
+
+	fmt.Printf("✓ Opened backport PR #%d (%s → %s)\n", newPRNumber, branchName, result.TargetBranch)
+	fmt.Println()
+
+	return nil
+}
+
+// stageOnBase creates branchName from base and cherry-picks sha onto it in a temporary worktree,
+// committed with identity, leaving the current checkout untouched.
+func stageOnBase(branchName, base, sha string, identity *git.Identity) error {
+	if err := git.CreateBranchFrom(branchName, base); err != nil {
+		return err
+	}
+
+	cpResult, err := backportci.LocalGit{}.CherryPick(branchName, sha, git.CherryPickOptions{Identity: identity}, "", true)
+	if err == nil {
+		switch {
+		case cpResult.HasConflict:
+			err = fmt.Errorf("backport conflicts with base %s", base)
+		case cpResult.Empty:
+			err = fmt.Errorf("backport is already present on base %s", base)
+		}
+	}
+	if err != nil {
+		_ = git.DeleteBranch(branchName)
+		return err
+	}
+
+	return nil
+}
+
 func handleBackportResult(result *backport.BackportResult) error {
+	if result.Aborted {
+		log.Debug().Msg("cherry-pick in worktree resulted in conflicts and was aborted")
+
+		fmt.Println()
+		fmt.Printf("✗ Cherry-pick onto %s resulted in conflicts and was aborted\n", result.TargetBranch)
+		fmt.Println()
+		fmt.Println("Run again without --worktree to resolve the conflicts manually.")
+		fmt.Println()
+		fmt.Println("Conflict details:")
+		fmt.Println(logger.Redact(result.Message))
+
+		return fmt.Errorf("%w in worktree", backport.ErrConflict)
+	}
+
 	if result.HasConflict {
 		log.Debug().Msg("cherry-pick resulted in conflicts")
 
 		if logger.IsCI() {
-			return fmt.Errorf("cherry-pick conflicts detected in CI mode")
+			return fmt.Errorf("%w detected in CI mode", backport.ErrConflict)
 		}
 
 		fmt.Println()
 		fmt.Println("✗ Cherry-pick resulted in conflicts")
 		fmt.Println()
 		fmt.Println("To resolve:")
-		fmt.Println("  1. Fix the conflicts in the affected files")
-		fmt.Println("  2. Run: git cherry-pick --continue")
+		fmt.Println("  1. Fix the conflicts in the affected files and stage them")
+		fmt.Println("  2. Run: backporter backport continue")
 		fmt.Println()
 		fmt.Println("To abort:")
 		fmt.Println("  Run: git cherry-pick --abort")
 		fmt.Println()
 		fmt.Println("Conflict details:")
-		fmt.Println(result.Message)
+		fmt.Println(logger.Redact(result.Message))
 
-		return fmt.Errorf("cherry-pick conflicts need resolution")
+		return fmt.Errorf("%w need resolution", backport.ErrConflict)
+	}
+
+	if result.Empty {
+		fmt.Println()
+		fmt.Printf("✓ Nothing to backport to %s: changes are already present\n", result.TargetBranch)
+		fmt.Println()
+		return nil
 	}
 
 	if result.Success {
@@ -0,0 +1,100 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// defaultPager shows long output a screen at a time, and exits right away for short output.
+const defaultPager = "less -FRX"
+
+// confirmPR shows the files changed by a PR and asks whether to backport it.
+// Returns false if the user wants to pick another PR.
+func confirmPR(ctx context.Context, service *backport.Service, prNumber int) (bool, error) {
+	pr, err := service.GetPR(ctx, prNumber)
+	if err != nil {
+		return false, err
+	}
+
+	files, err := service.ListPRFiles(ctx, prNumber)
+	if err != nil {
+		// The preview is a convenience, the backport works without it.
+		log.Warn().Err(err).Int("pr", prNumber).Msg("failed to fetch changed files, skipping preview")
+		return true, nil
+	}
+
+	showPaged(formatPRPreview(pr, files))
+
+	confirmed := true
+	err = huh.NewConfirm().
+		Title(fmt.Sprintf("Backport PR #%d?", prNumber)).
+		Affirmative("Yes").
+		Negative("Pick another PR").
+		Value(&confirmed).
+		Run()
+	return confirmed, err
+}
+
+// formatPRPreview renders the title and changed files of a PR.
+func formatPRPreview(pr *forge.PRInfo, files []forge.ChangedFile) string {
+	additions, deletions, width := 0, 0, 0
+	for _, f := range files {
+		additions += f.Additions
+		deletions += f.Deletions
+		width = max(width, len(f.Path))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "PR #%d: %s (%s)\n", pr.Number, pr.Title, pr.Author)
+	fmt.Fprintf(&b, "%d files changed, +%d -%d\n\n", len(files), additions, deletions)
+	for _, f := range files {
+		fmt.Fprintf(&b, "  %s  %-*s  +%d -%d\n", fileStatusLetter(f.Status), width, f.Path, f.Additions, f.Deletions)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// fileStatusLetter abbreviates a file status like git status --short.
+func fileStatusLetter(status string) string {
+	switch status {
+	case "added":
+		return "A"
+	case "removed":
+		return "D"
+	case "renamed":
+		return "R"
+	default:
+		return "M"
+	}
+}
+
+// showPaged prints text through $PAGER (or less) when stdout is a terminal, and directly otherwise.
+func showPaged(text string) {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = strings.Fields(defaultPager)
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print(text)
+		return
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Debug().Err(err).Msg("failed to run pager")
+		fmt.Print(text)
+	}
+}
@@ -0,0 +1,25 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestFormatPRPreview(t *testing.T) {
+	pr := &forge.PRInfo{Number: 42, Title: "Fix crash", Author: "alice"}
+	files := []forge.ChangedFile{
+		{Path: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "internal/new.go", Status: "added", Additions: 7},
+		{Path: "old.go", Status: "removed", Deletions: 10},
+	}
+
+	expected := "PR #42: Fix crash (alice)\n" +
+		"3 files changed, +10 -11\n\n" +
+		"  M  main.go          +3 -1\n" +
+		"  A  internal/new.go  +7 -0\n" +
+		"  D  old.go           +0 -10\n\n"
+	assert.Equal(t, expected, formatPRPreview(pr, files))
+}
@@ -0,0 +1,122 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+)
+
+// describeQuietWindow returns a short description of a quiet window for logs and comments.
+func describeQuietWindow(w *config.QuietWindow) string {
+	switch {
+	case w.From != "" || w.Until != "":
+		return fmt.Sprintf("quiet period %s to %s", orOpen(w.From), orOpen(w.Until))
+	case w.Start != "":
+		return fmt.Sprintf("quiet hours %s-%s", w.Start, w.End)
+	default:
+		return "quiet hours"
+	}
+}
+
+// orOpen returns the date, or "open" for an unbounded end of a quiet period.
+func orOpen(date string) string {
+	if date == "" {
+		return "open"
+	}
+	return date
+}
+
+// deferBackports queues the backports of a PR for a run outside the quiet window and returns
+// their deferred results. Nothing is queued in dry-run mode or without the cache.
+func deferBackports(cfg *config.Config, owner, repoName string, prNumber int, targetBranches []string, window *config.QuietWindow, dryRun bool) []backportci.Result {
+	reason := describeQuietWindow(window)
+	log.Info().Int("pr", prNumber).Str("window", reason).Msg("deferring backports during quiet hours")
+
+	message := fmt.Sprintf("deferred during %s, queued for a later run", reason)
+	switch {
+	case dryRun:
+		message = fmt.Sprintf("dry-run: would defer during %s", reason)
+	case !cfg.Cache.Enabled:
+		message = fmt.Sprintf("deferred during %s, enable the cache to queue it", reason)
+		log.Warn().Msg("cache is disabled, deferred backports are not queued")
+	default:
+		if err := backport.NewDeferredQueue(cfg.Cache.Path).Add(owner, repoName, prNumber, targetBranches); err != nil {
+			log.Warn().Err(err).Msg("failed to queue deferred backports")
+		}
+	}
+
+	results := make([]backportci.Result, 0, len(targetBranches))
+	for _, targetBranch := range targetBranches {
+		results = append(results, backportci.Result{TargetBranch: targetBranch, Deferred: true, Message: message})
+	}
+	return results
+}
+
+// backportDeferred processes the backports queued during quiet hours, e.g. from a scheduled
+// CI job. Entries stay queued while still in quiet hours or if the PR cannot be fetched.
+func backportDeferred(ctx context.Context, c *cli.Command) error {
+	dryRun := c.Bool("dry-run")
+
+	service, cfg, forgeClient, owner, repoName, err := prepareCI(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if window := cfg.CI.QuietWindowAt(time.Now()); window != nil {
+		log.Info().Str("window", describeQuietWindow(window)).Msg("still in quiet hours, keeping deferred backports queued")
+		return nil
+	}
+
+	if !cfg.Cache.Enabled {
+		return fmt.Errorf("deferred backports are queued in the cache, which is disabled")
+	}
+	queue := backport.NewDeferredQueue(cfg.Cache.Path)
+
+	var all []backportci.Result
+	var fetchErr error
+	for _, entry := range queue.List() {
+		if entry.Owner != owner || entry.Repo != repoName {
+			continue
+		}
+
+		log.Info().Int("pr", entry.PRNumber).Strs("branches", entry.TargetBranches).Time("deferred_at", entry.DeferredAt).Msg("processing deferred backport")
+
+		prInfo, err := getPR(ctx, forgeClient, newPRCache(cfg), owner, repoName, entry.PRNumber, !c.Bool("no-stale-cache"))
+		if err != nil {
+			log.Error().Err(err).Int("pr", entry.PRNumber).Msg("failed to get PR, keeping it queued")
+			fetchErr = fmt.Errorf("failed to get PR #%d: %w", entry.PRNumber, err)
+			continue
+		}
+
+		followUps := followUpFixes(backport.FindFollowUps(ctx, forgeClient, owner, repoName, prInfo))
+		results := backportToTargets(ctx, c, cfg, service, forgeClient, prInfo, followUps, entry.TargetBranches)
+		outputCISummary(results, prInfo.Number)
+		all = append(all, results...)
+
+		if dryRun {
+			continue
+		}
+		notifyResults(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI.Notify)
+		labelOriginalPR(ctx, forgeClient, owner, repoName, prInfo, results, cfg.CI)
+		if err := queue.Remove(owner, repoName, entry.PRNumber); err != nil {
+			log.Warn().Err(err).Int("pr", entry.PRNumber).Msg("failed to remove deferred backport from the queue")
+		}
+	}
+
+	if all == nil && fetchErr == nil {
+		log.Info().Msg("no deferred backports queued")
+	}
+	writeActionsOutputs(all)
+
+	if err := backportci.FailedError(all); err != nil {
+		return err
+	}
+	return fetchErr
+}
@@ -0,0 +1,48 @@
+package backport
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+)
+
+func TestDescribeQuietWindow(t *testing.T) {
+	assert.Equal(t, "quiet hours 22:00-06:00", describeQuietWindow(&config.QuietWindow{Start: "22:00", End: "06:00"}))
+	assert.Equal(t, "quiet period 2024-12-20 to 2025-01-06", describeQuietWindow(&config.QuietWindow{From: "2024-12-20", Until: "2025-01-06"}))
+	assert.Equal(t, "quiet period 2024-12-20 to open", describeQuietWindow(&config.QuietWindow{From: "2024-12-20"}))
+	assert.Equal(t, "quiet hours", describeQuietWindow(&config.QuietWindow{Days: []string{"sun"}}))
+}
+
+func TestDeferBackports(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Cache.Enabled = true
+	cfg.Cache.Path = filepath.Join(t.TempDir(), "history.json")
+	window := &config.QuietWindow{From: "2024-12-20", Until: "2025-01-06"}
+	branches := []string{"release-1.x", "release-2.x"}
+
+	// Dry runs queue nothing.
+	results := deferBackports(cfg, "owner", "repo", 42, branches, window, true)
+	require.Len(t, results, 2)
+	assert.Empty(t, backport.NewDeferredQueue(cfg.Cache.Path).List())
+
+	results = deferBackports(cfg, "owner", "repo", 42, branches, window, false)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.True(t, r.Deferred)
+		assert.False(t, r.Failed())
+		assert.Equal(t, "⏸️ Deferred", resultStatus(r))
+		assert.Contains(t, r.Message, "quiet period 2024-12-20 to 2025-01-06")
+	}
+	assert.NoError(t, backportci.FailedError(results))
+
+	queued := backport.NewDeferredQueue(cfg.Cache.Path).List()
+	require.Len(t, queued, 1)
+	assert.Equal(t, 42, queued[0].PRNumber)
+	assert.Equal(t, branches, queued[0].TargetBranches)
+}
@@ -0,0 +1,380 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Release report formats.
+const (
+	reportFormatMarkdown = "markdown"
+	reportFormatHTML     = "html"
+)
+
+// Release report sections.
+const (
+	sectionBackported = "Backported"
+	sectionPending    = "Not yet backported"
+	sectionConflicts  = "Conflicts"
+)
+
+// defaultReportLimit is the number of recently merged PRs a release report scans.
+const defaultReportLimit = 100
+
+// ReportCommand prints a release-planning report of the backports to a target branch.
+var ReportCommand = &cli.Command{
+	Name:   "report",
+	Usage:  "summarize merged backports, labeled PRs not yet backported and conflicted backports of a target branch, e.g. for a release-planning issue",
+	Action: printReport,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "target",
+			Usage:    "target branch to report on",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "tag or ref of the last release on the target, backports it contains are left out",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "report format: markdown or html",
+			Value: reportFormatMarkdown,
+			Validator: func(s string) error {
+				if s != reportFormatMarkdown && s != reportFormatHTML {
+					return fmt.Errorf("invalid format: %s (must be 'markdown' or 'html')", s)
+				}
+				return nil
+			},
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of recently merged PRs to scan for backport PRs and PRs still to backport",
+			Value: defaultReportLimit,
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "write the report to a file instead of stdout",
+		},
+	},
+}
+
+// reportItem is a change in a release report.
+type reportItem struct {
+	PR          int // Original PR, 0 for individual commits
+	Title       string
+	SHA         string // Original commit
+	BackportPR  int
+	BackportSHA string
+}
+
+// releaseReport is the backport state of a target branch.
+type releaseReport struct {
+	Target     string
+	Since      string
+	Backported []reportItem
+	Pending    []reportItem
+	Conflicts  []reportItem
+}
+
+// reportSources holds what a release report is built from.
+type reportSources struct {
+	History   []backport.CacheEntry
+	MergedPRs []*forge.PRInfo // Recently merged PRs, original and backport PRs
+	OpenPRs   []*forge.PRInfo
+	Stopped   *backport.PendingBackport // Local backport stopped on conflicts, if any
+
+	// Shipped reports whether a commit is part of the last release.
+	Shipped func(sha string) bool
+	// Landed reports whether a commit is on the target branch, directly or backported.
+	Landed func(sha string) bool
+}
+
+func printReport(ctx context.Context, c *cli.Command) error {
+	target := c.String("target")
+	since := c.String("since")
+
+	service, cfg, forgeClient, owner, repoName, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// Prefer the published branch, a local one may be outdated.
+	targetRef := cfg.WriteRemote() + "/" + target
+	if !git.CommitExists(targetRef) {
+		targetRef = target
+		if !git.CommitExists(targetRef) {
+			return &backport.BranchNotFoundError{Branch: target, Remote: cfg.WriteRemote()}
+		}
+	}
+	if since != "" && !git.CommitExists(since) {
+		return fmt.Errorf("release %s not found, fetch its tag first", since)
+	}
+
+	sources := reportSources{
+		History: service.ListBackports(),
+		Shipped: func(sha string) bool {
+			if since == "" || sha == "" {
+				return false
+			}
+			shipped, err := git.IsAncestor(sha, since)
+			return err == nil && shipped
+		},
+		Landed: func(sha string) bool {
+			return sha != "" && service.LandedOn(sha, target, targetRef) != ""
+		},
+	}
+
+	if sources.MergedPRs, err = forgeClient.ListRecentPRs(ctx, owner, repoName, c.Int("limit")); err != nil {
+		return fmt.Errorf("failed to list merged PRs: %w", err)
+	}
+	pushOwner, pushRepoName := service.PushRepo()
+	if pushOwner != owner || pushRepoName != repoName {
+		backportPRs, err := forgeClient.ListRecentPRs(ctx, pushOwner, pushRepoName, c.Int("limit"))
+		if err != nil {
+			return fmt.Errorf("failed to list merged backport PRs: %w", err)
+		}
+		sources.MergedPRs = append(sources.MergedPRs, backportPRs...)
+	}
+	if sources.OpenPRs, err = forgeClient.ListOpenPRs(ctx, pushOwner, pushRepoName, forge.ListPROptions{}); err != nil {
+		return fmt.Errorf("failed to list open PRs: %w", err)
+	}
+	if sources.Stopped, err = backport.LoadPending(); err != nil {
+		log.Warn().Err(err).Msg("failed to load the backport in progress")
+	}
+
+	report := buildReleaseReport(target, since, sources)
+
+	out := io.Writer(os.Stdout)
+	if path := c.String("output"); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if c.String("format") == reportFormatHTML {
+		return renderReportHTML(out, report)
+	}
+	return renderReportMarkdown(out, report)
+}
+
+// buildReleaseReport sorts the changes of the sources into the sections of a release report.
+// Changes without a backport to the target are pending if their PR has a backport label,
+// unless a backport with conflicts is open for them.
+func buildReleaseReport(target, since string, src reportSources) releaseReport {
+	report := releaseReport{Target: target, Since: since}
+
+	originals := make(map[int]*forge.PRInfo)
+	for _, pr := range src.MergedPRs {
+		if _, _, ok := parseBackportBranchName(pr.HeadBranch); !ok {
+			originals[pr.Number] = pr
+		}
+	}
+	title := func(number int, fallback string) string {
+		if pr, ok := originals[number]; ok {
+			return pr.Title
+		}
+		return fallback
+	}
+
+	// Backports of PRs are listed once, with the history entry and the backport PR merged.
+	backported := make(map[int]int)
+	addBackported := func(item reportItem) {
+		if item.PR > 0 {
+			if i, ok := backported[item.PR]; ok {
+				existing := &report.Backported[i]
+				existing.BackportPR = max(existing.BackportPR, item.BackportPR)
+				if existing.BackportSHA == "" {
+					existing.BackportSHA = item.BackportSHA
+				}
+				return
+			}
+			backported[item.PR] = len(report.Backported)
+		}
+		report.Backported = append(report.Backported, item)
+	}
+
+	for _, entry := range src.History {
+		if entry.TargetBranch != target || src.Shipped(entry.BackportSHA) {
+			continue
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(entry.Message), "\n")
+		addBackported(reportItem{PR: entry.PRNumber, Title: title(entry.PRNumber, subject), SHA: entry.OriginalSHA, BackportSHA: entry.BackportSHA})
+	}
+	for _, pr := range src.MergedPRs {
+		number, prTarget, ok := parseBackportBranchName(pr.HeadBranch)
+		if !ok || prTarget != target || src.Shipped(pr.MergeCommit) {
+			continue
+		}
+		addBackported(reportItem{PR: number, Title: title(number, pr.Title), BackportPR: pr.Number, BackportSHA: pr.MergeCommit})
+	}
+
+	conflicted := make(map[int]bool)
+	open := make(map[int]int)
+	for _, pr := range src.OpenPRs {
+		number, prTarget, ok := parseBackportBranchName(pr.HeadBranch)
+		if !ok || prTarget != target {
+			continue
+		}
+		if !slices.Contains(pr.Labels, backportci.ConflictLabel) {
+			open[number] = pr.Number
+			continue
+		}
+		conflicted[number] = true
+		report.Conflicts = append(report.Conflicts, reportItem{PR: number, Title: title(number, pr.Title), BackportPR: pr.Number})
+	}
+	if stopped := src.Stopped; stopped != nil && stopped.TargetBranch == target && !conflicted[stopped.PRNumber] {
+		if stopped.PRNumber > 0 {
+			conflicted[stopped.PRNumber] = true
+		}
+		report.Conflicts = append(report.Conflicts, reportItem{PR: stopped.PRNumber, Title: title(stopped.PRNumber, ""), SHA: stopped.OriginalSHA})
+	}
+
+	for _, pr := range src.MergedPRs {
+		if originals[pr.Number] != pr || pr.BaseBranch == target || !pr.HasBackportLabel() {
+			continue
+		}
+		if _, ok := backported[pr.Number]; ok || conflicted[pr.Number] || src.Landed(pr.MergeCommit) {
+			continue
+		}
+		report.Pending = append(report.Pending, reportItem{PR: pr.Number, Title: pr.Title, SHA: pr.MergeCommit, BackportPR: open[pr.Number]})
+	}
+
+	for _, items := range [][]reportItem{report.Backported, report.Pending, report.Conflicts} {
+		slices.SortStableFunc(items, compareReportItems)
+	}
+	return report
+}
+
+// compareReportItems orders items by PR number, with individual commits last.
+func compareReportItems(a, b reportItem) int {
+	switch {
+	case a.PR == b.PR:
+		return 0
+	case a.PR == 0:
+		return 1
+	case b.PR == 0:
+		return -1
+	default:
+		return a.PR - b.PR
+	}
+}
+
+// reportSection is a titled list of a release report.
+type reportSection struct {
+	Title string
+	Items []reportItem
+	Empty string
+}
+
+// sections returns the sections of the report in display order.
+func (r releaseReport) sections() []reportSection {
+	return []reportSection{
+		{sectionBackported, r.Backported, "No backports yet."},
+		{sectionPending, r.Pending, "Nothing left to backport."},
+		{sectionConflicts, r.Conflicts, "No conflicted backports."},
+	}
+}
+
+// heading returns the title of the report, e.g. "Backports to release-1.x since v1.4.0".
+func (r releaseReport) heading() string {
+	if r.Since != "" {
+		return fmt.Sprintf("Backports to %s since %s", r.Target, r.Since)
+	}
+	return "Backports to " + r.Target
+}
+
+// summary describes an item in one line, e.g. "Fix crash (#42): backport PR #50".
+func (item reportItem) summary(section string) string {
+	var b strings.Builder
+	switch {
+	case item.Title != "":
+		b.WriteString(item.Title)
+	case item.SHA != "":
+		b.WriteString(backport.ShortSHA(item.SHA))
+	default:
+		b.WriteString("Unknown change")
+	}
+	if item.PR > 0 && !strings.Contains(item.Title, fmt.Sprintf("#%d", item.PR)) {
+		fmt.Fprintf(&b, " (#%d)", item.PR)
+	}
+
+	switch {
+	case item.BackportPR > 0 && section == sectionConflicts:
+		fmt.Fprintf(&b, ": draft backport PR #%d with conflicts", item.BackportPR)
+	case item.BackportPR > 0 && section == sectionPending:
+		fmt.Fprintf(&b, ": backport PR #%d open", item.BackportPR)
+	case item.BackportPR > 0:
+		fmt.Fprintf(&b, ": backport PR #%d", item.BackportPR)
+	case section == sectionConflicts:
+		b.WriteString(": stopped on conflicts locally")
+	case item.BackportSHA != "":
+		fmt.Fprintf(&b, ": %s → %s", backport.ShortSHA(item.SHA), backport.ShortSHA(item.BackportSHA))
+	}
+	return b.String()
+}
+
+// renderReportMarkdown writes the report as Markdown, e.g. for a release-planning issue.
+func renderReportMarkdown(w io.Writer, report releaseReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", report.heading())
+	for _, section := range report.sections() {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", section.Title, len(section.Items))
+		if len(section.Items) == 0 {
+			fmt.Fprintf(&b, "%s\n", section.Empty)
+			continue
+		}
+		for _, item := range section.Items {
+			check := " "
+			if section.Title == sectionBackported {
+				check = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", check, item.summary(section.Title))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderReportHTML writes the report as a standalone HTML document.
+func renderReportHTML(w io.Writer, report releaseReport) error {
+	heading := html.EscapeString(report.heading())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n", heading, heading)
+	for _, section := range report.sections() {
+		fmt.Fprintf(&b, "<h2>%s (%d)</h2>\n", html.EscapeString(section.Title), len(section.Items))
+		if len(section.Items) == 0 {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(section.Empty))
+			continue
+		}
+		b.WriteString("<ul>\n")
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(item.summary(section.Title)))
+		}
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
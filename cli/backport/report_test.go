@@ -0,0 +1,94 @@
+package backport
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestBuildReleaseReport(t *testing.T) {
+	src := reportSources{
+		History: []backport.CacheEntry{
+			{OriginalSHA: "aaaa1111", BackportSHA: "bbbb1111", TargetBranch: "release-1.x", PRNumber: 1, Message: "Fix crash"},
+			{OriginalSHA: "aaaa2222", BackportSHA: "bbbb2222", TargetBranch: "release-1.x", Message: "Fix typo\n\nDetails"},
+			{OriginalSHA: "aaaa3333", BackportSHA: "shipped", TargetBranch: "release-1.x", PRNumber: 3, Message: "Old fix"},
+			{OriginalSHA: "aaaa4444", BackportSHA: "bbbb4444", TargetBranch: "release-2.x", PRNumber: 4, Message: "Other branch"},
+		},
+		MergedPRs: []*forge.PRInfo{
+			{Number: 1, Title: "fix: crash", MergeCommit: "aaaa1111", Labels: []string{"backport"}},
+			{Number: 5, Title: "fix: leak", MergeCommit: "aaaa5555", Labels: []string{"backport"}},
+			{Number: 6, Title: "fix: race", MergeCommit: "aaaa6666", Labels: []string{"backport"}},
+			{Number: 7, Title: "fix: panic", MergeCommit: "aaaa7777", Labels: []string{"backport"}},
+			{Number: 8, Title: "feat: new", MergeCommit: "aaaa8888"},
+			{Number: 9, Title: "fix: on target", MergeCommit: "aaaa9999", Labels: []string{"backport"}},
+			{Number: 10, Title: "fix: by hand", MergeCommit: "aaaa1010", Labels: []string{"backport"}},
+			{Number: 11, Title: "fix: docs", MergeCommit: "aaaa1011", Labels: []string{"backport"}},
+			{Number: 20, Title: "fix: backport #1 to release-1.x", HeadBranch: backportci.BranchName(1, "release-1.x"), BaseBranch: "release-1.x", MergeCommit: "cccc1111"},
+			{Number: 21, Title: "fix: backport #11 to release-1.x", HeadBranch: backportci.BranchName(11, "release-1.x"), BaseBranch: "release-1.x", MergeCommit: "cccc1011"},
+		},
+		OpenPRs: []*forge.PRInfo{
+			{Number: 30, HeadBranch: backportci.BranchName(6, "release-1.x"), Labels: []string{backportci.ConflictLabel}},
+			{Number: 31, HeadBranch: backportci.BranchName(7, "release-1.x")},
+			{Number: 32, HeadBranch: backportci.BranchName(5, "release-2.x"), Labels: []string{backportci.ConflictLabel}},
+		},
+		Stopped: &backport.PendingBackport{OriginalSHA: "aaaa9999", TargetBranch: "release-1.x", PRNumber: 9},
+		Shipped: func(sha string) bool { return sha == "shipped" },
+		Landed:  func(sha string) bool { return sha == "aaaa1010" },
+	}
+
+	report := buildReleaseReport("release-1.x", "v1.4.0", src)
+
+	assert.Equal(t, []reportItem{
+		{PR: 1, Title: "fix: crash", SHA: "aaaa1111", BackportPR: 20, BackportSHA: "bbbb1111"},
+		{PR: 11, Title: "fix: docs", BackportPR: 21, BackportSHA: "cccc1011"},
+		{Title: "Fix typo", SHA: "aaaa2222", BackportSHA: "bbbb2222"},
+	}, report.Backported)
+	assert.Equal(t, []reportItem{
+		{PR: 5, Title: "fix: leak", SHA: "aaaa5555"},
+		{PR: 7, Title: "fix: panic", SHA: "aaaa7777", BackportPR: 31},
+	}, report.Pending)
+	assert.Equal(t, []reportItem{
+		{PR: 6, Title: "fix: race", BackportPR: 30},
+		{PR: 9, Title: "fix: on target", SHA: "aaaa9999"},
+	}, report.Conflicts)
+}
+
+func TestRenderReportMarkdown(t *testing.T) {
+	report := releaseReport{
+		Target:     "release-1.x",
+		Since:      "v1.4.0",
+		Backported: []reportItem{{PR: 1, Title: "fix: crash", BackportPR: 20}, {Title: "Fix typo", SHA: "aaaa2222aaaa", BackportSHA: "bbbb2222bbbb"}},
+		Pending:    []reportItem{{PR: 7, Title: "fix: panic", BackportPR: 31}},
+		Conflicts:  []reportItem{{PR: 6, Title: "fix: race", BackportPR: 30}, {PR: 9, Title: "fix: on target"}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, renderReportMarkdown(&out, report))
+	assert.Equal(t, `# Backports to release-1.x since v1.4.0
+
+## Backported (2)
+
+- [x] fix: crash (#1): backport PR #20
+- [x] Fix typo: aaaa222 → bbbb222
+
+## Not yet backported (1)
+
+- [ ] fix: panic (#7): backport PR #31 open
+
+## Conflicts (2)
+
+- [ ] fix: race (#6): draft backport PR #30 with conflicts
+- [ ] fix: on target (#9): stopped on conflicts locally
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, renderReportHTML(&out, releaseReport{Target: "release-1.x"}))
+	assert.Contains(t, out.String(), "<h1>Backports to release-1.x</h1>")
+	assert.Contains(t, out.String(), "<p>Nothing left to backport.</p>")
+}
@@ -0,0 +1,166 @@
+package backport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Conflict resolution menu values, file specific steps are suffixed with ":<file>".
+const (
+	resolveEdit      = "edit"
+	resolveMergetool = "mergetool"
+	resolveMark      = "mark"
+	resolveContinue  = "continue"
+	resolveAbort     = "abort"
+	resolveLater     = "later"
+)
+
+// withConflictResolution wraps a backport so conflicts are resolved in a guided flow right away.
+func withConflictResolution(
+	ctx context.Context,
+	service *backport.Service,
+	run func(opts backport.BackportOptions) (*backport.BackportResult, error),
+) func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+	return func(opts backport.BackportOptions) (*backport.BackportResult, error) {
+		result, err := run(opts)
+		if err != nil || !result.HasConflict || result.Aborted {
+			return result, err
+		}
+		return resolveConflicts(ctx, service, result)
+	}
+}
+
+// resolveConflicts guides the user through the conflicts of a backport: each conflicted file
+// can be edited or opened in the merge tool, files without conflict markers are marked resolved,
+// and once none are left the backport is continued and signed like with backport continue.
+// The conflicting result is returned unchanged if the user leaves the conflicts for later.
+func resolveConflicts(ctx context.Context, service *backport.Service, result *backport.BackportResult) (*backport.BackportResult, error) {
+	fmt.Println()
+	fmt.Printf("✗ Cherry-pick onto %s resulted in conflicts\n", result.TargetBranch)
+
+	mergetool := git.MergetoolConfigured()
+	for {
+		conflicts := git.ConflictedFiles()
+		fmt.Println()
+		if len(conflicts) == 0 {
+			fmt.Println("✓ All conflicts are resolved")
+		} else {
+			fmt.Println("Conflicted files:")
+			for _, file := range conflicts {
+				fmt.Printf("  - %s\n", file)
+			}
+		}
+		fmt.Println()
+
+		var step string
+		err := huh.NewSelect[string]().
+			Title("Resolve the conflicts").
+			Options(resolveOptions(conflicts, mergetool)...).
+			Value(&step).
+			Run()
+		if errors.Is(err, huh.ErrUserAborted) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		action, file, _ := strings.Cut(step, ":")
+		switch action {
+		case resolveEdit:
+			if err := git.EditFile(file); err != nil {
+				log.Error().Err(err).Str("file", file).Msg("failed to edit file")
+				continue
+			}
+			markResolvedIfClean(file)
+
+		case resolveMergetool:
+			if err := git.RunMergetool(file); err != nil {
+				log.Error().Err(err).Str("file", file).Msg("failed to run merge tool")
+			}
+
+		case resolveMark:
+			if markers, err := git.HasConflictMarkers(file); err == nil && markers && !confirm(file+" still contains conflict markers, mark it as resolved anyway?") {
+				continue
+			}
+			if err := git.MarkResolved(file); err != nil {
+				log.Error().Err(err).Str("file", file).Msg("failed to mark file as resolved")
+			}
+
+		case resolveContinue:
+			continued, _, err := service.ContinueBackport(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to continue backport")
+				continue
+			}
+			return continued, nil
+
+		case resolveAbort:
+			if _, err := service.AbortBackport(ctx); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("backport to %s aborted because of conflicts", result.TargetBranch)
+
+		default:
+			return result, nil
+		}
+	}
+}
+
+// resolveOptions returns the menu options for resolving the conflicted files. Continuing
+// is only offered once no conflicts are left.
+func resolveOptions(conflicts []string, mergetool bool) []huh.Option[string] {
+	var options []huh.Option[string]
+	if len(conflicts) == 0 {
+		options = append(options, huh.NewOption("Continue and finish the backport", resolveContinue))
+	}
+	for _, file := range conflicts {
+		options = append(options, huh.NewOption("Edit "+file, resolveEdit+":"+file))
+		if mergetool {
+			options = append(options, huh.NewOption("Open "+file+" in the merge tool", resolveMergetool+":"+file))
+		}
+		options = append(options, huh.NewOption("Mark "+file+" as resolved", resolveMark+":"+file))
+	}
+
+	options = append(options,
+		huh.NewOption("Abort the backport", resolveAbort),
+		huh.NewOption("Leave the conflicts to resolve later", resolveLater),
+	)
+
+	return options
+}
+
+// markResolvedIfClean marks an edited file as resolved once it has no conflict markers left.
+func markResolvedIfClean(file string) {
+	markers, err := git.HasConflictMarkers(file)
+	if err != nil {
+		log.Error().Err(err).Str("file", file).Msg("failed to check for conflict markers")
+		return
+	}
+	if markers {
+		fmt.Printf("%s still contains conflict markers\n", file)
+		return
+	}
+	if err := git.MarkResolved(file); err != nil {
+		log.Error().Err(err).Str("file", file).Msg("failed to mark file as resolved")
+		return
+	}
+	fmt.Printf("✓ Marked %s as resolved\n", file)
+}
+
+// confirm asks a yes/no question, defaulting to no.
+func confirm(question string) bool {
+	var ok bool
+	if err := huh.NewConfirm().Title(question).Value(&ok).Run(); err != nil {
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,31 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveOptions(t *testing.T) {
+	values := func(conflicts []string, mergetool bool) []string {
+		var v []string
+		for _, o := range resolveOptions(conflicts, mergetool) {
+			v = append(v, o.Value)
+		}
+		return v
+	}
+
+	assert.Equal(t, []string{
+		"edit:a.go", "mark:a.go",
+		"edit:b.go", "mark:b.go",
+		"abort", "later",
+	}, values([]string{"a.go", "b.go"}, false))
+
+	assert.Equal(t, []string{
+		"edit:a.go", "mergetool:a.go", "mark:a.go",
+		"abort", "later",
+	}, values([]string{"a.go"}, true))
+
+	// Continuing is only offered once all conflicts are resolved.
+	assert.Equal(t, []string{"continue", "abort", "later"}, values(nil, true))
+}
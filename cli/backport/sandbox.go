@@ -0,0 +1,159 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// SandboxCommand simulates the backport of a PR in a throwaway clone of the repository.
+var SandboxCommand = &cli.Command{
+	Name:      "sandbox",
+	Usage:     "simulate the backport of a PR in a throwaway clone and print the commit, diff and PR that would be created, without touching the repository or the forge",
+	ArgsUsage: "<pr-number> <target-branch>",
+	Action:    runSandbox,
+	Flags: []cli.Flag{
+		emptyFlag,
+		keepRedundantCommitsFlag,
+		mergeStrategyFlag,
+		strategyOptionFlag,
+		pathsFlag,
+		strategyFlag,
+		forceFlag,
+	},
+}
+
+func runSandbox(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 2 { //nolint:mnd
+		return fmt.Errorf("usage: sandbox <pr-number> <target-branch>")
+	}
+
+	prNumberStr := strings.TrimPrefix(strings.TrimPrefix(c.Args().Get(0), internal.PRPrefix), "#")
+	prNumber, err := strconv.Atoi(prNumberStr)
+	if err != nil {
+		return fmt.Errorf("invalid PR number: %s", prNumberStr)
+	}
+	targetBranch := c.Args().Get(1)
+
+	service, cfg, forgeClient, owner, repoName, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	prInfo, err := service.GetPR(ctx, prNumber)
+	if err != nil {
+		return err
+	}
+
+	sandbox, err := git.NewSandbox()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sandbox.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", sandbox.Path).Msg("failed to remove sandbox")
+		}
+	}()
+	log.Debug().Str("path", sandbox.Path).Msg("created sandbox clone")
+
+	if err := sandbox.Enter(); err != nil {
+		return err
+	}
+	repo, err := git.OpenCurrent()
+	if err != nil {
+		return fmt.Errorf("failed to open sandbox repository: %w", err)
+	}
+
+	// The sandbox must not leave traces in the backport cache or the audit log either.
+	sandboxCfg := *cfg
+	sandboxCfg.Cache.Enabled = false
+	sandboxCfg.Audit.Enabled = false
+	sandboxService := backport.NewService(repo, forgeClient, &sandboxCfg, owner, repoName)
+	sandboxService.SetPushRepo(service.PushRepo())
+
+	result, err := sandboxService.BackportPR(ctx, prNumber, backport.BackportOptions{
+		TargetBranch:         targetBranch,
+		Empty:                c.String("empty"),
+		KeepRedundantCommits: c.Bool("keep-redundant-commits"),
+		MergeStrategy:        c.String("merge-strategy"),
+		StrategyOption:       c.StringSlice("strategy-option"),
+		Paths:                c.StringSlice("paths"),
+		Strategy:             c.String("strategy"),
+		Force:                c.Bool("force"),
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.HasConflict:
+		fmt.Printf("✗ Backport of PR #%d to %s results in conflicts\n", prNumber, targetBranch)
+		fmt.Println()
+		fmt.Println(logger.Redact(result.Message))
+		return fmt.Errorf("%w in sandbox", backport.ErrConflict)
+	case result.Empty:
+		fmt.Printf("✓ Nothing to backport to %s: changes are already present\n", targetBranch)
+		return nil
+	}
+
+	commits, err := sandbox.Log(result.TargetSHA, result.BackportSHA)
+	if err != nil {
+		return err
+	}
+
+	prOpts := localBackportPROptions(cfg, prInfo, targetBranch, result.TargetSHA, result.BackportSHA, followUpFixes(service.FollowUps(ctx, prInfo)))
+	prOpts.Reviewers = backportci.OriginalReviewers(cfg.CI, prInfo)
+
+	fmt.Println("=== Commits ===")
+	fmt.Println()
+	fmt.Println(commits)
+	fmt.Println()
+	fmt.Println("=== Pull request ===")
+	fmt.Println()
+	fmt.Print(formatSandboxPR(prOpts))
+	fmt.Println()
+	fmt.Println("✓ Sandbox removed, nothing was pushed or created on the forge")
+
+	return nil
+}
+
+// formatSandboxPR renders the PR a backport would open.
+func formatSandboxPR(opts forge.CreatePROptions) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Title:  %s\n", opts.Title))
+	sb.WriteString(fmt.Sprintf("Branch: %s → %s\n", opts.Head, opts.Base))
+	if opts.Draft {
+		sb.WriteString("Draft:  yes\n")
+	}
+	if len(opts.Labels) > 0 {
+		sb.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(opts.Labels, ", ")))
+	}
+	if len(opts.Assignees) > 0 {
+		sb.WriteString(fmt.Sprintf("Assignees: %s\n", strings.Join(opts.Assignees, ", ")))
+	}
+	if len(opts.Reviewers) > 0 {
+		sb.WriteString(fmt.Sprintf("Reviewers: %s\n", strings.Join(opts.Reviewers, ", ")))
+	}
+	if opts.Milestone > 0 {
+		sb.WriteString(fmt.Sprintf("Milestone: %d\n", opts.Milestone))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(opts.Body)
+	if !strings.HasSuffix(opts.Body, "\n") {
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,29 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestFormatSandboxPR(t *testing.T) {
+	out := formatSandboxPR(forge.CreatePROptions{
+		Title:     "fix: backport #12 to release-1.x",
+		Body:      "Backport of #12 to `release-1.x`.",
+		Head:      "backport-12-to-release-1.x",
+		Base:      "release-1.x",
+		Labels:    []string{"bug"},
+		Reviewers: []string{"alice"},
+		Draft:     true,
+	})
+
+	assert.Equal(t, "Title:  fix: backport #12 to release-1.x\n"+
+		"Branch: backport-12-to-release-1.x → release-1.x\n"+
+		"Draft:  yes\n"+
+		"Labels: bug\n"+
+		"Reviewers: alice\n"+
+		"\n"+
+		"Backport of #12 to `release-1.x`.\n", out)
+}
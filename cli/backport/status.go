@@ -0,0 +1,148 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+var statusCmd = &cli.Command{
+	Name:      "status",
+	Usage:     "show the backport in progress and which target branches have received a commit or PR",
+	ArgsUsage: "[<commit-sha|pr-number>]",
+	Action:    backportStatus,
+}
+
+// backportBranchPattern matches the branch names created by backportBranchName.
+var backportBranchPattern = regexp.MustCompile(`^backport-(\d+)-to-(.+)$`)
+
+// parseBackportBranchName returns the PR number and target branch of a backport branch.
+func parseBackportBranchName(branch string) (int, string, bool) {
+	m := backportBranchPattern.FindStringSubmatch(branch)
+	if m == nil {
+		return 0, "", false
+	}
+	number, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return number, m[2], true
+}
+
+func backportStatus(ctx context.Context, c *cli.Command) error {
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	repo, err := internal.GetRepository()
+	if err != nil {
+		return err
+	}
+
+	pending, err := backport.LoadPending()
+	if err != nil {
+		return err
+	}
+
+	branch, err := repo.CurrentBranch()
+	if err != nil {
+		branch = ""
+	}
+
+	fmt.Print(formatProgress(pending, git.CherryPickInProgress(), branch))
+
+	if c.Args().Len() == 0 {
+		return nil
+	}
+
+	sha, prNumber, err := internal.ResolveCommitOrPR(ctx, service, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	graph, err := service.Graph(ctx, sha)
+	if err != nil {
+		return err
+	}
+	if prNumber > 0 {
+		graph.PRNumber = prNumber
+	}
+
+	fmt.Println()
+	fmt.Print(formatTargetStatus(graph))
+
+	return nil
+}
+
+// formatProgress describes the backport in progress in the current repository, if any.
+func formatProgress(pending *backport.PendingBackport, cherryPicking bool, branch string) string {
+	var sb strings.Builder
+
+	switch {
+	case pending != nil:
+		subject := backport.ShortSHA(pending.OriginalSHA)
+		if pending.PRNumber > 0 {
+			subject = fmt.Sprintf("PR #%d", pending.PRNumber)
+		}
+		fmt.Fprintf(&sb, "Backport in progress: %s to %s, stopped on conflicts\n", subject, pending.TargetBranch)
+		sb.WriteString("  Resolve the conflicts, then run `backporter backport continue`.\n")
+	case cherryPicking:
+		sb.WriteString("Cherry-pick in progress (not started by backporter)\n")
+	default:
+		sb.WriteString("No backport in progress\n")
+	}
+
+	if number, target, ok := parseBackportBranchName(branch); ok {
+		fmt.Fprintf(&sb, "On backport branch %s (PR #%d to %s)\n", branch, number, target)
+	} else if branch != "" {
+		fmt.Fprintf(&sb, "On branch %s\n", branch)
+	}
+
+	return sb.String()
+}
+
+// formatTargetStatus lists the branches that already received a change and the pending ones.
+func formatTargetStatus(graph *backport.Graph) string {
+	var done, pending []backport.BranchPropagation
+	for _, b := range graph.Branches {
+		if b.Status == backport.StatusMissing {
+			pending = append(pending, b)
+		} else {
+			done = append(done, b)
+		}
+	}
+
+	var sb strings.Builder
+	subject := backport.ShortSHA(graph.OriginalSHA)
+	if graph.PRNumber > 0 {
+		subject = fmt.Sprintf("PR #%d (%s)", graph.PRNumber, subject)
+	}
+	fmt.Fprintf(&sb, "Backport status of %s:\n", subject)
+
+	if len(done) == 0 && len(pending) == 0 {
+		sb.WriteString("  no target branches found\n")
+		return sb.String()
+	}
+
+	for _, b := range done {
+		if b.Status == backport.StatusContained {
+			fmt.Fprintf(&sb, "  ✓ %s (contained)\n", b.Branch)
+		} else {
+			fmt.Fprintf(&sb, "  ✓ %s (backported as %s, found by %s)\n", b.Branch, backport.ShortSHA(b.SHA), b.Source)
+		}
+	}
+	for _, b := range pending {
+		fmt.Fprintf(&sb, "  ○ %s (pending)\n", b.Branch)
+	}
+
+	return sb.String()
+}
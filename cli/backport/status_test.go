@@ -0,0 +1,51 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+)
+
+func TestParseBackportBranchName(t *testing.T) {
+	number, target, ok := parseBackportBranchName(backportci.BranchName(42, "release/1.x"))
+	assert.True(t, ok)
+	assert.Equal(t, 42, number)
+	assert.Equal(t, "release/1.x", target)
+
+	_, _, ok = parseBackportBranchName("main")
+	assert.False(t, ok)
+}
+
+func TestFormatProgress(t *testing.T) {
+	assert.Equal(t, "No backport in progress\nOn branch main\n", formatProgress(nil, false, "main"))
+
+	pending := &backport.PendingBackport{OriginalSHA: "abc1234def", TargetBranch: "release-1.x", PRNumber: 42}
+	out := formatProgress(pending, true, "backport-42-to-release-1.x")
+	assert.Contains(t, out, "Backport in progress: PR #42 to release-1.x, stopped on conflicts")
+	assert.Contains(t, out, "backporter backport continue")
+	assert.Contains(t, out, "On backport branch backport-42-to-release-1.x (PR #42 to release-1.x)")
+
+	assert.Contains(t, formatProgress(nil, true, ""), "Cherry-pick in progress (not started by backporter)")
+}
+
+func TestFormatTargetStatus(t *testing.T) {
+	graph := &backport.Graph{
+		OriginalSHA: "abc1234def",
+		PRNumber:    42,
+		Branches: []backport.BranchPropagation{
+			{Branch: "release-2.x", Status: backport.StatusMissing},
+			{Branch: "main", Status: backport.StatusContained, SHA: "abc1234def"},
+			{Branch: "release-1.x", Status: backport.StatusBackported, SHA: "fedcba98765", Source: "signature"},
+		},
+	}
+
+	assert.Equal(t, "Backport status of PR #42 (abc1234):\n"+
+		"  ✓ main (contained)\n"+
+		"  ✓ release-1.x (backported as fedcba9, found by signature)\n"+
+		"  ○ release-2.x (pending)\n", formatTargetStatus(graph))
+
+	assert.Contains(t, formatTargetStatus(&backport.Graph{OriginalSHA: "abc1234def"}), "no target branches found")
+}
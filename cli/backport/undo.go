@@ -0,0 +1,81 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+var undoCmd = &cli.Command{
+	Name:      "undo",
+	Usage:     "undo a backport from the history, dropping it if unpushed and reverting it otherwise",
+	ArgsUsage: "[<backport-sha>|last]",
+	Action:    backportUndo,
+}
+
+func backportUndo(ctx context.Context, c *cli.Command) error {
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	ref := c.Args().First()
+	if ref == "" {
+		ref = backport.UndoLast
+	}
+
+	result, err := service.UndoBackport(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	entry := result.Entry
+	switch result.Action {
+	case backport.UndoRemoved:
+		fmt.Printf("✓ Removed backport %s from %s\n", backport.ShortSHA(entry.BackportSHA), entry.TargetBranch)
+	case backport.UndoReverted:
+		fmt.Printf("✓ Reverted backport %s on %s with %s\n", backport.ShortSHA(entry.BackportSHA), entry.TargetBranch, backport.ShortSHA(result.RevertSHA))
+		fmt.Printf("  Push %s to publish the revert\n", entry.TargetBranch)
+	default:
+		fmt.Printf("✓ Backport %s is not on %s, nothing to revert\n", backport.ShortSHA(entry.BackportSHA), entry.TargetBranch)
+	}
+
+	if entry.PRNumber > 0 {
+		removeBackportBranch(service.PushRemote(), backportci.BranchName(entry.PRNumber, entry.TargetBranch))
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// removeBackportBranch deletes the local branch of a backport PR unless it was pushed,
+// pushed branches belong to an open PR that has to be closed on the forge.
+func removeBackportBranch(remote, branch string) {
+	repo, err := internal.GetRepository()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open repository")
+		return
+	}
+
+	if exists, err := repo.BranchExists(branch); err != nil || !exists {
+		return
+	}
+
+	if _, err := repo.GetCommitSHA(remote + "/" + branch); err == nil {
+		fmt.Printf("  Branch %s was pushed to %s, close its PR and delete the branch there\n", branch, remote)
+		return
+	}
+
+	if err := git.DeleteBranch(branch); err != nil {
+		log.Warn().Err(err).Str("branch", branch).Msg("failed to delete backport branch")
+		return
+	}
+	fmt.Printf("✓ Deleted unpushed branch %s\n", branch)
+}
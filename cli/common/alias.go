@@ -0,0 +1,94 @@
+package common
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	internalconfig "codefloe.com/pat-s/backporter/cli/internal/config"
+	"codefloe.com/pat-s/backporter/pkg/config"
+)
+
+// noDefaultCommandArgs are arguments that print information instead of running a command,
+// the default command is not added to them.
+var noDefaultCommandArgs = []string{"-h", "--help", "-v", "--version", "--generate-shell-completion", "--"}
+
+// ExpandAliases expands the command aliases of the config in the arguments of the app: an
+// alias given as the command is replaced by its command line, and without a command the
+// configured default command is run instead of the interactive wizard. Built-in commands
+// take precedence over aliases and aliases are not expanded recursively. The config files
+// are read before the app runs, invalid ones are left to fail later.
+func ExpandAliases(app *cli.Command, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	configPath := os.Getenv("BACKPORTER_CONFIG")
+	command := -1
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if slices.Contains(noDefaultCommandArgs, arg) {
+			return args
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			command = i
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag := globalFlag(app, name)
+		if _, isBool := flag.(*cli.BoolFlag); flag == nil || isBool || hasValue {
+			if name == "config" || name == "c" {
+				configPath = value
+			}
+			continue
+		}
+		// The value of the flag is the next argument.
+		if i+1 < len(args) {
+			i++
+			if name == "config" || name == "c" {
+				configPath = args[i]
+			}
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	for _, file := range internalconfig.FilesFor(configPath) {
+		if fileCfg, err := config.LoadFromFile(file.Path); err == nil {
+			cfg.Merge(fileCfg)
+		}
+	}
+
+	var line string
+	insert := len(args)
+	remove := 0
+	if command < 0 {
+		line = cfg.DefaultCommand
+	} else if name := args[command]; app.Command(name) == nil && name != "help" {
+		line = cfg.Aliases[name]
+		insert = command
+		remove = 1
+	}
+	if line == "" {
+		return args
+	}
+
+	expansion, err := config.SplitCommandLine(line)
+	if err != nil {
+		// Validation reports the invalid command line once the config is loaded.
+		return args
+	}
+	return slices.Concat(args[:insert], expansion, args[insert+remove:])
+}
+
+// globalFlag returns the flag of the app with the name, nil if it has none.
+func globalFlag(app *cli.Command, name string) cli.Flag {
+	for _, flag := range app.Flags {
+		if slices.Contains(flag.Names(), name) {
+			return flag
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestExpandAliases(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BACKPORTER_CONFIG", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`aliases:
+  bp1: backport pr --base "v1.x"
+  list: graph
+default_command: list --json
+`), 0o600))
+
+	app := &cli.Command{
+		Name:  "backporter",
+		Flags: GlobalFlags,
+		Commands: []*cli.Command{
+			{Name: "backport", Commands: []*cli.Command{{Name: "pr"}}},
+			{Name: "list"},
+		},
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"backporter", "--config", path, "bp1", "42"},
+			[]string{"backporter", "--config", path, "backport", "pr", "--base", "v1.x", "42"},
+		},
+		{
+			[]string{"backporter", "-c=" + path, "--strict", "bp1"},
+			[]string{"backporter", "-c=" + path, "--strict", "backport", "pr", "--base", "v1.x"},
+		},
+		// Built-in commands take precedence over aliases.
+		{[]string{"backporter", "-c", path, "list"}, []string{"backporter", "-c", path, "list"}},
+		{[]string{"backporter", "-c", path, "unknown"}, []string{"backporter", "-c", path, "unknown"}},
+		// The default command replaces the interactive wizard.
+		{[]string{"backporter", "-c", path}, []string{"backporter", "-c", path, "list", "--json"}},
+		{[]string{"backporter", "-c", path, "--help"}, []string{"backporter", "-c", path, "--help"}},
+		// Without config, nothing is expanded.
+		{[]string{"backporter", "bp1"}, []string{"backporter", "bp1"}},
+		{[]string{"backporter"}, []string{"backporter"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ExpandAliases(app, tt.args), tt.args)
+	}
+
+	t.Setenv("BACKPORTER_CONFIG", path)
+	assert.Equal(t, []string{"backporter", "backport", "pr", "--base", "v1.x"}, ExpandAliases(app, []string{"backporter", "bp1"}))
+}
@@ -0,0 +1,135 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// docsURL is the base URL of the documentation linked from suggestions.
+const docsURL = "https://codefloe.com/pat-s/backporter"
+
+// Suggestion is an actionable hint for a known failure mode.
+type Suggestion struct {
+	Hint string
+	Doc  string // Link to the relevant documentation (optional)
+}
+
+// StrictError is returned in strict mode if warnings were logged, e.g. about partial successes.
+type StrictError struct {
+	Warnings int
+}
+
+// Error implements error.
+func (e *StrictError) Error() string {
+	return fmt.Sprintf("%d warning(s) logged in strict mode", e.Warnings)
+}
+
+// Suggest returns a suggestion for the failure behind err, or nil if it is not a known failure mode.
+func Suggest(err error) *Suggestion {
+	var apiErr *forge.APIError
+	var branchErr *backport.BranchNotFoundError
+	var versionErr *config.VersionTooOldError
+	var dupErr *backport.AlreadyBackportedError
+	var revertErr *backport.RevertOfMissingError
+	var keysErr *config.UnknownKeysError
+	var strictErr *StrictError
+
+	switch {
+	case errors.Is(err, forge.ErrRateLimited):
+		hint := "The forge API rate limit was exceeded, wait a few minutes and try again."
+		if errors.As(err, &apiErr) && apiErr.NoToken {
+			hint = fmt.Sprintf("Unauthenticated requests have a low rate limit, set %s to raise it.", tokenEnvVar(apiErr.Forge))
+		}
+		return &Suggestion{Hint: hint, Doc: docsURL + "#authentication"}
+
+	case errors.Is(err, forge.ErrUnauthorized):
+		hint := "Check that the forge token is valid and has access to the repository."
+		if errors.As(err, &apiErr) {
+			if apiErr.NoToken {
+				hint = fmt.Sprintf("Set %s to a token with access to the repository, or store one with `backporter auth login`.", tokenEnvVar(apiErr.Forge))
+			} else {
+				hint = fmt.Sprintf("Check that %s is valid and has the required scopes.", tokenEnvVar(apiErr.Forge))
+			}
+		}
+		return &Suggestion{Hint: hint, Doc: docsURL + "#authentication"}
+
+	case errors.Is(err, forge.ErrNotFound) && errors.As(err, &apiErr):
+		return &Suggestion{
+			Hint: fmt.Sprintf("Check the repository and PR or commit, forges also answer 404 for private repositories %s cannot access.", tokenEnvVar(apiErr.Forge)),
+			Doc:  docsURL + "#authentication",
+		}
+
+	case errors.Is(err, forge.ErrNotMerged):
+		return &Suggestion{Hint: "Only merged PRs can be backported, use `backporter backport commit <sha>` to backport individual commits."}
+
+	case errors.Is(err, backport.ErrNotSquashed):
+		return &Suggestion{
+			Hint: "Use --strategy mainline or --strategy commits, or backport individual commits instead.",
+			Doc:  docsURL + "#prs-merged-via-a-merge-commit",
+		}
+
+	case errors.Is(err, backport.ErrUncommittedChanges):
+		return &Suggestion{
+			Hint: "Commit or stash your changes first, or use --worktree to leave them untouched.",
+			Doc:  docsURL + "#keep-your-working-copy-untouched",
+		}
+
+	case errors.Is(err, backport.ErrBackportNotFound):
+		return &Suggestion{Hint: "Run `backporter list` to see the backports in the history, only backports made with the history enabled can be undone."}
+
+	case errors.As(err, &dupErr):
+		return &Suggestion{Hint: fmt.Sprintf("Run `backporter graph %s` to see where it was backported, or use --force to backport it again.", dupErr.OriginalSHA)}
+
+	case errors.As(err, &revertErr):
+		return &Suggestion{Hint: fmt.Sprintf("Run `backporter graph %s` to see where the reverted change landed, or use --force to backport the revert anyway.", revertErr.RevertedSHA)}
+
+	case errors.As(err, &versionErr):
+		return &Suggestion{
+			Hint: fmt.Sprintf("Run `backporter self-update`, or use backporter %s or newer in the CI image or action.", versionErr.Required),
+			Doc:  docsURL + "#updating",
+		}
+
+	case errors.As(err, &keysErr):
+		return &Suggestion{
+			Hint: "Fix the keys in the config file, or pass --strict-config=false to ignore unknown keys.",
+			Doc:  docsURL + "#configuration",
+		}
+
+	case errors.As(err, &strictErr):
+		return &Suggestion{Hint: "Resolve the warnings logged above, or run without --strict to only report them."}
+
+	case errors.As(err, &branchErr):
+		return &Suggestion{
+			Hint: fmt.Sprintf("Run `git fetch %s` if the branch exists on the remote, or check target_branches in your config.", branchErr.Remote),
+			Doc:  docsURL + "#configuration",
+		}
+	}
+
+	return nil
+}
+
+// PrintSuggestion writes the suggestion for err to w, if there is one.
+func PrintSuggestion(w io.Writer, err error) {
+	suggestion := Suggest(err)
+	if suggestion == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "\nHint: %s\n", suggestion.Hint)
+	if suggestion.Doc != "" {
+		fmt.Fprintf(w, "See:  %s\n", suggestion.Doc)
+	}
+}
+
+// tokenEnvVar returns the token environment variable of a forge, with a generic fallback.
+func tokenEnvVar(forgeName string) string {
+	if envVar := forge.TokenEnvVar(forgeName); envVar != "" {
+		return envVar
+	}
+	return "the forge token"
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		hint string // Substring of the expected hint, empty for no suggestion
+	}{
+		{"unknown error", errors.New("boom"), ""},
+		{"missing token", fmt.Errorf("failed to get PR #1: %w", &forge.APIError{Forge: "github", StatusCode: 401, Status: "401 Unauthorized", NoToken: true}), "Set GITHUB_TOKEN"},
+		{"invalid token", &forge.APIError{Forge: "forgejo", StatusCode: 403, Status: "403 Forbidden"}, "Check that FORGEJO_TOKEN is valid"},
+		{"rate limited", &forge.APIError{Forge: "bitbucket", StatusCode: 429, Status: "429 Too Many Requests"}, "rate limit was exceeded"},
+		{"rate limited without token", &forge.APIError{Forge: "bitbucket", StatusCode: 429, Status: "429 Too Many Requests", NoToken: true}, "set BITBUCKET_TOKEN"},
+		{"not found", fmt.Errorf("failed to get PR #1: %w", &forge.APIError{Forge: "forgejo", StatusCode: 404, Status: "404 Not Found"}), "private repositories FORGEJO_TOKEN cannot access"},
+		{"unmerged PR", fmt.Errorf("PR #1 is %w", forge.ErrNotMerged), "Only merged PRs"},
+		{"not squashed", fmt.Errorf("PR #1 was %w", backport.ErrNotSquashed), "--strategy"},
+		{"dirty tree", backport.ErrUncommittedChanges, "--worktree"},
+		{"backport not in history", fmt.Errorf("%w: abc123", backport.ErrBackportNotFound), "backporter list"},
+		{"outdated binary", &config.VersionTooOldError{Required: "1.4.0", Current: "1.3.0"}, "backporter 1.4.0 or newer"},
+		{"warnings in strict mode", &StrictError{Warnings: 2}, "without --strict"},
+		{"unknown config keys", &config.UnknownKeysError{Path: ".backporter.yaml", Keys: []config.UnknownKey{{Key: "targed_branches", Line: 2}}}, "--strict-config=false"},
+		{"revert of missing change", &backport.RevertOfMissingError{RevertSHA: "abc123", RevertedSHA: "def456", Branch: "release-1.x"}, "--force to backport the revert"},
+		{"missing branch", fmt.Errorf("backport failed: %w", &backport.BranchNotFoundError{Branch: "release-1.x", Remote: "origin"}), "git fetch origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestion := Suggest(tt.err)
+			if tt.hint == "" {
+				assert.Nil(t, suggestion)
+				return
+			}
+			if assert.NotNil(t, suggestion) {
+				assert.Contains(t, suggestion.Hint, tt.hint)
+			}
+		})
+	}
+}
+
+func TestPrintSuggestion(t *testing.T) {
+	var buf bytes.Buffer
+	PrintSuggestion(&buf, backport.ErrUncommittedChanges)
+	assert.Contains(t, buf.String(), "Hint: Commit or stash your changes")
+	assert.Contains(t, buf.String(), "See:  "+docsURL+"#keep-your-working-copy-untouched")
+
+	buf.Reset()
+	PrintSuggestion(&buf, errors.New("boom"))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// Exit codes, so CI pipelines can tell failures apart without parsing the logs.
+const (
+	ExitSuccess        = 0
+	ExitError          = 1 // Any other error
+	ExitPartialFailure = 2 // Some backports of a CI run failed, others succeeded
+	ExitConflict       = 3 // All failed backports stopped on conflicts
+	ExitConfigError    = 4 // The config could not be loaded or is invalid
+	ExitAuthError      = 5 // The forge rejected the token
+)
+
+// exitCodes describes the exit codes for --help-exit-codes.
+var exitCodes = []struct {
+	Code        int
+	Description string
+}{
+	{ExitSuccess, "success, including backports skipped because they exist"},
+	{ExitError, "any other error, including CI runs in which no backport succeeded"},
+	{ExitPartialFailure, "some backports of a CI run failed for other reasons than conflicts, others succeeded"},
+	{ExitConflict, "the backports failed on cherry-pick conflicts only"},
+	{ExitConfigError, "the config could not be loaded, is invalid or requires a newer backporter"},
+	{ExitAuthError, "the forge rejected the token, it is missing, invalid or lacks access"},
+}
+
+// ExitCode returns the exit code of a command that returned err.
+func ExitCode(err error) int {
+	var invalidErr *config.InvalidError
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.Is(err, forge.ErrUnauthorized):
+		return ExitAuthError
+	case errors.As(err, &invalidErr):
+		return ExitConfigError
+	case errors.Is(err, backport.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, backportci.ErrFailed):
+		return ExitPartialFailure
+	default:
+		return ExitError
+	}
+}
+
+// PrintExitCodes writes the exit codes and their meaning to w.
+func PrintExitCodes(w io.Writer) {
+	fmt.Fprintln(w, "Exit codes:")
+	for _, c := range exitCodes {
+		fmt.Fprintf(w, "  %d  %s\n", c.Code, c.Description)
+	}
+}
+
+// helpExitCodes prints the exit codes for --help-exit-codes and exits.
+func helpExitCodes(_ context.Context, c *cli.Command, show bool) error {
+	if !show {
+		return nil
+	}
+	PrintExitCodes(c.Root().Writer)
+	return cli.Exit("", ExitSuccess)
+}
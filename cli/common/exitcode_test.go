@@ -0,0 +1,37 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/backportci"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestExitCode(t *testing.T) {
+	unauthorized := &forge.APIError{Forge: "github", StatusCode: 401, Status: "401 Unauthorized"}
+
+	assert.Equal(t, ExitSuccess, ExitCode(nil))
+	assert.Equal(t, ExitError, ExitCode(errors.New("boom")))
+	assert.Equal(t, ExitPartialFailure, ExitCode(backportci.ErrFailed))
+	assert.Equal(t, ExitError, ExitCode(backportci.ErrAllFailed))
+	assert.Equal(t, ExitConflict, ExitCode(fmt.Errorf("%w: %w", backportci.ErrFailed, backport.ErrConflict)))
+	assert.Equal(t, ExitConflict, ExitCode(fmt.Errorf("%w need resolution", backport.ErrConflict)))
+	assert.Equal(t, ExitConfigError, ExitCode(fmt.Errorf("failed to load config: %w", &config.InvalidError{Err: errors.New("invalid forge_type")})))
+	assert.Equal(t, ExitAuthError, ExitCode(fmt.Errorf("failed to get PR #1: %w", unauthorized)))
+	assert.Equal(t, ExitAuthError, ExitCode(fmt.Errorf("%w: %w", backportci.ErrFailed, unauthorized)))
+}
+
+func TestPrintExitCodes(t *testing.T) {
+	var buf bytes.Buffer
+	PrintExitCodes(&buf)
+	for _, code := range []string{"  0  ", "  2  ", "  3  ", "  4  ", "  5  "} {
+		assert.Contains(t, buf.String(), code)
+	}
+}
@@ -2,6 +2,8 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/urfave/cli/v3"
 
 	"codefloe.com/pat-s/backporter/shared/logger"
@@ -21,4 +23,57 @@ var GlobalFlags = append([]cli.Flag{
 		Usage:   "git remote name",
 		Value:   "origin",
 	},
+	&cli.StringFlag{
+		Sources: cli.EnvVars("BACKPORTER_PUSH_REMOTE"),
+		Name:    "push-remote",
+		Usage:   "git remote to push backport branches to and open backport PRs against (defaults to --remote)",
+	},
+	&cli.StringFlag{
+		Sources: cli.EnvVars("BACKPORTER_PROFILE"),
+		Name:    "profile",
+		Usage:   "use the forge settings of this profile from the config",
+	},
+	&cli.BoolFlag{
+		Sources: cli.EnvVars("BACKPORTER_STRICT_CONFIG"),
+		Name:    "strict-config",
+		Usage:   "reject config files with unknown keys, e.g. misspelled ones (disable with --strict-config=false)",
+		Value:   true,
+	},
+	&cli.BoolFlag{
+		Sources: cli.EnvVars("BACKPORTER_STRICT"),
+		Name:    "strict",
+		Usage:   "exit with an error if any warning was logged, e.g. about failed label copies or cache writes",
+	},
+	&cli.BoolFlag{
+		Sources: cli.EnvVars("BACKPORTER_NO_CACHE"),
+		Name:    "no-cache",
+		Usage:   "do not use or update the on-disk cache of forge responses (PRs and commits)",
+	},
+	&cli.BoolFlag{
+		Sources: cli.EnvVars("BACKPORTER_ISOLATED"),
+		Name:    "isolated",
+		Usage:   "run in a temporary clone and never modify the checkout (no branch switches, no config writes), handing out the result as patches or pushed branches",
+	},
+	&cli.StringFlag{
+		Sources: cli.EnvVars("BACKPORTER_ISOLATED_RESULT"),
+		Name:    "isolated-result",
+		Usage:   "how to hand out the branches changed by an --isolated run: patch (files to apply with git am) or push",
+		Value:   isolatedResultPatch,
+		Validator: func(s string) error {
+			if s != isolatedResultPatch && s != isolatedResultPush {
+				return fmt.Errorf("invalid isolated result: %s (must be '%s' or '%s')", s, isolatedResultPatch, isolatedResultPush)
+			}
+			return nil
+		},
+	},
+	&cli.BoolFlag{
+		Name:   "help-exit-codes",
+		Usage:  "show the exit codes and their meaning, e.g. to branch on the failure type in CI",
+		Action: helpExitCodes,
+	},
+	&cli.StringFlag{
+		Sources: cli.EnvVars("BACKPORTER_PPROF"),
+		Name:    "pprof",
+		Usage:   "write CPU and heap profiles (pprof) to this directory",
+	},
 }, logger.GlobalLoggerFlags...)
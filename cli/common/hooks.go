@@ -6,6 +6,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 
+	"codefloe.com/pat-s/backporter/cli/complete"
 	"codefloe.com/pat-s/backporter/cli/internal/config"
 	"codefloe.com/pat-s/backporter/cli/setup"
 	"codefloe.com/pat-s/backporter/shared/logger"
@@ -19,8 +20,26 @@ func Before(ctx context.Context, c *cli.Command) (context.Context, error) {
 
 	log.Debug().Str("version", c.Root().Version).Msg("backporter starting")
 
-	// Check if we should prompt for config creation.
-	if setup.ShouldPromptForConfig() && !logger.IsCI() && c.String("config") == "" {
+	// Showing the exit codes needs neither a repository nor a config.
+	if c.Bool("help-exit-codes") {
+		return ctx, nil
+	}
+
+	if dir := c.String("pprof"); dir != "" {
+		if err := startProfile(dir); err != nil {
+			return ctx, err
+		}
+	}
+
+	if c.Bool("isolated") {
+		if err := startIsolation(c); err != nil {
+			return ctx, err
+		}
+	}
+
+	// Check if we should prompt for config creation (never while completing or isolated).
+	isCompletion := c.Args().First() == complete.CommandName || c.Args().First() == "completion"
+	if !isCompletion && !c.Bool("isolated") && setup.ShouldPromptForConfig() && !logger.IsCI() && c.String("config") == "" {
 		if err := setup.PromptForConfigCreation(); err != nil {
 			log.Warn().Err(err).Msg("failed to create config")
 		}
@@ -35,7 +54,28 @@ func Before(ctx context.Context, c *cli.Command) (context.Context, error) {
 		if err := config.ApplyToFlags(c, cfg); err != nil {
 			log.Warn().Err(err).Msg("failed to apply config to flags")
 		}
+		startUsage(cfg)
 	}
 
 	return ctx, nil
 }
+
+// After is the global after hook that finishes the profiles started by --pprof, hands out the
+// result of an --isolated run and fails in strict mode if warnings were logged.
+func After(_ context.Context, c *cli.Command) error {
+	if err := stopProfile(c); err != nil {
+		return err
+	}
+
+	if err := finishIsolation(c); err != nil {
+		return err
+	}
+
+	if c.Bool("strict") {
+		if n := logger.Warnings(); n > 0 {
+			return &StrictError{Warnings: n}
+		}
+	}
+
+	return nil
+}
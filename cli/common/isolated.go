@@ -0,0 +1,167 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Results of an isolated run, see --isolated-result.
+const (
+	isolatedResultPatch = "patch"
+	isolatedResultPush  = "push"
+)
+
+// isolatedRun is a command running in a sandbox clone because of --isolated.
+type isolatedRun struct {
+	sandbox *git.Sandbox
+
+	// Branches of the sandbox before the command ran.
+	heads map[string]string
+}
+
+// isolated is the current isolated run, or nil if --isolated is off.
+var isolated *isolatedRun
+
+// startIsolation moves the command into a sandbox clone of the repository, so the checkout,
+// its branches and its config are never modified.
+func startIsolation(c *cli.Command) error {
+	if _, err := git.OpenCurrent(); err != nil {
+		log.Debug().Err(err).Msg("not in a git repository, --isolated has no effect")
+		return nil
+	}
+
+	// The sandbox only has committed files, keep using the repo-local config of the checkout.
+	if path := c.String("config"); path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			_ = c.Set("config", abs)
+		}
+	} else if abs, err := filepath.Abs(config.RepoConfigPath()); err == nil {
+		if _, err := os.Stat(abs); err == nil {
+			_ = c.Set("config", abs)
+		}
+	}
+
+	sandbox, err := git.NewSandboxWithOptions(git.SandboxOptions{Push: true})
+	if err != nil {
+		return err
+	}
+	heads, err := sandbox.Heads()
+	if err == nil {
+		err = sandbox.Enter()
+	}
+	if err != nil {
+		_ = sandbox.Remove()
+		return err
+	}
+
+	isolated = &isolatedRun{sandbox: sandbox, heads: heads}
+	log.Debug().Str("path", sandbox.Path).Msg("running isolated in a sandbox clone")
+
+	return nil
+}
+
+// finishIsolation hands out the branches an isolated run changed, as patches or pushed branches,
+// and removes the sandbox. A sandbox stopped on conflicts is kept to resolve them.
+func finishIsolation(c *cli.Command) error {
+	run := isolated
+	if run == nil {
+		return nil
+	}
+	isolated = nil
+
+	if pending, err := backport.LoadPending(); err == nil && pending != nil {
+		fmt.Printf("\nThe backport to %s stopped on conflicts in the isolated clone %s\n", pending.TargetBranch, run.sandbox.Path)
+		fmt.Println("Resolve them there and run `backporter backport continue`, then delete the clone.")
+		return run.sandbox.Leave()
+	}
+
+	defer func() {
+		if err := run.sandbox.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", run.sandbox.Path).Msg("failed to remove isolated clone")
+		}
+	}()
+
+	heads, err := run.sandbox.Heads()
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for branch, sha := range heads {
+		if run.heads[branch] != sha {
+			changed = append(changed, branch)
+		}
+	}
+	slices.Sort(changed)
+	if len(changed) == 0 {
+		log.Debug().Msg("isolated run changed no branches")
+		return nil
+	}
+
+	if c.String("isolated-result") == isolatedResultPush {
+		remote := c.String("push-remote")
+		if remote == "" {
+			remote = c.String("remote")
+		}
+		for _, branch := range changed {
+			sandbox := run.sandbox
+			err := backport.RetryRejectedPush(branch,
+				func() error { return sandbox.Push(remote, branch) },
+				func() error { return sandbox.RebaseOnRemote(remote, branch) },
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Pushed %s to %s\n", branch, remote)
+		}
+		return nil
+	}
+
+	return writeIsolatedPatches(run, heads, changed)
+}
+
+// writeIsolatedPatches writes the new commits of the changed branches to a patch file per branch.
+func writeIsolatedPatches(run *isolatedRun, heads map[string]string, changed []string) error {
+	dir, err := os.MkdirTemp("", "backporter-isolated-*")
+	if err != nil {
+		return fmt.Errorf("failed to create patch directory: %w", err)
+	}
+
+	// Commits already on a remote or in the checkout need no patch.
+	exclude := []string{"--remotes"}
+	for _, sha := range run.heads {
+		exclude = append(exclude, sha)
+	}
+
+	written := 0
+	for _, branch := range changed {
+		patch, err := run.sandbox.FormatPatch(heads[branch], exclude)
+		if err != nil {
+			return err
+		}
+		if patch == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, strings.ReplaceAll(branch, "/", "-")+".patch")
+		if err := os.WriteFile(path, []byte(patch+"\n"), 0o644); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+		fmt.Printf("✓ Wrote the new commits of %s to %s\n", branch, path)
+		written++
+	}
+	if written > 0 {
+		fmt.Println("  Apply them with `git checkout <branch> && git am <patch>`")
+	}
+
+	return nil
+}
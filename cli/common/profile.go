@@ -0,0 +1,70 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// Profile file names, written to the directory given by --pprof.
+const (
+	cpuProfileFile  = "cpu.pprof"
+	heapProfileFile = "heap.pprof"
+)
+
+// cpuProfile is the file the running CPU profile is written to, or nil if profiling is off.
+var cpuProfile *os.File
+
+// startProfile starts writing a CPU profile to dir.
+func startProfile(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, cpuProfileFile))
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+
+	cpuProfile = f
+	log.Debug().Str("dir", dir).Msg("profiling enabled")
+
+	return nil
+}
+
+// stopProfile finishes the profiles started by --pprof.
+func stopProfile(c *cli.Command) error {
+	if cpuProfile == nil {
+		return nil
+	}
+
+	pprof.StopCPUProfile()
+	_ = cpuProfile.Close()
+	cpuProfile = nil
+
+	dir := c.String("pprof")
+	f, err := os.Create(filepath.Join(dir, heapProfileFile))
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer f.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	log.Info().Str("dir", dir).Msg("wrote CPU and heap profiles")
+
+	return nil
+}
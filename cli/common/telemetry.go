@@ -0,0 +1,92 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/telemetry"
+	"codefloe.com/pat-s/backporter/shared/logger"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// usage is the invocation reported by ReportUsage, set up by Before if telemetry is enabled.
+var usage struct {
+	start    time.Time
+	endpoint string
+	forge    string
+}
+
+// startUsage records the start of an invocation if the config opts in to telemetry.
+func startUsage(cfg *config.Config) {
+	if !cfg.Telemetry.Enabled {
+		return
+	}
+	usage.start = time.Now()
+	usage.endpoint = cfg.Telemetry.Endpoint
+	usage.forge = cfg.ForgeType
+}
+
+// ReportUsage sends the anonymous usage event of an invocation, if telemetry is enabled.
+// Failures are only logged, telemetry never changes the outcome of a command.
+func ReportUsage(ctx context.Context, app *cli.Command, args []string, err error) {
+	if usage.endpoint == "" {
+		return
+	}
+
+	event := telemetry.NewEvent(
+		commandPath(app, args),
+		usage.forge,
+		outcome(err),
+		version.Version,
+		logger.IsCI(),
+		time.Since(usage.start),
+	)
+	if err := telemetry.New(usage.endpoint).Send(ctx, event); err != nil {
+		log.Debug().Err(err).Msg("failed to report usage")
+	}
+}
+
+// commandPath returns the names of the (sub)commands invoked by args, e.g. "backport pr".
+// Arguments and flag values are never included.
+func commandPath(app *cli.Command, args []string) string {
+	var path []string
+	cmd := app
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		sub := cmd.Command(arg)
+		if sub == nil {
+			if len(cmd.Commands) == 0 {
+				break
+			}
+			continue
+		}
+		path = append(path, sub.Name)
+		cmd = sub
+	}
+
+	if len(path) == 0 {
+		return app.Name
+	}
+	return strings.Join(path, " ")
+}
+
+// outcome classifies the result of a command for usage events.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return telemetry.OutcomeSuccess
+	case errors.Is(err, backport.ErrConflict):
+		return telemetry.OutcomeConflict
+	default:
+		return telemetry.OutcomeError
+	}
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/telemetry"
+)
+
+func TestCommandPath(t *testing.T) {
+	app := &cli.Command{
+		Name: "backporter",
+		Commands: []*cli.Command{
+			{Name: "backport", Commands: []*cli.Command{{Name: "pr"}, {Name: "commit"}}},
+			{Name: "list"},
+		},
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"backporter"}, "backporter"},
+		{[]string{"backporter", "--remote", "upstream", "list"}, "list"},
+		{[]string{"backporter", "backport", "pr", "42", "list"}, "backport pr"},
+		{[]string{"backporter", "backport", "--ci"}, "backport"},
+		{[]string{"backporter", "pr:42", "release-1.x"}, "backporter"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, commandPath(app, tt.args), tt.args)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, telemetry.OutcomeSuccess, outcome(nil))
+	assert.Equal(t, telemetry.OutcomeConflict, outcome(fmt.Errorf("%w need resolution", backport.ErrConflict)))
+	assert.Equal(t, telemetry.OutcomeError, outcome(errors.New("boom")))
+}
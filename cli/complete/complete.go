@@ -0,0 +1,161 @@
+// Package complete provides dynamic shell completion suggestions.
+package complete
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/cli/internal/config"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	pkgconfig "codefloe.com/pat-s/backporter/pkg/config"
+)
+
+// CommandName is the name of the hidden completion command.
+const CommandName = "__complete"
+
+// Suggestion kinds supported by the completion command.
+const (
+	KindPRs      = "prs"
+	KindTargets  = "targets"
+	KindBranches = "branches"
+)
+
+// Command is the hidden command returning live completion suggestions.
+// Each suggestion is printed on its own line as "<value>\t<description>",
+// which can be consumed directly by zsh and fish completion functions.
+var Command = &cli.Command{
+	Name:      CommandName,
+	Usage:     "print dynamic shell completion suggestions",
+	ArgsUsage: "<prs|targets|branches>",
+	Hidden:    true,
+	Action:    complete,
+}
+
+// suggestion is a single completion candidate.
+type suggestion struct {
+	Value       string
+	Description string
+}
+
+func complete(ctx context.Context, c *cli.Command) error {
+	kind := c.Args().First()
+
+	var suggestions []suggestion
+	switch kind {
+	case KindPRs:
+		suggestions = prSuggestions(ctx, c)
+	case KindTargets:
+		suggestions = targetSuggestions(c)
+	case KindBranches:
+		suggestions = branchSuggestions(c)
+	default:
+		return fmt.Errorf("unknown completion kind: %s (must be one of %s, %s, %s)", kind, KindPRs, KindTargets, KindBranches)
+	}
+
+	writeSuggestions(os.Stdout, suggestions)
+	return nil
+}
+
+// prSuggestions returns recently merged PRs from the forge.
+// Errors are only logged, completion must never fail loudly.
+func prSuggestions(ctx context.Context, c *cli.Command) []suggestion {
+	_, cfg, forgeClient, owner, repoName, err := internal.CreateServiceWithDetails(ctx, c)
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot complete PRs")
+		return nil
+	}
+
+	limit := cfg.RecentPRCount
+	if limit <= 0 {
+		limit = pkgconfig.DefaultRecentPRCount
+	}
+
+	prs, err := forgeClient.ListRecentPRs(ctx, owner, repoName, limit)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to list recent PRs for completion")
+		return nil
+	}
+
+	suggestions := make([]suggestion, 0, len(prs))
+	for _, pr := range prs {
+		suggestions = append(suggestions, suggestion{
+			Value:       strconv.Itoa(pr.Number),
+			Description: pr.Title,
+		})
+	}
+	return suggestions
+}
+
+// targetSuggestions returns the configured target branches, with patterns expanded
+// against the local and remote branches.
+func targetSuggestions(c *cli.Command) []suggestion {
+	cfg, err := config.GetConfig(c)
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot complete target branches")
+		return nil
+	}
+
+	targets := cfg.TargetBranches
+	if repo, err := internal.GetRepository(); err == nil {
+		branches, _ := repo.ListBranches()
+		remoteBranches, _ := repo.ListRemoteBranches(cfg.WriteRemote())
+		targets = backport.ExpandTargetBranches(targets, append(branches, remoteBranches...))
+	}
+
+	suggestions := make([]suggestion, 0, len(targets))
+	for _, target := range targets {
+		suggestions = append(suggestions, suggestion{Value: target, Description: "configured target branch"})
+	}
+	return suggestions
+}
+
+// branchSuggestions returns all local branches, marking configured target branches.
+func branchSuggestions(c *cli.Command) []suggestion {
+	repo, err := internal.GetRepository()
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot complete branches")
+		return nil
+	}
+
+	branches, err := repo.ListBranches()
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to list branches for completion")
+		return nil
+	}
+
+	var targets []string
+	if cfg, err := config.GetConfig(c); err == nil {
+		targets = cfg.TargetBranches
+	}
+
+	suggestions := make([]suggestion, 0, len(branches))
+	for _, branch := range branches {
+		description := "local branch"
+		if backport.MatchesTargetBranch(branch, targets) {
+			description = "configured target branch"
+		}
+		suggestions = append(suggestions, suggestion{Value: branch, Description: description})
+	}
+	return suggestions
+}
+
+// writeSuggestions writes suggestions as tab-separated value/description lines.
+func writeSuggestions(w io.Writer, suggestions []suggestion) {
+	for _, s := range suggestions {
+		// Tabs and newlines in descriptions would break the line format.
+		description := strings.NewReplacer("\t", " ", "\n", " ").Replace(s.Description)
+		if description == "" {
+			fmt.Fprintln(w, s.Value)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", s.Value, description)
+	}
+}
@@ -0,0 +1,52 @@
+package complete
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSuggestions(t *testing.T) {
+	tests := []struct {
+		name        string
+		suggestions []suggestion
+		expected    string
+	}{
+		{
+			name:        "no suggestions",
+			suggestions: nil,
+			expected:    "",
+		},
+		{
+			name: "value with description",
+			suggestions: []suggestion{
+				{Value: "123", Description: "feat: add feature"},
+				{Value: "124", Description: "fix: resolve bug"},
+			},
+			expected: "123\tfeat: add feature\n124\tfix: resolve bug\n",
+		},
+		{
+			name: "value without description",
+			suggestions: []suggestion{
+				{Value: "release-1.x"},
+			},
+			expected: "release-1.x\n",
+		},
+		{
+			name: "description with tabs and newlines",
+			suggestions: []suggestion{
+				{Value: "42", Description: "multi\tline\ntitle"},
+			},
+			expected: "42\tmulti line title\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeSuggestions(&buf, tt.suggestions)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Package config provides commands for inspecting and checking the configuration.
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	pkgconfig "codefloe.com/pat-s/backporter/pkg/config"
+)
+
+// reachTimeout bounds the check whether a forge URL is reachable.
+const reachTimeout = 10 * time.Second
+
+// sourceDefault is the source of settings no config file or flag sets.
+const sourceDefault = "default"
+
+// Command is the config command.
+var Command = &cli.Command{
+	Name:  "config",
+	Usage: "inspect and check the configuration",
+	Commands: []*cli.Command{
+		showCmd,
+		validateCmd,
+	},
+}
+
+var showCmd = &cli.Command{
+	Name:   "show",
+	Usage:  "print the effective configuration (global and repo-local files and flags) with the source of each setting",
+	Action: showConfig,
+}
+
+var validateCmd = &cli.Command{
+	Name:      "validate",
+	Usage:     "check config files for unknown keys, invalid values and target branch patterns and unreachable forge URLs",
+	ArgsUsage: "[file...]",
+	Action:    validateConfig,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "offline",
+			Usage: "skip checking that forge URLs are reachable",
+		},
+	},
+}
+
+func showConfig(_ context.Context, c *cli.Command) error {
+	cfg, err := cliconfig.Merged(c)
+	if err != nil {
+		return err
+	}
+
+	sources := make(map[string]string)
+	for _, file := range cliconfig.Files(c) {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		keys, err := pkgconfig.SetKeys(data)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			sources[key] = fmt.Sprintf("%s (%s)", file.Source, file.Path)
+		}
+	}
+	if c.String("push-remote") != "" {
+		sources["push_remote"] = "--push-remote"
+	}
+	remote := cfg.Remote
+	if c.String("remote") != "" {
+		remote = c.String("remote")
+	}
+	if host := cliconfig.RemoteHost(remote); host != "" {
+		if settings, ok := cfg.Host(host); ok {
+			keys := map[string]string{"forge_type": settings.Type, "token_env": settings.TokenEnv, "token_command": settings.TokenCommand, "token_file": settings.TokenFile}
+			switch settings.Type {
+			case "forgejo":
+				keys["forgejo_url"] = cfg.ForgejoURL
+			case "gerrit":
+				keys["gerrit_url"] = cfg.GerritURL
+			}
+			for key, value := range keys {
+				if value != "" {
+					sources[key] = "hosts." + host
+				}
+			}
+		}
+	}
+	if name := c.String("profile"); name != "" {
+		profile := cfg.Profiles[name]
+		for key, value := range map[string]string{"forge_type": profile.ForgeType, "forgejo_url": profile.ForgejoURL, "gerrit_url": profile.GerritURL, "token_env": profile.TokenEnv, "token_command": profile.TokenCommand, "token_file": profile.TokenFile} {
+			if value != "" {
+				sources[key] = "--profile " + name
+			}
+		}
+	}
+	// The remote flag replaces the configured remote for all commands.
+	if remote != cfg.Remote {
+		cfg.Remote = remote
+		sources["remote"] = "--remote"
+	}
+
+	values, err := cfg.Values()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd
+	for _, kv := range values {
+		fmt.Fprintf(w, "%s\t%s\t# %s\n", kv.Key, kv.Value, sourceOf(sources, kv.Key))
+	}
+	return w.Flush()
+}
+
+// sourceOf returns the source of a setting, the one of the closest parent key for settings
+// inside a list or map that was set as a whole.
+func sourceOf(sources map[string]string, key string) string {
+	for {
+		if source, ok := sources[key]; ok {
+			return source
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			return sourceDefault
+		}
+		key = key[:i]
+	}
+}
+
+func validateConfig(ctx context.Context, c *cli.Command) error {
+	paths := c.Args().Slice()
+	if len(paths) == 0 {
+		for _, file := range cliconfig.Files(c) {
+			paths = append(paths, file.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no config file found (looked for %s and %s)", pkgconfig.GlobalConfigPath(), pkgconfig.RepoConfigPath())
+	}
+
+	failed := 0
+	for _, path := range paths {
+		problems := checkFile(ctx, path, c.Root(), !c.Bool("offline"))
+		if len(problems) == 0 {
+			fmt.Printf("✓ %s is valid\n", path)
+			continue
+		}
+		failed++
+		fmt.Printf("✗ %s has %d problem(s):\n", path, len(problems))
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d config file(s) are invalid", failed, len(paths))
+	}
+	return nil
+}
+
+// checkFile returns the problems of a config file, checking that its aliases don't shadow
+// commands of the app and that its forge URLs are reachable if online.
+func checkFile(ctx context.Context, path string, app *cli.Command, online bool) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	unknown, err := pkgconfig.UnknownKeys(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, key := range unknown {
+		problems = append(problems, key.String())
+	}
+
+	cfg, err := pkgconfig.LoadFromFile(path)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	problems = append(problems, checkBranchPatterns(cfg)...)
+	problems = append(problems, checkAliases(cfg, app)...)
+
+	if online {
+		urls := forgeURLs(cfg)
+		for _, key := range slices.Sorted(maps.Keys(urls)) {
+			forgeURL := urls[key]
+			if err := checkReachable(ctx, forgeURL); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %s is unreachable: %v", key, forgeURL, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+// branchPatterns is a list of target branch patterns in the config.
+type branchPatterns struct {
+	key      string
+	patterns []string
+}
+
+// checkAliases returns the aliases of cfg that are never expanded because a built-in command
+// has the same name.
+func checkAliases(cfg *pkgconfig.Config, app *cli.Command) []string {
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Aliases)) {
+		if app.Command(name) != nil || name == "help" {
+			problems = append(problems, fmt.Sprintf("alias %s is shadowed by the built-in command", name))
+		}
+	}
+	return problems
+}
+
+// checkBranchPatterns returns the target branch patterns of cfg that are no valid regex.
+func checkBranchPatterns(cfg *pkgconfig.Config) []string {
+	lists := []branchPatterns{
+		{"target_branches", cfg.TargetBranches},
+		{"interactive.target_branches", cfg.Interactive.TargetBranches},
+		{"ci.target_branches", cfg.CI.TargetBranches},
+	}
+	for i, checklist := range cfg.ReviewChecklists {
+		lists = append(lists, branchPatterns{fmt.Sprintf("review_checklists[%d].branches", i), checklist.Branches})
+	}
+	for i, mapping := range cfg.PathMappings {
+		lists = append(lists, branchPatterns{fmt.Sprintf("path_mappings[%d].branches", i), mapping.Branches})
+	}
+
+	var problems []string
+	for _, list := range lists {
+		for _, pattern := range list.patterns {
+			if err := backport.ValidateTargetPattern(pattern); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid pattern %q in %s: %v", pattern, list.key, err))
+			}
+		}
+	}
+	return problems
+}
+
+// forgeURLs returns the forge URLs of cfg by key, including the ones of its profiles.
+func forgeURLs(cfg *pkgconfig.Config) map[string]string {
+	urls := make(map[string]string)
+	if cfg.ForgejoURL != "" {
+		urls["forgejo_url"] = cfg.ForgejoURL
+	}
+	if cfg.GerritURL != "" {
+		urls["gerrit_url"] = cfg.GerritURL
+	}
+	for name, profile := range cfg.Profiles {
+		if profile.ForgejoURL != "" {
+			urls["profiles."+name+".forgejo_url"] = profile.ForgejoURL
+		}
+		if profile.GerritURL != "" {
+			urls["profiles."+name+".gerrit_url"] = profile.GerritURL
+		}
+	}
+	for host, settings := range cfg.Hosts {
+		if settings.URL != "" {
+			urls["hosts."+host+".url"] = settings.URL
+		}
+	}
+	return urls
+}
+
+// checkReachable checks that the server of an http(s) URL answers, with any status.
+func checkReachable(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("not an http(s) URL")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reachTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
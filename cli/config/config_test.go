@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestSourceOf(t *testing.T) {
+	sources := map[string]string{
+		"forge_type":        "repo (.backporter.yaml)",
+		"review_checklists": "global (config.yaml)",
+	}
+
+	assert.Equal(t, "repo (.backporter.yaml)", sourceOf(sources, "forge_type"))
+	assert.Equal(t, "global (config.yaml)", sourceOf(sources, "review_checklists[0].branches"))
+	assert.Equal(t, sourceDefault, sourceOf(sources, "ci.default_prefix"))
+}
+
+func TestCheckFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	write("forge_type: forgejo\nforgejo_url: " + server.URL + "\ntarget_branches: [release-.*]\n")
+	assert.Empty(t, checkFile(context.Background(), path, &cli.Command{}, true))
+
+	write(`
+forge_type: forgejo
+forgejo_url: http://127.0.0.1:1
+target_branchs: [main]
+ci:
+  target_branches: ["release-(1"]
+profiles:
+  work:
+    forge_type: gitlab
+`)
+	problems := checkFile(context.Background(), path, &cli.Command{}, true)
+	assert.Len(t, problems, 4)
+	assert.Equal(t, "unknown key target_branchs at line 4, did you mean target_branches?", problems[0])
+	assert.Contains(t, problems[1], "invalid profiles.work.forge_type")
+	assert.Contains(t, problems[2], `invalid pattern "release-(1" in ci.target_branches`)
+	assert.Contains(t, problems[3], "forgejo_url http://127.0.0.1:1 is unreachable")
+
+	assert.Len(t, checkFile(context.Background(), path, &cli.Command{}, false), 3)
+
+	write("aliases:\n  list: graph\n  bp1: backport pr --base v1.x\n")
+	app := &cli.Command{Commands: []*cli.Command{{Name: "list"}}}
+	assert.Equal(t, []string{"alias list is shadowed by the built-in command"}, checkFile(context.Background(), path, app, false))
+}
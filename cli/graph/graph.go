@@ -0,0 +1,70 @@
+// Package graph provides the graph command for showing backport propagation.
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+)
+
+// Output formats supported by the graph command.
+const (
+	formatASCII = "ascii"
+	formatDOT   = "dot"
+)
+
+// Command is the graph command.
+var Command = &cli.Command{
+	Name:      "graph",
+	Usage:     "show which branches a commit or PR has been backported to",
+	ArgsUsage: "<commit-sha|pr-number>",
+	Action:    showGraph,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format: ascii or dot",
+			Value: formatASCII,
+			Validator: func(s string) error {
+				if s != formatASCII && s != formatDOT {
+					return fmt.Errorf("invalid format: %s (must be '%s' or '%s')", s, formatASCII, formatDOT)
+				}
+				return nil
+			},
+		},
+	},
+}
+
+func showGraph(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("usage: graph <commit-sha|pr-number>")
+	}
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	sha, prNumber, err := internal.ResolveCommitOrPR(ctx, service, c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	graph, err := service.Graph(ctx, sha)
+	if err != nil {
+		return err
+	}
+	if prNumber > 0 {
+		graph.PRNumber = prNumber
+	}
+
+	if c.String("format") == formatDOT {
+		fmt.Print(graph.DOT())
+	} else {
+		fmt.Print(graph.ASCII())
+	}
+
+	return nil
+}
@@ -2,66 +2,235 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 
 	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
 )
 
+// skewWarning reports an outdated binary once, the config is loaded several times per invocation.
+var skewWarning sync.Once
+
+// detections memoizes the forge detected per remote host, so self-hosted forges are probed
+// and failures reported once per invocation.
+var (
+	detectionsMu sync.Mutex
+	detections   = make(map[string]detection)
+)
+
+// detection is the result of forge.Detect for a host.
+type detection struct {
+	forge *forge.Detection
+	err   error
+}
+
+// File is a config file contributing to the effective configuration.
+type File struct {
+	// Source of the file: "global", "repo" or "--config".
+	Source string
+	Path   string
+}
+
+// Files returns the config files Load merges, lowest precedence first.
+// The global and repo-local files are only included if they exist.
+func Files(c *cli.Command) []File {
+	return FilesFor(c.String("config"))
+}
+
+// FilesFor returns the config files that apply with an explicit config file path, which
+// may be empty, in the order they are merged.
+func FilesFor(configPath string) []File {
+	var files []File
+	if globalPath := config.GlobalConfigPath(); globalPath != "" {
+		if _, err := os.Stat(globalPath); err == nil {
+			files = append(files, File{Source: "global", Path: globalPath})
+		}
+	}
+	if _, err := os.Stat(config.RepoConfigPath()); err == nil {
+		files = append(files, File{Source: "repo", Path: config.RepoConfigPath()})
+	}
+	if configPath != "" {
+		files = append(files, File{Source: "--config", Path: configPath})
+	}
+	return files
+}
+
 // Load loads configuration from global and repo-local config files.
 func Load(c *cli.Command) (*config.Config, error) {
-	cfg := config.DefaultConfig()
+	cfg, err := Merged(c)
+	if err != nil {
+		return nil, &config.InvalidError{Err: err}
+	}
 
-	// Load global config first.
-	globalPath := config.GlobalConfigPath()
-	if globalPath != "" {
-		if _, err := os.Stat(globalPath); err == nil {
-			globalCfg, err := config.LoadFromFile(globalPath)
-			if err != nil {
-				log.Debug().Err(err).Str("path", globalPath).Msg("failed to load global config")
-			} else {
-				log.Debug().Str("path", globalPath).Msg("loaded global config")
-				cfg.Merge(globalCfg)
-			}
+	if err := cfg.Validate(); err != nil {
+		return nil, &config.InvalidError{Err: err}
+	}
+
+	scope := ScopeFor(c)
+
+	// CI fails on stale runner images, interactive use only warns.
+	if err := cfg.CheckMinVersion(version.Version); err != nil {
+		if scope == config.ScopeCI {
+			return nil, &config.InvalidError{Err: err}
 		}
+		skewWarning.Do(func() {
+			log.Warn().Err(err).Msg("backporter is older than the config requires, run `backporter self-update` to upgrade")
+		})
 	}
 
-	// Load repo-local config (overrides global).
-	repoPath := config.RepoConfigPath()
-	if _, err := os.Stat(repoPath); err == nil {
-		repoCfg, err := config.LoadFromFile(repoPath)
-		if err != nil {
-			log.Debug().Err(err).Str("path", repoPath).Msg("failed to load repo config")
-		} else {
-			log.Debug().Str("path", repoPath).Msg("loaded repo config")
-			cfg.Merge(repoCfg)
+	// Infer an unset forge type from the remote.
+	if cfg.ForgeType == "" {
+		detectForge(c, cfg)
+	}
+
+	log.Debug().Str("scope", string(scope)).Msg("resolving config scope")
+
+	return cfg.ForScope(scope), nil
+}
+
+// detectForge sets the forge type of cfg, and the server URL of self-hosted forges, from the
+// host of the remote. Without a detectable forge, PR features are unavailable.
+func detectForge(c *cli.Command, cfg *config.Config) {
+	detected, err := DetectForge(context.Background(), remoteName(c, cfg))
+	if err != nil || detected == nil {
+		log.Warn().Err(err).Msg("forge_type not configured - PR features will be unavailable")
+		return
+	}
+
+	cfg.ForgeType = detected.Type
+	switch detected.Type {
+	case "forgejo":
+		if cfg.ForgejoURL == "" {
+			cfg.ForgejoURL = detected.URL
+		}
+	case "gerrit":
+		if cfg.GerritURL == "" {
+			cfg.GerritURL = detected.URL
 		}
 	}
+}
+
+// DetectForge detects the forge of a remote of the current repository, see forge.Detect.
+// Returns nil without an error outside of a repository or for remotes without a host, like local paths.
+func DetectForge(ctx context.Context, remote string) (*forge.Detection, error) {
+	host := RemoteHost(remote)
+	if host == "" {
+		return nil, nil
+	}
+
+	detectionsMu.Lock()
+	defer detectionsMu.Unlock()
+
+	result, ok := detections[host]
+	if !ok {
+		result.forge, result.err = forge.Detect(ctx, host)
+		detections[host] = result
+		if result.err == nil {
+			log.Debug().Str("host", host).Str("forge", result.forge.Type).Msg("detected forge type from remote, set forge_type to skip the detection")
+		}
+	}
+	return result.forge, result.err
+}
+
+// RemoteHost returns the host of a remote of the current repository, empty outside of a
+// repository, for unknown remotes and for remotes without a host, like local paths.
+func RemoteHost(remote string) string {
+	// A missing remote is reported where it is used.
+	repo, err := git.OpenCurrent()
+	if err != nil {
+		return ""
+	}
+	url, err := repo.RemoteURL(remote)
+	if err != nil {
+		return ""
+	}
+	host, err := git.ParseRemoteHost(url)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// remoteName returns the name of the remote the invocation works with.
+func remoteName(c *cli.Command, cfg *config.Config) string {
+	if c.IsSet("remote") {
+		return c.String("remote")
+	}
+	return cfg.Remote
+}
+
+// Merged merges the config files and the flags overriding them, without validating the result
+// or resolving its scope. Unparsable global and repo-local files are skipped, an explicit one is an error.
+func Merged(c *cli.Command) (*config.Config, error) {
+	cfg := config.DefaultConfig()
 
-	// Override with explicit config file if provided.
-	if configPath := c.String("config"); configPath != "" {
-		explicitCfg, err := config.LoadFromFile(configPath)
+	// Later files override earlier ones.
+	opts := config.LoadOptions{Strict: c.Bool("strict-config")}
+	for _, file := range Files(c) {
+		fileCfg, err := config.LoadFromFileWithOptions(file.Path, opts)
 		if err != nil {
-			return nil, err
+			// Unknown keys are reported for all files, they would silently change the behavior.
+			var unknownErr *config.UnknownKeysError
+			if file.Source == "--config" || errors.As(err, &unknownErr) {
+				return nil, err
+			}
+			log.Debug().Err(err).Str("path", file.Path).Msgf("failed to load %s config", file.Source)
+			continue
+		}
+		log.Debug().Str("path", file.Path).Msgf("loaded %s config", file.Source)
+		if file.Source == "repo" {
+			if err := fileCfg.CheckRepoLocal(); err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Path, err)
+			}
 		}
-		log.Debug().Str("path", configPath).Msg("loaded explicit config")
-		cfg.Merge(explicitCfg)
+		cfg.Merge(fileCfg)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+	// Telemetry can also be opted in to from the environment, e.g. in CI without a global config.
+	if endpoint := os.Getenv("BACKPORTER_TELEMETRY_ENDPOINT"); endpoint != "" {
+		cfg.Telemetry = config.TelemetryConfig{Enabled: true, Endpoint: endpoint}
 	}
 
-	// Warn if forge type is not set.
-	if cfg.ForgeType == "" {
-		log.Warn().Msg("forge_type not configured - PR features will be unavailable")
+	// The push remote flag overrides the config files.
+	if pushRemote := c.String("push-remote"); pushRemote != "" {
+		cfg.PushRemote = pushRemote
+	}
+
+	// The settings of the remote's host override the forge settings of the config files.
+	if len(cfg.Hosts) > 0 {
+		if host := RemoteHost(remoteName(c, cfg)); host != "" && cfg.UseHost(host) {
+			log.Debug().Str("host", host).Str("forge", cfg.ForgeType).Msg("using host settings")
+		}
+	}
+
+	// A selected profile overrides the forge settings of the config files and the host.
+	if profile := c.String("profile"); profile != "" {
+		if err := cfg.UseProfile(profile); err != nil {
+			return nil, err
+		}
+		log.Debug().Str("profile", profile).Str("forge", cfg.ForgeType).Msg("using profile")
 	}
 
 	return cfg, nil
 }
 
+// ScopeFor returns the config scope for the current invocation.
+func ScopeFor(c *cli.Command) config.Scope {
+	if c.Bool("ci") {
+		return config.ScopeCI
+	}
+	return config.ScopeInteractive
+}
+
 // ApplyToFlags applies config values to CLI flags if they haven't been explicitly set.
 func ApplyToFlags(c *cli.Command, cfg *config.Config) error {
 	// Only apply if the flag hasn't been explicitly set.
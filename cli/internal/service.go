@@ -3,8 +3,11 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
@@ -12,12 +15,13 @@ import (
 	"codefloe.com/pat-s/backporter/cli/internal/config"
 	"codefloe.com/pat-s/backporter/pkg/backport"
 	pkgconfig "codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/credentials"
 	"codefloe.com/pat-s/backporter/pkg/forge"
 	"codefloe.com/pat-s/backporter/pkg/git"
 )
 
 // CreateService creates a backport service from CLI context.
-func CreateService(_ context.Context, c *cli.Command) (*backport.Service, error) {
+func CreateService(ctx context.Context, c *cli.Command) (*backport.Service, error) {
 	cfg, err := config.GetConfig(c)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
@@ -34,6 +38,7 @@ func CreateService(_ context.Context, c *cli.Command) (*backport.Service, error)
 	if remote == "" {
 		remote = cfg.Remote
 	}
+	cfg.Remote = remote
 
 	remoteURL, err := repo.RemoteURL(remote)
 	if err != nil {
@@ -50,31 +55,225 @@ func CreateService(_ context.Context, c *cli.Command) (*backport.Service, error)
 	// Create forge client if configured.
 	var f forge.Forge
 	if cfg.ForgeType != "" {
-		token := getForgeToken(cfg.ForgeType)
-		opts := forge.NewOptions{
-			ForgejoURL: cfg.ForgejoURL,
-		}
-		f, err = forge.NewWithOptions(cfg.ForgeType, token, opts)
+		token := ForgeToken(cfg)
+		f, err = forge.NewWithOptions(cfg.ForgeType, token, ForgeOptions(c, cfg))
 		if err != nil {
 			log.Warn().Err(err).Msg("failed to create forge client")
 		} else {
 			log.Debug().Str("forge", cfg.ForgeType).Msg("forge client created")
+			owner, repoName = resolveRepo(ctx, f, repo, cfg, remoteURL, owner, repoName)
 		}
 	}
 
-	return backport.NewService(repo, f, cfg, owner, repoName), nil
+	if !cfg.RepoAllowed(owner, repoName) {
+		return nil, repoNotAllowedError(owner, repoName)
+	}
+
+	pushOwner, pushRepoName, err := resolvePushRepo(repo, cfg, owner, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := backport.NewService(repo, f, cfg, owner, repoName)
+	svc.SetPushRepo(pushOwner, pushRepoName)
+	return svc, nil
 }
 
-// getForgeToken retrieves the token for the specified forge type from environment.
-func getForgeToken(forgeType string) string {
-	switch forgeType {
-	case "github":
-		return os.Getenv("GITHUB_TOKEN")
-	case "forgejo":
-		return os.Getenv("FORGEJO_TOKEN")
-	default:
+// resolvePushRepo returns the owner and name of the repository of the push remote,
+// which is the repository PRs are read from unless a different push remote is configured.
+func resolvePushRepo(repo *git.Repository, cfg *pkgconfig.Config, owner, repoName string) (string, string, error) {
+	pushRemote := cfg.WriteRemote()
+	if pushRemote == cfg.Remote {
+		return owner, repoName, nil
+	}
+
+	pushURL, err := repo.RemoteURL(pushRemote)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get push remote URL: %w", err)
+	}
+
+	pushOwner, pushRepoName, err := git.ParseRemoteURL(pushURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse push remote URL: %w", err)
+	}
+
+	if !cfg.RepoAllowed(pushOwner, pushRepoName) {
+		return "", "", repoNotAllowedError(pushOwner, pushRepoName)
+	}
+
+	log.Debug().
+		Str("remote", pushRemote).
+		Str("owner", pushOwner).
+		Str("repo", pushRepoName).
+		Msg("pushing backports to a different repository")
+
+	return pushOwner, pushRepoName, nil
+}
+
+// resolveRepo returns the current owner and name of the repository, following renames and
+// transfers reported by the forge. Cached PRs are moved to the new name and the remote URL is
+// rewritten if configured. Lookup failures keep the names from the remote URL.
+func resolveRepo(
+	ctx context.Context,
+	f forge.Forge,
+	repo *git.Repository,
+	cfg *pkgconfig.Config,
+	remoteURL, owner, repoName string,
+) (string, string) {
+	info, err := f.GetRepo(ctx, owner, repoName)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to look up repository")
+		return owner, repoName
+	}
+
+	if info.Owner == owner && info.Name == repoName {
+		return owner, repoName
+	}
+
+	log.Warn().
+		Str("old", owner+"/"+repoName).
+		Str("new", info.Owner+"/"+info.Name).
+		Msg("repository was renamed or transferred")
+
+	if cfg.Cache.Enabled {
+		prCache := backport.NewPRCache(cfg.Cache.Path)
+		if err := prCache.Rename(owner, repoName, info.Owner, info.Name); err != nil {
+			log.Warn().Err(err).Msg("failed to move cached PRs to the new repository name")
+		}
+	}
+
+	if cfg.UpdateRemoteURL {
+		newURL, err := git.RewriteRemoteURL(remoteURL, info.Owner, info.Name)
+		if err == nil {
+			err = repo.SetRemoteURL(cfg.Remote, newURL)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("remote", cfg.Remote).Msg("failed to update remote URL")
+		} else {
+			log.Info().Str("remote", cfg.Remote).Str("url", newURL).Msg("updated remote URL")
+		}
+	} else {
+		log.Info().Str("remote", cfg.Remote).Msg("set update_remote_url to rewrite the remote URL automatically")
+	}
+
+	return info.Owner, info.Name
+}
+
+// repoNotAllowedError returns the error for a repository excluded by repos_allow or repos_deny.
+func repoNotAllowedError(owner, repoName string) error {
+	return fmt.Errorf("repository %s/%s is excluded by repos_allow/repos_deny in the config", owner, repoName)
+}
+
+// ForgeToken retrieves the forge token from the environment variable configured with
+// token_env (or else the one of the forge type), the token_command, the token_file or the OS keyring.
+func ForgeToken(cfg *pkgconfig.Config) string {
+	token, err := credentials.Lookup(context.Background(), CredentialOptions(cfg))
+	if err != nil {
+		if errors.Is(err, credentials.ErrNoToken) {
+			log.Debug().Err(err).Msg("no forge token configured")
+		} else {
+			log.Warn().Err(err).Msg("failed to get forge token")
+		}
 		return ""
 	}
+	log.Debug().Str("source", token.Source).Msg("found forge token")
+	return token.Value
+}
+
+// forgeCacheDir is the directory of the forge response cache, next to the backport history.
+const forgeCacheDir = "forge"
+
+// ForgeOptions returns the options of the forge client of cfg. Forge responses are cached
+// next to the backport history unless the cache is disabled or --no-cache is set.
+func ForgeOptions(c *cli.Command, cfg *pkgconfig.Config) forge.NewOptions {
+	opts := forge.NewOptions{
+		ForgejoURL:    cfg.ForgejoURL,
+		GerritURL:     cfg.GerritURL,
+		GitHubGraphQL: cfg.GitHubGraphQL,
+		CacheTTL:      cfg.Cache.ForgeCacheTTL(),
+	}
+	if cfg.Cache.Enabled && !c.Bool("no-cache") {
+		if dir := backport.CacheDir(cfg.Cache.Path); dir != "" {
+			opts.CacheDir = filepath.Join(dir, forgeCacheDir)
+		}
+	}
+	return opts
+}
+
+// CredentialOptions returns where the forge token of cfg is looked up.
+func CredentialOptions(cfg *pkgconfig.Config) credentials.Options {
+	return credentials.Options{
+		ForgeType:    cfg.ForgeType,
+		ForgeURL:     cfg.ForgeURL(),
+		TokenEnv:     cfg.TokenEnv,
+		TokenCommand: cfg.TokenCommand,
+		TokenFile:    cfg.TokenFile,
+	}
+}
+
+// Prefixes that mark an argument explicitly as a PR or a commit, e.g. "pr:123" or "commit:1234567".
+const (
+	PRPrefix     = "pr:"
+	CommitPrefix = "commit:"
+)
+
+// Kinds of commit or PR arguments.
+const (
+	RefUnknown = ""
+	RefPR      = "pr"
+	RefCommit  = "commit"
+)
+
+// SplitRefArg returns the kind and value of an argument prefixed with "pr:", "#" or "commit:".
+// Unprefixed arguments are returned as is with RefUnknown.
+func SplitRefArg(arg string) (string, string) {
+	switch {
+	case strings.HasPrefix(arg, PRPrefix):
+		return RefPR, strings.TrimPrefix(arg, PRPrefix)
+	case strings.HasPrefix(arg, "#"):
+		return RefPR, strings.TrimPrefix(arg, "#")
+	case strings.HasPrefix(arg, CommitPrefix):
+		return RefCommit, strings.TrimPrefix(arg, CommitPrefix)
+	default:
+		return RefUnknown, arg
+	}
+}
+
+// ResolveCommitOrPR resolves a commit or PR argument to a commit SHA, see SplitRefArg.
+// Unprefixed arguments are preferably resolved as commits, PR numbers that don't resolve to
+// a commit are looked up on the forge and returned along with the merge commit of the PR.
+func ResolveCommitOrPR(ctx context.Context, service *backport.Service, arg string) (string, int, error) {
+	kind, arg := SplitRefArg(arg)
+
+	repo, err := GetRepository()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if kind != RefPR {
+		_, shaErr := repo.GetCommitSHA(arg)
+		if shaErr == nil {
+			return arg, 0, nil
+		}
+		if kind == RefCommit {
+			return "", 0, fmt.Errorf("commit %s not found: %w", arg, shaErr)
+		}
+		if _, err := strconv.Atoi(arg); err != nil {
+			return "", 0, fmt.Errorf("%s is neither a commit nor a PR number: %w", arg, shaErr)
+		}
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid PR number: %s", arg)
+	}
+
+	pr, err := service.GetPR(ctx, number)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return pr.MergeCommit, number, nil
 }
 
 // GetRepository opens the current git repository.
@@ -83,8 +282,9 @@ func GetRepository() (*git.Repository, error) {
 }
 
 // CreateServiceWithDetails creates a backport service and returns additional details.
-// Returns: service, config, forge client, owner, repo name, error.
-func CreateServiceWithDetails(_ context.Context, c *cli.Command) (
+// Returns: service, config, forge client, owner, repo name, error. The owner and repo name are
+// those of the remote PRs are read from, see Service.PushRepo for the repository written to.
+func CreateServiceWithDetails(ctx context.Context, c *cli.Command) (
 	*backport.Service,
 	*pkgconfig.Config,
 	forge.Forge,
@@ -108,6 +308,7 @@ func CreateServiceWithDetails(_ context.Context, c *cli.Command) (
 	if remote == "" {
 		remote = cfg.Remote
 	}
+	cfg.Remote = remote
 
 	remoteURL, err := repo.RemoteURL(remote)
 	if err != nil {
@@ -124,19 +325,27 @@ func CreateServiceWithDetails(_ context.Context, c *cli.Command) (
 	// Create forge client if configured.
 	var f forge.Forge
 	if cfg.ForgeType != "" {
-		token := getForgeToken(cfg.ForgeType)
-		opts := forge.NewOptions{
-			ForgejoURL: cfg.ForgejoURL,
-		}
-		f, err = forge.NewWithOptions(cfg.ForgeType, token, opts)
+		token := ForgeToken(cfg)
+		f, err = forge.NewWithOptions(cfg.ForgeType, token, ForgeOptions(c, cfg))
 		if err != nil {
 			return nil, nil, nil, "", "", fmt.Errorf("failed to create forge client: %w", err)
 		}
 		log.Debug().Str("forge", cfg.ForgeType).Msg("forge client created")
+		owner, repoName = resolveRepo(ctx, f, repo, cfg, remoteURL, owner, repoName)
 	} else {
 		return nil, nil, nil, "", "", fmt.Errorf("forge_type must be configured for CI mode")
 	}
 
+	if !cfg.RepoAllowed(owner, repoName) {
+		return nil, nil, nil, "", "", repoNotAllowedError(owner, repoName)
+	}
+
+	pushOwner, pushRepoName, err := resolvePushRepo(repo, cfg, owner, repoName)
+	if err != nil {
+		return nil, nil, nil, "", "", err
+	}
+
 	svc := backport.NewService(repo, f, cfg, owner, repoName)
+	svc.SetPushRepo(pushOwner, pushRepoName)
 	return svc, cfg, f, owner, repoName, nil
 }
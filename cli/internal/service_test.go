@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pkgconfig "codefloe.com/pat-s/backporter/pkg/config"
+)
+
+func TestForgeToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "default-token")
+	t.Setenv("WORK_TOKEN", "work-token")
+
+	assert.Equal(t, "default-token", ForgeToken(&pkgconfig.Config{ForgeType: "github"}))
+	assert.Equal(t, "work-token", ForgeToken(&pkgconfig.Config{ForgeType: "github", TokenEnv: "WORK_TOKEN"}))
+	assert.Empty(t, ForgeToken(&pkgconfig.Config{}))
+}
+
+func TestSplitRefArg(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  string
+		value string
+	}{
+		{"pr:42", RefPR, "42"},
+		{"#42", RefPR, "42"},
+		{"commit:1234567", RefCommit, "1234567"},
+		{"1234567", RefUnknown, "1234567"},
+		{"origin/main~2", RefUnknown, "origin/main~2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			kind, value := SplitRefArg(tt.input)
+			assert.Equal(t, tt.kind, kind)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+}
@@ -3,11 +3,14 @@ package list
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/urfave/cli/v3"
 
 	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
 )
 
 const shaTruncateLength = 12
@@ -22,9 +25,71 @@ var Command = &cli.Command{
 			Name:  "clear",
 			Usage: "clear the cache",
 		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "only list backports to this target branch",
+		},
+		&cli.IntFlag{
+			Name:  "pr",
+			Usage: "only list backports of this PR",
+		},
+		&cli.StringFlag{
+			Name:  "sha",
+			Usage: "only list backports whose original or backport commit starts with this SHA",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "only list backports made at or after this date (2006-01-02) or RFC 3339 timestamp",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "only list backports made before this date (2006-01-02) or RFC 3339 timestamp",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "sort by date, branch or pr, prefix with - for descending order (e.g. -date)",
+			Value: backport.SortDate,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the backports as JSON, same as --format json",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format: table, json, or a report in markdown, csv or html",
+			Value: formatTable,
+		},
+		&cli.StringFlag{
+			Name:  "group",
+			Usage: "group reports per target branch by month or pr",
+			Value: groupMonth,
+		},
 	},
 }
 
+// historyFilter builds the history filter from the command flags.
+func historyFilter(c *cli.Command) (backport.HistoryFilter, error) {
+	filter := backport.HistoryFilter{
+		Branch: c.String("branch"),
+		PR:     c.Int("pr"),
+		SHA:    c.String("sha"),
+	}
+
+	var err error
+	if since := c.String("since"); since != "" {
+		if filter.Since, err = backport.ParseHistoryTime(since); err != nil {
+			return filter, err
+		}
+	}
+	if until := c.String("until"); until != "" {
+		if filter.Until, err = backport.ParseHistoryTime(until); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
 func listBackports(ctx context.Context, c *cli.Command) error {
 	service, err := internal.CreateService(ctx, c)
 	if err != nil {
@@ -39,14 +104,52 @@ func listBackports(ctx context.Context, c *cli.Command) error {
 		return nil
 	}
 
-	entries := service.ListBackports()
+	filter, err := historyFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries := backport.FilterHistory(service.ListBackports(), filter)
+	if err := backport.SortHistory(entries, c.String("sort")); err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if c.Bool("json") {
+		format = formatJSON
+	}
+
+	groupBy := c.String("group")
+	if groupBy != groupMonth && groupBy != groupPR {
+		return fmt.Errorf("invalid group %q, expected month or pr", groupBy)
+	}
+
+	switch format {
+	case formatTable:
+	case formatJSON:
+		if entries == nil {
+			entries = []backport.CacheEntry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case formatMarkdown:
+		return renderMarkdown(os.Stdout, buildReport(entries, groupBy))
+	case formatCSV:
+		return renderCSV(os.Stdout, buildReport(entries, groupBy))
+	case formatHTML:
+		return renderHTML(os.Stdout, buildReport(entries, groupBy))
+	default:
+		return fmt.Errorf("invalid format %q, expected table, json, markdown, csv or html", format)
+	}
+
 	if len(entries) == 0 {
 		fmt.Println("No backports found in cache")
 		return nil
 	}
 
-	fmt.Printf("%-12s %-12s %-20s %-10s %s\n", "ORIGINAL", "BACKPORT", "BRANCH", "PR", "TIMESTAMP")
-	fmt.Println("--------------------------------------------------------------------------------------------")
+	fmt.Printf("%-12s %-12s %-20s %-10s %-12s %s\n", "ORIGINAL", "BACKPORT", "BRANCH", "PR", "RELEASE", "TIMESTAMP")
+	fmt.Println("---------------------------------------------------------------------------------------------------------")
 
 	for _, entry := range entries {
 		prStr := "-"
@@ -54,11 +157,17 @@ func listBackports(ctx context.Context, c *cli.Command) error {
 			prStr = fmt.Sprintf("#%d", entry.PRNumber)
 		}
 
-		fmt.Printf("%-12s %-12s %-20s %-10s %s\n",
+		releaseStr := "-"
+		if entry.Release != "" {
+			releaseStr = entry.Release
+		}
+
+		fmt.Printf("%-12s %-12s %-20s %-10s %-12s %s\n",
 			safeTruncate(entry.OriginalSHA, shaTruncateLength),
 			safeTruncate(entry.BackportSHA, shaTruncateLength),
 			entry.TargetBranch,
 			prStr,
+			releaseStr,
 			entry.Timestamp.Format("2006-01-02 15:04"),
 		)
 	}
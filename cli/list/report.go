@@ -0,0 +1,204 @@
+package list
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+// Output formats of the list command.
+const (
+	formatTable    = "table"
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+	formatCSV      = "csv"
+	formatHTML     = "html"
+)
+
+// Report groupings.
+const (
+	groupMonth = "month"
+	groupPR    = "pr"
+)
+
+// noPRGroup is the group of backports of individual commits when grouping by PR.
+const noPRGroup = "Commits"
+
+// reportGroup is a group of backports to a branch, e.g. those of one month.
+type reportGroup struct {
+	Name    string
+	Entries []backport.CacheEntry
+}
+
+// branchReport holds the grouped backports to one target branch.
+type branchReport struct {
+	Branch string
+	Groups []reportGroup
+}
+
+// buildReport groups entries by target branch and then by month or PR. Branches are sorted
+// by name, months chronologically and PRs by number with individual commits last.
+// Entries keep their order within a group.
+func buildReport(entries []backport.CacheEntry, groupBy string) []branchReport {
+	byBranch := make(map[string][]backport.CacheEntry)
+	for _, entry := range entries {
+		byBranch[entry.TargetBranch] = append(byBranch[entry.TargetBranch], entry)
+	}
+
+	branches := make([]string, 0, len(byBranch))
+	for branch := range byBranch {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	report := make([]branchReport, 0, len(branches))
+	for _, branch := range branches {
+		report = append(report, branchReport{Branch: branch, Groups: groupEntries(byBranch[branch], groupBy)})
+	}
+	return report
+}
+
+// groupEntries groups the backports to a branch by month or PR.
+func groupEntries(entries []backport.CacheEntry, groupBy string) []reportGroup {
+	var keys []string
+	groups := make(map[string][]backport.CacheEntry)
+	for _, entry := range entries {
+		key := groupKey(entry, groupBy)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if groupBy != groupPR {
+			return keys[i] < keys[j]
+		}
+		a, errA := strconv.Atoi(strings.TrimPrefix(keys[i], "#"))
+		b, errB := strconv.Atoi(strings.TrimPrefix(keys[j], "#"))
+		if errA != nil || errB != nil {
+			return errB != nil && errA == nil
+		}
+		return a < b
+	})
+
+	result := make([]reportGroup, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, reportGroup{Name: key, Entries: groups[key]})
+	}
+	return result
+}
+
+// groupKey returns the group of an entry, e.g. "2024-05" or "#42".
+func groupKey(entry backport.CacheEntry, groupBy string) string {
+	if groupBy == groupPR {
+		if entry.PRNumber > 0 {
+			return fmt.Sprintf("#%d", entry.PRNumber)
+		}
+		return noPRGroup
+	}
+	return entry.Timestamp.Format("2006-01")
+}
+
+// entryTitle returns the subject line of the backported commit.
+func entryTitle(entry backport.CacheEntry) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(entry.Message), "\n")
+	return title
+}
+
+// entrySummary describes a backport in one line, e.g. "Fix crash (#42): abc1234 → def5678 (v1.2.3)".
+func entrySummary(entry backport.CacheEntry) string {
+	var b strings.Builder
+	b.WriteString(entryTitle(entry))
+	if entry.PRNumber > 0 {
+		fmt.Fprintf(&b, " (#%d)", entry.PRNumber)
+	}
+	fmt.Fprintf(&b, ": %s → %s", safeTruncate(entry.OriginalSHA, shortSHALength), safeTruncate(entry.BackportSHA, shortSHALength))
+	if entry.Release != "" {
+		fmt.Fprintf(&b, " (%s)", entry.Release)
+	}
+	return b.String()
+}
+
+// shortSHALength is the length of commit SHAs in reports.
+const shortSHALength = 7
+
+// renderMarkdown writes the report as a Markdown document, e.g. for a changelog.
+func renderMarkdown(w io.Writer, report []branchReport) error {
+	var b strings.Builder
+	b.WriteString("# Backports\n")
+	for _, branch := range report {
+		fmt.Fprintf(&b, "\n## %s\n", branch.Branch)
+		for _, group := range branch.Groups {
+			fmt.Fprintf(&b, "\n### %s\n\n", group.Name)
+			for _, entry := range group.Entries {
+				fmt.Fprintf(&b, "- %s\n", entrySummary(entry))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderCSV writes the report as CSV with one row per backport.
+func renderCSV(w io.Writer, report []branchReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"target_branch", "group", "original_sha", "backport_sha", "pr", "release", "timestamp", "title"}); err != nil {
+		return err
+	}
+
+	for _, branch := range report {
+		for _, group := range branch.Groups {
+			for _, entry := range group.Entries {
+				pr := ""
+				if entry.PRNumber > 0 {
+					pr = strconv.Itoa(entry.PRNumber)
+				}
+				row := []string{
+					branch.Branch,
+					group.Name,
+					entry.OriginalSHA,
+					entry.BackportSHA,
+					pr,
+					entry.Release,
+					entry.Timestamp.Format(time.RFC3339),
+					entryTitle(entry),
+				}
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderHTML writes the report as a standalone HTML document, e.g. for audit records.
+func renderHTML(w io.Writer, report []branchReport) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Backports</title>\n</head>\n<body>\n<h1>Backports</h1>\n")
+	for _, branch := range report {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(branch.Branch))
+		for _, group := range branch.Groups {
+			fmt.Fprintf(&b, "<h3>%s</h3>\n<ul>\n", html.EscapeString(group.Name))
+			for _, entry := range group.Entries {
+				fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(entrySummary(entry)))
+			}
+			b.WriteString("</ul>\n")
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
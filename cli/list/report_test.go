@@ -0,0 +1,76 @@
+package list
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+)
+
+func reportEntries() []backport.CacheEntry {
+	may := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	return []backport.CacheEntry{
+		{OriginalSHA: "aaaaaaaaaa", BackportSHA: "bbbbbbbbbb", TargetBranch: "release-2.x", PRNumber: 12, Message: "Fix leak\n\nDetails", Timestamp: june},
+		{OriginalSHA: "cccccccccc", BackportSHA: "dddddddddd", TargetBranch: "release-1.x", PRNumber: 10, Message: "Fix crash", Release: "v1.2.3", Timestamp: june},
+		{OriginalSHA: "eeeeeeeeee", BackportSHA: "ffffffffff", TargetBranch: "release-1.x", Message: "Fix <typo>", Timestamp: may},
+		{OriginalSHA: "1111111111", BackportSHA: "2222222222", TargetBranch: "release-1.x", PRNumber: 9, Message: "Fix race", Timestamp: may},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	t.Run("by month", func(t *testing.T) {
+		report := buildReport(reportEntries(), groupMonth)
+		require.Len(t, report, 2)
+		assert.Equal(t, "release-1.x", report[0].Branch)
+		assert.Equal(t, "release-2.x", report[1].Branch)
+		require.Len(t, report[0].Groups, 2)
+		assert.Equal(t, "2024-05", report[0].Groups[0].Name)
+		assert.Len(t, report[0].Groups[0].Entries, 2)
+		assert.Equal(t, "2024-06", report[0].Groups[1].Name)
+	})
+
+	t.Run("by PR", func(t *testing.T) {
+		report := buildReport(reportEntries(), groupPR)
+		require.Len(t, report[0].Groups, 3)
+		assert.Equal(t, "#9", report[0].Groups[0].Name)
+		assert.Equal(t, "#10", report[0].Groups[1].Name)
+		assert.Equal(t, noPRGroup, report[0].Groups[2].Name)
+	})
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderMarkdown(&buf, buildReport(reportEntries(), groupMonth)))
+
+	out := buf.String()
+	assert.Contains(t, out, "## release-1.x\n\n### 2024-05\n\n- Fix <typo>: eeeeeee → fffffff\n- Fix race (#9): 1111111 → 2222222\n")
+	assert.Contains(t, out, "- Fix crash (#10): ccccccc → ddddddd (v1.2.3)\n")
+	assert.Contains(t, out, "## release-2.x\n\n### 2024-06\n\n- Fix leak (#12): aaaaaaa → bbbbbbb\n")
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderCSV(&buf, buildReport(reportEntries(), groupPR)))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, "target_branch", rows[0][0])
+	assert.Equal(t, []string{"release-1.x", "#9", "1111111111", "2222222222", "9", "", "2024-05-10T12:00:00Z", "Fix race"}, rows[1])
+	assert.Equal(t, []string{"release-1.x", noPRGroup, "eeeeeeeeee", "ffffffffff", "", "", "2024-05-10T12:00:00Z", "Fix <typo>"}, rows[3])
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, renderHTML(&buf, buildReport(reportEntries(), groupMonth)))
+
+	out := buf.String()
+	assert.Contains(t, out, "<h2>release-1.x</h2>\n<h3>2024-05</h3>\n<ul>\n<li>Fix &lt;typo&gt;: eeeeeee → fffffff</li>\n")
+	assert.NotContains(t, out, "<typo>")
+}
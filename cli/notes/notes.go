@@ -0,0 +1,86 @@
+// Package notes provides commands for storing PR metadata in git notes.
+package notes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Command is the notes command.
+var Command = &cli.Command{
+	Name:  "notes",
+	Usage: "store PR metadata in git notes, for backporting PRs without access to the forge",
+	Commands: []*cli.Command{
+		recordCmd,
+	},
+}
+
+var recordCmd = &cli.Command{
+	Name:      "record",
+	Usage:     "store the number, title and author of merged PRs in notes on their merge commits (" + backport.NotesRef + ")",
+	ArgsUsage: "<pr-number>...",
+	Action:    recordNotes,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "push",
+			Usage: "push the notes to the remote afterwards",
+		},
+	},
+}
+
+func recordNotes(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() < 1 {
+		return fmt.Errorf("usage: notes record <pr-number>...")
+	}
+
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	recorded := 0
+	for _, arg := range c.Args().Slice() {
+		prNumberStr := strings.TrimPrefix(strings.TrimPrefix(arg, internal.PRPrefix), "#")
+		prNumber, err := strconv.Atoi(prNumberStr)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid PR number: %s", prNumberStr)
+			log.Error().Err(lastErr).Send()
+			continue
+		}
+
+		pr, err := service.GetPR(ctx, prNumber)
+		if err == nil && !pr.Merged {
+			err = fmt.Errorf("PR #%d is not merged", prNumber)
+		}
+		if err == nil {
+			err = backport.WritePRNote(pr)
+		}
+		if err != nil {
+			log.Error().Err(err).Int("pr", prNumber).Msg("failed to record PR")
+			lastErr = err
+			continue
+		}
+
+		recorded++
+		fmt.Printf("✓ Recorded PR #%d on %s\n", prNumber, pr.MergeCommit)
+	}
+
+	if recorded > 0 && c.Bool("push") {
+		if err := git.PushNotes(service.Remote(), backport.NotesRef); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Pushed %s to %s\n", backport.NotesRef, service.Remote())
+	}
+
+	return lastErr
+}
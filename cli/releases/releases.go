@@ -0,0 +1,56 @@
+// Package releases provides commands for associating backports with releases.
+package releases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/cli/internal"
+)
+
+const shaTruncateLength = 12
+
+// Command is the releases command.
+var Command = &cli.Command{
+	Name:  "releases",
+	Usage: "associate cached backports with releases",
+	Commands: []*cli.Command{
+		attachCmd,
+	},
+}
+
+var attachCmd = &cli.Command{
+	Name:   "attach",
+	Usage:  "record the first release tag containing each cached backport",
+	Action: attachReleases,
+}
+
+func attachReleases(ctx context.Context, c *cli.Command) error {
+	service, err := internal.CreateService(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	updated, err := service.AttachReleases()
+	if err != nil {
+		return err
+	}
+
+	if len(updated) == 0 {
+		fmt.Println("No new releases found for cached backports")
+		return nil
+	}
+
+	for _, entry := range updated {
+		sha := entry.BackportSHA
+		if len(sha) > shaTruncateLength {
+			sha = sha[:shaTruncateLength]
+		}
+		fmt.Printf("✓ %s on %s shipped in %s\n", sha, entry.TargetBranch, entry.Release)
+	}
+	fmt.Printf("\nAttached releases to %d backports\n", len(updated))
+
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package schema provides the command printing the JSON Schemas of backporter's files and output.
+package schema
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/schema"
+)
+
+// Command is the schema command.
+var Command = &cli.Command{
+	Name:      "schema",
+	Usage:     fmt.Sprintf("print the JSON Schema (version %d) of a file or JSON output of backporter, or list the schemas", schema.Version),
+	ArgsUsage: "[name]",
+	Action:    printSchema,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "validate",
+			Usage: "check that a JSON file matches the schema instead of printing it",
+		},
+	},
+}
+
+func printSchema(_ context.Context, c *cli.Command) error {
+	name := c.Args().First()
+	if name == "" {
+		for _, name := range schema.Names() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	if path := c.String("validate"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := schema.Validate(name, data); err != nil {
+			return fmt.Errorf("%s %w", path, err)
+		}
+		fmt.Printf("✓ %s matches the %s schema\n", path, name)
+		return nil
+	}
+
+	data, err := schema.Get(name)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package selfupdate provides the self-update command.
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"codefloe.com/pat-s/backporter/pkg/selfupdate"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// Command is the self-update command.
+var Command = &cli.Command{
+	Name:   "self-update",
+	Usage:  "update backporter to the latest release",
+	Action: selfUpdate,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "check",
+			Usage: "only report whether a newer release is available",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "install the latest release even if it is not newer, e.g. over a development build",
+		},
+	},
+}
+
+func selfUpdate(ctx context.Context, c *cli.Command) error {
+	updater := selfupdate.New()
+
+	release, err := updater.Latest(ctx)
+	if err != nil {
+		return err
+	}
+
+	newer, cmpErr := selfupdate.IsNewer(release.Version, version.Version)
+	if cmpErr != nil {
+		log.Debug().Err(cmpErr).Msg("cannot compare versions")
+	}
+
+	switch {
+	case cmpErr != nil:
+		fmt.Printf("Latest release is %s, the installed version %s cannot be compared\n", release.Version, version.Version)
+	case newer:
+		fmt.Printf("A newer release is available: %s (installed: %s)\n", release.Version, version.Version)
+	default:
+		fmt.Printf("backporter %s is up to date\n", version.Version)
+	}
+
+	if c.Bool("check") {
+		return nil
+	}
+	if !newer && !c.Bool("force") {
+		if cmpErr != nil {
+			fmt.Println("Use --force to install it anyway.")
+		}
+		return nil
+	}
+
+	target, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	log.Debug().Str("path", target).Str("version", release.Version).Msg("installing release")
+	if err := updater.Install(ctx, release, runtime.GOOS, runtime.GOARCH, target); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Updated %s to %s\n", target, release.Version)
+
+	return nil
+}
@@ -2,12 +2,14 @@
 package setup
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/charmbracelet/huh"
 	"github.com/rs/zerolog/log"
 
+	cliconfig "codefloe.com/pat-s/backporter/cli/internal/config"
 	"codefloe.com/pat-s/backporter/pkg/config"
 )
 
@@ -39,13 +41,25 @@ func PromptForConfigCreation() error {
 func CreateConfigInteractive() error {
 	cfg := config.DefaultConfig()
 
-	// Select forge type.
+	// Preselect the forge detected from the remote.
 	var forgeType string
-	err := huh.NewSelect[string]().
-		Title("Select your forge type:").
+	title := "Select your forge type:"
+	detected, err := cliconfig.DetectForge(context.Background(), cfg.Remote)
+	if err != nil {
+		fmt.Printf("Could not detect the forge of the %s remote: %v\n", cfg.Remote, err)
+	} else if detected != nil {
+		forgeType = detected.Type
+		title = fmt.Sprintf("Select your forge type (detected %s from the %s remote):", detected.Type, cfg.Remote)
+	}
+
+	// Select forge type.
+	err = huh.NewSelect[string]().
+		Title(title).
 		Options(
 			huh.NewOption("GitHub", "github"),
 			huh.NewOption("Forgejo/Gitea", "forgejo"),
+			huh.NewOption("Bitbucket Cloud", "bitbucket"),
+			huh.NewOption("Gerrit", "gerrit"),
 			huh.NewOption("None (skip)", ""),
 		).
 		Value(&forgeType).
@@ -56,10 +70,16 @@ func CreateConfigInteractive() error {
 
 	cfg.ForgeType = forgeType
 
+	// Prefill the server URL of a detected self-hosted forge.
+	var forgeURL string
+	if detected != nil && detected.Type == forgeType {
+		forgeURL = detected.URL
+	}
+
 	switch forgeType {
 	case "forgejo":
 		// Query for Forgejo URL.
-		var forgejoURL string
+		forgejoURL := forgeURL
 		err = huh.NewInput().
 			Title("Forgejo instance URL (e.g., https://codeberg.org):").
 			Value(&forgejoURL).
@@ -86,6 +106,33 @@ func CreateConfigInteractive() error {
 		fmt.Println("\nRequired token scopes for GitHub:")
 		fmt.Println("  - repo (for private repositories)")
 		fmt.Println("  - public_repo (for public repositories only)")
+	case "bitbucket":
+		fmt.Println("\nNote: Set BITBUCKET_TOKEN environment variable:")
+		fmt.Println("  export BITBUCKET_TOKEN=<your-token>")
+		fmt.Println("\nRequired token scopes for Bitbucket Cloud:")
+		fmt.Println("  - pullrequest:write (to fetch and create PRs)")
+	case "gerrit":
+		// Query for Gerrit URL.
+		gerritURL := forgeURL
+		err = huh.NewInput().
+			Title("Gerrit server URL (e.g., https://review.example.com):").
+			Value(&gerritURL).
+			Validate(func(s string) error {
+				if s == "" {
+					return fmt.Errorf("URL is required for Gerrit")
+				}
+				return nil
+			}).
+			Run()
+		if err != nil {
+			return err
+		}
+
+		cfg.GerritURL = gerritURL
+
+		fmt.Println("\nNote: Set GERRIT_TOKEN environment variable to your username and HTTP password:")
+		fmt.Println("  export GERRIT_TOKEN=<username>:<http-password>")
+		fmt.Println("\nThe HTTP password is generated in the Gerrit settings.")
 	}
 
 	// Select default branch.
@@ -132,6 +179,36 @@ func CreateConfigInteractive() error {
 
 	cfg.Cache.Enabled = enableCache
 
+	// Opt in to anonymous usage statistics? Off unless explicitly confirmed.
+	var enableTelemetry bool
+	err = huh.NewConfirm().
+		Title("Share anonymous usage statistics?").
+		Description("Only the command, forge type, outcome and duration are reported, never repository or user data.").
+		Affirmative("Yes").
+		Negative("No").
+		Value(&enableTelemetry).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	if enableTelemetry {
+		var endpoint string
+		err = huh.NewInput().
+			Title("Usage statistics endpoint:").
+			Value(&endpoint).
+			Placeholder("https://").
+			Validate(func(s string) error {
+				probe := config.TelemetryConfig{Enabled: true, Endpoint: s}
+				return (&config.Config{Telemetry: probe}).Validate()
+			}).
+			Run()
+		if err != nil {
+			return err
+		}
+		cfg.Telemetry = config.TelemetryConfig{Enabled: true, Endpoint: endpoint}
+	}
+
 	// Select config file location.
 	var configLocation string
 	err = huh.NewSelect[string]().
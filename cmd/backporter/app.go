@@ -3,9 +3,18 @@ package main
 import (
 	"github.com/urfave/cli/v3"
 
+	"codefloe.com/pat-s/backporter/cli/audit"
+	"codefloe.com/pat-s/backporter/cli/auth"
 	"codefloe.com/pat-s/backporter/cli/backport"
 	"codefloe.com/pat-s/backporter/cli/common"
+	"codefloe.com/pat-s/backporter/cli/complete"
+	"codefloe.com/pat-s/backporter/cli/config"
+	"codefloe.com/pat-s/backporter/cli/graph"
 	"codefloe.com/pat-s/backporter/cli/list"
+	"codefloe.com/pat-s/backporter/cli/notes"
+	"codefloe.com/pat-s/backporter/cli/releases"
+	"codefloe.com/pat-s/backporter/cli/schema"
+	"codefloe.com/pat-s/backporter/cli/selfupdate"
 	"codefloe.com/pat-s/backporter/shared/version"
 )
 
@@ -17,10 +26,25 @@ func newApp() *cli.Command {
 	app.Usage = "backport commits and PRs to target branches"
 	app.Flags = common.GlobalFlags
 	app.Before = common.Before
+	app.After = common.After
 	app.Suggest = true
+	app.EnableShellCompletion = true
 	app.Commands = []*cli.Command{
 		backport.Command,
 		list.Command,
+		audit.Command,
+		releases.Command,
+		graph.Command,
+		backport.BadgeCommand,
+		backport.ReportCommand,
+		backport.SandboxCommand,
+		backport.ImportCommand,
+		config.Command,
+		auth.Command,
+		notes.Command,
+		schema.Command,
+		complete.Command,
+		selfupdate.Command,
 	}
 
 	// Default action when called without subcommand (interactive mode).
@@ -8,6 +8,8 @@ import (
 	"syscall"
 
 	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/cli/common"
 )
 
 func main() {
@@ -23,9 +25,13 @@ func main() {
 	}()
 
 	app := newApp()
-	if err := app.Run(ctx, os.Args); err != nil {
-		cancel()
-		log.Fatal().Err(err).Msg("error running backporter")
-	}
+	args := common.ExpandAliases(app, os.Args)
+	err := app.Run(ctx, args)
+	common.ReportUsage(ctx, app, args, err)
 	cancel()
+	if err != nil {
+		log.Error().Err(err).Msg("error running backporter")
+		common.PrintSuggestion(os.Stderr, err)
+		os.Exit(common.ExitCode(err))
+	}
 }
@@ -0,0 +1,260 @@
+package backport
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Results of audited backport attempts.
+const (
+	AuditSuccess   = "success"    // Backported, or backport PR created in CI mode
+	AuditEmpty     = "empty"      // The changes were already on the target branch
+	AuditConflict  = "conflict"   // Stopped on cherry-pick conflicts
+	AuditFailed    = "failed"     // Failed for another reason
+	AuditSkipped   = "skipped"    // A backport PR already existed
+	AuditDeferred  = "deferred"   // Queued because it ran during quiet hours
+	AuditPRCreated = "pr_created" // A backport PR was opened for a local backport
+)
+
+// AuditEntry is a backport attempt in the audit log. Hash covers all other fields including
+// PrevHash, the hash of the previous entry, so edited, removed or reordered entries are detected.
+type AuditEntry struct {
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"`
+	Repo         string    `json:"repo,omitempty"` // owner/repo
+	SourceSHA    string    `json:"source_sha,omitempty"`
+	SourcePR     int       `json:"source_pr,omitempty"`
+	TargetBranch string    `json:"target_branch"`
+	Result       string    `json:"result"` // See the Audit result constants
+	BackportSHA  string    `json:"backport_sha,omitempty"`
+	BackportPR   int       `json:"backport_pr,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	PrevHash     string    `json:"prev_hash,omitempty"`
+	Hash         string    `json:"hash"`
+}
+
+// hash returns the hash of the entry, computed over its JSON encoding without the hash.
+func (e AuditEntry) hash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog appends backport attempts to an append-only JSON Lines file. It is kept apart from
+// the backport history and caches, which are rewritten and may be cleared.
+type AuditLog struct {
+	path  string
+	actor string
+	mu    sync.Mutex
+}
+
+// DefaultAuditPath returns the default path of the audit log, empty if no home directory is found.
+func DefaultAuditPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "backporter", "audit.jsonl")
+}
+
+// NewAuditLog returns the audit log at path, or at DefaultAuditPath if path is empty.
+// Entries without an actor are recorded with actor.
+func NewAuditLog(path, actor string) *AuditLog {
+	if path == "" {
+		path = DefaultAuditPath()
+	} else if abs, err := filepath.Abs(path); err == nil {
+		// Backports may run from a temporary worktree.
+		path = abs
+	}
+	return &AuditLog{path: path, actor: actor}
+}
+
+// Path returns the path of the audit log.
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Record appends an entry, chained to the last entry of the log. The time and actor are
+// filled in if unset. A nil log records nothing.
+func (l *AuditLog) Record(entry AuditEntry) error {
+	if l == nil || l.path == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	// UTC without monotonic clock, so the entry encodes the same after reading it back.
+	entry.Time = entry.Time.UTC().Round(0)
+	if entry.Actor == "" {
+		entry.Actor = l.actor
+	}
+
+	entries, err := ReadAuditLog(l.path)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		entry.PrevHash = entries[len(entries)-1].Hash
+	}
+	entry.Hash = entry.hash()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadAuditLog reads the entries of the audit log at path, oldest first. A missing log has none.
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit log %s: line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// AuditTamperedError is returned by VerifyAuditLog for an entry that does not match its hash
+// or does not follow the entry before it.
+type AuditTamperedError struct {
+	Index int // Index of the first entry failing verification
+}
+
+// Error implements error.
+func (e *AuditTamperedError) Error() string {
+	return fmt.Sprintf("audit log was modified at entry %d", e.Index+1)
+}
+
+// VerifyAuditLog checks the hash chain of the entries of an audit log.
+func VerifyAuditLog(entries []AuditEntry) error {
+	prev := ""
+	for i, entry := range entries {
+		if entry.PrevHash != prev || entry.Hash != entry.hash() {
+			return &AuditTamperedError{Index: i}
+		}
+		prev = entry.Hash
+	}
+	return nil
+}
+
+// AuditFilter selects entries of the audit log. Zero fields match everything.
+type AuditFilter struct {
+	Repo   string    // owner/repo
+	Branch string    // Target branch
+	PR     int       // Source PR number
+	SHA    string    // Prefix of the source or backport commit SHA
+	Result string    // See the Audit result constants
+	Actor  string    // Actor
+	Since  time.Time // Attempted at or after
+	Until  time.Time // Attempted before
+}
+
+// matches reports whether an entry is selected by the filter.
+func (f AuditFilter) matches(entry AuditEntry) bool {
+	switch {
+	case f.Repo != "" && entry.Repo != f.Repo,
+		f.Branch != "" && entry.TargetBranch != f.Branch,
+		f.PR != 0 && entry.SourcePR != f.PR,
+		f.SHA != "" && !strings.HasPrefix(entry.SourceSHA, f.SHA) && !strings.HasPrefix(entry.BackportSHA, f.SHA),
+		f.Result != "" && entry.Result != f.Result,
+		f.Actor != "" && entry.Actor != f.Actor,
+		!f.Since.IsZero() && entry.Time.Before(f.Since),
+		!f.Until.IsZero() && !entry.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// FilterAudit returns the entries selected by the filter, in their original order.
+func FilterAudit(entries []AuditEntry, filter AuditFilter) []AuditEntry {
+	var result []AuditEntry
+	for _, entry := range entries {
+		if filter.matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// auditActorEnvVars are the variables naming the user that triggered a CI job, per CI system:
+// GitHub, Forgejo and Gitea Actions, Woodpecker and Crow CI and Bitbucket Pipelines.
+var auditActorEnvVars = []string{"GITHUB_ACTOR", "CI_PIPELINE_AUTHOR", "CI_COMMIT_AUTHOR", "BITBUCKET_STEP_TRIGGERER_UUID"}
+
+// AuditActor returns who runs a backport: the user that triggered the CI job, or else the git user.
+func AuditActor() string {
+	for _, name := range auditActorEnvVars {
+		if actor := os.Getenv(name); actor != "" {
+			return actor
+		}
+	}
+	name, email := git.GetConfigValue("user.name"), git.GetConfigValue("user.email")
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case email != "":
+		return email
+	default:
+		return name
+	}
+}
+
+// AuditResult returns the audit result of a local backport.
+func AuditResult(result *BackportResult, err error) string {
+	switch {
+	case err != nil:
+		return AuditFailed
+	case result.HasConflict:
+		return AuditConflict
+	case result.Empty:
+		return AuditEmpty
+	case result.Success:
+		return AuditSuccess
+	default:
+		return AuditFailed
+	}
+}
@@ -0,0 +1,100 @@
+package backport
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	auditLog := NewAuditLog(path, "alice")
+
+	entries, err := ReadAuditLog(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, auditLog.Record(AuditEntry{Repo: "owner/repo", SourcePR: 42, TargetBranch: "release-1.x", Result: AuditSuccess}))
+	require.NoError(t, auditLog.Record(AuditEntry{Repo: "owner/repo", SourcePR: 43, TargetBranch: "release-1.x", Result: AuditConflict}))
+	require.NoError(t, NewAuditLog(path, "bob").Record(AuditEntry{Repo: "owner/repo", SourcePR: 42, TargetBranch: "release-2.x", Result: AuditSuccess}))
+
+	entries, err = ReadAuditLog(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, "bob", entries[2].Actor)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.False(t, entries[0].Time.IsZero())
+	require.NoError(t, VerifyAuditLog(entries))
+
+	assert.Len(t, FilterAudit(entries, AuditFilter{PR: 42}), 2)
+	assert.Len(t, FilterAudit(entries, AuditFilter{Branch: "release-1.x", Result: AuditConflict}), 1)
+	assert.Len(t, FilterAudit(entries, AuditFilter{Actor: "bob"}), 1)
+	assert.Empty(t, FilterAudit(entries, AuditFilter{Since: time.Now().Add(time.Hour)}))
+	assert.Nil(t, (*AuditLog)(nil).Record(AuditEntry{}))
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog := NewAuditLog(path, "alice")
+	for _, pr := range []int{1, 2, 3} {
+		require.NoError(t, auditLog.Record(AuditEntry{SourcePR: pr, TargetBranch: "release-1.x", Result: AuditSuccess}))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		edit  func(lines []string) []string
+		index int
+	}{
+		{
+			name: "edited entry",
+			edit: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"result":"success"`, `"result":"failed"`, 1)
+				return lines
+			},
+			index: 1,
+		},
+		{
+			name:  "removed entry",
+			edit:  func(lines []string) []string { return append(lines[:1], lines[2:]...) },
+			index: 1,
+		},
+		{
+			name:  "reordered entries",
+			edit:  func(lines []string) []string { return []string{lines[1], lines[0], lines[2]} },
+			index: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.edit(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			tampered := filepath.Join(t.TempDir(), "audit.jsonl")
+			require.NoError(t, os.WriteFile(tampered, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+			entries, err := ReadAuditLog(tampered)
+			require.NoError(t, err)
+
+			var tamperedErr *AuditTamperedError
+			require.True(t, errors.As(VerifyAuditLog(entries), &tamperedErr))
+			assert.Equal(t, tt.index, tamperedErr.Index)
+		})
+	}
+}
+
+func TestAuditResult(t *testing.T) {
+	assert.Equal(t, AuditFailed, AuditResult(nil, errors.New("boom")))
+	assert.Equal(t, AuditConflict, AuditResult(&BackportResult{HasConflict: true}, nil))
+	assert.Equal(t, AuditEmpty, AuditResult(&BackportResult{Success: true, Empty: true}, nil))
+	assert.Equal(t, AuditSuccess, AuditResult(&BackportResult{Success: true}, nil))
+}
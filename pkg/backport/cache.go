@@ -7,6 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/fileutil"
+	"codefloe.com/pat-s/backporter/pkg/schema"
 )
 
 // CacheEntry represents a cached backport operation.
@@ -17,29 +22,72 @@ type CacheEntry struct {
 	PRNumber     int       `json:"pr_number,omitempty"`
 	Timestamp    time.Time `json:"timestamp"`
 	Message      string    `json:"message"`
+	Release      string    `json:"release,omitempty"` // First release tag containing the backport
 }
 
 // Cache manages the local cache of backported commits/PRs.
 type Cache struct {
 	path    string
 	entries []CacheEntry
+
+	// extra holds the entries of read-only history files, e.g. a ledger shared in the repository.
+	// They are merged into lookups but never written.
+	extra []CacheEntry
 }
 
-// NewCache creates a new cache instance.
-func NewCache(path string) *Cache {
+// NewCache creates a new cache instance. The entries of extraPaths are merged into lookups,
+// while writes only go to the cache at path.
+func NewCache(path string, extraPaths ...string) *Cache {
 	if path == "" {
 		home, err := os.UserHomeDir()
 		if err == nil {
 			path = filepath.Join(home, ".cache", "backporter", "history.json")
 		}
+	} else if abs, err := filepath.Abs(path); err == nil {
+		// Backports may run from a temporary worktree.
+		path = abs
 	}
 
 	cache := &Cache{path: path}
-	_ = cache.load()
+	if err := cache.load(); err != nil {
+		// Do not overwrite a history that cannot be read.
+		log.Warn().Err(err).Str("path", path).Msg("failed to load backport history, it is not updated")
+		cache.path = ""
+	}
+
+	for _, extraPath := range extraPaths {
+		entries, err := loadEntries(extraPath)
+		if err != nil {
+			log.Warn().Err(err).Str("path", extraPath).Msg("failed to load extra history file")
+			continue
+		}
+		cache.extra = append(cache.extra, entries...)
+	}
 
 	return cache
 }
 
+// loadEntries reads the entries of a read-only history file, a missing file has none.
+func loadEntries(path string) ([]CacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := schema.Validate(schema.History, data); err != nil {
+		return nil, fmt.Errorf("invalid history file %s: %w", path, err)
+	}
+
+	var entries []CacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return entries, nil
+}
+
 // load loads the cache from disk.
 func (c *Cache) load() error {
 	if c.path == "" {
@@ -55,43 +103,107 @@ func (c *Cache) load() error {
 		return err
 	}
 
+	if err := schema.Validate(schema.History, data); err != nil {
+		return fmt.Errorf("invalid history file %s: %w", c.path, err)
+	}
 	return json.Unmarshal(data, &c.entries)
 }
 
 // save saves the cache to disk.
 func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written to a temporary file that is renamed, so an interrupted backport cannot leave a truncated history.
+	return fileutil.WriteAtomic(c.path, data, 0o644)
+}
+
+// update applies change to the entries while holding the lock of the history file. The entries
+// are reloaded first, so concurrent backporter processes do not lose each other's entries.
+func (c *Cache) update(change func()) error {
 	if c.path == "" {
+		change()
 		return nil
 	}
 
-	dir := filepath.Dir(c.path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
 		return fmt.Errorf("failed to create cache directory: %w", err)
 	}
-
-	data, err := json.MarshalIndent(c.entries, "", "  ")
+	unlock, err := fileutil.Lock(c.path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	return os.WriteFile(c.path, data, 0o644)
+	if err := c.load(); err != nil {
+		return err
+	}
+	change()
+	return c.save()
 }
 
 // Add adds a new entry to the cache.
 func (c *Cache) Add(entry CacheEntry) error {
-	c.entries = append(c.entries, entry)
-	return c.save()
+	return c.update(func() {
+		c.entries = append(c.entries, entry)
+	})
 }
 
-// List returns all cache entries.
+// Import adds the entries that are not in the cache yet, including the extra history files.
+// Returns the added entries.
+func (c *Cache) Import(entries []CacheEntry) ([]CacheEntry, error) {
+	var added []CacheEntry
+	err := c.update(func() {
+		known := make(map[string]bool)
+		for _, entry := range c.List() {
+			known[entryKey(entry)] = true
+		}
+
+		for _, entry := range entries {
+			if known[entryKey(entry)] {
+				continue
+			}
+			known[entryKey(entry)] = true
+			added = append(added, entry)
+		}
+		c.entries = append(c.entries, added...)
+	})
+	return added, err
+}
+
+// List returns all cache entries, the entries of the extra history files first.
+// Extra entries that are also in the local cache are only listed once.
 func (c *Cache) List() []CacheEntry {
-	return c.entries
+	if len(c.extra) == 0 {
+		return c.entries
+	}
+
+	local := make(map[string]bool, len(c.entries))
+	for _, entry := range c.entries {
+		local[entryKey(entry)] = true
+	}
+
+	var result []CacheEntry
+	for _, entry := range c.extra {
+		if !local[entryKey(entry)] {
+			local[entryKey(entry)] = true
+			result = append(result, entry)
+		}
+	}
+	return append(result, c.entries...)
+}
+
+// entryKey identifies the backport of a commit to a target branch.
+func entryKey(entry CacheEntry) string {
+	return entry.OriginalSHA + " " + entry.BackportSHA + " " + entry.TargetBranch
 }
 
 // FindByOriginalSHA finds entries by original SHA.
 func (c *Cache) FindByOriginalSHA(sha string) []CacheEntry {
 	var result []CacheEntry
-	for _, entry := range c.entries {
+	for _, entry := range c.List() {
 		if entry.OriginalSHA == sha {
 			result = append(result, entry)
 		}
@@ -102,7 +214,7 @@ func (c *Cache) FindByOriginalSHA(sha string) []CacheEntry {
 // FindByPRNumber finds entries by PR number.
 func (c *Cache) FindByPRNumber(number int) []CacheEntry {
 	var result []CacheEntry
-	for _, entry := range c.entries {
+	for _, entry := range c.List() {
 		if entry.PRNumber == number {
 			result = append(result, entry)
 		}
@@ -110,8 +222,22 @@ func (c *Cache) FindByPRNumber(number int) []CacheEntry {
 	return result
 }
 
-// Clear clears all cache entries.
+// Remove removes the entries of a backport commit on a target branch from the local cache.
+func (c *Cache) Remove(entry CacheEntry) error {
+	return c.update(func() {
+		kept := c.entries[:0]
+		for _, e := range c.entries {
+			if e.BackportSHA != entry.BackportSHA || e.TargetBranch != entry.TargetBranch {
+				kept = append(kept, e)
+			}
+		}
+		c.entries = kept
+	})
+}
+
+// Clear clears all entries of the local cache.
 func (c *Cache) Clear() error {
-	c.entries = []CacheEntry{}
-	return c.save()
+	return c.update(func() {
+		c.entries = []CacheEntry{}
+	})
 }
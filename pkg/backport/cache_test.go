@@ -1,7 +1,9 @@
 package backport
 
 import (
+	"fmt"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -159,6 +161,43 @@ func TestCacheClear(t *testing.T) {
 	assert.Empty(t, cache.List())
 }
 
+func TestCacheRemove(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache.json"))
+	require.NoError(t, cache.Add(CacheEntry{BackportSHA: "a", TargetBranch: "release-1"}))
+	require.NoError(t, cache.Add(CacheEntry{BackportSHA: "b", TargetBranch: "release-1"}))
+
+	require.NoError(t, cache.Remove(CacheEntry{BackportSHA: "a", TargetBranch: "release-1"}))
+
+	entries := cache.List()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "b", entries[0].BackportSHA)
+}
+
+func TestCacheExtraPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	sharedPath := filepath.Join(tmpDir, "shared.json")
+	shared := NewCache(sharedPath)
+	require.NoError(t, shared.Add(CacheEntry{OriginalSHA: "a", BackportSHA: "a1", TargetBranch: "release-1", PRNumber: 1}))
+	require.NoError(t, shared.Add(CacheEntry{OriginalSHA: "b", BackportSHA: "b1", TargetBranch: "release-1"}))
+
+	localPath := filepath.Join(tmpDir, "local.json")
+	cache := NewCache(localPath, sharedPath, filepath.Join(tmpDir, "missing.json"))
+	require.NoError(t, cache.Add(CacheEntry{OriginalSHA: "b", BackportSHA: "b1", TargetBranch: "release-1"}))
+	require.NoError(t, cache.Add(CacheEntry{OriginalSHA: "c", BackportSHA: "c1", TargetBranch: "release-1"}))
+
+	entries := cache.List()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "a", entries[0].OriginalSHA)
+	assert.Len(t, cache.FindByOriginalSHA("a"), 1)
+	assert.Len(t, cache.FindByPRNumber(1), 1)
+
+	// Writes only go to the local cache.
+	require.NoError(t, cache.Clear())
+	assert.Len(t, cache.List(), 2)
+	assert.Empty(t, NewCache(localPath).List())
+	assert.Len(t, NewCache(sharedPath).List(), 2)
+}
+
 func TestCachePersistence(t *testing.T) {
 	tmpDir := t.TempDir()
 	cachePath := filepath.Join(tmpDir, "cache.json")
@@ -187,6 +226,34 @@ func TestCachePersistence(t *testing.T) {
 	assert.Equal(t, 42, entries[0].PRNumber)
 }
 
+func TestCacheConcurrent(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	// Each process loaded the history before the others wrote to it.
+	caches := make([]*Cache, 8)
+	for i := range caches {
+		caches[i] = NewCache(cachePath)
+	}
+
+	var wg sync.WaitGroup
+	for i, cache := range caches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cache.Add(CacheEntry{
+				OriginalSHA:  fmt.Sprintf("sha-%d", i),
+				BackportSHA:  fmt.Sprintf("backport-%d", i),
+				TargetBranch: "release-1.0",
+				Timestamp:    time.Now(),
+			}))
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, NewCache(cachePath).List(), len(caches))
+	assert.NoFileExists(t, cachePath+".lock")
+}
+
 func TestCacheEmptyPath(t *testing.T) {
 	// Use a temp directory to avoid loading default cache.
 	tmpDir := t.TempDir()
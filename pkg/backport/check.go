@@ -0,0 +1,166 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Predicted outcomes of backporting a change to a target branch.
+const (
+	CheckClean    = "clean"    // All commits apply without conflicts
+	CheckConflict = "conflict" // A commit conflicts with the target branch
+	CheckEmpty    = "empty"    // The changes are already on the target branch
+	CheckError    = "error"    // The change could not be tried, e.g. the target branch is missing
+)
+
+// CheckResult is the predicted outcome of backporting a change to a target branch.
+type CheckResult struct {
+	TargetBranch string   `json:"target_branch"`
+	Outcome      string   `json:"outcome"`                // See the Check outcome constants
+	ConflictSHA  string   `json:"conflict_sha,omitempty"` // The first commit that conflicts
+	Conflicts    []string `json:"conflicts,omitempty"`    // Files conflicting in ConflictSHA
+	Error        string   `json:"error,omitempty"`
+}
+
+// CheckCommit predicts whether a commit applies cleanly to each target branch.
+// See checkCommits.
+func (s *Service) CheckCommit(_ context.Context, sha string, targetBranches []string) ([]CheckResult, error) {
+	if _, err := git.EnsureCommit(s.config.Remote, sha); err != nil {
+		log.Debug().Err(err).Str("sha", sha).Msg("commit not available locally")
+	}
+	fullSHA, err := s.repo.GetCommitSHA(sha)
+	if err != nil {
+		return nil, fmt.Errorf("commit not found: %w", err)
+	}
+
+	return s.checkCommits([]string{fullSHA}, git.CherryPickOptions{}, targetBranches), nil
+}
+
+// CheckPR predicts whether a PR applies cleanly to each target branch, trying the commits
+// the strategy would backport. See checkCommits.
+func (s *Service) CheckPR(ctx context.Context, prNumber int, strategy string, targetBranches []string) ([]CheckResult, error) {
+	if err := ValidateStrategy(strategy); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.GetPR(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git.EnsureCommit(s.config.Remote, pr.MergeCommit); err != nil {
+		log.Debug().Err(err).Str("sha", pr.MergeCommit).Msg("merge commit not available locally")
+	}
+
+	commits, opts, err := s.checkedPRCommits(ctx, pr, strategy)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkCommits(commits, opts, targetBranches), nil
+}
+
+// checkedPRCommits returns the commits of a PR the strategy backports and the options to pick them.
+func (s *Service) checkedPRCommits(ctx context.Context, pr *forge.PRInfo, strategy string) ([]string, git.CherryPickOptions, error) {
+	switch {
+	case pr.IsSquashMerge():
+		return []string{pr.MergeCommit}, git.CherryPickOptions{}, nil
+	case strategy == StrategyMainline:
+		return []string{pr.MergeCommit}, git.CherryPickOptions{Mainline: 1}, nil
+	case strategy == StrategySquash:
+		return nil, git.CherryPickOptions{}, fmt.Errorf("PR #%d was %w", pr.Number, ErrNotSquashed)
+	}
+
+	commits, err := s.prCommits(ctx, pr)
+	if err != nil {
+		return nil, git.CherryPickOptions{}, err
+	}
+	if len(commits) == 0 {
+		return nil, git.CherryPickOptions{}, fmt.Errorf("PR #%d has no commits to backport", pr.Number)
+	}
+	return commits, git.CherryPickOptions{}, nil
+}
+
+// checkCommits test-applies the commits in order to each target branch in a throwaway
+// worktree, stopping at the first conflict. No branches are created or changed.
+func (s *Service) checkCommits(commits []string, opts git.CherryPickOptions, targetBranches []string) []CheckResult {
+	// Commits that are already on the target are kept, so they don't fail the cherry-pick.
+	opts.KeepRedundantCommits = true
+	// Conflicts resolved before are predicted to be resolved again.
+	opts.Rerere = s.config.Rerere
+
+	results := make([]CheckResult, 0, len(targetBranches))
+	for _, targetBranch := range targetBranches {
+		result, err := s.checkCommitsOn(commits, opts, targetBranch)
+		if err != nil {
+			result = CheckResult{TargetBranch: targetBranch, Outcome: CheckError, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkCommitsOn test-applies the commits to a target branch, see checkCommits.
+func (s *Service) checkCommitsOn(commits []string, opts git.CherryPickOptions, targetBranch string) (CheckResult, error) {
+	result := CheckResult{TargetBranch: targetBranch}
+
+	targetRef := targetBranch
+	exists, err := s.repo.BranchExists(targetBranch)
+	if err != nil {
+		return result, fmt.Errorf("failed to check target branch: %w", err)
+	}
+	if !exists {
+		targetRef = s.PushRemote() + "/" + targetBranch
+		if _, err := s.repo.GetCommitSHA(targetRef); err != nil {
+			return result, &BranchNotFoundError{Branch: targetBranch, Remote: s.PushRemote()}
+		}
+	}
+
+	// Shallow clones may lack the history needed to cherry-pick onto the target.
+	deepened, err := git.EnsureMergeBase(s.config.Remote, commits[0], targetRef)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch history for shallow clone: %w", err)
+	}
+	logDeepen(deepened, s.config.Remote)
+
+	worktree, err := git.AddDetachedWorktree(targetRef)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err := worktree.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", worktree.Path).Msg("failed to remove worktree")
+		}
+	}()
+
+	opts.PathMappings = pathMappings(s.config.PathMappings, targetBranch)
+	for _, sha := range commits {
+		log.Debug().Str("sha", sha).Str("target", targetBranch).Msg("test-applying commit")
+		picked, err := worktree.CherryPick(sha, opts)
+		if err != nil {
+			return result, err
+		}
+		if picked.HasConflict {
+			if err := worktree.AbortCherryPick(); err != nil {
+				log.Debug().Err(err).Msg("failed to abort cherry-pick in worktree")
+			}
+			result.Outcome = CheckConflict
+			result.ConflictSHA = sha
+			result.Conflicts = picked.Conflicts
+			return result, nil
+		}
+	}
+
+	changed, err := worktree.ChangedSince(targetRef)
+	if err != nil {
+		return result, err
+	}
+	result.Outcome = CheckClean
+	if !changed {
+		result.Outcome = CheckEmpty
+	}
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package backport
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCommit(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+
+	run("branch", "contains-fix", "main")
+	run("checkout", "-q", "-b", "conflicting", "target")
+	require.NoError(t, os.WriteFile("fix.txt", []byte("other fix\n"), 0o644))
+	run("add", "fix.txt")
+	run("commit", "-q", "-m", "Other fix")
+	run("checkout", "-q", "main")
+
+	before, err := exec.Command("git", "for-each-ref", "--format=%(refname) %(objectname)").Output()
+	require.NoError(t, err)
+
+	results, err := service.CheckCommit(context.Background(), sha, []string{"target", "conflicting", "contains-fix", "missing"})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, CheckResult{TargetBranch: "target", Outcome: CheckClean}, results[0])
+	assert.Equal(t, CheckResult{TargetBranch: "conflicting", Outcome: CheckConflict, ConflictSHA: sha, Conflicts: []string{"fix.txt"}}, results[1])
+	assert.Equal(t, CheckResult{TargetBranch: "contains-fix", Outcome: CheckEmpty}, results[2])
+	assert.Equal(t, CheckError, results[3].Outcome)
+	assert.Contains(t, results[3].Error, "does not exist")
+
+	// No branch was changed and no worktree is left behind.
+	after, err := exec.Command("git", "for-each-ref", "--format=%(refname) %(objectname)").Output()
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+	worktrees, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(worktrees), "worktree "))
+}
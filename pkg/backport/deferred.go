@@ -0,0 +1,120 @@
+package backport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"codefloe.com/pat-s/backporter/pkg/schema"
+)
+
+// deferredFile is the name of the queue of deferred backports, stored next to the backport history.
+const deferredFile = "deferred.json"
+
+// DeferredBackport is a CI backport that was postponed because it ran during quiet hours.
+type DeferredBackport struct {
+	Owner          string    `json:"owner"`
+	Repo           string    `json:"repo"`
+	PRNumber       int       `json:"pr_number"`
+	TargetBranches []string  `json:"target_branches"`
+	DeferredAt     time.Time `json:"deferred_at"`
+}
+
+// DeferredQueue persists the backports deferred during quiet hours until a later run
+// processes them.
+type DeferredQueue struct {
+	path    string
+	entries []DeferredBackport
+}
+
+// NewDeferredQueue opens the queue next to the backport history at historyPath,
+// or in the default cache directory if historyPath is empty.
+func NewDeferredQueue(historyPath string) *DeferredQueue {
+	queue := &DeferredQueue{}
+	if dir := CacheDir(historyPath); dir != "" {
+		queue.path = filepath.Join(dir, deferredFile)
+	}
+	_ = queue.load()
+
+	return queue
+}
+
+// load loads the queue from disk.
+func (q *DeferredQueue) load() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := schema.Validate(schema.Deferred, data); err != nil {
+		return fmt.Errorf("invalid deferred backports %s: %w", q.path, err)
+	}
+	return json.Unmarshal(data, &q.entries)
+}
+
+// save saves the queue to disk.
+func (q *DeferredQueue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(q.path, data, 0o644)
+}
+
+// List returns the deferred backports, oldest first.
+func (q *DeferredQueue) List() []DeferredBackport {
+	return slices.Clone(q.entries)
+}
+
+// Add queues the backport of a PR to the target branches. Branches of a PR that is
+// already queued are added to its entry.
+func (q *DeferredQueue) Add(owner, repo string, prNumber int, targetBranches []string) error {
+	for i := range q.entries {
+		entry := &q.entries[i]
+		if entry.Owner != owner || entry.Repo != repo || entry.PRNumber != prNumber {
+			continue
+		}
+		for _, branch := range targetBranches {
+			if !slices.Contains(entry.TargetBranches, branch) {
+				entry.TargetBranches = append(entry.TargetBranches, branch)
+			}
+		}
+		return q.save()
+	}
+
+	q.entries = append(q.entries, DeferredBackport{
+		Owner:          owner,
+		Repo:           repo,
+		PRNumber:       prNumber,
+		TargetBranches: slices.Clone(targetBranches),
+		DeferredAt:     time.Now(),
+	})
+	return q.save()
+}
+
+// Remove drops the queued backport of a PR.
+func (q *DeferredQueue) Remove(owner, repo string, prNumber int) error {
+	q.entries = slices.DeleteFunc(q.entries, func(entry DeferredBackport) bool {
+		return entry.Owner == owner && entry.Repo == repo && entry.PRNumber == prNumber
+	})
+	return q.save()
+}
@@ -0,0 +1,33 @@
+package backport
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredQueue(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+
+	queue := NewDeferredQueue(historyPath)
+	assert.Empty(t, queue.List())
+
+	require.NoError(t, queue.Add("owner", "repo", 42, []string{"release-1.x"}))
+	require.NoError(t, queue.Add("owner", "repo", 43, []string{"release-1.x"}))
+	require.NoError(t, queue.Add("owner", "repo", 42, []string{"release-1.x", "release-2.x"}))
+	assert.FileExists(t, filepath.Join(filepath.Dir(historyPath), "deferred.json"))
+
+	// Reload from disk.
+	entries := NewDeferredQueue(historyPath).List()
+	require.Len(t, entries, 2)
+	assert.Equal(t, 42, entries[0].PRNumber)
+	assert.Equal(t, []string{"release-1.x", "release-2.x"}, entries[0].TargetBranches)
+	assert.False(t, entries[0].DeferredAt.IsZero())
+
+	require.NoError(t, queue.Remove("owner", "repo", 42))
+	entries = NewDeferredQueue(historyPath).List()
+	require.Len(t, entries, 1)
+	assert.Equal(t, 43, entries[0].PRNumber)
+}
@@ -0,0 +1,55 @@
+package backport
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// cherryPickTrailer is the line `git cherry-pick -x` appends to picked commits.
+const cherryPickTrailer = "(cherry picked from commit "
+
+// findDuplicate returns an earlier backport of a commit on the target and where it was found,
+// or an empty SHA if the commit was not backported yet. Cached backports only count while they
+// are still on the target, backports made elsewhere are found by their message.
+func (s *Service) findDuplicate(sha, targetBranch, targetRef string) (string, string) {
+	if s.cache != nil {
+		for _, entry := range s.cache.FindByOriginalSHA(sha) {
+			if entry.TargetBranch != targetBranch || entry.BackportSHA == "" {
+				continue
+			}
+			if onTarget, err := git.IsAncestor(entry.BackportSHA, targetRef); err == nil && onTarget {
+				return entry.BackportSHA, sourceCache
+			}
+		}
+	}
+
+	found, err := findSigned(targetRef, sha)
+	if err != nil {
+		log.Debug().Err(err).Str("branch", targetBranch).Msg("failed to scan for backport signatures")
+	}
+	if found != "" {
+		return found, sourceSignature
+	}
+
+	found, err = git.FindCommitByMessage(targetRef, cherryPickTrailer+sha+")")
+	if err != nil {
+		log.Debug().Err(err).Str("branch", targetBranch).Msg("failed to scan for cherry-pick trailers")
+	}
+	if found != "" {
+		return found, sourceTrailer
+	}
+
+	return "", ""
+}
+
+// findSigned returns the most recent commit on ref signed as a backport of sha, through its
+// Backported-from trailer or the free-text signature of older versions, or an empty SHA.
+func findSigned(ref, sha string) (string, error) {
+	found, err := git.FindCommitByTrailer(ref, version.TrailerBackportedFrom, sha)
+	if err != nil || found != "" {
+		return found, err
+	}
+	return git.FindCommitByMessage(ref, version.SignatureMarker(sha))
+}
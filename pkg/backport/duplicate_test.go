@@ -0,0 +1,63 @@
+package backport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+func TestBackportCommitRefusesDuplicate(t *testing.T) {
+	service, sha, _ := setupUndoRepo(t)
+
+	first, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	require.True(t, first.Success)
+
+	_, err = service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	var dup *AlreadyBackportedError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, first.BackportSHA, dup.BackportSHA)
+	assert.Equal(t, sourceCache, dup.Source)
+
+	// Without the history the backport is still found by its signature.
+	require.NoError(t, service.ClearCache())
+	_, err = service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, sourceSignature, dup.Source)
+
+	// --force only warns.
+	_, err = service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target", Force: true})
+	assert.NotErrorAs(t, err, &dup)
+}
+
+func TestBackportCommitRefusesCherryPickedCommit(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+
+	run("checkout", "-q", "target")
+	run("cherry-pick", "-x", sha)
+	run("checkout", "-q", "main")
+
+	_, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	var dup *AlreadyBackportedError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, sourceTrailer, dup.Source)
+}
+
+func TestBackportCommitRefusesLegacySignature(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+
+	// Backports of older versions carry a free-text signature instead of trailers.
+	run("checkout", "-q", "target")
+	run("cherry-pick", sha)
+	run("commit", "-q", "--amend", "-m", "Fix bug\n\n"+version.SignatureMessage(sha))
+	run("checkout", "-q", "main")
+
+	_, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	var dup *AlreadyBackportedError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, sourceSignature, dup.Source)
+}
@@ -0,0 +1,58 @@
+package backport
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUncommittedChanges is returned when a backport would switch branches in a dirty working copy.
+	ErrUncommittedChanges = errors.New("repository has uncommitted changes")
+
+	// ErrNotSquashed is returned when a PR merged via a merge commit is backported with the squash strategy.
+	ErrNotSquashed = errors.New("not squash merged")
+
+	// ErrBackportNotFound is returned when a backport to undo is not in the backport history.
+	ErrBackportNotFound = errors.New("backport not found in history")
+
+	// ErrConflict is returned when a backport stopped on cherry-pick conflicts.
+	ErrConflict = errors.New("cherry-pick conflicts")
+)
+
+// AlreadyBackportedError is returned when a commit was already backported to the target branch.
+type AlreadyBackportedError struct {
+	OriginalSHA string
+	BackportSHA string
+	Branch      string
+	Source      string // Where the backport was found: the cache, its signature or its cherry-pick trailer
+}
+
+// Error implements error.
+func (e *AlreadyBackportedError) Error() string {
+	return fmt.Sprintf("commit %s was already backported to %s as %s (found by %s)",
+		ShortSHA(e.OriginalSHA), e.Branch, ShortSHA(e.BackportSHA), e.Source)
+}
+
+// BranchNotFoundError is returned when the target branch exists neither locally nor on the remote.
+type BranchNotFoundError struct {
+	Branch string
+	Remote string
+}
+
+// Error implements error.
+func (e *BranchNotFoundError) Error() string {
+	return fmt.Sprintf("target branch %s does not exist", e.Branch)
+}
+
+// RevertOfMissingError is returned when a revert is backported to a branch that the reverted change never landed on.
+type RevertOfMissingError struct {
+	RevertSHA   string
+	RevertedSHA string
+	Branch      string
+}
+
+// Error implements error.
+func (e *RevertOfMissingError) Error() string {
+	return fmt.Sprintf("commit %s reverts %s, which was never backported to %s",
+		ShortSHA(e.RevertSHA), ShortSHA(e.RevertedSHA), e.Branch)
+}
@@ -0,0 +1,68 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// followUpKeywords match the words that mark a mention of a PR as a fix of it,
+// e.g. "fixes regression from #123" or "follow-up to #123".
+var followUpKeywords = regexp.MustCompile(`(?i)\b(fix(es|ed)?|regression|follow[- ]?up|broke|broken)\b`)
+
+// IsFollowUp reports whether candidate fixes or follows up on the original PR: it was merged
+// after it and mentions #<number> on a line of its title or body together with a word like
+// "fix", "regression" or "follow-up". Backports of the original PR are no follow-ups.
+func IsFollowUp(original, candidate *forge.PRInfo) bool {
+	if candidate.Number == original.Number || !candidate.Merged {
+		return false
+	}
+	if strings.HasPrefix(candidate.HeadBranch, fmt.Sprintf("backport-%d-to-", original.Number)) ||
+		strings.Contains(candidate.Title, fmt.Sprintf("backport #%d ", original.Number)) {
+		return false
+	}
+	if !original.MergedAt.IsZero() && !candidate.MergedAt.IsZero() && candidate.MergedAt.Before(original.MergedAt) {
+		return false
+	}
+
+	reference := regexp.MustCompile(fmt.Sprintf(`(^|[^\w&/])#%d\b`, original.Number))
+	for _, line := range strings.Split(candidate.Title+"\n"+candidate.Body, "\n") {
+		if reference.MatchString(line) && followUpKeywords.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindFollowUps returns the merged PRs of a repository that fix or follow up on a PR, see
+// IsFollowUp, oldest first. Candidates are found through the forge's search for #<number>.
+func FindFollowUps(ctx context.Context, f forge.Forge, owner, repo string, pr *forge.PRInfo) ([]*forge.PRInfo, error) {
+	candidates, err := f.SearchMergedPRs(ctx, owner, repo, fmt.Sprintf("#%d", pr.Number))
+	if err != nil {
+		return nil, err
+	}
+
+	var followUps []*forge.PRInfo
+	for _, candidate := range candidates {
+		if IsFollowUp(pr, candidate) {
+			followUps = append(followUps, candidate)
+		}
+	}
+	slices.SortStableFunc(followUps, func(a, b *forge.PRInfo) int {
+		return a.MergedAt.Compare(b.MergedAt)
+	})
+	return followUps, nil
+}
+
+// FollowUps returns the follow-up fixes of a PR on the configured forge, see FindFollowUps.
+// Without a forge there are none.
+func (s *Service) FollowUps(ctx context.Context, pr *forge.PRInfo) ([]*forge.PRInfo, error) {
+	if s.forge == nil {
+		return nil, nil
+	}
+	return FindFollowUps(ctx, s.forge, s.owner, s.repoN, pr)
+}
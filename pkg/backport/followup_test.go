@@ -0,0 +1,69 @@
+package backport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestIsFollowUp(t *testing.T) {
+	mergedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	original := &forge.PRInfo{Number: 123, Merged: true, MergedAt: mergedAt}
+	later := mergedAt.Add(24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		candidate *forge.PRInfo
+		want      bool
+	}{
+		{"fix in title", &forge.PRInfo{Number: 130, Title: "fix: crash introduced in #123", Merged: true, MergedAt: later}, true},
+		{"regression in body", &forge.PRInfo{Number: 130, Title: "Handle empty input", Body: "Fixes a regression from #123.", Merged: true, MergedAt: later}, true},
+		{"follow-up", &forge.PRInfo{Number: 130, Title: "Follow-up to #123", Merged: true, MergedAt: later}, true},
+		{"mention without fix", &forge.PRInfo{Number: 130, Title: "docs: describe #123", Merged: true, MergedAt: later}, false},
+		{"keyword on other line", &forge.PRInfo{Number: 130, Title: "fix: typo", Body: "Related to #123", Merged: true, MergedAt: later}, false},
+		{"other PR number", &forge.PRInfo{Number: 130, Title: "fix: regression from #1234", Merged: true, MergedAt: later}, false},
+		{"other repository", &forge.PRInfo{Number: 130, Title: "fix: regression from other/repo#123", Merged: true, MergedAt: later}, false},
+		{"merged before", &forge.PRInfo{Number: 120, Title: "fix: prepare #123", Merged: true, MergedAt: mergedAt.Add(-time.Hour)}, false},
+		{"backport", &forge.PRInfo{Number: 131, Title: "fix: backport #123 to release-1.x", HeadBranch: "backport-123-to-release-1.x", Merged: true, MergedAt: later}, false},
+		{"original", &forge.PRInfo{Number: 123, Title: "fix: #123", Merged: true, MergedAt: mergedAt}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsFollowUp(original, tt.candidate))
+		})
+	}
+}
+
+// searchForge returns fixed search results.
+type searchForge struct {
+	forge.Forge
+	query   string
+	results []*forge.PRInfo
+}
+
+func (f *searchForge) SearchMergedPRs(_ context.Context, _, _, text string) ([]*forge.PRInfo, error) {
+	f.query = text
+	return f.results, nil
+}
+
+func TestFindFollowUps(t *testing.T) {
+	mergedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := &searchForge{results: []*forge.PRInfo{
+		{Number: 140, Title: "fix: second regression from #123", Merged: true, MergedAt: mergedAt.Add(48 * time.Hour)},
+		{Number: 135, Title: "docs: mention #123", Merged: true, MergedAt: mergedAt.Add(36 * time.Hour)},
+		{Number: 130, Title: "fix: crash introduced in #123", Merged: true, MergedAt: mergedAt.Add(24 * time.Hour)},
+	}}
+
+	followUps, err := FindFollowUps(context.Background(), f, "owner", "repo", &forge.PRInfo{Number: 123, Merged: true, MergedAt: mergedAt})
+	require.NoError(t, err)
+	assert.Equal(t, "#123", f.query)
+	require.Len(t, followUps, 2)
+	assert.Equal(t, 130, followUps[0].Number)
+	assert.Equal(t, 140, followUps[1].Number)
+}
@@ -0,0 +1,260 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// PropagationStatus describes whether a change is present on a branch.
+type PropagationStatus string
+
+const (
+	// StatusContained means the original commit is part of the branch history.
+	StatusContained PropagationStatus = "contained"
+	// StatusBackported means an equivalent commit exists on the branch.
+	StatusBackported PropagationStatus = "backported"
+	// StatusMissing means the change was not found on the branch.
+	StatusMissing PropagationStatus = "missing"
+)
+
+// Sources used to detect a backport.
+const (
+	sourceAncestor  = "ancestor"
+	sourceCache     = "cache"
+	sourceSignature = "signature"
+	sourcePatchID   = "patch-id"
+	sourceTrailer   = "cherry-pick trailer"
+)
+
+// BranchPropagation describes the state of a change on a single branch.
+type BranchPropagation struct {
+	Branch string
+	Status PropagationStatus
+	SHA    string // Commit carrying the change on this branch, if any
+	Source string // How the change was detected: "ancestor", "cache", "signature" or "patch-id"
+}
+
+// Graph describes which branches a change has propagated to.
+type Graph struct {
+	OriginalSHA string
+	PRNumber    int
+	Branches    []BranchPropagation
+}
+
+// Graph builds the propagation graph of a commit across local branches.
+// Branches are limited to configured target branches and branches found in the cache;
+// if no target branches are configured, all local branches are checked.
+func (s *Service) Graph(_ context.Context, sha string) (*Graph, error) {
+	fullSHA, err := s.repo.GetCommitSHA(sha)
+	if err != nil {
+		return nil, fmt.Errorf("commit not found: %w", err)
+	}
+
+	branches, err := s.graphBranches(fullSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge commits without own diff have no patch-id, only ancestry and cache are used then.
+	patchID, err := git.PatchID(fullSHA)
+	if err != nil {
+		log.Debug().Err(err).Str("sha", fullSHA).Msg("failed to compute patch-id")
+	}
+
+	graph := &Graph{OriginalSHA: fullSHA}
+
+	cached := make(map[string]CacheEntry)
+	if s.cache != nil {
+		for _, entry := range s.cache.FindByOriginalSHA(fullSHA) {
+			cached[entry.TargetBranch] = entry
+			if entry.PRNumber > 0 {
+				graph.PRNumber = entry.PRNumber
+			}
+		}
+	}
+
+	for _, branch := range branches {
+		graph.Branches = append(graph.Branches, s.branchPropagation(fullSHA, patchID, branch, cached))
+	}
+
+	return graph, nil
+}
+
+// branchPropagation determines the state of a change on a single branch.
+func (s *Service) branchPropagation(sha, patchID, branch string, cached map[string]CacheEntry) BranchPropagation {
+	result := BranchPropagation{Branch: branch, Status: StatusMissing}
+
+	contained, err := git.IsAncestor(sha, branch)
+	if err != nil {
+		log.Debug().Err(err).Str("branch", branch).Msg("failed to check ancestry")
+	}
+	if contained {
+		result.Status = StatusContained
+		result.SHA = sha
+		result.Source = sourceAncestor
+		return result
+	}
+
+	if entry, ok := cached[branch]; ok {
+		result.Status = StatusBackported
+		result.SHA = entry.BackportSHA
+		result.Source = sourceCache
+		return result
+	}
+
+	// Backports made with backporter carry the original SHA in their trailers, even when
+	// the cache is not shared, e.g. for backports made in CI. CI backports of versions that
+	// did not add trailers yet are only found by their patch-id below.
+	signed, err := findSigned(branch, sha)
+	if err != nil {
+		log.Debug().Err(err).Str("branch", branch).Msg("failed to scan for backport signatures")
+	}
+	if signed != "" {
+		result.Status = StatusBackported
+		result.SHA = signed
+		result.Source = sourceSignature
+		return result
+	}
+
+	if match := findByPatchID(sha, patchID, branch); match != "" {
+		result.Status = StatusBackported
+		result.SHA = match
+		result.Source = sourcePatchID
+	}
+
+	return result
+}
+
+// findByPatchID returns the commit on ref since its merge base with sha that has the given
+// patch-id, or an empty SHA if there is none or patchID is empty.
+func findByPatchID(sha, patchID, ref string) string {
+	if patchID == "" {
+		return ""
+	}
+
+	base, err := git.MergeBase(sha, ref)
+	if err != nil {
+		log.Debug().Err(err).Str("branch", ref).Msg("failed to get merge base")
+		return ""
+	}
+
+	ids, err := git.RangePatchIDs(base, ref)
+	if err != nil {
+		log.Debug().Err(err).Str("branch", ref).Msg("failed to scan patch-ids")
+		return ""
+	}
+
+	return ids[patchID]
+}
+
+// graphBranches returns the branches to include in the graph of a commit.
+func (s *Service) graphBranches(sha string) ([]string, error) {
+	all, err := s.repo.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := s.config.TargetBranches
+	if len(targets) == 0 {
+		sort.Strings(all)
+		return all, nil
+	}
+
+	include := make(map[string]bool)
+	if s.cache != nil {
+		for _, entry := range s.cache.FindByOriginalSHA(sha) {
+			include[entry.TargetBranch] = true
+		}
+	}
+
+	var branches []string
+	for _, branch := range all {
+		if include[branch] || MatchesTargetBranch(branch, targets) {
+			branches = append(branches, branch)
+		}
+	}
+
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// ShortSHA shortens a SHA for display.
+func ShortSHA(sha string) string {
+	const shortLen = 7
+	if len(sha) > shortLen {
+		return sha[:shortLen]
+	}
+	return sha
+}
+
+// title returns the label of the root node.
+func (g *Graph) title() string {
+	if g.PRNumber > 0 {
+		return fmt.Sprintf("%s (PR #%d)", ShortSHA(g.OriginalSHA), g.PRNumber)
+	}
+	return ShortSHA(g.OriginalSHA)
+}
+
+// ASCII renders the graph as an ASCII tree.
+func (g *Graph) ASCII() string {
+	var sb strings.Builder
+	sb.WriteString(g.title())
+	sb.WriteString("\n")
+
+	width := 0
+	for _, b := range g.Branches {
+		width = max(width, len(b.Branch))
+	}
+
+	for i, b := range g.Branches {
+		connector := "├──"
+		if i == len(g.Branches)-1 {
+			connector = "└──"
+		}
+
+		var state string
+		switch b.Status {
+		case StatusContained:
+			state = "● contained"
+		case StatusBackported:
+			state = fmt.Sprintf("● backported as %s (%s)", ShortSHA(b.SHA), b.Source)
+		default:
+			state = "○ missing"
+		}
+
+		fmt.Fprintf(&sb, "%s %-*s  %s\n", connector, width, b.Branch, state)
+	}
+
+	return sb.String()
+}
+
+// DOT renders the graph in Graphviz DOT format.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	root := ShortSHA(g.OriginalSHA)
+
+	sb.WriteString("digraph backports {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	fmt.Fprintf(&sb, "  %q [shape=box, label=%q];\n", root, g.title())
+
+	for _, b := range g.Branches {
+		switch b.Status {
+		case StatusContained:
+			fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", root, b.Branch, string(b.Status))
+		case StatusBackported:
+			fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", root, b.Branch, ShortSHA(b.SHA))
+		default:
+			fmt.Fprintf(&sb, "  %q [style=dashed];\n", b.Branch)
+			fmt.Fprintf(&sb, "  %q -> %q [style=dashed, label=%q];\n", root, b.Branch, string(b.Status))
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,38 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testGraph() *Graph {
+	return &Graph{
+		OriginalSHA: "abc123def4567890",
+		PRNumber:    42,
+		Branches: []BranchPropagation{
+			{Branch: "main", Status: StatusContained, SHA: "abc123def4567890", Source: sourceAncestor},
+			{Branch: "release-1.x", Status: StatusBackported, SHA: "fedcba9876543210", Source: sourcePatchID},
+			{Branch: "release-2.x", Status: StatusMissing},
+		},
+	}
+}
+
+func TestGraphASCII(t *testing.T) {
+	expected := "abc123d (PR #42)\n" +
+		"├── main         ● contained\n" +
+		"├── release-1.x  ● backported as fedcba9 (patch-id)\n" +
+		"└── release-2.x  ○ missing\n"
+
+	assert.Equal(t, expected, testGraph().ASCII())
+}
+
+func TestGraphDOT(t *testing.T) {
+	dot := testGraph().DOT()
+
+	assert.Contains(t, dot, "digraph backports {")
+	assert.Contains(t, dot, `"abc123d" [shape=box, label="abc123d (PR #42)"];`)
+	assert.Contains(t, dot, `"abc123d" -> "main" [label="contained"];`)
+	assert.Contains(t, dot, `"abc123d" -> "release-1.x" [label="fedcba9"];`)
+	assert.Contains(t, dot, `"abc123d" -> "release-2.x" [style=dashed, label="missing"];`)
+}
@@ -0,0 +1,94 @@
+package backport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort keys of the backport history, prefixed with "-" for descending order.
+const (
+	SortDate   = "date"
+	SortBranch = "branch"
+	SortPR     = "pr"
+)
+
+// HistoryFilter selects entries of the backport history. Zero fields match everything.
+type HistoryFilter struct {
+	Branch string    // Target branch
+	PR     int       // Original PR number
+	SHA    string    // Prefix of the original or backport commit SHA
+	Since  time.Time // Backported at or after
+	Until  time.Time // Backported before
+}
+
+// matches reports whether an entry is selected by the filter.
+func (f HistoryFilter) matches(entry CacheEntry) bool {
+	if f.Branch != "" && entry.TargetBranch != f.Branch {
+		return false
+	}
+	if f.PR != 0 && entry.PRNumber != f.PR {
+		return false
+	}
+	if f.SHA != "" && !strings.HasPrefix(entry.OriginalSHA, f.SHA) && !strings.HasPrefix(entry.BackportSHA, f.SHA) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Timestamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// FilterHistory returns the entries selected by the filter, in their original order.
+func FilterHistory(entries []CacheEntry, filter HistoryFilter) []CacheEntry {
+	var result []CacheEntry
+	for _, entry := range entries {
+		if filter.matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// SortHistory sorts entries in place by a sort key (see the Sort constants), prefixed with "-"
+// for descending order. Entries with equal keys keep their order.
+func SortHistory(entries []CacheEntry, key string) error {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	var less func(a, b CacheEntry) bool
+	switch key {
+	case SortDate:
+		less = func(a, b CacheEntry) bool { return a.Timestamp.Before(b.Timestamp) }
+	case SortBranch:
+		less = func(a, b CacheEntry) bool { return a.TargetBranch < b.TargetBranch }
+	case SortPR:
+		less = func(a, b CacheEntry) bool { return a.PRNumber < b.PRNumber }
+	default:
+		return fmt.Errorf("invalid sort key: %s (must be '%s', '%s' or '%s', prefixed with '-' for descending order)", key, SortDate, SortBranch, SortPR)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if desc {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+	return nil
+}
+
+// ParseHistoryTime parses a --since/--until value: a date (2006-01-02) in local time or an RFC 3339 timestamp.
+func ParseHistoryTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (must be a date like 2006-01-02 or an RFC 3339 timestamp)", s)
+	}
+	return t, nil
+}
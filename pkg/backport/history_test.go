@@ -0,0 +1,70 @@
+package backport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyFixture() []CacheEntry {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	return []CacheEntry{
+		{OriginalSHA: "aaa111", BackportSHA: "bbb111", TargetBranch: "release-2", PRNumber: 3, Timestamp: day(1)},
+		{OriginalSHA: "aaa222", BackportSHA: "bbb222", TargetBranch: "release-1", PRNumber: 1, Timestamp: day(3)},
+		{OriginalSHA: "aaa333", BackportSHA: "bbb333", TargetBranch: "release-1", PRNumber: 2, Timestamp: day(2)},
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	entries := historyFixture()
+
+	assert.Len(t, FilterHistory(entries, HistoryFilter{}), 3)
+	assert.Len(t, FilterHistory(entries, HistoryFilter{Branch: "release-1"}), 2)
+	assert.Len(t, FilterHistory(entries, HistoryFilter{PR: 3}), 1)
+	assert.Len(t, FilterHistory(entries, HistoryFilter{SHA: "bbb2"}), 1)
+	assert.Len(t, FilterHistory(entries, HistoryFilter{SHA: "aaa"}), 3)
+
+	since := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+	filtered := FilterHistory(entries, HistoryFilter{Since: since, Until: until})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "aaa333", filtered[0].OriginalSHA)
+}
+
+func TestSortHistory(t *testing.T) {
+	entries := historyFixture()
+
+	require.NoError(t, SortHistory(entries, SortDate))
+	assert.Equal(t, []int{3, 2, 1}, prNumbers(entries))
+
+	require.NoError(t, SortHistory(entries, "-"+SortPR))
+	assert.Equal(t, []int{3, 2, 1}, prNumbers(entries))
+
+	require.NoError(t, SortHistory(entries, SortBranch))
+	assert.Equal(t, []int{2, 1, 3}, prNumbers(entries))
+
+	assert.Error(t, SortHistory(entries, "author"))
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	date, err := ParseHistoryTime("2024-05-02")
+	require.NoError(t, err)
+	assert.Equal(t, time.May, date.Month())
+
+	ts, err := ParseHistoryTime("2024-05-02T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, 10, ts.Hour())
+
+	_, err = ParseHistoryTime("yesterday")
+	assert.Error(t, err)
+}
+
+func prNumbers(entries []CacheEntry) []int {
+	numbers := make([]int, len(entries))
+	for i, entry := range entries {
+		numbers[i] = entry.PRNumber
+	}
+	return numbers
+}
@@ -0,0 +1,192 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Backport bots whose earlier backports can be imported into the history.
+const (
+	BotBackportBot = "backport-bot" // sqren/backport, branches "backport/<target>/pr-<number>"
+	BotTibdex      = "tibdex"       // tibdex/backport, branches "backport-<number>-to-<target>"
+	BotKorthout    = "korthout"     // korthout/backport-action, branches "backport-<number>-to-<target>"
+)
+
+// botBranchPatterns match the head branches of the backport PRs a bot opens,
+// capturing the number of the original PR and the target branch.
+var botBranchPatterns = map[string]*regexp.Regexp{
+	BotBackportBot: regexp.MustCompile(`^backport/(?P<target>.+)/pr-(?P<number>\d+)$`),
+	BotTibdex:      regexp.MustCompile(`^backport-(?P<number>\d+)-to-(?P<target>.+)$`),
+	BotKorthout:    regexp.MustCompile(`^backport-(?P<number>\d+)-to-(?P<target>.+)$`),
+}
+
+// ValidateBot checks if the history of a backport bot can be imported.
+func ValidateBot(bot string) error {
+	if _, ok := botBranchPatterns[bot]; !ok {
+		return fmt.Errorf("invalid bot: %s (must be '%s', '%s' or '%s')", bot, BotBackportBot, BotTibdex, BotKorthout)
+	}
+	return nil
+}
+
+// parseBotBranch returns the original PR number and target branch of a backport branch of bot.
+func parseBotBranch(bot, branch string) (int, string, bool) {
+	pattern := botBranchPatterns[bot]
+	if pattern == nil {
+		return 0, "", false
+	}
+	match := pattern.FindStringSubmatch(branch)
+	if match == nil {
+		return 0, "", false
+	}
+	number, err := strconv.Atoi(match[pattern.SubexpIndex("number")])
+	if err != nil {
+		return 0, "", false
+	}
+	return number, match[pattern.SubexpIndex("target")], true
+}
+
+// shaPattern matches a full commit SHA.
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// trailerSHA returns the commit a `git cherry-pick -x` commit was picked from, or "" if the
+// message has no cherry-pick trailer.
+func trailerSHA(message string) string {
+	i := strings.LastIndex(message, cherryPickTrailer)
+	if i < 0 {
+		return ""
+	}
+	sha, _, ok := strings.Cut(message[i+len(cherryPickTrailer):], ")")
+	if !ok || !shaPattern.MatchString(sha) {
+		return ""
+	}
+	return sha
+}
+
+// FindBotBackports returns the backports made by a backport bot: its merged backport PRs among
+// the latest limit merged PRs, and its backport branches on the remote that were merged into
+// their target. Backports whose original PR cannot be resolved are skipped.
+func (s *Service) FindBotBackports(ctx context.Context, bot string, limit int) ([]CacheEntry, error) {
+	if err := ValidateBot(bot); err != nil {
+		return nil, err
+	}
+	if s.forge == nil {
+		return nil, fmt.Errorf("forge not configured, cannot list backport PRs")
+	}
+
+	originals := make(map[int]*forge.PRInfo)
+	original := func(number int) *forge.PRInfo {
+		if pr, ok := originals[number]; ok {
+			return pr
+		}
+		pr, err := s.GetPR(ctx, number)
+		if err != nil || pr.MergeCommit == "" {
+			log.Warn().Err(err).Int("pr", number).Msg("failed to resolve original PR of backport, skipping")
+			pr = nil
+		}
+		originals[number] = pr
+		return pr
+	}
+
+	prs, err := s.forge.ListRecentPRs(ctx, s.owner, s.repoN, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	seen := make(map[string]bool)
+	for _, pr := range prs {
+		number, target, ok := parseBotBranch(bot, pr.HeadBranch)
+		if !ok || pr.MergeCommit == "" {
+			continue
+		}
+		seen[pr.HeadBranch] = true
+		if pr.BaseBranch != "" {
+			target = pr.BaseBranch
+		}
+		orig := original(number)
+		if orig == nil {
+			continue
+		}
+		entries = append(entries, CacheEntry{
+			OriginalSHA:  orig.MergeCommit,
+			BackportSHA:  pr.MergeCommit,
+			TargetBranch: target,
+			PRNumber:     number,
+			Timestamp:    pr.MergedAt,
+			Message:      orig.Title,
+		})
+	}
+
+	// Backport branches that were merged without a PR listed above, e.g. older ones.
+	branches, err := s.repo.ListRemoteBranches(s.config.Remote)
+	if err != nil {
+		return nil, err
+	}
+	for _, branch := range branches {
+		number, target, ok := parseBotBranch(bot, branch)
+		if !ok || seen[branch] {
+			continue
+		}
+		entry, ok := s.branchBackport(branch, target)
+		if !ok {
+			continue
+		}
+		entry.PRNumber = number
+		if entry.OriginalSHA == "" {
+			orig := original(number)
+			if orig == nil {
+				continue
+			}
+			entry.OriginalSHA = orig.MergeCommit
+			entry.Message = orig.Title
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// branchBackport returns the backport on a remote backport branch if the branch was merged into
+// its target. The original commit is taken from the cherry-pick trailer, if there is one.
+func (s *Service) branchBackport(branch, target string) (CacheEntry, bool) {
+	remote := s.config.Remote
+	tip, err := s.repo.GetCommitSHA(remote + "/" + branch)
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	merged, err := git.IsAncestor(tip, remote+"/"+target)
+	if err != nil || !merged {
+		log.Debug().Err(err).Str("branch", branch).Msg("backport branch is not merged into its target, skipping")
+		return CacheEntry{}, false
+	}
+
+	entry := CacheEntry{BackportSHA: tip, TargetBranch: target}
+	if message, err := s.repo.GetCommitMessage(tip); err == nil {
+		entry.OriginalSHA = trailerSHA(message)
+		entry.Message, _, _ = strings.Cut(message, "\n")
+	}
+	if when, err := s.repo.CommitTime(tip); err == nil {
+		entry.Timestamp = when
+	}
+	return entry, true
+}
+
+// ImportBackports adds the entries missing from the history, or from the history file at path
+// if it is set, e.g. a ledger shared in the repository. Returns the added entries.
+func (s *Service) ImportBackports(entries []CacheEntry, path string) ([]CacheEntry, error) {
+	if path != "" {
+		return NewCache(path).Import(entries)
+	}
+	if !s.config.Cache.Enabled {
+		return nil, fmt.Errorf("the backport cache is disabled, enable it or import into a history file")
+	}
+	return s.cache.Import(entries)
+}
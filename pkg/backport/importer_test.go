@@ -0,0 +1,75 @@
+package backport
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBotBranch(t *testing.T) {
+	tests := []struct {
+		bot    string
+		branch string
+		number int
+		target string
+		ok     bool
+	}{
+		{BotBackportBot, "backport/release-1.2/pr-42", 42, "release-1.2", true},
+		{BotBackportBot, "backport/release/v1/pr-7", 7, "release/v1", true},
+		{BotBackportBot, "backport-42-to-release-1.2", 0, "", false},
+		{BotTibdex, "backport-42-to-release-1.2", 42, "release-1.2", true},
+		{BotKorthout, "backport-13-to-stable", 13, "stable", true},
+		{BotKorthout, "backport/stable/pr-13", 0, "", false},
+		{BotTibdex, "feature-branch", 0, "", false},
+		{"unknown", "backport-1-to-main", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bot+"/"+tt.branch, func(t *testing.T) {
+			number, target, ok := parseBotBranch(tt.bot, tt.branch)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.number, number)
+			assert.Equal(t, tt.target, target)
+		})
+	}
+}
+
+func TestValidateBot(t *testing.T) {
+	assert.NoError(t, ValidateBot(BotBackportBot))
+	assert.NoError(t, ValidateBot(BotTibdex))
+	assert.NoError(t, ValidateBot(BotKorthout))
+	assert.Error(t, ValidateBot("mergify"))
+}
+
+func TestTrailerSHA(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	assert.Equal(t, sha, trailerSHA("fix: crash\n\n(cherry picked from commit "+sha+")\n"))
+	assert.Empty(t, trailerSHA("fix: crash"))
+	assert.Empty(t, trailerSHA("fix: crash\n\n(cherry picked from commit abc)"))
+}
+
+func TestCacheImport(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "ledger.json")
+
+	shared := NewCache(ledger)
+	known := CacheEntry{OriginalSHA: "aaa", BackportSHA: "bbb", TargetBranch: "release-1", PRNumber: 1}
+	require.NoError(t, shared.Add(known))
+
+	cache := NewCache(filepath.Join(dir, "history.json"), ledger)
+	fresh := CacheEntry{OriginalSHA: "ccc", BackportSHA: "ddd", TargetBranch: "release-1", PRNumber: 2}
+
+	added, err := cache.Import([]CacheEntry{known, fresh, fresh})
+	require.NoError(t, err)
+	assert.Equal(t, []CacheEntry{fresh}, added)
+
+	// The import is saved, importing again adds nothing.
+	reloaded := NewCache(filepath.Join(dir, "history.json"), ledger)
+	assert.Len(t, reloaded.FindByOriginalSHA("ccc"), 1)
+	added, err = reloaded.Import([]CacheEntry{known, fresh})
+	require.NoError(t, err)
+	assert.Empty(t, added)
+}
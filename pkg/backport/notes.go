@@ -0,0 +1,65 @@
+package backport
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// NotesRef is the git notes ref holding the metadata of merged PRs on their merge commits.
+// Mirrors of a forge can backport PRs offline by fetching it along with the branches.
+const NotesRef = "refs/notes/backporter"
+
+// PRNote is the PR metadata stored in a git note on the merge commit of a PR.
+type PRNote struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+}
+
+// WritePRNote stores the metadata of a merged PR in a note on its merge commit.
+func WritePRNote(pr *forge.PRInfo) error {
+	if pr.MergeCommit == "" {
+		return fmt.Errorf("PR #%d has no merge commit", pr.Number)
+	}
+
+	data, err := json.Marshal(PRNote{Number: pr.Number, Title: pr.Title, Author: pr.Author})
+	if err != nil {
+		return err
+	}
+	return git.AddNote(NotesRef, pr.MergeCommit, string(data))
+}
+
+// prFromNotes looks up a PR by number in the notes. The merge commit is the commit the note is
+// attached to, it counts as squash merged if it has a single parent.
+func (s *Service) prFromNotes(number int) (*forge.PRInfo, error) {
+	notes, err := git.ReadNotes(NotesRef)
+	if err != nil {
+		return nil, err
+	}
+
+	for sha, content := range notes {
+		var note PRNote
+		if err := json.Unmarshal([]byte(content), &note); err != nil || note.Number != number {
+			continue
+		}
+
+		parents, err := s.repo.ParentCount(sha)
+		if err != nil {
+			return nil, err
+		}
+		return &forge.PRInfo{
+			Number:      note.Number,
+			Title:       note.Title,
+			Author:      note.Author,
+			State:       "closed",
+			MergeCommit: sha,
+			Merged:      true,
+			Squashed:    parents == 1,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no note for PR #%d in %s", number, NotesRef)
+}
@@ -0,0 +1,44 @@
+package backport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestGetPRFromNotes(t *testing.T) {
+	service, sha, _ := setupUndoRepo(t)
+
+	_, err := service.GetPR(context.Background(), 42)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no note for PR #42")
+
+	require.NoError(t, WritePRNote(&forge.PRInfo{Number: 42, Title: "fix: crash on empty input", Author: "alice", MergeCommit: sha}))
+
+	pr, err := service.GetPR(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, &forge.PRInfo{
+		Number:      42,
+		Title:       "fix: crash on empty input",
+		Author:      "alice",
+		State:       "closed",
+		MergeCommit: sha,
+		Merged:      true,
+		Squashed:    true,
+	}, pr)
+
+	// Without a forge the PR is backported from its note.
+	result, err := service.BackportPR(context.Background(), 42, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 42, result.PRNumber)
+	assert.Equal(t, sha, result.OriginalSHA)
+}
+
+func TestWritePRNoteWithoutMergeCommit(t *testing.T) {
+	assert.Error(t, WritePRNote(&forge.PRInfo{Number: 1}))
+}
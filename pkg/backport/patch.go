@@ -0,0 +1,112 @@
+package backport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// mboxFromPattern matches the line git format-patch starts each patch of a mailbox with.
+var mboxFromPattern = regexp.MustCompile(`(?m)^From ([0-9a-f]{40}) Mon Sep 17 00:00:00 2001$`)
+
+// subjectPattern matches the subject header of a patch.
+var subjectPattern = regexp.MustCompile(`(?m)^Subject: (.*)$`)
+
+// Patch is a single commit of a mailbox, as exported by ExportCommit or ExportPR.
+type Patch struct {
+	OriginalSHA string // The commit the patch was created from
+	Subject     string
+	Data        []byte // The patch in mbox format
+}
+
+// ParseMailbox splits a mailbox created by git format-patch into its patches, in order.
+func ParseMailbox(data []byte) ([]Patch, error) {
+	starts := mboxFromPattern.FindAllSubmatchIndex(data, -1)
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("no patches found, expected a mailbox created by git format-patch or backport export")
+	}
+
+	patches := make([]Patch, 0, len(starts))
+	for i, start := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		patch := Patch{
+			OriginalSHA: string(data[start[2]:start[3]]),
+			Data:        data[start[0]:end],
+		}
+		if m := subjectPattern.FindSubmatch(patch.Data); m != nil {
+			patch.Subject = string(m[1])
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+// ExportCommit returns a commit as a mailbox patch, limited to the files matching paths if any.
+func (s *Service) ExportCommit(_ context.Context, sha string, paths []string) ([]byte, error) {
+	fullSHA, err := s.ensureCommit(sha)
+	if err != nil {
+		return nil, err
+	}
+	return exportCommits([]string{fullSHA}, paths)
+}
+
+// ExportPR returns the commits of a PR the strategy backports as a mailbox of patches,
+// limited to the files matching paths if any.
+func (s *Service) ExportPR(ctx context.Context, prNumber int, strategy string, paths []string) ([]byte, error) {
+	if err := ValidateStrategy(strategy); err != nil {
+		return nil, err
+	}
+
+	pr, err := s.GetPR(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureCommit(pr.MergeCommit); err != nil {
+		return nil, err
+	}
+
+	commits, opts, err := s.checkedPRCommits(ctx, pr, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Mainline > 0 {
+		return nil, fmt.Errorf("merge commits cannot be exported as patches, export the individual commits instead")
+	}
+	return exportCommits(commits, paths)
+}
+
+// exportCommits concatenates the patches of the commits into a mailbox. Commits changing
+// none of the paths are left out.
+func exportCommits(commits, paths []string) ([]byte, error) {
+	var mbox bytes.Buffer
+	for _, sha := range commits {
+		patch, err := git.FormatPatch(sha, paths)
+		if err != nil {
+			return nil, err
+		}
+		mbox.Write(patch)
+	}
+	if mbox.Len() == 0 {
+		return nil, fmt.Errorf("no changes to export, the commits change none of the given paths")
+	}
+	return mbox.Bytes(), nil
+}
+
+// ApplyPatch applies a patch to the target branch like BackportCommit does with a cherry-pick,
+// for target branches living in another repository. The patch keeps the message and author of
+// its commit and gets the backport trailers of the original commit. Conflicts are left to be
+// resolved and finished with ContinueBackport.
+func (s *Service) ApplyPatch(ctx context.Context, patch Patch, opts BackportOptions) (*BackportResult, error) {
+	opts.patch = patch.Data
+	result, err := s.backportCommit(ctx, patch.OriginalSHA, opts)
+	if !opts.DryRun {
+		s.recordAudit(AuditEntry{SourceSHA: patch.OriginalSHA, TargetBranch: opts.TargetBranch}, result, err)
+	}
+	return result, err
+}
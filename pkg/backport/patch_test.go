@@ -0,0 +1,86 @@
+package backport
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+func TestParseMailbox(t *testing.T) {
+	mbox := "From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001\n" +
+		"From: Test User <test@example.com>\n" +
+		"Subject: First change\n\n---\n" +
+		"From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001\n" +
+		"From: Test User <test@example.com>\n" +
+		"Subject: Second change\n\n---\n"
+
+	patches, err := ParseMailbox([]byte(mbox))
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+	assert.Equal(t, "1111111111111111111111111111111111111111", patches[0].OriginalSHA)
+	assert.Equal(t, "First change", patches[0].Subject)
+	assert.Equal(t, "Second change", patches[1].Subject)
+	assert.Equal(t, mbox, string(patches[0].Data)+string(patches[1].Data))
+
+	_, err = ParseMailbox([]byte("not a patch"))
+	assert.Error(t, err)
+}
+
+func TestExportApplyPatch(t *testing.T) {
+	service, sha, _ := setupUndoRepo(t)
+
+	mbox, err := service.ExportCommit(context.Background(), sha, nil)
+	require.NoError(t, err)
+
+	// Apply the patch in another repository that does not know the commit.
+	repoPath := t.TempDir()
+	t.Chdir(repoPath)
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q", "-b", "release")
+	run("config", "user.name", "Other User")
+	run("config", "user.email", "other@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("base\n"), 0o644))
+	run("add", "file.txt")
+	run("commit", "-q", "-m", "Initial commit")
+
+	repo, err := git.Open(repoPath)
+	require.NoError(t, err)
+	other := NewService(repo, nil, &config.Config{Remote: "origin"}, "owner", "repo")
+
+	patches, err := ParseMailbox(mbox)
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	assert.Equal(t, sha, patches[0].OriginalSHA)
+	assert.Equal(t, "Fix bug", patches[0].Subject)
+
+	result, err := other.ApplyPatch(context.Background(), patches[0], BackportOptions{TargetBranch: "release"})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, sha, result.OriginalSHA)
+
+	message, err := repo.GetCommitMessage(result.BackportSHA)
+	require.NoError(t, err)
+	assert.Contains(t, message, "Fix bug")
+	assert.Contains(t, message, version.TrailerBackportedFrom+": "+sha)
+
+	author, err := exec.Command("git", "log", "-1", "--format=%an", result.BackportSHA).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "Test User\n", string(author))
+
+	// Applying the patch again is caught by its signature.
+	_, err = other.ApplyPatch(context.Background(), patches[0], BackportOptions{TargetBranch: "release"})
+	var dup *AlreadyBackportedError
+	assert.ErrorAs(t, err, &dup)
+}
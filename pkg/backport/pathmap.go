@@ -0,0 +1,36 @@
+package backport
+
+import (
+	"sort"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// pathMappings returns the path mappings of all configs matching the target branch. Longer
+// prefixes come first so nested directories can be mapped separately, and for equal prefixes
+// the first config wins.
+func pathMappings(configs []config.PathMappingConfig, targetBranch string) []git.PathMapping {
+	seen := make(map[string]bool)
+	var mappings []git.PathMapping
+	for _, cfg := range configs {
+		if !MatchesTargetBranch(targetBranch, cfg.Branches) {
+			continue
+		}
+		for from, to := range cfg.Paths {
+			if !seen[from] {
+				seen[from] = true
+				mappings = append(mappings, git.PathMapping{From: from, To: to})
+			}
+		}
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		if len(mappings[i].From) != len(mappings[j].From) {
+			return len(mappings[i].From) > len(mappings[j].From)
+		}
+		return mappings[i].From < mappings[j].From
+	})
+
+	return mappings
+}
@@ -0,0 +1,27 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+func TestPathMappings(t *testing.T) {
+	configs := []config.PathMappingConfig{
+		{Branches: []string{"release-1.*"}, Paths: map[string]string{"src/": "lib/", "src/api/": "api/"}},
+		{Branches: []string{"release-1.x"}, Paths: map[string]string{"src/": "source/", "docs/": "doc/"}},
+		{Branches: []string{"release-2.x"}, Paths: map[string]string{"cmd/": "bin/"}},
+	}
+
+	assert.Equal(t, []git.PathMapping{
+		{From: "src/api/", To: "api/"},
+		{From: "docs/", To: "doc/"},
+		{From: "src/", To: "lib/"},
+	}, pathMappings(configs, "release-1.x"))
+
+	assert.Equal(t, []git.PathMapping{{From: "cmd/", To: "bin/"}}, pathMappings(configs, "release-2.x"))
+	assert.Empty(t, pathMappings(configs, "main"))
+}
@@ -0,0 +1,98 @@
+package backport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/pkg/schema"
+)
+
+// pendingFile is the name of the pending backport state file inside the git directory.
+const pendingFile = "backporter-pending.json"
+
+// PendingBackport describes a backport that stopped on cherry-pick conflicts.
+type PendingBackport struct {
+	OriginalSHA    string `json:"original_sha"`
+	TargetBranch   string `json:"target_branch"`
+	TargetSHA      string `json:"target_sha"`                // Target branch HEAD before the cherry-pick
+	OriginalBranch string `json:"original_branch,omitempty"` // Branch (or detached HEAD commit) to return to once finished
+	PRNumber       int    `json:"pr_number,omitempty"`
+	CreatePR       bool   `json:"create_pr,omitempty"` // Open a backport PR once finished
+	Base           string `json:"base,omitempty"`      // Ref to create the backport PR branch from
+}
+
+// pendingPath returns the path of the pending backport state file.
+func pendingPath() (string, error) {
+	return git.GitPath(pendingFile)
+}
+
+// SavePending stores the pending backport of the current repository.
+func SavePending(pending *PendingBackport) error {
+	path, err := pendingPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save pending backport: %w", err)
+	}
+	return nil
+}
+
+// LoadPending returns the pending backport of the current repository, or nil if there is none.
+func LoadPending() (*PendingBackport, error) {
+	path, err := pendingPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending backport: %w", err)
+	}
+
+	if err := schema.Validate(schema.Pending, data); err != nil {
+		return nil, fmt.Errorf("invalid pending backport %s: %w", path, err)
+	}
+
+	var pending PendingBackport
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("failed to parse pending backport: %w", err)
+	}
+	return &pending, nil
+}
+
+// UpdatePending modifies the pending backport, if any.
+func UpdatePending(update func(p *PendingBackport)) error {
+	pending, err := LoadPending()
+	if err != nil || pending == nil {
+		return err
+	}
+
+	update(pending)
+	return SavePending(pending)
+}
+
+// ClearPending removes the pending backport.
+func ClearPending() error {
+	path, err := pendingPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear pending backport: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package backport
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// setupConflictRepo creates a repository where backporting HEAD of main onto "target" conflicts.
+func setupConflictRepo(t *testing.T) (string, string) {
+	t.Helper()
+
+	repoPath := t.TempDir()
+	t.Chdir(repoPath)
+
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte(content), 0o644))
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.name", "Test User")
+	run("config", "user.email", "test@example.com")
+	write("base\n")
+	run("add", "file.txt")
+	run("commit", "-q", "-m", "Initial commit")
+	run("branch", "target")
+	write("main change\n")
+	run("commit", "-q", "-am", "Change on main")
+
+	sha, err := git.GetCurrentCommitSHA()
+	require.NoError(t, err)
+
+	run("checkout", "-q", "target")
+	write("target change\n")
+	run("commit", "-q", "-am", "Change on target")
+	run("checkout", "-q", "main")
+
+	return repoPath, sha
+}
+
+func TestPendingRoundTrip(t *testing.T) {
+	setupConflictRepo(t)
+
+	pending, err := LoadPending()
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+
+	require.NoError(t, SavePending(&PendingBackport{OriginalSHA: "abc", TargetBranch: "target"}))
+	require.NoError(t, UpdatePending(func(p *PendingBackport) { p.PRNumber = 42 }))
+
+	pending, err = LoadPending()
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, "abc", pending.OriginalSHA)
+	assert.Equal(t, 42, pending.PRNumber)
+
+	require.NoError(t, ClearPending())
+	pending, err = LoadPending()
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+}
+
+func TestContinueBackport(t *testing.T) {
+	repoPath, sha := setupConflictRepo(t)
+
+	repo, err := git.Open(repoPath)
+	require.NoError(t, err)
+	service := NewService(repo, nil, &config.Config{}, "owner", "repo")
+
+	result, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	require.True(t, result.HasConflict)
+
+	// Nothing to continue while conflicts are unresolved.
+	_, _, err = service.ContinueBackport(context.Background())
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("resolved\n"), 0o644))
+	require.NoError(t, exec.Command("git", "add", "file.txt").Run())
+
+	result, pending, err := service.ContinueBackport(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "target", pending.TargetBranch)
+
+	message, err := git.GetCommitMessage("target")
+	require.NoError(t, err)
+	assert.Contains(t, message, version.TrailerBackportedFrom+": "+sha)
+
+	// Back on the original branch, without pending state.
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	pending, err = LoadPending()
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+}
+
+func TestAbortBackport(t *testing.T) {
+	repoPath, sha := setupConflictRepo(t)
+
+	repo, err := git.Open(repoPath)
+	require.NoError(t, err)
+	service := NewService(repo, nil, &config.Config{}, "owner", "repo")
+
+	before, err := repo.GetCommitSHA("target")
+	require.NoError(t, err)
+
+	result, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	require.True(t, result.HasConflict)
+	assert.Equal(t, []string{"file.txt"}, git.ConflictedFiles())
+
+	pending, err := service.AbortBackport(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, sha, pending.OriginalSHA)
+	assert.False(t, git.CherryPickInProgress())
+
+	// The target is untouched and we are back on the original branch.
+	after, err := repo.GetCommitSHA("target")
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	branch, err := repo.CurrentBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	_, err = service.AbortBackport(context.Background())
+	assert.Error(t, err)
+}
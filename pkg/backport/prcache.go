@@ -0,0 +1,132 @@
+package backport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// prCacheFile is the name of the PR cache, stored next to the backport history.
+const prCacheFile = "prs.json"
+
+// CachedPR is a PR as returned by the forge at the time it was cached.
+type CachedPR struct {
+	PR       *forge.PRInfo `json:"pr"`
+	CachedAt time.Time     `json:"cached_at"`
+}
+
+// PRCache persists the forge responses for merged PRs, so that retried CI runs can proceed
+// from cached data while the forge is unavailable.
+type PRCache struct {
+	path    string
+	entries map[string]CachedPR
+}
+
+// NewPRCache creates a PR cache next to the backport history at historyPath,
+// or in the default cache directory if historyPath is empty.
+func NewPRCache(historyPath string) *PRCache {
+	dir := CacheDir(historyPath)
+	if dir == "" {
+		return &PRCache{entries: map[string]CachedPR{}}
+	}
+
+	cache := &PRCache{
+		path:    filepath.Join(dir, prCacheFile),
+		entries: map[string]CachedPR{},
+	}
+	_ = cache.load()
+
+	return cache
+}
+
+// CacheDir returns the directory of the backport history at historyPath, or the default
+// cache directory if historyPath is empty. It is empty if no home directory is found.
+func CacheDir(historyPath string) string {
+	if historyPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(home, ".cache", "backporter")
+	}
+	if abs, err := filepath.Abs(historyPath); err == nil {
+		return filepath.Dir(abs)
+	}
+	return filepath.Dir(historyPath)
+}
+
+// prCacheKey returns the key of a PR in the cache.
+func prCacheKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// load loads the cache from disk.
+func (c *PRCache) load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, &c.entries)
+}
+
+// save saves the cache to disk.
+func (c *PRCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// Get returns the cached PR of a repository, if there is one.
+func (c *PRCache) Get(owner, repo string, number int) (CachedPR, bool) {
+	entry, ok := c.entries[prCacheKey(owner, repo, number)]
+	return entry, ok && entry.PR != nil
+}
+
+// Rename moves the cached PRs of a repository that was renamed or transferred.
+func (c *PRCache) Rename(oldOwner, oldRepo, newOwner, newRepo string) error {
+	oldPrefix := oldOwner + "/" + oldRepo + "#"
+	moved := false
+	for key, entry := range c.entries {
+		if !strings.HasPrefix(key, oldPrefix) || entry.PR == nil {
+			continue
+		}
+		delete(c.entries, key)
+		c.entries[prCacheKey(newOwner, newRepo, entry.PR.Number)] = entry
+		moved = true
+	}
+
+	if !moved {
+		return nil
+	}
+	return c.save()
+}
+
+// Put caches a PR of a repository.
+func (c *PRCache) Put(owner, repo string, pr *forge.PRInfo) error {
+	c.entries[prCacheKey(owner, repo, pr.Number)] = CachedPR{PR: pr, CachedAt: time.Now()}
+	return c.save()
+}
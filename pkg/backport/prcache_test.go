@@ -0,0 +1,53 @@
+package backport
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestPRCachePutAndGet(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+
+	cache := NewPRCache(historyPath)
+	_, ok := cache.Get("owner", "repo", 42)
+	assert.False(t, ok)
+
+	pr := &forge.PRInfo{Number: 42, Title: "fix: bug", MergeCommit: "abc123", Labels: []string{"backport"}}
+	require.NoError(t, cache.Put("owner", "repo", pr))
+	assert.FileExists(t, filepath.Join(filepath.Dir(historyPath), "prs.json"))
+
+	// Reload from disk.
+	cached, ok := NewPRCache(historyPath).Get("owner", "repo", 42)
+	require.True(t, ok)
+	assert.Equal(t, pr, cached.PR)
+	assert.False(t, cached.CachedAt.IsZero())
+
+	// PRs are cached per repository.
+	_, ok = cache.Get("owner", "other", 42)
+	assert.False(t, ok)
+}
+
+func TestPRCacheRename(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+
+	cache := NewPRCache(historyPath)
+	require.NoError(t, cache.Put("owner", "repo", &forge.PRInfo{Number: 1}))
+	require.NoError(t, cache.Put("owner", "repo-other", &forge.PRInfo{Number: 2}))
+
+	require.NoError(t, cache.Rename("owner", "repo", "new-owner", "new-repo"))
+
+	reloaded := NewPRCache(historyPath)
+	_, ok := reloaded.Get("owner", "repo", 1)
+	assert.False(t, ok)
+	_, ok = reloaded.Get("new-owner", "new-repo", 1)
+	assert.True(t, ok)
+
+	// Repositories sharing the name prefix are left alone.
+	_, ok = reloaded.Get("owner", "repo-other", 2)
+	assert.True(t, ok)
+}
@@ -0,0 +1,61 @@
+package backport
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// maxPushRetries is how often a push rejected because the remote branch moved is retried.
+const maxPushRetries = 3
+
+// pushRetryDelay is the delay before the first retry of a rejected push, doubled for each further
+// one and jittered, so concurrent jobs pushing to the same branch do not collide again.
+var pushRetryDelay = time.Second
+
+// PushBranch pushes a backport branch to remote. Forges that create PRs from pushes for
+// review (Gerrit) get it pushed to refs/for/<target> instead of as a branch. A backport pushed
+// directly to its target branch is rebased and pushed again if the target moved, see RetryRejectedPush.
+func PushBranch(f forge.Forge, remote, branch, target string) error {
+	if f != nil && f.Capabilities().PushForReview {
+		return git.PushForReview(remote, branch, target)
+	}
+	if branch == target {
+		return RetryRejectedPush(branch,
+			func() error { return git.Push(remote, branch) },
+			func() error { return git.RebaseOnRemote(remote, branch) },
+		)
+	}
+	return git.Push(remote, branch)
+}
+
+// RetryRejectedPush runs push and, while it fails with git.ErrPushRejected because the remote
+// branch moved since it was fetched, rebases the branch onto the remote branch with rebase and
+// pushes again, at most maxPushRetries times. This keeps backports to busy release branches from
+// failing on other pushes in between.
+func RetryRejectedPush(branch string, push, rebase func() error) error {
+	delay := pushRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := push()
+		if err == nil || !errors.Is(err, git.ErrPushRejected) {
+			return err
+		}
+		if attempt == maxPushRetries {
+			return fmt.Errorf("%s kept moving, gave up after %d pushes: %w", branch, maxPushRetries+1, err)
+		}
+
+		log.Info().Str("branch", branch).Int("attempt", attempt+1).Msg("push rejected, the branch moved - rebasing onto it and retrying")
+		time.Sleep(delay/2 + rand.N(delay/2+1)) //nolint:gosec // Jitter needs no cryptographic randomness
+		delay *= 2
+
+		if err := rebase(); err != nil {
+			return fmt.Errorf("failed to rebase %s onto the moved branch: %w", branch, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package backport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+func TestRetryRejectedPush(t *testing.T) {
+	pushRetryDelay = 0
+	t.Cleanup(func() { pushRetryDelay = time.Second })
+
+	rejected := fmt.Errorf("failed to push release to origin: %w", git.ErrPushRejected)
+
+	t.Run("rebases and retries", func(t *testing.T) {
+		pushes, rebases := 0, 0
+		err := RetryRejectedPush("release",
+			func() error {
+				pushes++
+				if pushes < 3 {
+					return rejected
+				}
+				return nil
+			},
+			func() error { rebases++; return nil },
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 3, pushes)
+		assert.Equal(t, 2, rebases)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		pushes := 0
+		err := RetryRejectedPush("release", func() error { pushes++; return rejected }, func() error { return nil })
+		require.ErrorIs(t, err, git.ErrPushRejected)
+		assert.Equal(t, maxPushRetries+1, pushes)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		pushes := 0
+		err := RetryRejectedPush("release", func() error { pushes++; return errors.New("remote rejected") }, func() error { return nil })
+		require.EqualError(t, err, "remote rejected")
+		assert.Equal(t, 1, pushes)
+	})
+
+	t.Run("failed rebase", func(t *testing.T) {
+		err := RetryRejectedPush("release", func() error { return rejected }, func() error { return errors.New("conflict") })
+		require.ErrorContains(t, err, "failed to rebase release onto the moved branch: conflict")
+	})
+}
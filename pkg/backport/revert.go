@@ -0,0 +1,75 @@
+package backport
+
+import (
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// revertPattern matches the line `git revert` adds to the message of a revert commit.
+var revertPattern = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{7,40})\b`)
+
+// RevertedSHA returns the commit that a revert commit reverts according to its message,
+// or an empty SHA if the message is not the one of a revert.
+func RevertedSHA(message string) string {
+	if m := revertPattern.FindStringSubmatch(message); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// CheckRevert returns a *RevertOfMissingError if sha reverts a commit that never landed on the
+// target, whose ref is targetRef: it is neither part of the target history nor backported to it
+// according to the history, backport signatures, cherry-pick trailers and patch-ids.
+// Commits that are no reverts, or revert a commit that is not available locally, pass.
+// Only *RevertOfMissingError is returned.
+func (s *Service) CheckRevert(sha, targetBranch, targetRef string) error {
+	message, err := s.repo.GetCommitMessage(sha)
+	if err != nil {
+		log.Debug().Err(err).Str("sha", sha).Msg("failed to get commit message, not checking for a revert")
+		return nil
+	}
+	reverted := RevertedSHA(message)
+	if reverted == "" {
+		return nil
+	}
+
+	fullReverted, err := s.repo.GetCommitSHA(reverted)
+	if err != nil {
+		log.Debug().Err(err).Str("sha", reverted).Msg("reverted commit not available, not checking where it landed")
+		return nil
+	}
+
+	if landed := s.LandedOn(fullReverted, targetBranch, targetRef); landed != "" {
+		log.Debug().Str("sha", fullReverted).Str("branch", targetBranch).Str("source", landed).Msg("reverted commit is on target branch")
+		return nil
+	}
+
+	return &RevertOfMissingError{RevertSHA: sha, RevertedSHA: fullReverted, Branch: targetBranch}
+}
+
+// LandedOn returns how sha was found on the target, whose ref is targetRef: "ancestor", "cache",
+// "signature", "cherry-pick trailer" or "patch-id". It returns "" if sha never landed there.
+func (s *Service) LandedOn(sha, targetBranch, targetRef string) string {
+	if contained, err := git.IsAncestor(sha, targetRef); err == nil && contained {
+		return sourceAncestor
+	}
+
+	if found, source := s.findDuplicate(sha, targetBranch, targetRef); found != "" {
+		return source
+	}
+
+	// Backports made by hand carry neither a signature nor a trailer.
+	patchID, err := git.PatchID(sha)
+	if err != nil {
+		log.Debug().Err(err).Str("sha", sha).Msg("failed to compute patch-id")
+		return ""
+	}
+	if findByPatchID(sha, patchID, targetRef) != "" {
+		return sourcePatchID
+	}
+
+	return ""
+}
@@ -0,0 +1,57 @@
+package backport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+func TestRevertedSHA(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Revert \"Fix bug\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567.\n", "0123456789abcdef0123456789abcdef01234567"},
+		{"Revert \"Merge PR\"\n\nThis reverts commit abcdef1, reversing\nchanges made to 1234567.\n", "abcdef1"},
+		{"fix: crash (#12)\n\n* Revert \"Fix bug\"\n\nThis reverts commit fedcba9876543210.\n", "fedcba9876543210"},
+		{"Fix bug\n\nSee: This reverts commit abcdef1.\n", ""},
+		{"Fix bug", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, RevertedSHA(tt.message), tt.message)
+	}
+}
+
+func TestBackportCommitRefusesRevertOfMissingChange(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+
+	run("revert", "--no-edit", sha)
+	revertSHA, err := git.GetCurrentCommitSHA()
+	require.NoError(t, err)
+
+	_, err = service.BackportCommit(context.Background(), revertSHA, BackportOptions{TargetBranch: "target"})
+	var revertErr *RevertOfMissingError
+	require.ErrorAs(t, err, &revertErr)
+	assert.Equal(t, sha, revertErr.RevertedSHA)
+	assert.Equal(t, "target", revertErr.Branch)
+
+	// A change backported by hand is found by its patch-id.
+	run("checkout", "-q", "target")
+	run("cherry-pick", sha)
+	run("checkout", "-q", "main")
+
+	result, err := service.BackportCommit(context.Background(), revertSHA, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestCheckRevertPassesNonReverts(t *testing.T) {
+	service, sha, _ := setupUndoRepo(t)
+
+	assert.NoError(t, service.CheckRevert(sha, "target", "target"))
+}
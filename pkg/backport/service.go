@@ -3,6 +3,7 @@ package backport
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -19,31 +20,135 @@ type Service struct {
 	forge  forge.Forge
 	config *config.Config
 	cache  *Cache
+	audit  *AuditLog
 	owner  string
 	repoN  string
+
+	// Repository backport branches are pushed to and backport PRs are opened in,
+	// the repository of the push remote. Defaults to owner/repoN.
+	pushOwner string
+	pushRepoN string
 }
 
 // NewService creates a new backport service.
 func NewService(repo *git.Repository, f forge.Forge, cfg *config.Config, owner, repoName string) *Service {
 	cachePath := cfg.Cache.Path
+	extraPaths := cfg.Cache.ExtraPaths
 	if !cfg.Cache.Enabled {
 		cachePath = ""
+		extraPaths = nil
 	}
 
+	var audit *AuditLog
+	if cfg.Audit.Enabled {
+		audit = NewAuditLog(cfg.Audit.Path, AuditActor())
+	}
 	return &Service{
 		repo:   repo,
 		forge:  f,
 		config: cfg,
-		cache:  NewCache(cachePath),
+		cache:  NewCache(cachePath, extraPaths...),
+		audit:  audit,
 		owner:  owner,
 		repoN:  repoName,
+
+		pushOwner: owner,
+		pushRepoN: repoName,
 	}
 }
 
+// SetPushRepo sets the repository of the push remote, if it differs from the one PRs are read from.
+func (s *Service) SetPushRepo(owner, repoName string) {
+	s.pushOwner = owner
+	s.pushRepoN = repoName
+}
+
+// Repo returns the owner and name of the repository PRs are read from.
+func (s *Service) Repo() (string, string) {
+	return s.owner, s.repoN
+}
+
+// PushRepo returns the owner and name of the repository backport PRs are opened in.
+func (s *Service) PushRepo() (string, string) {
+	return s.pushOwner, s.pushRepoN
+}
+
 // BackportOptions contains options for backport operations.
 type BackportOptions struct {
 	TargetBranch string
 	DryRun       bool
+
+	// Empty controls how commits that become empty on the target are handled
+	// ("drop", "keep" or "stop", see git cherry-pick --empty).
+	Empty string
+
+	// KeepRedundantCommits keeps commits that become empty on the target.
+	KeepRedundantCommits bool
+
+	// UseWorktree runs the backport in a temporary git worktree instead of
+	// switching branches in the current working copy.
+	UseWorktree bool
+
+	// Force backports commits that were already backported to the target branch,
+	// only warning about the duplicate.
+	Force bool
+
+	// SelectHunks lets the user pick the hunks of each cherry-picked commit to keep (like git add -p),
+	// for changes that only partly apply to the target.
+	SelectHunks bool
+
+	// Strategy controls how PRs that were not squash merged are backported
+	// ("squash", "mainline" or "commits", see the Strategy constants). Defaults to "commits".
+	Strategy string
+
+	// MergeStrategy is the merge strategy of the cherry-picks ("ort", "recursive" or "resolve").
+	MergeStrategy string
+
+	// StrategyOption are options of the merge strategy, e.g. "theirs" or "ignore-space-change",
+	// to resolve trivial conflicts automatically (see git cherry-pick -X).
+	StrategyOption []string
+
+	// Paths limits the backport to the changes of files matching these glob patterns,
+	// e.g. "src/server/**". Commits changing none of them are dropped as empty.
+	Paths []string
+
+	// mainline is the parent number used when cherry-picking a merge commit.
+	mainline int
+
+	// patch is the mbox patch applied instead of cherry-picking the commit, see ApplyPatch.
+	patch []byte
+
+	// prNumber is the PR being backported, recorded in the Backport-of trailer.
+	prNumber int
+}
+
+// PR backport strategies for PRs merged via a merge commit.
+const (
+	StrategySquash   = "squash"   // Only backport squash merged PRs, refuse others
+	StrategyMainline = "mainline" // Cherry-pick the merge commit against its first parent (-m 1)
+	StrategyCommits  = "commits"  // Cherry-pick the individual PR commits in order (default)
+)
+
+// ValidateStrategy checks if the given PR backport strategy is supported.
+func ValidateStrategy(strategy string) error {
+	switch strategy {
+	case "", StrategySquash, StrategyMainline, StrategyCommits:
+		return nil
+	default:
+		return fmt.Errorf("invalid strategy: %s (must be 'squash', 'mainline' or 'commits')", strategy)
+	}
+}
+
+// cherryPickOptions returns the git cherry-pick options for these backport options.
+func (o BackportOptions) cherryPickOptions() git.CherryPickOptions {
+	return git.CherryPickOptions{
+		Empty:                o.Empty,
+		KeepRedundantCommits: o.KeepRedundantCommits,
+		Mainline:             o.mainline,
+		Strategy:             o.MergeStrategy,
+		StrategyOptions:      o.StrategyOption,
+		Paths:                o.Paths,
+	}
 }
 
 // BackportResult contains the result of a backport operation.
@@ -51,35 +156,53 @@ type BackportResult struct {
 	OriginalSHA  string
 	BackportSHA  string
 	TargetBranch string
+	TargetSHA    string // Target branch HEAD before the backport
 	PRNumber     int
 	Success      bool
 	HasConflict  bool
+	Empty        bool // True if the commit was dropped because it is already on the target
+	Aborted      bool // True if a conflicting cherry-pick was aborted (worktree mode)
 	Message      string
 }
 
 // BackportCommit backports a single commit to the target branch.
-func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOptions) (*BackportResult, error) {
+func (s *Service) BackportCommit(ctx context.Context, sha string, opts BackportOptions) (*BackportResult, error) {
+	result, err := s.backportCommit(ctx, sha, opts)
+	if !opts.DryRun {
+		s.recordAudit(AuditEntry{SourceSHA: sha, TargetBranch: opts.TargetBranch}, result, err)
+	}
+	return result, err
+}
+
+// backportCommit backports a single commit to the target branch without recording it in the audit log.
+func (s *Service) backportCommit(_ context.Context, sha string, opts BackportOptions) (*BackportResult, error) {
 	log.Debug().Str("sha", sha).Str("target", opts.TargetBranch).Msg("backporting commit")
 
-	// Verify the commit exists.
-	fullSHA, err := s.repo.GetCommitSHA(sha)
-	if err != nil {
-		return nil, fmt.Errorf("commit not found: %w", err)
+	if err := git.ValidateEmptyMode(opts.Empty); err != nil {
+		return nil, err
 	}
-
-	// Check for uncommitted changes.
-	hasChanges, err := s.repo.HasUncommittedChanges()
-	if err != nil {
-		return nil, fmt.Errorf("failed to check for uncommitted changes: %w", err)
+	if err := git.ValidateMergeStrategy(opts.MergeStrategy); err != nil {
+		return nil, err
 	}
-	if hasChanges {
-		return nil, fmt.Errorf("repository has uncommitted changes, please commit or stash them first")
+
+	// A patch carries its commit, which may only exist in another repository.
+	fullSHA := sha
+	if opts.patch == nil {
+		var err error
+		if fullSHA, err = s.ensureCommit(sha); err != nil {
+			return nil, err
+		}
 	}
 
-	// Store original branch.
-	originalBranch, err := s.repo.CurrentBranch()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	// Check for uncommitted changes, a worktree leaves the working copy alone.
+	if !opts.UseWorktree {
+		hasChanges, err := s.repo.HasUncommittedChanges()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for uncommitted changes: %w", err)
+		}
+		if hasChanges {
+			return nil, ErrUncommittedChanges
+		}
 	}
 
 	// Verify target branch exists.
@@ -87,8 +210,45 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 	if err != nil {
 		return nil, fmt.Errorf("failed to check target branch: %w", err)
 	}
+	targetRef := opts.TargetBranch
 	if !exists {
-		return nil, fmt.Errorf("target branch %s does not exist", opts.TargetBranch)
+		// Target branches only present on the remote (e.g. matched by a pattern) get a local branch.
+		targetRef = s.PushRemote() + "/" + opts.TargetBranch
+		if _, err := s.repo.GetCommitSHA(targetRef); err != nil {
+			return nil, &BranchNotFoundError{Branch: opts.TargetBranch, Remote: s.PushRemote()}
+		}
+		if !opts.DryRun {
+			if err := git.CreateBranchFrom(opts.TargetBranch, targetRef); err != nil {
+				return nil, err
+			}
+			log.Info().Str("branch", opts.TargetBranch).Str("from", targetRef).Msg("created local target branch from remote")
+			targetRef = opts.TargetBranch
+		}
+	}
+
+	// Shallow clones may lack the history needed to cherry-pick onto the target.
+	if opts.patch == nil {
+		deepened, err := git.EnsureMergeBase(s.config.Remote, fullSHA, targetRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch history for shallow clone: %w", err)
+		}
+		logDeepen(deepened, s.config.Remote)
+	}
+
+	if backportSHA, source := s.findDuplicate(fullSHA, opts.TargetBranch, targetRef); backportSHA != "" {
+		dup := &AlreadyBackportedError{OriginalSHA: fullSHA, BackportSHA: backportSHA, Branch: opts.TargetBranch, Source: source}
+		if !opts.Force {
+			return nil, dup
+		}
+		log.Warn().Err(dup).Msg("backporting again because of --force")
+	}
+
+	// Reverting a change that never reached the target makes no sense, and rarely applies.
+	if err := s.CheckRevert(fullSHA, opts.TargetBranch, targetRef); err != nil {
+		if !opts.Force {
+			return nil, err
+		}
+		log.Warn().Err(err).Msg("backporting the revert anyway because of --force")
 	}
 
 	if opts.DryRun {
@@ -101,32 +261,128 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 		}, nil
 	}
 
-	// Checkout target branch.
-	log.Debug().Str("branch", opts.TargetBranch).Msg("checking out target branch")
-	if err := git.CheckoutBranch(opts.TargetBranch); err != nil {
-		return nil, err
-	}
-
 	// Track whether we should return to original branch.
 	shouldCheckoutBack := true
+	var originalBranch string
+
+	if opts.UseWorktree {
+		// Check out the target branch in a temporary worktree.
+		log.Debug().Str("branch", opts.TargetBranch).Msg("creating worktree for target branch")
+		worktree, err := git.AddWorktree(opts.TargetBranch)
+		if err != nil {
+			return nil, err
+		}
+
+		// Always clean up the worktree, conflicts are aborted below.
+		defer func() {
+			if err := worktree.Remove(); err != nil {
+				log.Warn().Err(err).Str("path", worktree.Path).Msg("failed to remove worktree")
+			}
+		}()
 
-	// Ensure we return to original branch on error (unless conflict).
-	defer func() {
-		if shouldCheckoutBack && originalBranch != "" {
-			_ = git.CheckoutBranch(originalBranch)
+		if err := worktree.Enter(); err != nil {
+			return nil, err
 		}
-	}()
+	} else {
+		// Store original branch, or the commit of a detached HEAD (e.g. CI checkouts of a SHA).
+		originalBranch, err = s.repo.CurrentRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current branch: %w", err)
+		}
+
+		// Checkout target branch.
+		log.Debug().Str("branch", opts.TargetBranch).Msg("checking out target branch")
+		if err := git.CheckoutBranch(opts.TargetBranch); err != nil {
+			return nil, err
+		}
+
+		// Ensure we return to original branch on error (unless conflict).
+		defer func() {
+			if shouldCheckoutBack && originalBranch != "" {
+				_ = git.CheckoutBranch(originalBranch)
+			}
+		}()
+	}
 
-	// Perform cherry-pick.
-	log.Debug().Str("sha", fullSHA).Msg("cherry-picking commit")
-	result, err := git.CherryPick(fullSHA)
+	targetSHA, err := git.GetCurrentCommitSHA()
 	if err != nil {
 		return nil, err
 	}
 
+	// Perform cherry-pick, with the paths mapped for branches that predate a restructure.
+	pickOpts := opts.cherryPickOptions()
+	pickOpts.PathMappings = pathMappings(s.config.PathMappings, opts.TargetBranch)
+	pickOpts.Rerere = s.config.Rerere
+	pickOpts.Identity = CommitIdentity(s.config)
+	var result *git.CherryPickResult
+	switch {
+	case opts.patch != nil:
+		log.Debug().Str("sha", fullSHA).Msg("applying patch")
+	case len(pickOpts.Paths) > 0:
+		log.Debug().Str("sha", fullSHA).Strs("paths", pickOpts.Paths).Msg("applying commit limited to the given paths")
+	case len(pickOpts.PathMappings) > 0:
+		log.Debug().Str("sha", fullSHA).Int("mappings", len(pickOpts.PathMappings)).Msg("applying commit with mapped paths")
+	default:
+		log.Debug().Str("sha", fullSHA).Msg("cherry-picking commit")
+	}
+	if opts.patch != nil {
+		result, err = git.ApplyPatch(opts.patch, pickOpts)
+	} else {
+		result, err = git.CherryPickWithOptions(fullSHA, pickOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Resolved) > 0 {
+		log.Info().Strs("files", result.Resolved).Msg("resolved conflicts from recorded resolutions (rerere)")
+	}
+
+	if result.Empty {
+		log.Debug().Str("sha", fullSHA).Msg("commit became empty on target branch and was dropped")
+		message := "commit is already present on target branch, nothing to backport"
+		if len(opts.Paths) > 0 {
+			message = "the changes to the given paths are already present on target branch or there are none, nothing to backport"
+		}
+		return &BackportResult{
+			OriginalSHA:  fullSHA,
+			TargetBranch: opts.TargetBranch,
+			TargetSHA:    targetSHA,
+			Success:      true,
+			Empty:        true,
+			Message:      message,
+		}, nil
+	}
+
+	if result.HasConflict && opts.UseWorktree {
+		// The worktree is removed, so the conflict cannot be resolved there.
+		if err := git.AbortCherryPick(); err != nil {
+			log.Warn().Err(err).Msg("failed to abort cherry-pick in worktree")
+		}
+		return &BackportResult{
+			OriginalSHA:  fullSHA,
+			TargetBranch: opts.TargetBranch,
+			Success:      false,
+			HasConflict:  true,
+			Aborted:      true,
+			Message:      result.Message,
+		}, nil
+	}
+
 	if result.HasConflict {
 		// Don't switch back to original branch - user needs to resolve conflicts.
 		shouldCheckoutBack = false
+
+		// Remember the backport so it can be finished with "backport continue".
+		pending := &PendingBackport{
+			OriginalSHA:    fullSHA,
+			TargetBranch:   opts.TargetBranch,
+			TargetSHA:      targetSHA,
+			OriginalBranch: originalBranch,
+		}
+		if err := SavePending(pending); err != nil {
+			log.Warn().Err(err).Msg("failed to save pending backport")
+		}
+
 		return &BackportResult{
 			OriginalSHA:  fullSHA,
 			TargetBranch: opts.TargetBranch,
@@ -136,22 +392,90 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 		}, nil
 	}
 
+	if opts.SelectHunks {
+		kept, err := git.SelectHunks()
+		if err != nil {
+			return nil, err
+		}
+		if !kept {
+			return &BackportResult{
+				OriginalSHA:  fullSHA,
+				TargetBranch: opts.TargetBranch,
+				TargetSHA:    targetSHA,
+				Success:      true,
+				Empty:        true,
+				Message:      "no hunks selected, nothing to backport",
+			}, nil
+		}
+	}
+
+	signed, err := s.signBackport(fullSHA, opts.TargetBranch, opts.prNumber)
+	if err != nil {
+		return nil, err
+	}
+	signed.TargetSHA = targetSHA
+	if len(result.Resolved) > 0 {
+		signed.Message += fmt.Sprintf(", conflicts in %s resolved from recorded resolutions", strings.Join(result.Resolved, ", "))
+	}
+
+	return signed, nil
+}
+
+// CommitIdentity returns the identity backport commits are created with according to the
+// commit_identity setting, nil to keep the original author and git's default committer.
+func CommitIdentity(cfg *config.Config) *git.Identity {
+	switch cfg.CommitIdentity {
+	case config.IdentityCommitter:
+		return &git.Identity{Name: cfg.AuthorName, Email: cfg.AuthorEmail}
+	case config.IdentityReauthor:
+		return &git.Identity{Name: cfg.AuthorName, Email: cfg.AuthorEmail, Reauthor: true}
+	default:
+		return nil
+	}
+}
+
+// ensureCommit returns the full SHA of a commit, fetching it if it is not available locally
+// (e.g. shallow clones).
+func (s *Service) ensureCommit(sha string) (string, error) {
+	fetched, fetchErr := git.EnsureCommit(s.config.Remote, sha)
+	if fetchErr != nil {
+		log.Debug().Err(fetchErr).Str("sha", sha).Msg("commit not available locally")
+	} else if fetched {
+		log.Info().Str("sha", sha).Str("remote", s.config.Remote).Msg("fetched missing commit from remote")
+	}
+
+	// Verify the commit exists. If it could not be fetched, the fetch failure tells why.
+	fullSHA, err := s.repo.GetCommitSHA(sha)
+	if err != nil {
+		if fetchErr != nil {
+			return "", fmt.Errorf("commit not found: %w", fetchErr)
+		}
+		return "", fmt.Errorf("commit not found: %w", err)
+	}
+	return fullSHA, nil
+}
+
+// signBackport amends the freshly cherry-picked HEAD commit with the backport trailers
+// and records it in the cache. prNumber is 0 for commits backported on their own.
+func (s *Service) signBackport(fullSHA, targetBranch string, prNumber int) (*BackportResult, error) {
 	// Get the new commit SHA.
 	newSHA, err := git.GetCurrentCommitSHA()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get new commit SHA: %w", err)
 	}
 
-	// Amend commit message with backport signature.
+	// Amend commit message with the backport trailers.
 	originalMessage, err := s.repo.GetCommitMessage(newSHA)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get commit message: %w", err)
 	}
 
-	signature := version.SignatureMessage(fullSHA)
-	newMessage := fmt.Sprintf("%s\n\n%s", originalMessage, signature)
+	newMessage, err := git.AddTrailers(originalMessage, version.SignatureTrailers(fullSHA, prNumber))
+	if err != nil {
+		return nil, err
+	}
 
-	if err := git.AmendCommitMessage(newMessage); err != nil {
+	if err := git.AmendCommitMessageAs(newMessage, CommitIdentity(s.config)); err != nil {
 		return nil, fmt.Errorf("failed to amend commit message: %w", err)
 	}
 
@@ -166,7 +490,7 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 		entry := CacheEntry{
 			OriginalSHA:  fullSHA,
 			BackportSHA:  finalSHA,
-			TargetBranch: opts.TargetBranch,
+			TargetBranch: targetBranch,
 			Timestamp:    time.Now(),
 			Message:      originalMessage,
 		}
@@ -180,7 +504,7 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 	return &BackportResult{
 		OriginalSHA:  fullSHA,
 		BackportSHA:  finalSHA,
-		TargetBranch: opts.TargetBranch,
+		TargetBranch: targetBranch,
 		Success:      true,
 		Message:      "commit successfully backported",
 	}, nil
@@ -188,45 +512,378 @@ func (s *Service) BackportCommit(_ context.Context, sha string, opts BackportOpt
 
 // BackportPR backports a PR's merge commit to the target branch.
 func (s *Service) BackportPR(ctx context.Context, prNumber int, opts BackportOptions) (*BackportResult, error) {
-	if s.forge == nil {
-		return nil, fmt.Errorf("forge not configured, cannot backport PR")
+	result, err := s.backportPR(ctx, prNumber, opts)
+	if !opts.DryRun {
+		s.recordAudit(AuditEntry{SourcePR: prNumber, TargetBranch: opts.TargetBranch}, result, err)
 	}
+	return result, err
+}
 
+// backportPR backports a PR's merge commit to the target branch without recording it in the audit log.
+func (s *Service) backportPR(ctx context.Context, prNumber int, opts BackportOptions) (*BackportResult, error) {
 	log.Debug().Int("pr", prNumber).Str("target", opts.TargetBranch).Msg("backporting PR")
 
 	// Fetch PR information.
-	prInfo, err := s.forge.GetPR(ctx, s.owner, s.repoN, prNumber)
+	prInfo, err := s.GetPR(ctx, prNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if PR was squash merged.
-	if !prInfo.IsSquashMerge() {
-		return nil, fmt.Errorf("PR #%d was not squash merged - please backport individual commits instead", prNumber)
+	if err := ValidateStrategy(opts.Strategy); err != nil {
+		return nil, err
 	}
 
-	// Backport the merge commit.
-	result, err := s.BackportCommit(ctx, prInfo.MergeCommit, opts)
+	opts.prNumber = prNumber
+
+	var result *BackportResult
+	switch {
+	case prInfo.IsSquashMerge():
+		result, err = s.backportCommit(ctx, prInfo.MergeCommit, opts)
+	case opts.Strategy == StrategyMainline:
+		opts.mainline = 1
+		result, err = s.backportCommit(ctx, prInfo.MergeCommit, opts)
+	case opts.Strategy == StrategySquash:
+		return nil, fmt.Errorf("PR #%d was %w", prNumber, ErrNotSquashed)
+	default:
+		result, err = s.backportPRCommits(ctx, prInfo, opts)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	result.PRNumber = prNumber
 
+	if result.HasConflict && !result.Aborted {
+		if err := UpdatePending(func(p *PendingBackport) { p.PRNumber = prNumber }); err != nil {
+			log.Warn().Err(err).Msg("failed to update pending backport")
+		}
+	}
+
 	// Update cache with PR number.
 	if s.cache != nil && s.config.Cache.Enabled && result.Success {
-		entries := s.cache.FindByOriginalSHA(result.OriginalSHA)
-		if len(entries) > 0 {
-			// Update the last entry with PR number.
-			lastIdx := len(s.cache.entries) - 1
-			s.cache.entries[lastIdx].PRNumber = prNumber
-			_ = s.cache.save()
-		}
+		s.setCachedPRNumber(result.OriginalSHA, prNumber)
 	}
 
 	return result, nil
 }
 
+// backportPRCommits backports the individual commits of a PR merged via a merge commit in
+// order, keeping their authors. It stops at the first commit that cannot be applied cleanly.
+func (s *Service) backportPRCommits(ctx context.Context, pr *forge.PRInfo, opts BackportOptions) (*BackportResult, error) {
+	mergeSHA, prNumber := pr.MergeCommit, pr.Number
+
+	// The commits are only reachable through the merge commit.
+	if fetched, err := git.EnsureCommit(s.config.Remote, mergeSHA); err != nil {
+		log.Debug().Err(err).Str("sha", mergeSHA).Msg("merge commit not available locally")
+	} else if fetched {
+		log.Info().Str("sha", mergeSHA).Str("remote", s.config.Remote).Msg("fetched missing merge commit from remote")
+	}
+
+	commits, err := s.prCommits(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("PR #%d has no commits to backport", prNumber)
+	}
+
+	var last *BackportResult
+	var targetSHA string
+	picked := 0
+	for _, sha := range commits {
+		result, err := s.backportCommit(ctx, sha, opts)
+		if err != nil {
+			return nil, err
+		}
+		if targetSHA == "" {
+			targetSHA = result.TargetSHA
+		}
+		if !result.Success {
+			return result, nil
+		}
+
+		if s.cache != nil && s.config.Cache.Enabled && !result.Empty && !opts.DryRun {
+			s.setCachedPRNumber(result.OriginalSHA, prNumber)
+		}
+		if !result.Empty {
+			last = result
+			picked++
+		}
+	}
+
+	if last == nil {
+		return &BackportResult{
+			OriginalSHA:  mergeSHA,
+			TargetBranch: opts.TargetBranch,
+			Success:      true,
+			Empty:        true,
+			Message:      "all commits are already present on target branch, nothing to backport",
+		}, nil
+	}
+
+	message := fmt.Sprintf("%d commits successfully backported", picked)
+	if opts.DryRun {
+		message = fmt.Sprintf("dry-run: would backport %d commits", picked)
+	}
+
+	return &BackportResult{
+		OriginalSHA:  mergeSHA,
+		BackportSHA:  last.BackportSHA,
+		TargetBranch: opts.TargetBranch,
+		TargetSHA:    targetSHA,
+		Success:      true,
+		Message:      message,
+	}, nil
+}
+
+// prCommits returns the non-merge commits of a PR, oldest first: the commit list of the
+// forge, or the commits its merge commit brought in if the forge cannot list them.
+func (s *Service) prCommits(ctx context.Context, pr *forge.PRInfo) ([]string, error) {
+	if s.forge != nil {
+		commits, err := s.forge.ListPRCommits(ctx, s.owner, s.repoN, pr.Number)
+		if err == nil {
+			var shas []string
+			for _, commit := range commits {
+				// Merges of the base branch into the PR are not part of its changes.
+				if len(commit.Parents) <= 1 {
+					shas = append(shas, commit.SHA)
+				}
+			}
+			return shas, nil
+		}
+		log.Warn().Err(err).Int("pr", pr.Number).Msg("failed to list PR commits, using the commits of the merge commit")
+	}
+	return git.ListMergedCommits(pr.MergeCommit)
+}
+
+// logDeepen reports what was fetched to complete the history of a shallow clone.
+func logDeepen(result *git.DeepenResult, remote string) {
+	switch {
+	case result.Unshallowed:
+		log.Info().Str("remote", remote).Msg("shallow clone: fetched full history to find merge base")
+	case result.Deepened > 0:
+		log.Info().Str("remote", remote).Int("commits", result.Deepened).Msg("shallow clone: deepened history to find merge base")
+	}
+}
+
+// setCachedPRNumber records the PR number on the most recent cache entry for a commit.
+func (s *Service) setCachedPRNumber(originalSHA string, prNumber int) {
+	// Entries of the extra history files are read-only, only local entries are updated.
+	lastIdx := len(s.cache.entries) - 1
+	if lastIdx < 0 || len(s.cache.FindByOriginalSHA(originalSHA)) == 0 {
+		return
+	}
+	// Update the last entry with PR number.
+	s.cache.entries[lastIdx].PRNumber = prNumber
+	_ = s.cache.save()
+}
+
+// ContinueBackport finishes a backport that stopped on cherry-pick conflicts once they are resolved:
+// it continues the cherry-pick, adds the backport trailers and records the cache entry.
+// With the "commits" strategy only the conflicting commit is finished.
+func (s *Service) ContinueBackport(_ context.Context) (*BackportResult, *PendingBackport, error) {
+	pending, err := LoadPending()
+	if err != nil {
+		return nil, nil, err
+	}
+	if pending == nil {
+		return nil, nil, fmt.Errorf("no interrupted backport found")
+	}
+
+	if git.CherryPickInProgress() {
+		log.Debug().Str("sha", pending.OriginalSHA).Msg("continuing cherry-pick")
+		if err := git.ContinueCherryPick(); err != nil {
+			return nil, nil, fmt.Errorf("%w (resolve and stage all conflicts first)", err)
+		}
+	} else {
+		// The cherry-pick was either committed or aborted by hand.
+		head, err := git.GetCurrentCommitSHA()
+		if err != nil {
+			return nil, nil, err
+		}
+		if head == pending.TargetSHA {
+			_ = ClearPending()
+			return nil, nil, fmt.Errorf("cherry-pick of %s was aborted, nothing to continue", pending.OriginalSHA)
+		}
+	}
+
+	result, err := s.signBackport(pending.OriginalSHA, pending.TargetBranch, pending.PRNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.TargetSHA = pending.TargetSHA
+	result.PRNumber = pending.PRNumber
+
+	if pending.PRNumber > 0 && s.cache != nil && s.config.Cache.Enabled {
+		s.setCachedPRNumber(result.OriginalSHA, pending.PRNumber)
+	}
+
+	if err := ClearPending(); err != nil {
+		log.Warn().Err(err).Msg("failed to clear pending backport")
+	}
+	s.recordAudit(AuditEntry{SourcePR: pending.PRNumber, TargetBranch: pending.TargetBranch}, result, nil)
+
+	if pending.OriginalBranch != "" && pending.OriginalBranch != pending.TargetBranch {
+		if err := git.CheckoutBranch(pending.OriginalBranch); err != nil {
+			log.Warn().Err(err).Str("branch", pending.OriginalBranch).Msg("failed to return to original branch")
+		}
+	}
+
+	return result, pending, nil
+}
+
+// AbortBackport gives up a backport that stopped on cherry-pick conflicts: it aborts the
+// cherry-pick, forgets the pending backport and returns to the original branch.
+func (s *Service) AbortBackport(_ context.Context) (*PendingBackport, error) {
+	pending, err := LoadPending()
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, fmt.Errorf("no interrupted backport found")
+	}
+
+	if git.CherryPickInProgress() {
+		log.Debug().Str("sha", pending.OriginalSHA).Msg("aborting cherry-pick")
+		if err := git.AbortCherryPick(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ClearPending(); err != nil {
+		log.Warn().Err(err).Msg("failed to clear pending backport")
+	}
+
+	if pending.OriginalBranch != "" && pending.OriginalBranch != pending.TargetBranch {
+		if err := git.CheckoutBranch(pending.OriginalBranch); err != nil {
+			log.Warn().Err(err).Str("branch", pending.OriginalBranch).Msg("failed to return to original branch")
+		}
+	}
+
+	return pending, nil
+}
+
+// recordAudit records a backport attempt in the audit log, if enabled, completing the entry from
+// its result or error. Failures to record are logged, they do not fail the backport.
+func (s *Service) recordAudit(entry AuditEntry, result *BackportResult, err error) {
+	if s.audit == nil {
+		return
+	}
+
+	entry.Repo = s.owner + "/" + s.repoN
+	if entry.Result == "" {
+		entry.Result = AuditResult(result, err)
+	}
+	switch {
+	case err != nil:
+		entry.Message = err.Error()
+	case result != nil:
+		if result.OriginalSHA != "" {
+			entry.SourceSHA = result.OriginalSHA
+		}
+		entry.BackportSHA = result.BackportSHA
+		entry.Message = result.Message
+	}
+	if recordErr := s.audit.Record(entry); recordErr != nil {
+		log.Warn().Err(recordErr).Str("path", s.audit.Path()).Msg("failed to record backport in audit log")
+	}
+}
+
+// RecordBackportPR records in the audit log, if enabled, that a backport PR was opened for a backport.
+func (s *Service) RecordBackportPR(result *BackportResult, number int) {
+	s.recordAudit(AuditEntry{
+		SourcePR:     result.PRNumber,
+		TargetBranch: result.TargetBranch,
+		Result:       AuditPRCreated,
+		BackportPR:   number,
+	}, result, nil)
+}
+
+// GetPR fetches a PR from the configured forge. Without a forge, or if the forge fails,
+// the PR metadata is read from the git notes in NotesRef.
+func (s *Service) GetPR(ctx context.Context, prNumber int) (*forge.PRInfo, error) {
+	if s.forge == nil {
+		pr, err := s.prFromNotes(prNumber)
+		if err != nil {
+			return nil, fmt.Errorf("forge not configured, cannot fetch PR: %w", err)
+		}
+		log.Debug().Int("pr", prNumber).Msg("read PR metadata from git notes")
+		return pr, nil
+	}
+
+	pr, err := s.forge.GetPR(ctx, s.owner, s.repoN, prNumber)
+	if err != nil {
+		noted, noteErr := s.prFromNotes(prNumber)
+		if noteErr != nil {
+			return nil, err
+		}
+		log.Warn().Err(err).Int("pr", prNumber).Msg("failed to fetch PR, using its metadata from git notes")
+		return noted, nil
+	}
+	return pr, nil
+}
+
+// ListMilestonePRs lists the merged PRs of a milestone on the configured forge.
+func (s *Service) ListMilestonePRs(ctx context.Context, milestone string) ([]*forge.PRInfo, error) {
+	if s.forge == nil {
+		return nil, fmt.Errorf("forge not configured, cannot fetch milestone")
+	}
+	if !s.forge.Capabilities().Milestones {
+		return nil, fmt.Errorf("%s does not support milestones", s.forge.Name())
+	}
+	return s.forge.ListMilestonePRs(ctx, s.owner, s.repoN, milestone)
+}
+
+// ListPRFiles lists the files changed by a PR on the configured forge.
+func (s *Service) ListPRFiles(ctx context.Context, prNumber int) ([]forge.ChangedFile, error) {
+	if s.forge == nil {
+		return nil, fmt.Errorf("forge not configured, cannot fetch PR files")
+	}
+	return s.forge.ListPRFiles(ctx, s.owner, s.repoN, prNumber)
+}
+
+// CreatePR opens a pull request on the configured forge and returns its number.
+func (s *Service) CreatePR(ctx context.Context, opts forge.CreatePROptions) (int, error) {
+	if s.forge == nil {
+		return 0, fmt.Errorf("forge not configured, cannot create PR")
+	}
+	return s.forge.CreatePR(ctx, s.pushOwner, s.pushRepoN, opts)
+}
+
+// RequestReview requests a review of a pull request on the configured forge.
+func (s *Service) RequestReview(ctx context.Context, number int, reviewers []string) error {
+	if s.forge == nil {
+		return fmt.Errorf("forge not configured, cannot request review")
+	}
+	return s.forge.RequestReview(ctx, s.pushOwner, s.pushRepoN, number, reviewers)
+}
+
+// ProtectBranch applies branch protection rules to a branch on the configured forge.
+func (s *Service) ProtectBranch(ctx context.Context, branch string, rules forge.BranchProtection) error {
+	if s.forge == nil {
+		return fmt.Errorf("forge not configured, cannot protect branch")
+	}
+	if !s.forge.Capabilities().BranchProtection {
+		return fmt.Errorf("%s does not support branch protection", s.forge.Name())
+	}
+	return s.forge.ProtectBranch(ctx, s.pushOwner, s.pushRepoN, branch, rules)
+}
+
+// Remote returns the name of the git remote PRs and commits are read from.
+func (s *Service) Remote() string {
+	return s.config.Remote
+}
+
+// PushRemote returns the name of the git remote backport branches are pushed to.
+func (s *Service) PushRemote() string {
+	return s.config.WriteRemote()
+}
+
+// PushBackport pushes a backport branch for target to the push remote, see PushBranch.
+func (s *Service) PushBackport(branch, target string) error {
+	return PushBranch(s.forge, s.PushRemote(), branch, target)
+}
+
 // ListBackports returns the list of cached backport operations.
 func (s *Service) ListBackports() []CacheEntry {
 	if s.cache == nil {
@@ -235,6 +892,43 @@ func (s *Service) ListBackports() []CacheEntry {
 	return s.cache.List()
 }
 
+// AttachReleases records the first release tag containing each cached backport
+// that has not been released yet. Returns the entries that were updated.
+func (s *Service) AttachReleases() ([]CacheEntry, error) {
+	if s.cache == nil {
+		return nil, nil
+	}
+
+	var updated []CacheEntry
+	for i, entry := range s.cache.entries {
+		if entry.Release != "" || entry.BackportSHA == "" {
+			continue
+		}
+
+		tag, err := git.FirstTagContaining(entry.BackportSHA)
+		if err != nil {
+			log.Debug().Err(err).Str("sha", entry.BackportSHA).Msg("failed to look up release of backport")
+			continue
+		}
+		if tag == "" {
+			continue
+		}
+
+		s.cache.entries[i].Release = tag
+		updated = append(updated, s.cache.entries[i])
+	}
+
+	if len(updated) == 0 {
+		return nil, nil
+	}
+
+	if err := s.cache.save(); err != nil {
+		return nil, fmt.Errorf("failed to save cache: %w", err)
+	}
+
+	return updated, nil
+}
+
 // ClearCache clears the backport cache.
 func (s *Service) ClearCache() error {
 	if s.cache == nil {
@@ -1,12 +1,19 @@
 package backport
 
 import (
+	"context"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
 )
 
 func TestNewService(t *testing.T) {
@@ -26,6 +33,24 @@ func TestNewService(t *testing.T) {
 	assert.NotNil(t, service.cache)
 }
 
+func TestServicePushRepo(t *testing.T) {
+	cfg := &config.Config{Remote: "upstream"}
+	service := NewService(nil, nil, cfg, "upstream-owner", "repo")
+
+	owner, repoName := service.PushRepo()
+	assert.Equal(t, "upstream-owner", owner)
+	assert.Equal(t, "repo", repoName)
+	assert.Equal(t, "upstream", service.PushRemote())
+
+	cfg.PushRemote = "origin"
+	service.SetPushRepo("fork-owner", "repo-fork")
+	owner, repoName = service.PushRepo()
+	assert.Equal(t, "fork-owner", owner)
+	assert.Equal(t, "repo-fork", repoName)
+	assert.Equal(t, "origin", service.PushRemote())
+	assert.Equal(t, "upstream", service.Remote())
+}
+
 func TestNewServiceWithCacheDisabled(t *testing.T) {
 	tmpDir := t.TempDir()
 	cfg := &config.Config{
@@ -71,3 +96,93 @@ func TestClearCache(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestValidateStrategy(t *testing.T) {
+	for _, strategy := range []string{"", StrategySquash, StrategyMainline, StrategyCommits} {
+		assert.NoError(t, ValidateStrategy(strategy))
+	}
+	assert.Error(t, ValidateStrategy("rebase"))
+}
+
+// commitsForge returns a PR merged via a merge commit and its commits.
+type commitsForge struct {
+	forge.Forge
+	pr      *forge.PRInfo
+	commits []*forge.CommitInfo
+}
+
+func (f *commitsForge) GetPR(context.Context, string, string, int) (*forge.PRInfo, error) {
+	return f.pr, nil
+}
+
+func (f *commitsForge) ListPRCommits(context.Context, string, string, int) ([]*forge.CommitInfo, error) {
+	return f.commits, nil
+}
+
+func TestBackportPRCommits(t *testing.T) {
+	service, _, run := setupUndoRepo(t)
+	head := func() string {
+		sha, err := git.GetCurrentCommitSHA()
+		require.NoError(t, err)
+		return sha
+	}
+
+	run("checkout", "-q", "-b", "feature", "target")
+	var commits []*forge.CommitInfo
+	for _, author := range []string{"Alice", "Bob"} {
+		require.NoError(t, os.WriteFile(author+".txt", []byte(author+"\n"), 0o644))
+		run("add", author+".txt")
+		run("-c", "user.name="+author, "-c", "user.email="+strings.ToLower(author)+"@example.com", "commit", "-q", "-m", "Change by "+author)
+		commits = append(commits, &forge.CommitInfo{SHA: head(), Parents: []string{"parent"}})
+	}
+	// Merges of the base branch into the PR are skipped.
+	commits = append(commits, &forge.CommitInfo{SHA: "merge", Parents: []string{"a", "b"}})
+	run("checkout", "-q", "main")
+	run("merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+
+	f := &commitsForge{pr: &forge.PRInfo{Number: 5, MergeCommit: head(), Merged: true}, commits: commits}
+	service = NewService(service.repo, f, service.config, "owner", "repo")
+
+	result, err := service.BackportPR(context.Background(), 5, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "2 commits successfully backported", result.Message)
+
+	out, err := exec.Command("git", "log", "--format=%an <%ae> %s", "-2", "target").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "Bob <bob@example.com> Change by Bob\nAlice <alice@example.com> Change by Alice\n", string(out))
+
+	// Each backport records the PR in its trailers.
+	out, err = exec.Command("git", "log", "--format=%(trailers:key=Backport-of,valueonly)", "-2", "target").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "#5\n\n#5\n\n", string(out))
+
+	// The squash strategy refuses PRs merged via a merge commit.
+	_, err = service.BackportPR(context.Background(), 5, BackportOptions{TargetBranch: "target", Strategy: StrategySquash})
+	assert.ErrorIs(t, err, ErrNotSquashed)
+}
+
+func TestBackportCommitFromDetachedHead(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+	run("checkout", "-q", "--detach", "main")
+
+	result, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+
+	// The detached HEAD is restored afterwards.
+	ref, err := service.repo.CurrentRef()
+	require.NoError(t, err)
+	assert.Equal(t, sha, ref)
+}
+
+func TestCommitIdentity(t *testing.T) {
+	cfg := &config.Config{AuthorName: "Bot", AuthorEmail: "bot@example.com"}
+	assert.Nil(t, CommitIdentity(cfg))
+
+	cfg.CommitIdentity = config.IdentityCommitter
+	assert.Equal(t, &git.Identity{Name: "Bot", Email: "bot@example.com"}, CommitIdentity(cfg))
+
+	cfg.CommitIdentity = config.IdentityReauthor
+	assert.Equal(t, &git.Identity{Name: "Bot", Email: "bot@example.com", Reauthor: true}, CommitIdentity(cfg))
+}
@@ -0,0 +1,111 @@
+package backport
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// targetPatternChars are the characters that make a configured target branch a regex pattern.
+const targetPatternChars = `*+?.[](){}|^$\`
+
+// IsTargetPattern checks if a configured target branch looks like a regex pattern.
+// Branch names such as "v4.4.x" also qualify, they match themselves literally.
+func IsTargetPattern(target string) bool {
+	return strings.ContainsAny(target, targetPatternChars)
+}
+
+// compileTargetPattern compiles a target branch pattern, anchored to match whole branch names.
+func compileTargetPattern(target string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + target + ")$")
+}
+
+// ValidateTargetPattern checks that a configured target branch compiles as a regex.
+func ValidateTargetPattern(target string) error {
+	_, err := compileTargetPattern(target)
+	return err
+}
+
+// MatchesTargetBranch checks if a branch matches any configured target branch,
+// either literally or as a fully anchored regex.
+func MatchesTargetBranch(branch string, targets []string) bool {
+	for _, target := range targets {
+		if target == branch {
+			return true
+		}
+		re, err := compileTargetPattern(target)
+		if err == nil && re.MatchString(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandTargetBranches resolves configured target branches against the given branches.
+// Targets naming an existing branch are kept as is, patterns are replaced by all matching
+// branches in sorted order and other names are kept so that the missing branch is reported later.
+// The result contains no duplicates.
+func ExpandTargetBranches(targets, branches []string) []string {
+	existing := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		existing[b] = true
+	}
+
+	sorted := make([]string, 0, len(existing))
+	for b := range existing {
+		sorted = append(sorted, b)
+	}
+	sort.Strings(sorted)
+
+	seen := make(map[string]bool)
+	var result []string
+	add := func(branch string) {
+		if !seen[branch] {
+			seen[branch] = true
+			result = append(result, branch)
+		}
+	}
+
+	for _, target := range targets {
+		if existing[target] || !IsTargetPattern(target) {
+			add(target)
+			continue
+		}
+
+		re, err := compileTargetPattern(target)
+		if err != nil {
+			// Not a valid regex, treat it as a branch name.
+			add(target)
+			continue
+		}
+		for _, branch := range sorted {
+			if re.MatchString(branch) {
+				add(branch)
+			}
+		}
+	}
+
+	return result
+}
+
+// TargetBranches returns the configured target branches with patterns expanded against
+// the local branches and the branches of the remote.
+func (s *Service) TargetBranches() ([]string, error) {
+	branches, err := s.repo.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteBranches, err := s.repo.ListRemoteBranches(s.PushRemote())
+	if err != nil {
+		return nil, err
+	}
+
+	targets := ExpandTargetBranches(s.config.TargetBranches, append(branches, remoteBranches...))
+	if len(targets) == 0 && len(s.config.TargetBranches) > 0 {
+		return nil, fmt.Errorf("no branches match the configured target branches %v", s.config.TargetBranches)
+	}
+
+	return targets, nil
+}
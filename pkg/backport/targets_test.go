@@ -0,0 +1,53 @@
+package backport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesTargetBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		branch   string
+		targets  []string
+		expected bool
+	}{
+		{"exact match", "stable", []string{"stable"}, true},
+		{"regex match", "release-1.x", []string{"release-.*"}, true},
+		{"regex is anchored", "old-release-1.x", []string{"release-.*"}, false},
+		{"literal with dots", "v4.4.x", []string{"v4.4.x"}, true},
+		{"no match", "main", []string{"release-.*", "stable"}, false},
+		{"invalid regex", "release-[", []string{"release-["}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchesTargetBranch(tt.branch, tt.targets))
+		})
+	}
+}
+
+func TestExpandTargetBranches(t *testing.T) {
+	branches := []string{"main", "release-2.x", "release-1.x", "v4.4.x", "stable"}
+
+	tests := []struct {
+		name     string
+		targets  []string
+		expected []string
+	}{
+		{"literal", []string{"stable"}, []string{"stable"}},
+		{"pattern", []string{"release-.*"}, []string{"release-1.x", "release-2.x"}},
+		{"existing branch with dots", []string{"v4.4.x"}, []string{"v4.4.x"}},
+		{"missing literal is kept", []string{"release-3"}, []string{"release-3"}},
+		{"pattern without match", []string{"hotfix-.*"}, nil},
+		{"no duplicates", []string{"release-1.x", "release-.*"}, []string{"release-1.x", "release-2.x"}},
+		{"invalid regex is kept", []string{"release-["}, []string{"release-["}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExpandTargetBranches(tt.targets, branches))
+		})
+	}
+}
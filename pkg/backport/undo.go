@@ -0,0 +1,146 @@
+package backport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// UndoLast selects the most recent backport for UndoBackport.
+const UndoLast = "last"
+
+// Ways a backport commit is undone on its target branch.
+const (
+	UndoRemoved  = "removed"  // The unpushed backport commit was dropped from the branch tip
+	UndoReverted = "reverted" // The backport commit was reverted by a new commit
+	UndoNone     = "none"     // The backport commit is not on the target branch (e.g. moved into a PR branch)
+)
+
+// UndoResult describes an undone backport.
+type UndoResult struct {
+	Entry     CacheEntry
+	Action    string // One of UndoRemoved, UndoReverted or UndoNone
+	RevertSHA string // Set if the backport was reverted
+}
+
+// FindBackport returns the history entry of a backport commit, or of the most recent backport
+// if ref is empty or UndoLast.
+func (s *Service) FindBackport(ref string) (CacheEntry, error) {
+	entries := s.ListBackports()
+	if len(entries) == 0 {
+		return CacheEntry{}, ErrBackportNotFound
+	}
+
+	if ref == "" || ref == UndoLast {
+		return entries[len(entries)-1], nil
+	}
+
+	sha := ref
+	if full, err := s.repo.GetCommitSHA(ref); err == nil {
+		sha = full
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].BackportSHA != "" && strings.HasPrefix(entries[i].BackportSHA, sha) {
+			return entries[i], nil
+		}
+	}
+
+	return CacheEntry{}, fmt.Errorf("%w: %s", ErrBackportNotFound, ref)
+}
+
+// UndoBackport undoes a backport from the history, see FindBackport for ref. An unpushed
+// backport at the tip of its target branch is dropped, otherwise it is reverted. The entry
+// is removed from the history either way.
+func (s *Service) UndoBackport(_ context.Context, ref string) (*UndoResult, error) {
+	entry, err := s.FindBackport(ref)
+	if err != nil {
+		return nil, err
+	}
+	result := &UndoResult{Entry: entry, Action: UndoNone}
+	target := entry.TargetBranch
+
+	onTarget, err := git.IsAncestor(entry.BackportSHA, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if onTarget {
+		current, err := s.repo.CurrentBranch()
+		if err != nil {
+			current = ""
+		}
+		if current == target {
+			hasChanges, err := s.repo.HasUncommittedChanges()
+			if err != nil {
+				return nil, fmt.Errorf("failed to check for uncommitted changes: %w", err)
+			}
+			if hasChanges {
+				return nil, ErrUncommittedChanges
+			}
+		}
+
+		tip, err := s.repo.GetCommitSHA(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", target, err)
+		}
+
+		// A missing remote branch counts as unpushed.
+		pushed, err := git.IsAncestor(entry.BackportSHA, s.PushRemote()+"/"+target)
+		if err != nil {
+			pushed = false
+		}
+
+		switch {
+		case tip == entry.BackportSHA && !pushed:
+			parent := entry.BackportSHA + "^"
+			if current == target {
+				err = git.ResetHard(parent)
+			} else {
+				err = git.ResetBranch(target, parent)
+			}
+			if err != nil {
+				return nil, err
+			}
+			result.Action = UndoRemoved
+
+		case current == target:
+			if result.RevertSHA, err = git.Revert(entry.BackportSHA); err != nil {
+				return nil, err
+			}
+			result.Action = UndoReverted
+
+		default:
+			if result.RevertSHA, err = s.revertInWorktree(target, entry.BackportSHA); err != nil {
+				return nil, err
+			}
+			result.Action = UndoReverted
+		}
+	}
+
+	log.Info().Str("sha", entry.BackportSHA).Str("target", target).Str("action", result.Action).Msg("undid backport")
+
+	if err := s.cache.Remove(entry); err != nil {
+		return result, fmt.Errorf("failed to update cache: %w", err)
+	}
+
+	return result, nil
+}
+
+// revertInWorktree reverts sha on a branch that is not checked out.
+func (s *Service) revertInWorktree(branch, sha string) (string, error) {
+	worktree, err := git.AddWorktree(branch)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := worktree.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", worktree.Path).Msg("failed to remove worktree")
+		}
+	}()
+
+	return worktree.Revert(sha)
+}
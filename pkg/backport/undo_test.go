@@ -0,0 +1,103 @@
+package backport
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// setupUndoRepo creates a repository with a commit on main that applies cleanly to "target",
+// and a service recording backports in a temporary history.
+func setupUndoRepo(t *testing.T) (*Service, string, func(args ...string)) {
+	t.Helper()
+
+	repoPath := t.TempDir()
+	t.Chdir(repoPath)
+
+	run := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.name", "Test User")
+	run("config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("base\n"), 0o644))
+	run("add", "file.txt")
+	run("commit", "-q", "-m", "Initial commit")
+	run("branch", "target")
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "fix.txt"), []byte("fix\n"), 0o644))
+	run("add", "fix.txt")
+	run("commit", "-q", "-m", "Fix bug")
+
+	sha, err := git.GetCurrentCommitSHA()
+	require.NoError(t, err)
+
+	repo, err := git.Open(repoPath)
+	require.NoError(t, err)
+	cfg := &config.Config{
+		Remote: "origin",
+		Cache:  config.CacheConfig{Enabled: true, Path: filepath.Join(t.TempDir(), "history.json")},
+	}
+
+	return NewService(repo, nil, cfg, "owner", "repo"), sha, run
+}
+
+func TestUndoBackportRemovesUnpushedTip(t *testing.T) {
+	service, sha, _ := setupUndoRepo(t)
+
+	before, err := service.repo.GetCommitSHA("target")
+	require.NoError(t, err)
+
+	result, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	undo, err := service.UndoBackport(context.Background(), UndoLast)
+	require.NoError(t, err)
+	assert.Equal(t, UndoRemoved, undo.Action)
+	assert.Equal(t, result.BackportSHA, undo.Entry.BackportSHA)
+
+	after, err := service.repo.GetCommitSHA("target")
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	assert.Empty(t, service.ListBackports())
+}
+
+func TestUndoBackportRevertsBuriedCommit(t *testing.T) {
+	service, sha, run := setupUndoRepo(t)
+
+	result, err := service.BackportCommit(context.Background(), sha, BackportOptions{TargetBranch: "target"})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	// Another commit on top of the backport, the target is not checked out when undoing.
+	run("checkout", "-q", "target")
+	run("commit", "-q", "--allow-empty", "-m", "Later change")
+	run("checkout", "-q", "main")
+
+	undo, err := service.UndoBackport(context.Background(), result.BackportSHA[:7])
+	require.NoError(t, err)
+	assert.Equal(t, UndoReverted, undo.Action)
+
+	tip, err := service.repo.GetCommitSHA("target")
+	require.NoError(t, err)
+	assert.Equal(t, undo.RevertSHA, tip)
+	assert.Error(t, exec.Command("git", "cat-file", "-e", "target:fix.txt").Run(), "revert should remove the backported file")
+	assert.Empty(t, service.ListBackports())
+}
+
+func TestUndoBackportNotFound(t *testing.T) {
+	service, _, _ := setupUndoRepo(t)
+
+	_, err := service.UndoBackport(context.Background(), UndoLast)
+	assert.ErrorIs(t, err, ErrBackportNotFound)
+}
@@ -0,0 +1,112 @@
+// Package backportci implements the CI mode of backporter: detecting the merged PR of a
+// commit, backporting it to each target branch with a backport PR and summarizing the results.
+// The forge, git and clock are injectable, so bots and servers can embed it.
+package backportci
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// Result represents the result of a CI backport operation for a single branch.
+type Result struct {
+	TargetBranch string
+	Success      bool
+	PRNumber     int    // The created backport PR number
+	Skipped      bool   // True if backport PR already exists
+	Conflict     bool   // True if the cherry-pick had conflicts and needs a manual backport
+	Deferred     bool   // True if the backport was queued because it ran during quiet hours
+	Diffstat     string // Short diffstat of the backport, with ci.include_diffstat
+	Error        error
+	Message      string
+}
+
+// Failed reports whether a CI backport failed.
+func (r Result) Failed() bool {
+	return r.Error != nil && !r.Skipped
+}
+
+// ErrFailed is returned by FailedError for CI runs in which some backports failed.
+var ErrFailed = errors.New("some backports failed")
+
+// ErrAllFailed is returned by FailedError for CI runs in which no backport succeeded.
+// It does not wrap ErrFailed, so the run is not reported as a partial failure.
+var ErrAllFailed = errors.New("all backports failed")
+
+// FailedError returns the error of a CI run with failed backports, or nil if all succeeded.
+// It wraps backport.ErrConflict if all failures were conflicts, and otherwise the first
+// failure caused by the forge rejecting the token, so both can be told apart by callers.
+// Other failures return ErrAllFailed if no backport succeeded and ErrFailed otherwise.
+func FailedError(results []Result) error {
+	var failed, conflicts int
+	var unauthorized error
+	for _, r := range results {
+		if !r.Failed() {
+			continue
+		}
+		failed++
+		if r.Conflict {
+			conflicts++
+		} else if unauthorized == nil && errors.Is(r.Error, forge.ErrUnauthorized) {
+			unauthorized = r.Error
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case conflicts == failed:
+		return fmt.Errorf("%w: %w", ErrFailed, backport.ErrConflict)
+	case unauthorized != nil:
+		return fmt.Errorf("%w: %w", ErrFailed, unauthorized)
+	case failed == len(results):
+		return ErrAllFailed
+	default:
+		return ErrFailed
+	}
+}
+
+// convCommitPattern matches conventional commit prefixes.
+var convCommitPattern = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]+\))?:\s`)
+
+// prNumberPatterns match PR numbers in commit messages.
+var prNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\(#(\d+)\)`),                // Squash merge: "feat: something (#123)"
+	regexp.MustCompile(`Merge pull request #(\d+)`), // GitHub merge commit
+	regexp.MustCompile(`Merge branch.*#(\d+)`),      // Alternative merge format
+	regexp.MustCompile(`See merge request.*!(\d+)`), // GitLab style
+	regexp.MustCompile(`Reviewed-on:.*pull/(\d+)`),  // Forgejo/Gitea style
+}
+
+// ParsePRNumber returns the number of the PR a commit message belongs to, 0 if it names none.
+func ParsePRNumber(message string) int {
+	for _, pattern := range prNumberPatterns {
+		matches := pattern.FindStringSubmatch(message)
+		if len(matches) >= 2 { //nolint:mnd
+			var num int
+			if _, err := fmt.Sscanf(matches[1], "%d", &num); err == nil && num > 0 {
+				return num
+			}
+		}
+	}
+	return 0
+}
+
+// ConvCommitPrefix extracts the conventional commit prefix from a PR title.
+// Returns the full prefix including scope if present (e.g., "feat(api)" from "feat(api): something").
+func ConvCommitPrefix(title string) string {
+	matches := convCommitPattern.FindStringSubmatch(title)
+	if len(matches) >= 2 { //nolint:mnd
+		// matches[1] is the type (feat, fix, etc.)
+		// matches[2] is the scope with parens (api) or empty
+		if len(matches) >= 3 && matches[2] != "" {
+			return matches[1] + matches[2]
+		}
+		return matches[1]
+	}
+	return ""
+}
@@ -0,0 +1,279 @@
+package backportci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestParsePRNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected int
+	}{
+		{
+			name:     "squash merge format",
+			message:  "feat: add new feature (#123)",
+			expected: 123,
+		},
+		{
+			name:     "squash merge with scope",
+			message:  "fix(api): resolve bug (#456)",
+			expected: 456,
+		},
+		{
+			name:     "GitHub merge commit",
+			message:  "Merge pull request #789 from user/branch",
+			expected: 789,
+		},
+		{
+			name:     "GitHub merge commit multiline",
+			message:  "Merge pull request #42 from user/feature\n\nSome description here",
+			expected: 42,
+		},
+		{
+			name:     "GitLab style",
+			message:  "Merge branch 'feature' into main\n\nSee merge request owner/repo!100",
+			expected: 100,
+		},
+		{
+			name:     "Forgejo/Gitea style",
+			message:  "Some commit message\n\nReviewed-on: https://codeberg.org/owner/repo/pull/55",
+			expected: 55,
+		},
+		{
+			name:     "alternative merge format",
+			message:  "Merge branch 'feature' #200",
+			expected: 200,
+		},
+		{
+			name:     "no PR number",
+			message:  "Just a regular commit message",
+			expected: 0,
+		},
+		{
+			name:     "empty message",
+			message:  "",
+			expected: 0,
+		},
+		{
+			name:     "PR number at end without parens",
+			message:  "fix: something #999",
+			expected: 0, // Not matched by our patterns
+		},
+		{
+			name:     "multiple PR references takes first",
+			message:  "feat: feature (#111)\n\nRelated to (#222)",
+			expected: 111,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParsePRNumber(tt.message)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestConvCommitPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		expected string
+	}{
+		{
+			name:     "feat prefix",
+			title:    "feat: add new feature",
+			expected: "feat",
+		},
+		{
+			name:     "fix prefix",
+			title:    "fix: resolve bug",
+			expected: "fix",
+		},
+		{
+			name:     "feat with scope",
+			title:    "feat(api): add endpoint",
+			expected: "feat(api)",
+		},
+		{
+			name:     "fix with scope",
+			title:    "fix(auth): fix login issue",
+			expected: "fix(auth)",
+		},
+		{
+			name:     "docs prefix",
+			title:    "docs: update README",
+			expected: "docs",
+		},
+		{
+			name:     "chore with scope",
+			title:    "chore(deps): update dependencies",
+			expected: "chore(deps)",
+		},
+		{
+			name:     "refactor prefix",
+			title:    "refactor: simplify code",
+			expected: "refactor",
+		},
+		{
+			name:     "test prefix",
+			title:    "test: add unit tests",
+			expected: "test",
+		},
+		{
+			name:     "ci prefix",
+			title:    "ci: update workflow",
+			expected: "ci",
+		},
+		{
+			name:     "build prefix",
+			title:    "build: update Dockerfile",
+			expected: "build",
+		},
+		{
+			name:     "perf prefix",
+			title:    "perf: optimize query",
+			expected: "perf",
+		},
+		{
+			name:     "style prefix",
+			title:    "style: format code",
+			expected: "style",
+		},
+		{
+			name:     "revert prefix",
+			title:    "revert: undo change",
+			expected: "revert",
+		},
+		{
+			name:     "no conventional commit",
+			title:    "Add new feature",
+			expected: "",
+		},
+		{
+			name:     "empty title",
+			title:    "",
+			expected: "",
+		},
+		{
+			name:     "wrong format - no colon",
+			title:    "feat add new feature",
+			expected: "",
+		},
+		{
+			name:     "wrong format - no space after colon",
+			title:    "feat:add new feature",
+			expected: "",
+		},
+		{
+			name:     "complex scope with dashes",
+			title:    "feat(my-scope): add feature",
+			expected: "feat(my-scope)",
+		},
+		{
+			name:     "complex scope with underscores",
+			title:    "fix(my_module): fix bug",
+			expected: "fix(my_module)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ConvCommitPrefix(tt.title)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestResultStates(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  Result
+		success bool
+		skipped bool
+		hasErr  bool
+	}{
+		{
+			name: "successful backport",
+			result: Result{
+				TargetBranch: "release-1.x",
+				Success:      true,
+				PRNumber:     100,
+				Message:      "created backport PR #100",
+			},
+			success: true,
+			skipped: false,
+			hasErr:  false,
+		},
+		{
+			name: "skipped - already exists",
+			result: Result{
+				TargetBranch: "release-1.x",
+				Success:      true,
+				Skipped:      true,
+				PRNumber:     50,
+				Message:      "backport PR #50 already exists",
+			},
+			success: true,
+			skipped: true,
+			hasErr:  false,
+		},
+		{
+			name: "failed backport",
+			result: Result{
+				TargetBranch: "release-1.x",
+				Success:      false,
+				Error:        assert.AnError,
+				Message:      "cherry-pick failed",
+			},
+			success: false,
+			skipped: false,
+			hasErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.success, tt.result.Success)
+			assert.Equal(t, tt.skipped, tt.result.Skipped)
+			if tt.hasErr {
+				assert.NotNil(t, tt.result.Error)
+			} else {
+				assert.Nil(t, tt.result.Error)
+			}
+		})
+	}
+}
+
+func TestFailedError(t *testing.T) {
+	ok := Result{Success: true}
+	conflict := Result{Conflict: true, Error: fmt.Errorf("cherry-pick has conflicts")}
+	failed := Result{Error: assert.AnError}
+	unauthorized := Result{Error: fmt.Errorf("failed to create PR: %w", &forge.APIError{Forge: "github", StatusCode: 401})}
+
+	assert.NoError(t, FailedError([]Result{ok, {Skipped: true, Error: assert.AnError}}))
+
+	err := FailedError([]Result{ok, conflict})
+	assert.ErrorIs(t, err, ErrFailed)
+	assert.ErrorIs(t, err, backport.ErrConflict)
+
+	// Conflicts are only reported if nothing else failed.
+	err = FailedError([]Result{ok, conflict, failed})
+	assert.ErrorIs(t, err, ErrFailed)
+	assert.NotErrorIs(t, err, backport.ErrConflict)
+
+	// A run without any successful backport is not a partial failure.
+	err = FailedError([]Result{conflict, failed})
+	assert.ErrorIs(t, err, ErrAllFailed)
+	assert.NotErrorIs(t, err, ErrFailed)
+
+	err = FailedError([]Result{failed, unauthorized, conflict})
+	assert.ErrorIs(t, err, forge.ErrUnauthorized)
+}
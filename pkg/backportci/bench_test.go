@@ -0,0 +1,126 @@
+package backportci
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// budgetCIBackport is the performance budget of a single CI backport, including the push.
+// It only fails the benchmark on order-of-magnitude regressions.
+const budgetCIBackport = 3 * time.Second
+
+// benchForge accepts every backport PR without talking to a forge.
+type benchForge struct {
+	forge.Forge
+}
+
+func (f *benchForge) Capabilities() forge.Capabilities {
+	return forge.Capabilities{}
+}
+
+func (f *benchForge) ListOpenPRs(_ context.Context, _, _ string, _ forge.ListPROptions) ([]*forge.PRInfo, error) {
+	return nil, nil
+}
+
+func (f *benchForge) CreatePR(_ context.Context, _, _ string, _ forge.CreatePROptions) (int, error) {
+	return 100, nil
+}
+
+// setupCIFixture creates a clone of a bare repository with a release-1.x branch and a merged
+// fix on main, changes into the clone and returns the merge commit.
+// With conflict set, release-1.x changes the same file as the fix.
+func setupCIFixture(b testing.TB, conflict bool) string {
+	b.Helper()
+
+	root := b.TempDir()
+	upstream := filepath.Join(root, "upstream.git")
+	clone := filepath.Join(root, "clone")
+
+	run := func(dir string, args ...string) {
+		b.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(b, err, string(out))
+	}
+
+	run(root, "init", "-q", "--bare", "-b", "main", upstream)
+	run(root, "clone", "-q", upstream, clone)
+	run(clone, "config", "user.name", "Test User")
+	run(clone, "config", "user.email", "test@example.com")
+	require.NoError(b, os.WriteFile(filepath.Join(clone, "app.txt"), []byte("v1\n"), 0o644))
+	run(clone, "add", "app.txt")
+	run(clone, "commit", "-q", "-m", "Initial commit")
+	run(clone, "branch", "release-1.x")
+	if conflict {
+		run(clone, "checkout", "-q", "release-1.x")
+		require.NoError(b, os.WriteFile(filepath.Join(clone, "app.txt"), []byte("v1.1\n"), 0o644))
+		run(clone, "commit", "-q", "-am", "Release 1.1")
+		run(clone, "checkout", "-q", "main")
+		require.NoError(b, os.WriteFile(filepath.Join(clone, "app.txt"), []byte("v2\n"), 0o644))
+	}
+	require.NoError(b, os.WriteFile(filepath.Join(clone, "fix.txt"), []byte("fix\n"), 0o644))
+	run(clone, "add", "app.txt", "fix.txt")
+	run(clone, "commit", "-q", "-m", "fix: bug (#42)")
+	run(clone, "push", "-q", "origin", "main", "release-1.x")
+
+	b.Chdir(clone)
+	sha, err := git.GetCurrentCommitSHA()
+	require.NoError(b, err)
+	require.NoError(b, git.CheckoutBranch("release-1.x"))
+
+	return sha
+}
+
+// BenchmarkCIBackport measures the CI flow for one target branch end to end against a
+// fixture repository: branch creation, cherry-pick, push and PR creation.
+func BenchmarkCIBackport(b *testing.B) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	b.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	mergeCommit := setupCIFixture(b, false)
+	prInfo := &forge.PRInfo{Number: 42, Title: "fix: bug", MergeCommit: mergeCommit, Squashed: true}
+	ctx := context.Background()
+	r := newTestRunner(&benchForge{}, LocalGit{})
+
+	for _, isolated := range []bool{false, true} {
+		name := "checkout"
+		if isolated {
+			name = "worktree"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				result := r.process(ctx, prInfo, nil, "release-1.x", isolated)
+				require.True(b, result.Success, result.Message)
+
+				b.StopTimer()
+				require.NoError(b, git.CheckoutBranch("release-1.x"))
+				require.NoError(b, git.DeleteBranch(BranchName(42, "release-1.x")))
+				require.NoError(b, exec.Command("git", "push", "-q", "origin", "--delete", BranchName(42, "release-1.x")).Run())
+				b.StartTimer()
+			}
+			checkBudget(b, budgetCIBackport)
+		})
+	}
+}
+
+// checkBudget fails the benchmark if an iteration took longer than budget on average.
+func checkBudget(b *testing.B, budget time.Duration) {
+	b.Helper()
+
+	if perOp := b.Elapsed() / time.Duration(b.N); perOp > budget {
+		b.Errorf("%s/op exceeds the performance budget of %s", perOp, budget)
+	}
+}
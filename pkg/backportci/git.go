@@ -0,0 +1,214 @@
+package backportci
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/git"
+)
+
+// Git is the git access of a Runner. LocalGit works on the repository of the working directory.
+type Git interface {
+	// CommitMessage returns the full message of a commit.
+	CommitMessage(ref string) (string, error)
+
+	// CreateBranch creates a local branch from a ref.
+	CreateBranch(branch, from string) error
+
+	// CheckoutBranch checks out a branch in the current checkout.
+	CheckoutBranch(branch string) error
+
+	// DeleteBranch deletes a local branch.
+	DeleteBranch(branch string) error
+
+	// IsAncestor reports whether ancestor is an ancestor of ref.
+	IsAncestor(ancestor, ref string) (bool, error)
+
+	// MergedCommits returns the non-merge commits a merge commit brought in, oldest first.
+	MergedCommits(mergeSHA string) ([]string, error)
+
+	// EnsureCommit makes sure a commit and enough history to cherry-pick it onto branch are
+	// available locally, fetching them from remote if needed. Failures are only logged.
+	EnsureCommit(remote, sha, branch string)
+
+	// CherryPick checks out branch and cherry-picks sha onto it, in a temporary worktree if
+	// isolated. Conflicts are committed with conflictMessage, or aborted if it is empty.
+	CherryPick(branch, sha string, opts git.CherryPickOptions, conflictMessage string, isolated bool) (*git.CherryPickResult, error)
+
+	// Push pushes a backport branch for the target branch to remote, to refs/for/<target> for
+	// forges that create PRs from pushes for review.
+	Push(remote, branch, target string, forReview bool) error
+
+	// ForcePush pushes a backport branch to remote, replacing the commits of a previous run
+	// unless the remote branch moved since it was looked up.
+	ForcePush(remote, branch string) error
+
+	// DiffStat returns the short diffstat between two refs.
+	DiffStat(from, to string) (string, error)
+
+	// RemoteURL returns the URL of a remote.
+	RemoteURL(remote string) (string, error)
+}
+
+// LocalGit is the Git of the repository in the working directory.
+type LocalGit struct{}
+
+var _ Git = LocalGit{}
+
+// fetchMu serializes the fetches of concurrent backports, which contend for the same lock files.
+var fetchMu sync.Mutex
+
+// CommitMessage implements Git.
+func (LocalGit) CommitMessage(ref string) (string, error) {
+	return git.GetCommitMessage(ref)
+}
+
+// CreateBranch implements Git.
+func (LocalGit) CreateBranch(branch, from string) error {
+	return git.CreateBranchFrom(branch, from)
+}
+
+// CheckoutBranch implements Git.
+func (LocalGit) CheckoutBranch(branch string) error {
+	return git.CheckoutBranch(branch)
+}
+
+// DeleteBranch implements Git.
+func (LocalGit) DeleteBranch(branch string) error {
+	return git.DeleteBranch(branch)
+}
+
+// IsAncestor implements Git.
+func (LocalGit) IsAncestor(ancestor, ref string) (bool, error) {
+	return git.IsAncestor(ancestor, ref)
+}
+
+// MergedCommits implements Git.
+func (LocalGit) MergedCommits(mergeSHA string) ([]string, error) {
+	return git.ListMergedCommits(mergeSHA)
+}
+
+// EnsureCommit implements Git.
+func (LocalGit) EnsureCommit(remote, sha, branch string) {
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+
+	if fetched, err := git.EnsureCommit(remote, sha); err != nil {
+		log.Warn().Err(err).Str("sha", sha).Msg("merge commit not available locally")
+	} else if fetched {
+		log.Info().Str("sha", sha).Msg("fetched missing merge commit from remote")
+	}
+
+	// Shallow clones (e.g. fetch-depth: 1) may lack the history needed to cherry-pick.
+	deepened, err := git.EnsureMergeBase(remote, sha, branch)
+	switch {
+	case err != nil:
+		log.Warn().Err(err).Str("sha", sha).Msg("failed to fetch history for shallow clone")
+	case deepened.Unshallowed:
+		log.Info().Str("remote", remote).Msg("shallow clone: fetched full history to find merge base")
+	case deepened.Deepened > 0:
+		log.Info().Str("remote", remote).Int("commits", deepened.Deepened).Msg("shallow clone: deepened history to find merge base")
+	}
+}
+
+// CherryPick implements Git.
+func (LocalGit) CherryPick(branch, sha string, opts git.CherryPickOptions, conflictMessage string, isolated bool) (*git.CherryPickResult, error) {
+	if isolated {
+		return cherryPickInWorktree(branch, sha, opts, conflictMessage)
+	}
+	return cherryPickInCheckout(branch, sha, opts, conflictMessage)
+}
+
+// Push implements Git.
+func (LocalGit) Push(remote, branch, target string, forReview bool) error {
+	if forReview {
+		return git.PushForReview(remote, branch, target)
+	}
+	return backport.PushBranch(nil, remote, branch, target)
+}
+
+// ForcePush implements Git.
+func (LocalGit) ForcePush(remote, branch string) error {
+	return git.ForcePushWithLease(remote, branch)
+}
+
+// DiffStat implements Git.
+func (LocalGit) DiffStat(from, to string) (string, error) {
+	return git.DiffStat(from, to)
+}
+
+// RemoteURL implements Git.
+func (LocalGit) RemoteURL(remote string) (string, error) {
+	repo, err := git.OpenCurrent()
+	if err != nil {
+		return "", err
+	}
+	return repo.RemoteURL(remote)
+}
+
+// cherryPickInCheckout checks out branch and cherry-picks sha onto it in the current checkout.
+// Conflicting cherry-picks are aborted, or committed with conflictMessage if it is set.
+func cherryPickInCheckout(branch, sha string, opts git.CherryPickOptions, conflictMessage string) (*git.CherryPickResult, error) {
+	if err := git.CheckoutBranch(branch); err != nil {
+		return nil, err
+	}
+
+	cpResult, err := git.CherryPickWithOptions(sha, opts)
+	if err != nil {
+		_ = git.AbortCherryPick()
+		return nil, err
+	}
+	if cpResult.HasConflict {
+		if conflictMessage == "" {
+			_ = git.AbortCherryPick()
+		} else if err := git.CommitConflicts(conflictCommitMessage(conflictMessage, opts), opts.Identity); err != nil {
+			_ = git.AbortCherryPick()
+			return nil, err
+		}
+	}
+
+	return cpResult, nil
+}
+
+// cherryPickInWorktree cherry-picks sha onto branch in a temporary worktree.
+// Conflicting cherry-picks are aborted, or committed with conflictMessage if it is set.
+// The current directory is not changed, so it is safe to call concurrently for different branches.
+func cherryPickInWorktree(branch, sha string, opts git.CherryPickOptions, conflictMessage string) (*git.CherryPickResult, error) {
+	worktree, err := git.AddWorktree(branch)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := worktree.Remove(); err != nil {
+			log.Warn().Err(err).Str("path", worktree.Path).Msg("failed to remove worktree")
+		}
+	}()
+
+	cpResult, err := worktree.CherryPick(sha, opts)
+	if err != nil {
+		_ = worktree.AbortCherryPick()
+		return nil, err
+	}
+	if cpResult.HasConflict {
+		if conflictMessage == "" {
+			_ = worktree.AbortCherryPick()
+		} else if err := worktree.CommitConflicts(conflictCommitMessage(conflictMessage, opts), opts.Identity); err != nil {
+			_ = worktree.AbortCherryPick()
+			return nil, err
+		}
+	}
+
+	return cpResult, nil
+}
+
+// conflictCommitMessage returns the message of a commit with conflict markers, followed by the
+// trailers of the cherry-pick, which stopped before it could add them.
+func conflictCommitMessage(message string, opts git.CherryPickOptions) string {
+	if len(opts.Trailers) == 0 {
+		return message
+	}
+	return message + "\n\n" + strings.Join(opts.Trailers, "\n")
+}
@@ -0,0 +1,189 @@
+package backportci
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+// ConflictLabel is added to draft backport PRs that contain conflict markers.
+const ConflictLabel = "backport-conflict"
+
+// BranchName returns the name of the branch holding the backport of a PR.
+func BranchName(prNumber int, targetBranch string) string {
+	return fmt.Sprintf("backport-%d-to-%s", prNumber, targetBranch)
+}
+
+// BaseRef returns the ref a backport branch is created from: base with {target} replaced
+// by the target branch, or <remote>/<target> if no base is given.
+func BaseRef(base, remote, targetBranch string) string {
+	if base == "" {
+		return remote + "/" + targetBranch
+	}
+	return strings.ReplaceAll(base, "{target}", targetBranch)
+}
+
+// PRTitle returns the title of a backport PR.
+func PRTitle(prefix string, prNumber int, targetBranch string) string {
+	return fmt.Sprintf("%s: backport #%d to %s", prefix, prNumber, targetBranch)
+}
+
+// FormatPRBody creates the PR body for a backport PR.
+// Checklist items are added as a task list for the reviewers, follow-up fixes of the original
+// PR as a list of PRs that may need a backport as well. A non-empty diffstat is shown with the
+// details of the original PR.
+func FormatPRBody(originalPR *forge.PRInfo, targetBranch string, checklist []string, followUps []*forge.PRInfo, diffstat string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Backport of #%d to `%s`.\n\n", originalPR.Number, targetBranch))
+	sb.WriteString("## Original PR\n\n")
+	sb.WriteString(fmt.Sprintf("- **Title**: %s\n", originalPR.Title))
+	sb.WriteString(fmt.Sprintf("- **Author**: @%s\n", originalPR.Author))
+	sb.WriteString(fmt.Sprintf("- **Merged**: %s\n", originalPR.MergedAt.Format("2006-01-02 15:04:05 UTC")))
+	if diffstat != "" {
+		sb.WriteString(fmt.Sprintf("- **Backport changes**: %s\n", diffstat))
+	}
+
+	if originalPR.Body != "" {
+		sb.WriteString("\n## Original Description\n\n")
+		// Truncate very long descriptions.
+		const maxBodyLen = 2000
+		body := originalPR.Body
+		if len(body) > maxBodyLen {
+			body = body[:maxBodyLen] + "\n\n... (truncated)"
+		}
+		sb.WriteString(body)
+		sb.WriteString("\n")
+	}
+
+	if len(followUps) > 0 {
+		sb.WriteString("\n## Follow-up Fixes\n\n")
+		sb.WriteString(fmt.Sprintf("These PRs fix or follow up on #%d and may need to be backported as well:\n\n", originalPR.Number))
+		for _, pr := range followUps {
+			sb.WriteString(fmt.Sprintf("- #%d %s\n", pr.Number, pr.Title))
+		}
+	}
+
+	if len(checklist) > 0 {
+		sb.WriteString("\n## Review Checklist\n\n")
+		for _, item := range checklist {
+			sb.WriteString(fmt.Sprintf("- [ ] %s\n", item))
+		}
+	}
+
+	sb.WriteString("\n---\n")
+	sb.WriteString("*This PR was automatically created by [backporter](https://github.com/pat-s/backporter).*\n")
+
+	return sb.String()
+}
+
+// formatConflictSection returns the note on top of the body of a backport PR with conflicts.
+func formatConflictSection(files []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("> [!WARNING]\n")
+	sb.WriteString("> The cherry-pick had conflicts, they were committed with their conflict markers.\n")
+	sb.WriteString("> Resolve them on this branch and mark the PR as ready for review.\n\n")
+
+	if len(files) > 0 {
+		sb.WriteString("## Conflicting Files\n\n")
+		for _, file := range files {
+			fmt.Fprintf(&sb, "- `%s`\n", file)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// formatRerereSection returns the PR body section listing the conflicts resolved from recorded resolutions.
+func formatRerereSection(files []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("> [!NOTE]\n")
+	sb.WriteString("> The cherry-pick had conflicts, they were resolved from recorded resolutions (git rerere).\n")
+	sb.WriteString("> Review the resolved files with care.\n\n")
+
+	sb.WriteString("## Resolved Files\n\n")
+	for _, file := range files {
+		fmt.Fprintf(&sb, "- `%s`\n", file)
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// PRMetadata returns the labels, assignees, reviewers, milestone and draft state of a
+// backport PR of the original PR, as configured. Backport labels are never copied.
+func PRMetadata(ci config.CIConfig, original *forge.PRInfo) forge.CreatePROptions {
+	meta := forge.CreatePROptions{Draft: ci.DraftPRs}
+
+	if ci.CopyLabels {
+		for _, label := range original.Labels {
+			if !strings.Contains(strings.ToLower(label), "backport") {
+				meta.Labels = append(meta.Labels, label)
+			}
+		}
+	}
+	for _, label := range ci.Labels {
+		if !slices.Contains(meta.Labels, label) {
+			meta.Labels = append(meta.Labels, label)
+		}
+	}
+
+	if ci.CopyAssignees {
+		meta.Assignees = original.Assignees
+	}
+	if ci.CopyMilestone {
+		meta.Milestone = original.Milestone
+	}
+	meta.Reviewers = ci.Reviewers
+
+	return meta
+}
+
+// OriginalReviewers returns the users of the original PR to request a review of a backport PR
+// from, as configured.
+func OriginalReviewers(ci config.CIConfig, original *forge.PRInfo) []string {
+	var reviewers []string
+	if ci.RequestReviewFromAuthor && original.Author != "" {
+		reviewers = append(reviewers, original.Author)
+	}
+	if ci.RequestReviewFromMerger && original.MergedBy != "" && !slices.Contains(reviewers, original.MergedBy) {
+		reviewers = append(reviewers, original.MergedBy)
+	}
+	return reviewers
+}
+
+// ReviewChecklist returns the items of all review checklists matching the target branch,
+// in configuration order and without duplicates.
+func ReviewChecklist(checklists []config.ReviewChecklistConfig, targetBranch string) []string {
+	seen := make(map[string]bool)
+	var items []string
+	for _, checklist := range checklists {
+		if !backport.MatchesTargetBranch(targetBranch, checklist.Branches) {
+			continue
+		}
+		for _, item := range checklist.Items {
+			if !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// AutoMerge returns the first auto-merge settings whose branches match the target branch, nil if none does.
+func AutoMerge(settings []config.AutoMergeConfig, targetBranch string) *config.AutoMergeConfig {
+	for i := range settings {
+		if backport.MatchesTargetBranch(targetBranch, settings[i].Branches) {
+			return &settings[i]
+		}
+	}
+	return nil
+}
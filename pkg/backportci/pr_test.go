@@ -0,0 +1,201 @@
+package backportci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+)
+
+func TestBranchNameAndTitle(t *testing.T) {
+	assert.Equal(t, "backport-123-to-release-1.x", BranchName(123, "release-1.x"))
+	assert.Equal(t, "feat(api): backport #123 to release-1.x", PRTitle("feat(api)", 123, "release-1.x"))
+}
+
+func TestBaseRef(t *testing.T) {
+	assert.Equal(t, "origin/release-1.x", BaseRef("", "origin", "release-1.x"))
+	assert.Equal(t, "abc1234", BaseRef("abc1234", "origin", "release-1.x"))
+	assert.Equal(t, "origin/staging/release-1.x", BaseRef("origin/staging/{target}", "origin", "release-1.x"))
+}
+
+func TestFormatPRBody(t *testing.T) {
+	mergedAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		pr           *forge.PRInfo
+		targetBranch string
+		checklist    []string
+		followUps    []*forge.PRInfo
+		diffstat     string
+		contains     []string
+		notContains  []string
+	}{
+		{
+			name: "basic PR info",
+			pr: &forge.PRInfo{
+				Number:   123,
+				Title:    "feat: add feature",
+				Body:     "This is the PR description.",
+				Author:   "testuser",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "release-1.x",
+			contains: []string{
+				"Backport of #123 to `release-1.x`",
+				"**Title**: feat: add feature",
+				"**Author**: @testuser",
+				"**Merged**: 2024-01-15 10:30:00 UTC",
+				"## Original Description",
+				"This is the PR description.",
+				"automatically created by [backporter]",
+			},
+			notContains: []string{
+				"## Review Checklist",
+			},
+		},
+		{
+			name: "PR with review checklist",
+			pr: &forge.PRInfo{
+				Number:   321,
+				Title:    "fix: migration",
+				Author:   "testuser",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "release-1.x",
+			checklist:    []string{"Verify migration guards", "Update version constants"},
+			contains: []string{
+				"## Review Checklist\n\n- [ ] Verify migration guards\n- [ ] Update version constants\n",
+			},
+		},
+		{
+			name: "PR with follow-up fixes",
+			pr: &forge.PRInfo{
+				Number:   321,
+				Title:    "feat: cache",
+				Author:   "testuser",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "release-1.x",
+			followUps:    []*forge.PRInfo{{Number: 330, Title: "fix: cache eviction"}},
+			contains: []string{
+				"## Follow-up Fixes\n\nThese PRs fix or follow up on #321 and may need to be backported as well:\n\n- #330 fix: cache eviction\n",
+			},
+		},
+		{
+			name: "PR with diffstat",
+			pr: &forge.PRInfo{
+				Number:   321,
+				Title:    "fix: migration",
+				Author:   "testuser",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "release-1.x",
+			diffstat:     "2 files changed, 10 insertions(+), 3 deletions(-)",
+			contains: []string{
+				"- **Backport changes**: 2 files changed, 10 insertions(+), 3 deletions(-)\n",
+			},
+		},
+		{
+			name: "PR without body",
+			pr: &forge.PRInfo{
+				Number:   456,
+				Title:    "fix: bug fix",
+				Body:     "",
+				Author:   "anotheruser",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "stable",
+			contains: []string{
+				"Backport of #456 to `stable`",
+				"**Title**: fix: bug fix",
+			},
+			notContains: []string{
+				"## Original Description",
+				"**Backport changes**",
+			},
+		},
+		{
+			name: "PR with long body gets truncated",
+			pr: &forge.PRInfo{
+				Number:   789,
+				Title:    "docs: update",
+				Body:     string(make([]byte, 2500)), // 2500 bytes, over 2000 limit
+				Author:   "user",
+				MergedAt: mergedAt,
+			},
+			targetBranch: "main",
+			contains: []string{
+				"... (truncated)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatPRBody(tt.pr, tt.targetBranch, tt.checklist, tt.followUps, tt.diffstat)
+
+			for _, s := range tt.contains {
+				assert.Contains(t, result, s)
+			}
+
+			for _, s := range tt.notContains {
+				assert.NotContains(t, result, s)
+			}
+		})
+	}
+}
+
+func TestReviewChecklist(t *testing.T) {
+	checklists := []config.ReviewChecklistConfig{
+		{Branches: []string{"release-.*"}, Items: []string{"Verify migration guards", "Update version constants"}},
+		{Branches: []string{"release-1.x"}, Items: []string{"Check Go 1.21 compatibility", "Update version constants"}},
+	}
+
+	assert.Equal(t, []string{"Verify migration guards", "Update version constants", "Check Go 1.21 compatibility"},
+		ReviewChecklist(checklists, "release-1.x"))
+	assert.Equal(t, []string{"Verify migration guards", "Update version constants"},
+		ReviewChecklist(checklists, "release-2.x"))
+	assert.Empty(t, ReviewChecklist(checklists, "stable"))
+}
+
+func TestPRMetadata(t *testing.T) {
+	original := &forge.PRInfo{
+		Labels:    []string{"bug", "backport release-1.x", "area/api"},
+		Assignees: []string{"alice"},
+		Milestone: 7,
+	}
+
+	assert.Equal(t, forge.CreatePROptions{}, PRMetadata(config.CIConfig{}, original))
+
+	meta := PRMetadata(config.CIConfig{
+		CopyLabels:    true,
+		CopyAssignees: true,
+		CopyMilestone: true,
+		ScopeConfig: config.ScopeConfig{
+			Labels:    []string{"backported", "bug"},
+			Reviewers: []string{"bob"},
+			DraftPRs:  true,
+		},
+	}, original)
+	assert.Equal(t, []string{"bug", "area/api", "backported"}, meta.Labels)
+	assert.Equal(t, []string{"alice"}, meta.Assignees)
+	assert.Equal(t, []string{"bob"}, meta.Reviewers)
+	assert.Equal(t, 7, meta.Milestone)
+	assert.True(t, meta.Draft)
+}
+
+func TestOriginalReviewers(t *testing.T) {
+	original := &forge.PRInfo{Author: "alice", MergedBy: "bob"}
+
+	assert.Empty(t, OriginalReviewers(config.CIConfig{}, original))
+	assert.Equal(t, []string{"alice"}, OriginalReviewers(config.CIConfig{RequestReviewFromAuthor: true}, original))
+	assert.Equal(t, []string{"alice", "bob"}, OriginalReviewers(config.CIConfig{RequestReviewFromAuthor: true, RequestReviewFromMerger: true}, original))
+
+	// Authors merging their own PRs are requested once.
+	selfMerged := &forge.PRInfo{Author: "alice", MergedBy: "alice"}
+	assert.Equal(t, []string{"alice"}, OriginalReviewers(config.CIConfig{RequestReviewFromAuthor: true, RequestReviewFromMerger: true}, selfMerged))
+}
@@ -0,0 +1,593 @@
+package backportci
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// Options are the per-run settings of a Runner, usually set from the command line.
+type Options struct {
+	// Ref the backport branches are created from, {target} is replaced by the target branch.
+	// Defaults to <push remote>/<target>.
+	Base string
+
+	// Options of the cherry-picks.
+	CherryPick git.CherryPickOptions
+
+	// Strategy for PRs that were not squash merged ("squash", "mainline" or "commits", see
+	// backport.StrategyCommits). Defaults to "commits".
+	Strategy string
+
+	// Number of target branches backported concurrently in their own worktrees.
+	Concurrency int
+
+	// Open all backport PRs as drafts.
+	Draft bool
+
+	// Only report what would be done, without creating branches or PRs.
+	DryRun bool
+}
+
+// Runner backports merged PRs to their target branches and opens a backport PR for each.
+type Runner struct {
+	Forge forge.Forge
+	Git   Git
+	Now   func() time.Time
+
+	// Repository the backport PRs are opened in.
+	Owner string
+	Repo  string
+
+	// Repository the merged PRs are read from, Owner and Repo if empty.
+	SourceOwner string
+	SourceRepo  string
+
+	// Remote the merged PRs are fetched from and remote the backport branches are based on and pushed to.
+	Remote     string
+	PushRemote string
+
+	CI               config.CIConfig
+	ReviewChecklists []config.ReviewChecklistConfig
+
+	// Audit log the backports are recorded in, nil to record nothing.
+	Audit *backport.AuditLog
+
+	Options
+}
+
+// NewRunner returns a Runner for the repository in the working directory, with the CI settings
+// of cfg and the system clock. owner and repo are those of the repository the backport PRs are opened in.
+func NewRunner(f forge.Forge, cfg *config.Config, owner, repo string, opts Options) *Runner {
+	var audit *backport.AuditLog
+	if cfg.Audit.Enabled {
+		audit = backport.NewAuditLog(cfg.Audit.Path, backport.AuditActor())
+	}
+	return &Runner{
+		Forge:            f,
+		Git:              LocalGit{},
+		Now:              time.Now,
+		Owner:            owner,
+		Repo:             repo,
+		Remote:           cfg.Remote,
+		PushRemote:       cfg.WriteRemote(),
+		CI:               cfg.CI,
+		ReviewChecklists: cfg.ReviewChecklists,
+		Audit:            audit,
+		Options:          opts,
+	}
+}
+
+// DetectPR returns the number of the PR a commit was merged from, 0 if its message names none.
+func (r *Runner) DetectPR(ref string) (int, error) {
+	message, err := r.Git.CommitMessage(ref)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get commit message from %s: %w", ref, err)
+	}
+	log.Debug().Str("ref", ref).Str("message", message).Msg("default branch commit message")
+
+	return ParsePRNumber(message), nil
+}
+
+// QuietWindow returns the configured quiet window the runner's clock is in, nil if pushing is allowed.
+func (r *Runner) QuietWindow() *config.QuietWindow {
+	return r.CI.QuietWindowAt(r.Now())
+}
+
+// Backport creates backport branches and PRs of a merged PR for each target branch. The
+// follow-up fixes of the PR are listed in the backport PRs. The results are recorded in the audit log.
+func (r *Runner) Backport(ctx context.Context, pr *forge.PRInfo, followUps []*forge.PRInfo, targetBranches []string) []Result {
+	results := make([]Result, len(targetBranches))
+	defer func() { r.RecordAudit(pr, results) }()
+
+	if r.Concurrency <= 1 || len(targetBranches) == 1 {
+		for i, targetBranch := range targetBranches {
+			results[i] = r.process(ctx, pr, followUps, targetBranch, false)
+		}
+		return results
+	}
+
+	// Concurrent backports cherry-pick in their own worktrees, the checkout is shared.
+	log.Debug().Int("concurrency", r.Concurrency).Msg("backporting target branches concurrently")
+	sem := make(chan struct{}, r.Concurrency)
+	var wg sync.WaitGroup
+	for i, targetBranch := range targetBranches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = r.process(ctx, pr, followUps, targetBranch, true)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// RecordAudit records the backport results of a PR in the audit log, unless in dry-run mode.
+// Failures to record are logged.
+func (r *Runner) RecordAudit(pr *forge.PRInfo, results []Result) {
+	if r.Audit == nil || r.DryRun {
+		return
+	}
+	for _, result := range results {
+		entry := backport.AuditEntry{
+			Repo:         r.Owner + "/" + r.Repo,
+			SourceSHA:    pr.MergeCommit,
+			SourcePR:     pr.Number,
+			TargetBranch: result.TargetBranch,
+			Result:       auditResult(result),
+			BackportPR:   result.PRNumber,
+			Message:      result.Message,
+		}
+		if result.Error != nil {
+			entry.Message = result.Error.Error()
+		}
+		if err := r.Audit.Record(entry); err != nil {
+			log.Warn().Err(err).Str("path", r.Audit.Path()).Msg("failed to record backport in audit log")
+		}
+	}
+}
+
+// auditResult returns the audit result of a CI backport.
+func auditResult(result Result) string {
+	switch {
+	case result.Skipped:
+		return backport.AuditSkipped
+	case result.Deferred:
+		return backport.AuditDeferred
+	case result.Conflict:
+		return backport.AuditConflict
+	case result.Success:
+		return backport.AuditSuccess
+	default:
+		return backport.AuditFailed
+	}
+}
+
+// prefix returns the conventional commit prefix of the backport PRs of a PR.
+func (r *Runner) prefix(pr *forge.PRInfo) string {
+	if prefix := ConvCommitPrefix(pr.Title); prefix != "" {
+		return prefix
+	}
+	return r.CI.DefaultPrefix
+}
+
+// process handles backporting to a single target branch. The merge commit is fetched from
+// Remote, while the backport branch is based on and pushed to PushRemote.
+// Isolated backports cherry-pick in a temporary worktree and leave the current checkout alone.
+func (r *Runner) process(ctx context.Context, pr *forge.PRInfo, followUps []*forge.PRInfo, targetBranch string, isolated bool) Result {
+	result := Result{
+		TargetBranch: targetBranch,
+	}
+
+	branchName := BranchName(pr.Number, targetBranch)
+
+	pushTo, head, err := r.pushTarget(branchName)
+	if err != nil {
+		result.Error = err
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	log.Info().
+		Str("target", targetBranch).
+		Str("branch", branchName).
+		Msg("processing backport")
+
+	// Check if backport PR already exists.
+	existingPRs, err := r.Forge.ListOpenPRs(ctx, r.Owner, r.Repo, forge.ListPROptions{
+		Head: head,
+	})
+	// Changes pushed for review cannot be replaced, pushing again opens new ones.
+	update := r.CI.UpdateExisting && !r.Forge.Capabilities().PushForReview
+	var existingPR int
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to check for existing backport PR")
+		// Continue anyway - we'll fail later if there's a real problem.
+	} else if len(existingPRs) > 0 && update {
+		existingPR = existingPRs[0].Number
+		log.Info().Int("pr", existingPR).Msg("backport PR already exists, updating it")
+	} else if len(existingPRs) > 0 {
+		result.Skipped = true
+		result.Success = true
+		result.PRNumber = existingPRs[0].Number
+		result.Message = fmt.Sprintf("backport PR #%d already exists", existingPRs[0].Number)
+		log.Info().Int("pr", existingPRs[0].Number).Msg("backport PR already exists, skipping")
+		return result
+	}
+
+	if r.DryRun && existingPR > 0 {
+		result.Success = true
+		result.PRNumber = existingPR
+		result.Message = fmt.Sprintf("would update backport PR #%d", existingPR)
+		log.Info().Int("pr", existingPR).Msg("dry-run: would rebuild backport branch and update PR")
+		return result
+	}
+	if r.DryRun {
+		result.Success = true
+		result.Message = "would create backport PR"
+		log.Info().Msg("dry-run: would create backport branch and PR")
+		return result
+	}
+
+	// Create backport branch from the target branch (or the configured base).
+	from := BaseRef(r.Base, r.PushRemote, targetBranch)
+	if r.Base != "" {
+		CheckBase(r.Git, from, r.PushRemote+"/"+targetBranch)
+	}
+	if update {
+		// A branch left behind by a previous run in this checkout is rebuilt.
+		_ = r.Git.DeleteBranch(branchName)
+	}
+	log.Debug().Str("branch", branchName).Str("from", from).Msg("creating backport branch")
+	if err := r.Git.CreateBranch(branchName, from); err != nil {
+		result.Error = fmt.Errorf("failed to create branch: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	// leave returns to the target branch, so that the backport branch can be deleted.
+	leave := func() {
+		if !isolated {
+			_ = r.Git.CheckoutBranch(targetBranch)
+		}
+	}
+
+	r.Git.EnsureCommit(r.Remote, pr.MergeCommit, branchName)
+
+	prTitle := PRTitle(r.prefix(pr), pr.Number, targetBranch)
+
+	// With conflict PRs enabled, conflicts are committed as they are instead of aborted.
+	var conflictMessage string
+	if r.CI.CreateConflictPR {
+		conflictMessage = prTitle + "\n\nThis commit contains unresolved conflict markers."
+	}
+
+	commits, mainline, err := r.prCommits(ctx, pr)
+	if err == nil && len(commits) == 0 {
+		err = fmt.Errorf("PR #%d has no commits to backport", pr.Number)
+	}
+	if err != nil {
+		leave()
+		_ = r.Git.DeleteBranch(branchName)
+		result.Error = err
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	cpResult, err := r.cherryPick(branchName, pr.Number, commits, mainline, conflictMessage, isolated)
+	if err != nil {
+		leave()
+		_ = r.Git.DeleteBranch(branchName)
+		result.Error = fmt.Errorf("cherry-pick failed: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	if cpResult.HasConflict && !r.CI.CreateConflictPR {
+		leave()
+		_ = r.Git.DeleteBranch(branchName)
+		result.Conflict = true
+		result.Error = fmt.Errorf("cherry-pick has conflicts")
+		result.Message = "cherry-pick has conflicts - manual backport required"
+		return result
+	}
+
+	if cpResult.Empty {
+		leave()
+		_ = r.Git.DeleteBranch(branchName)
+		result.Skipped = true
+		result.Success = true
+		result.Message = "changes already present on target branch"
+		log.Info().Str("target", targetBranch).Msg("changes already present on target branch, skipping")
+		return result
+	}
+
+	// Push the branch.
+	log.Debug().Str("branch", branchName).Bool("force", update).Msg("pushing backport branch")
+	push := func() error {
+		return r.Git.Push(pushTo, branchName, targetBranch, r.Forge.Capabilities().PushForReview)
+	}
+	if update {
+		// The branch of a previous run is replaced, its commits are not built upon.
+		push = func() error { return r.Git.ForcePush(pushTo, branchName) }
+	}
+	if err := push(); err != nil {
+		leave()
+		_ = r.Git.DeleteBranch(branchName)
+		result.Error = fmt.Errorf("failed to push: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	// Create the PR.
+	if r.CI.IncludeDiffstat {
+		result.Diffstat = Diffstat(r.Git, from, branchName)
+	}
+	prBody := FormatPRBody(pr, targetBranch, ReviewChecklist(r.ReviewChecklists, targetBranch), followUps, result.Diffstat)
+	if cpResult.HasConflict {
+		prBody = formatConflictSection(cpResult.Conflicts) + prBody
+	} else if len(cpResult.Resolved) > 0 {
+		prBody = formatRerereSection(cpResult.Resolved) + prBody
+	}
+
+	prOpts := PRMetadata(r.CI, pr)
+	prOpts.Draft = prOpts.Draft || r.Draft
+	prOpts.Title = prTitle
+	prOpts.Body = prBody
+	prOpts.Head = head
+	prOpts.Base = targetBranch
+	if cpResult.HasConflict {
+		prOpts.Draft = true
+		prOpts.Labels = append(slices.Clone(prOpts.Labels), ConflictLabel)
+	}
+	// The draft state still keeps the PR from being auto-merged on forges without drafts.
+	drafts := r.Forge.Capabilities().DraftPRs
+	if prOpts.Draft && !drafts {
+		log.Warn().Str("forge", r.Forge.Name()).Msg("forge does not support draft PRs, the backport PR is not opened as a draft")
+	}
+
+	if existingPR > 0 {
+		leave()
+		return r.updatePR(ctx, result, existingPR, prOpts, cpResult.HasConflict)
+	}
+
+	log.Debug().Str("title", prTitle).Msg("creating backport PR")
+	newPRNumber, err := r.Forge.CreatePR(ctx, r.Owner, r.Repo, prOpts)
+	if err != nil && newPRNumber == 0 {
+		result.Error = fmt.Errorf("failed to create PR: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+	if err != nil {
+		log.Warn().Err(err).Int("pr", newPRNumber).Msg("backport PR created without all metadata")
+	}
+
+	r.requestReview(ctx, newPRNumber, OriginalReviewers(r.CI, pr))
+	if !cpResult.HasConflict && !prOpts.Draft {
+		r.autoMerge(ctx, newPRNumber, targetBranch)
+	}
+
+	// Return to the target branch (optional cleanup).
+	leave()
+
+	if cpResult.HasConflict {
+		result.Conflict = true
+		result.PRNumber = newPRNumber
+		result.Error = fmt.Errorf("cherry-pick has conflicts")
+		kind := "draft PR"
+		if !drafts {
+			kind = "PR"
+		}
+		result.Message = fmt.Sprintf("cherry-pick has conflicts - resolve them in %s #%d", kind, newPRNumber)
+		log.Warn().Int("pr", newPRNumber).Str("target", targetBranch).Msg("opened backport PR with conflicts")
+		return result
+	}
+
+	result.Success = true
+	result.PRNumber = newPRNumber
+	result.Message = fmt.Sprintf("created backport PR #%d", newPRNumber)
+
+	log.Info().
+		Int("pr", newPRNumber).
+		Str("target", targetBranch).
+		Msg("backport PR created successfully")
+
+	return result
+}
+
+// prCommits returns the commits of a merged PR to cherry-pick in order and the parent they are
+// picked against (see git cherry-pick -m), following Strategy like local PR backports: squash
+// merges are picked as they are, other merges commit by commit unless Strategy says otherwise.
+func (r *Runner) prCommits(ctx context.Context, pr *forge.PRInfo) ([]string, int, error) {
+	switch {
+	case pr.IsSquashMerge():
+		return []string{pr.MergeCommit}, 0, nil
+	case r.Strategy == backport.StrategyMainline:
+		return []string{pr.MergeCommit}, 1, nil
+	case r.Strategy == backport.StrategySquash:
+		return nil, 0, fmt.Errorf("PR #%d was %w", pr.Number, backport.ErrNotSquashed)
+	}
+
+	owner, repo := r.SourceOwner, r.SourceRepo
+	if owner == "" {
+		owner, repo = r.Owner, r.Repo
+	}
+	commits, err := r.Forge.ListPRCommits(ctx, owner, repo, pr.Number)
+	if err != nil {
+		log.Warn().Err(err).Int("pr", pr.Number).Msg("failed to list PR commits, using the commits of the merge commit")
+		shas, err := r.Git.MergedCommits(pr.MergeCommit)
+		return shas, 0, err
+	}
+
+	var shas []string
+	for _, commit := range commits {
+		// Merges of the base branch into the PR are not part of its changes.
+		if len(commit.Parents) <= 1 {
+			shas = append(shas, commit.SHA)
+		}
+	}
+	return shas, 0, nil
+}
+
+// cherryPick cherry-picks the commits of a PR in order onto the backport branch, each with the
+// backport trailers of its original commit. It stops at the first conflict, the result is that
+// of the last cherry-pick, empty only if all commits were and listing all rerere resolutions.
+func (r *Runner) cherryPick(branchName string, prNumber int, commits []string, mainline int, conflictMessage string, isolated bool) (*git.CherryPickResult, error) {
+	var result *git.CherryPickResult
+	var resolved []string
+	empty := true
+	for _, sha := range commits {
+		opts := r.CherryPick
+		opts.Mainline = mainline
+		opts.Trailers = version.SignatureTrailers(sha, prNumber)
+
+		var err error
+		result, err = r.Git.CherryPick(branchName, sha, opts, conflictMessage, isolated)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, result.Resolved...)
+		empty = empty && result.Empty
+		if result.HasConflict {
+			break
+		}
+	}
+
+	result.Empty = empty && !result.HasConflict
+	result.Resolved = resolved
+	return result, nil
+}
+
+// updatePR updates the title and body of the existing backport PR of a rebuilt backport branch.
+// Labels, assignees and reviewers of the PR are left alone, they may have been changed since.
+func (r *Runner) updatePR(ctx context.Context, result Result, number int, opts forge.CreatePROptions, conflict bool) Result {
+	log.Debug().Int("pr", number).Str("title", opts.Title).Msg("updating backport PR")
+	result.PRNumber = number
+	if err := r.Forge.UpdatePR(ctx, r.Owner, r.Repo, number, forge.UpdatePROptions{Title: &opts.Title, Body: &opts.Body}); err != nil {
+		result.Error = fmt.Errorf("failed to update PR #%d: %w", number, err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	if conflict {
+		result.Conflict = true
+		result.Error = fmt.Errorf("cherry-pick has conflicts")
+		result.Message = fmt.Sprintf("cherry-pick has conflicts - resolve them in backport PR #%d", number)
+		log.Warn().Int("pr", number).Str("target", result.TargetBranch).Msg("updated backport PR with conflicts")
+		return result
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("updated backport PR #%d", number)
+	log.Info().Int("pr", number).Str("target", result.TargetBranch).Msg("backport PR updated successfully")
+	return result
+}
+
+// autoMerge approves a backport PR and schedules it to merge once its checks pass, as configured
+// for its target branch. Failures are only logged, the PR can still be merged by hand.
+func (r *Runner) autoMerge(ctx context.Context, number int, targetBranch string) {
+	settings := AutoMerge(r.CI.AutoMerge, targetBranch)
+	if settings == nil {
+		return
+	}
+	caps := r.Forge.Capabilities()
+	if settings.Approve && !caps.Reviews {
+		log.Warn().Int("pr", number).Str("forge", r.Forge.Name()).Msg("forge does not support approving PRs, skipping approval")
+	} else if settings.Approve {
+		if err := r.Forge.ApprovePR(ctx, r.Owner, r.Repo, number); err != nil {
+			log.Warn().Err(err).Int("pr", number).Msg("failed to approve backport PR")
+		} else {
+			log.Debug().Int("pr", number).Msg("approved backport PR")
+		}
+	}
+	if settings.Method != "" && !caps.AutoMerge {
+		log.Warn().Int("pr", number).Str("forge", r.Forge.Name()).Msg("forge does not support auto-merge, skipping it")
+	} else if settings.Method != "" {
+		if err := r.Forge.EnableAutoMerge(ctx, r.Owner, r.Repo, number, settings.Method); err != nil {
+			log.Warn().Err(err).Int("pr", number).Msg("failed to enable auto-merge of backport PR")
+		} else {
+			log.Info().Int("pr", number).Str("method", settings.Method).Msg("backport PR will be merged once its checks pass")
+		}
+	}
+}
+
+// pushTarget returns the remote or URL a backport branch is pushed to and the head its PR is
+// opened from, the branch of the configured fork with CI.PushFork.
+func (r *Runner) pushTarget(branchName string) (pushTo, head string, err error) {
+	if r.CI.PushFork == "" {
+		return r.PushRemote, branchName, nil
+	}
+	if r.Forge.Capabilities().PushForReview {
+		return "", "", fmt.Errorf("ci.push_fork is not supported by %s, changes are pushed for review", r.Forge.Name())
+	}
+
+	forkOwner, forkRepo, _ := strings.Cut(r.CI.PushFork, "/")
+	remoteURL, err := r.Git.RemoteURL(r.PushRemote)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the URL of %s: %w", r.PushRemote, err)
+	}
+	forkURL, err := git.RewriteRemoteURL(remoteURL, forkOwner, forkRepo)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to derive the URL of fork %s: %w", r.CI.PushFork, err)
+	}
+	return forkURL, forkOwner + ":" + branchName, nil
+}
+
+// requestReview requests a review of a backport PR. Failures are only logged, e.g. forges
+// reject review requests from the PR author when the author created the backport PR.
+func (r *Runner) requestReview(ctx context.Context, number int, reviewers []string) {
+	if len(reviewers) == 0 {
+		return
+	}
+	if !r.Forge.Capabilities().Reviews {
+		log.Warn().Int("pr", number).Strs("reviewers", reviewers).Str("forge", r.Forge.Name()).Msg("forge does not support review requests, skipping them")
+		return
+	}
+	if err := r.Forge.RequestReview(ctx, r.Owner, r.Repo, number, reviewers); err != nil {
+		log.Warn().Err(err).Int("pr", number).Strs("reviewers", reviewers).Msg("failed to request review")
+		return
+	}
+	log.Debug().Int("pr", number).Strs("reviewers", reviewers).Msg("requested review")
+}
+
+// CheckBase warns if base and target do not share history in either direction,
+// i.e. base is neither a commit on target nor built on top of it.
+func CheckBase(g Git, base, target string) {
+	onTarget, err := g.IsAncestor(base, target)
+	if err != nil {
+		log.Warn().Err(err).Str("base", base).Msg("failed to check backport base")
+		return
+	}
+	onTop, err := g.IsAncestor(target, base)
+	if err != nil {
+		log.Warn().Err(err).Str("base", base).Msg("failed to check backport base")
+		return
+	}
+	if !onTarget && !onTop {
+		log.Warn().Str("base", base).Str("target", target).Msg("backport base diverged from the target branch")
+	}
+}
+
+// Diffstat returns the short diffstat of the backport commits between from and to.
+// Failing to compute it is logged, backport PRs work without it.
+func Diffstat(g Git, from, to string) string {
+	diffstat, err := g.DiffStat(from, to)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to compute the diffstat of the backport")
+	}
+	return diffstat
+}
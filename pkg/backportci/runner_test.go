@@ -0,0 +1,497 @@
+package backportci
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/config"
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/pkg/git"
+	"codefloe.com/pat-s/backporter/shared/version"
+)
+
+// createPRForge records the PRs created, approved and scheduled to merge through it.
+type createPRForge struct {
+	forge.Forge
+	created    []forge.CreatePROptions
+	approved   []int
+	autoMerged map[int]string
+
+	// limited makes the forge support neither drafts, reviews nor auto-merge.
+	limited bool
+
+	// Commits of the merged PR, listing them fails with commitsErr.
+	commits    []*forge.CommitInfo
+	commitsErr error
+}
+
+func (f *createPRForge) Name() string { return "fake" }
+
+func (f *createPRForge) Capabilities() forge.Capabilities {
+	return forge.Capabilities{DraftPRs: !f.limited, AutoMerge: !f.limited, Reviews: !f.limited}
+}
+
+func (f *createPRForge) ListOpenPRs(_ context.Context, _, _ string, _ forge.ListPROptions) ([]*forge.PRInfo, error) {
+	return nil, nil
+}
+
+func (f *createPRForge) ListPRCommits(_ context.Context, _, _ string, _ int) ([]*forge.CommitInfo, error) {
+	return f.commits, f.commitsErr
+}
+
+func (f *createPRForge) CreatePR(_ context.Context, _, _ string, opts forge.CreatePROptions) (int, error) {
+	f.created = append(f.created, opts)
+	return 100 + len(f.created), nil
+}
+
+func (f *createPRForge) ApprovePR(_ context.Context, _, _ string, number int) error {
+	f.approved = append(f.approved, number)
+	return nil
+}
+
+func (f *createPRForge) EnableAutoMerge(_ context.Context, _, _ string, number int, method string) error {
+	if f.autoMerged == nil {
+		f.autoMerged = map[int]string{}
+	}
+	f.autoMerged[number] = method
+	return nil
+}
+
+// fakeGit records the branches a Runner creates, deletes and pushes without touching a repository.
+type fakeGit struct {
+	message  string
+	result   git.CherryPickResult
+	pickErr  error
+	created  []string
+	deleted  []string
+	pushed   []string
+	pushedTo []string
+	forced   []string
+	diffstat string
+}
+
+func (g *fakeGit) CommitMessage(_ string) (string, error) { return g.message, nil }
+
+func (g *fakeGit) CreateBranch(branch, _ string) error {
+	g.created = append(g.created, branch)
+	return nil
+}
+
+func (g *fakeGit) CheckoutBranch(_ string) error { return nil }
+
+func (g *fakeGit) DeleteBranch(branch string) error {
+	g.deleted = append(g.deleted, branch)
+	return nil
+}
+
+func (g *fakeGit) IsAncestor(_, _ string) (bool, error) { return true, nil }
+
+func (g *fakeGit) MergedCommits(_ string) ([]string, error) { return nil, nil }
+
+func (g *fakeGit) EnsureCommit(_, _, _ string) {}
+
+func (g *fakeGit) CherryPick(_, _ string, _ git.CherryPickOptions, _ string, _ bool) (*git.CherryPickResult, error) {
+	if g.pickErr != nil {
+		return nil, g.pickErr
+	}
+	result := g.result
+	return &result, nil
+}
+
+func (g *fakeGit) Push(remote, branch, _ string, _ bool) error {
+	g.pushed = append(g.pushed, branch)
+	g.pushedTo = append(g.pushedTo, remote)
+	return nil
+}
+
+func (g *fakeGit) ForcePush(remote, branch string) error {
+	g.forced = append(g.forced, branch)
+	g.pushedTo = append(g.pushedTo, remote)
+	return nil
+}
+
+func (g *fakeGit) DiffStat(_, _ string) (string, error) { return g.diffstat, nil }
+
+func (g *fakeGit) RemoteURL(_ string) (string, error) {
+	return "https://codeberg.org/owner/repo.git", nil
+}
+
+// newTestRunner returns a Runner for owner/repo on origin with the given forge and git.
+func newTestRunner(f forge.Forge, g Git) *Runner {
+	return &Runner{
+		Forge:      f,
+		Git:        g,
+		Now:        time.Now,
+		Owner:      "owner",
+		Repo:       "repo",
+		Remote:     "origin",
+		PushRemote: "origin",
+		CI:         config.CIConfig{DefaultPrefix: "chore"},
+	}
+}
+
+func TestRunnerDetectPR(t *testing.T) {
+	r := newTestRunner(&createPRForge{}, &fakeGit{message: "fix(api): resolve bug (#456)"})
+	number, err := r.DetectPR("origin/main")
+	require.NoError(t, err)
+	assert.Equal(t, 456, number)
+
+	r.Git = &fakeGit{message: "Just a regular commit message"}
+	number, err = r.DetectPR("origin/main")
+	require.NoError(t, err)
+	assert.Zero(t, number)
+}
+
+func TestRunnerQuietWindow(t *testing.T) {
+	r := newTestRunner(&createPRForge{}, &fakeGit{})
+	r.CI.QuietHours = []config.QuietWindow{{Start: "22:00", End: "06:00"}}
+
+	r.Now = func() time.Time { return time.Date(2024, 5, 8, 23, 0, 0, 0, time.UTC) }
+	assert.NotNil(t, r.QuietWindow())
+
+	r.Now = func() time.Time { return time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC) }
+	assert.Nil(t, r.QuietWindow())
+}
+
+func TestRunnerBackport(t *testing.T) {
+	ctx := context.Background()
+	pr := &forge.PRInfo{Number: 42, Title: "Fix a bug", MergeCommit: "abc1234", Squashed: true}
+	targets := []string{"release-1.x", "release-2.x"}
+
+	f := &createPRForge{}
+	g := &fakeGit{diffstat: "1 file changed, 1 insertion(+)"}
+	r := newTestRunner(f, g)
+	r.CI.IncludeDiffstat = true
+	results := r.Backport(ctx, pr, nil, targets)
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.True(t, result.Success, result.Message)
+		assert.Equal(t, targets[i], result.TargetBranch)
+		assert.Equal(t, 101+i, result.PRNumber)
+		assert.Equal(t, "1 file changed, 1 insertion(+)", result.Diffstat)
+	}
+	assert.Equal(t, []string{"backport-42-to-release-1.x", "backport-42-to-release-2.x"}, g.pushed)
+	require.Len(t, f.created, 2)
+	assert.Equal(t, "chore: backport #42 to release-1.x", f.created[0].Title)
+	assert.Equal(t, "release-2.x", f.created[1].Base)
+	require.NoError(t, FailedError(results))
+
+	// Dry runs neither create branches nor PRs.
+	g = &fakeGit{}
+	r = newTestRunner(&createPRForge{}, g)
+	r.DryRun = true
+	results = r.Backport(ctx, pr, nil, targets)
+	assert.True(t, results[0].Success)
+	assert.Empty(t, g.created)
+
+	// Conflicts delete the backport branch and fail the run with backport.ErrConflict.
+	g = &fakeGit{result: git.CherryPickResult{HasConflict: true}}
+	r = newTestRunner(&createPRForge{}, g)
+	results = r.Backport(ctx, pr, nil, targets[:1])
+	assert.True(t, results[0].Conflict)
+	assert.Equal(t, []string{"backport-42-to-release-1.x"}, g.deleted)
+	assert.Empty(t, g.pushed)
+	require.ErrorIs(t, FailedError(results), backport.ErrConflict)
+
+	// Conflict PRs on forges without drafts are not announced as drafts.
+	f = &createPRForge{limited: true}
+	r = newTestRunner(f, &fakeGit{result: git.CherryPickResult{HasConflict: true}})
+	r.CI.CreateConflictPR = true
+	results = r.Backport(ctx, pr, nil, targets[:1])
+	require.Len(t, f.created, 1)
+	assert.Equal(t, "cherry-pick has conflicts - resolve them in PR #101", results[0].Message)
+
+	// Conflicts resolved from recorded resolutions are listed in the PR.
+	f = &createPRForge{}
+	r = newTestRunner(f, &fakeGit{result: git.CherryPickResult{Success: true, Resolved: []string{"app.go"}}})
+	results = r.Backport(ctx, pr, nil, targets[:1])
+	assert.True(t, results[0].Success)
+	require.Len(t, f.created, 1)
+	assert.Contains(t, f.created[0].Body, "resolved from recorded resolutions")
+	assert.Contains(t, f.created[0].Body, "- `app.go`")
+
+	// Changes already on the target branch are skipped.
+	g = &fakeGit{result: git.CherryPickResult{Empty: true}}
+	r = newTestRunner(&createPRForge{}, g)
+	results = r.Backport(ctx, pr, nil, targets[:1])
+	assert.True(t, results[0].Skipped)
+	assert.Equal(t, []string{"backport-42-to-release-1.x"}, g.deleted)
+
+	g = &fakeGit{pickErr: errors.New("boom")}
+	r = newTestRunner(&createPRForge{}, g)
+	results = r.Backport(ctx, pr, nil, targets[:1])
+	assert.True(t, results[0].Failed())
+	assert.Equal(t, []string{"backport-42-to-release-1.x"}, g.deleted)
+}
+
+func TestRunnerPushFork(t *testing.T) {
+	pr := &forge.PRInfo{Number: 42, Title: "Fix a bug", MergeCommit: "abc1234", Squashed: true}
+
+	f := &createPRForge{}
+	g := &fakeGit{}
+	r := newTestRunner(f, g)
+	r.CI.PushFork = "bot/repo-fork"
+	results := r.Backport(context.Background(), pr, nil, []string{"release-1.x"})
+	require.True(t, results[0].Success, results[0].Message)
+	assert.Equal(t, []string{"https://codeberg.org/bot/repo-fork.git"}, g.pushedTo)
+	require.Len(t, f.created, 1)
+	assert.Equal(t, "bot:backport-42-to-release-1.x", f.created[0].Head)
+	assert.Equal(t, "release-1.x", f.created[0].Base)
+}
+
+// existingPRForge has an open backport PR for every head and records the PRs updated through it.
+type existingPRForge struct {
+	createPRForge
+	updated map[int]forge.UpdatePROptions
+}
+
+func (f *existingPRForge) ListOpenPRs(_ context.Context, _, _ string, _ forge.ListPROptions) ([]*forge.PRInfo, error) {
+	return []*forge.PRInfo{{Number: 7}}, nil
+}
+
+func (f *existingPRForge) UpdatePR(_ context.Context, _, _ string, number int, opts forge.UpdatePROptions) error {
+	f.updated[number] = opts
+	return nil
+}
+
+func TestRunnerUpdateExisting(t *testing.T) {
+	ctx := context.Background()
+	pr := &forge.PRInfo{Number: 42, Title: "Fix a bug", MergeCommit: "abc1234", Squashed: true}
+
+	// Existing backport PRs are skipped by default.
+	f := &existingPRForge{updated: map[int]forge.UpdatePROptions{}}
+	g := &fakeGit{}
+	results := newTestRunner(f, g).Backport(ctx, pr, nil, []string{"release-1.x"})
+	assert.True(t, results[0].Skipped)
+	assert.Empty(t, g.created)
+
+	// With ci.update_existing, the branch is rebuilt, force-pushed and the PR updated.
+	r := newTestRunner(f, g)
+	r.CI.UpdateExisting = true
+	results = r.Backport(ctx, pr, nil, []string{"release-1.x"})
+	require.True(t, results[0].Success, results[0].Message)
+	assert.False(t, results[0].Skipped)
+	assert.Equal(t, 7, results[0].PRNumber)
+	assert.Equal(t, "updated backport PR #7", results[0].Message)
+	assert.Equal(t, []string{"backport-42-to-release-1.x"}, g.created)
+	assert.Equal(t, []string{"backport-42-to-release-1.x"}, g.forced)
+	assert.Empty(t, g.pushed)
+	assert.Empty(t, f.created)
+	require.Contains(t, f.updated, 7)
+	assert.Equal(t, "chore: backport #42 to release-1.x", *f.updated[7].Title)
+}
+
+func TestRunnerAutoMerge(t *testing.T) {
+	pr := &forge.PRInfo{Number: 42, Title: "Fix a bug", MergeCommit: "abc1234", Squashed: true}
+
+	f := &createPRForge{}
+	r := newTestRunner(f, &fakeGit{})
+	r.CI.AutoMerge = []config.AutoMergeConfig{
+		{Branches: []string{"release-1.x"}, Method: "squash", Approve: true},
+		{Branches: []string{"release-.*"}, Approve: true},
+	}
+	results := r.Backport(context.Background(), pr, nil, []string{"release-1.x", "release-2.x", "stable"})
+	require.NoError(t, FailedError(results))
+	assert.Equal(t, []int{101, 102}, f.approved)
+	assert.Equal(t, map[int]string{101: "squash"}, f.autoMerged)
+
+	// Forges without reviews and auto-merge are not asked for them, RequestReview would panic.
+	f = &createPRForge{limited: true}
+	r.Forge = f
+	r.CI.RequestReviewFromAuthor = true
+	results = r.Backport(context.Background(), &forge.PRInfo{Number: 42, Author: "alice", MergeCommit: "abc1234", Squashed: true}, nil, []string{"release-1.x"})
+	require.NoError(t, FailedError(results))
+	assert.Empty(t, f.approved)
+	assert.Empty(t, f.autoMerged)
+
+	// Draft PRs are left alone.
+	f = &createPRForge{}
+	r.Forge = f
+	r.CI.RequestReviewFromAuthor = false
+	r.Draft = true
+	r.Backport(context.Background(), pr, nil, []string{"release-1.x"})
+	assert.Empty(t, f.approved)
+	assert.Empty(t, f.autoMerged)
+}
+
+func TestRunnerAudit(t *testing.T) {
+	pr := &forge.PRInfo{Number: 42, Title: "Fix a bug", MergeCommit: "abc1234", Squashed: true}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	r := newTestRunner(&createPRForge{}, &fakeGit{})
+	r.Audit = backport.NewAuditLog(path, "ci-bot")
+	r.Backport(context.Background(), pr, nil, []string{"release-1.x", "release-2.x"})
+
+	entries, err := backport.ReadAuditLog(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "owner/repo", entries[0].Repo)
+	assert.Equal(t, "ci-bot", entries[0].Actor)
+	assert.Equal(t, "abc1234", entries[0].SourceSHA)
+	assert.Equal(t, 42, entries[0].SourcePR)
+	assert.Equal(t, "release-1.x", entries[0].TargetBranch)
+	assert.Equal(t, backport.AuditSuccess, entries[0].Result)
+	assert.Equal(t, 101, entries[0].BackportPR)
+	require.NoError(t, backport.VerifyAuditLog(entries))
+
+	// Dry runs are not recorded.
+	r.DryRun = true
+	r.Backport(context.Background(), pr, nil, []string{"release-3.x"})
+	entries, err = backport.ReadAuditLog(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestProcessConflictPR(t *testing.T) {
+	mergeCommit := setupCIFixture(t, true)
+	prInfo := &forge.PRInfo{Number: 42, Title: "fix: bug", MergeCommit: mergeCommit, Squashed: true}
+	ctx := context.Background()
+
+	for _, isolated := range []bool{false, true} {
+		branch := BranchName(42, "release-1.x")
+
+		// Without conflict PRs the backport fails and leaves nothing behind.
+		f := &createPRForge{}
+		r := newTestRunner(f, LocalGit{})
+		r.CI.Labels = []string{"bug"}
+		result := r.process(ctx, prInfo, nil, "release-1.x", isolated)
+		assert.True(t, result.Conflict)
+		assert.Zero(t, result.PRNumber)
+		assert.Empty(t, f.created)
+		assert.Error(t, exec.Command("git", "rev-parse", "--verify", "-q", branch).Run())
+
+		r.CI.CreateConflictPR = true
+		result = r.process(ctx, prInfo, nil, "release-1.x", isolated)
+		assert.True(t, result.Failed())
+		assert.True(t, result.Conflict)
+		assert.Equal(t, 101, result.PRNumber)
+		assert.Equal(t, "cherry-pick has conflicts - resolve them in draft PR #101", result.Message)
+
+		require.Len(t, f.created, 1)
+		created := f.created[0]
+		assert.True(t, created.Draft)
+		assert.Equal(t, []string{"bug", "backport-conflict"}, created.Labels)
+		assert.Equal(t, []string{"bug"}, r.CI.Labels)
+		assert.Contains(t, created.Body, "- `app.txt`")
+
+		content, err := exec.Command("git", "show", "origin/"+branch+":app.txt").Output()
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "<<<<<<<")
+		assert.False(t, git.CherryPickInProgress())
+
+		message, err := git.GetCommitMessage("origin/" + branch)
+		require.NoError(t, err)
+		assert.Contains(t, message, version.TrailerBackportedFrom+": "+mergeCommit)
+		assert.Contains(t, message, version.TrailerBackportOf+": #42")
+
+		require.NoError(t, git.CheckoutBranch("release-1.x"))
+		require.NoError(t, git.DeleteBranch(branch))
+		require.NoError(t, exec.Command("git", "push", "-q", "origin", "--delete", branch).Run())
+	}
+}
+
+func TestProcessTrailers(t *testing.T) {
+	mergeCommit := setupCIFixture(t, false)
+	prInfo := &forge.PRInfo{Number: 42, Title: "fix: bug", MergeCommit: mergeCommit, Squashed: true}
+	ctx := context.Background()
+
+	for _, isolated := range []bool{false, true} {
+		branch := BranchName(42, "release-1.x")
+
+		r := newTestRunner(&createPRForge{}, LocalGit{})
+		result := r.process(ctx, prInfo, nil, "release-1.x", isolated)
+		require.True(t, result.Success, result.Message)
+
+		message, err := git.GetCommitMessage("origin/" + branch)
+		require.NoError(t, err)
+		for _, trailer := range version.SignatureTrailers(mergeCommit, 42) {
+			assert.Contains(t, message, trailer)
+		}
+
+		require.NoError(t, git.CheckoutBranch("release-1.x"))
+		require.NoError(t, git.DeleteBranch(branch))
+		require.NoError(t, exec.Command("git", "push", "-q", "origin", "--delete", branch).Run())
+	}
+}
+
+// setupMergeFixture extends the CI fixture with a PR of two commits merged into main via a
+// merge commit. Returns the merge commit and the commits of the PR.
+func setupMergeFixture(t *testing.T) (string, []string) {
+	t.Helper()
+	setupCIFixture(t, false)
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	run("checkout", "-q", "-b", "feature", "origin/main")
+	var commits []string
+	for _, name := range []string{"one.txt", "two.txt"} {
+		require.NoError(t, os.WriteFile(name, []byte(name+"\n"), 0o644))
+		run("add", name)
+		run("commit", "-q", "-m", "feat: add "+name)
+		commits = append(commits, run("rev-parse", "HEAD"))
+	}
+	run("checkout", "-q", "-B", "main", "origin/main")
+	run("merge", "-q", "--no-ff", "-m", "Merge pull request #43", "feature")
+	run("push", "-q", "origin", "main")
+	merge := run("rev-parse", "HEAD")
+	run("checkout", "-q", "release-1.x")
+
+	return merge, commits
+}
+
+func TestProcessMergeCommit(t *testing.T) {
+	merge, commits := setupMergeFixture(t)
+	prInfo := &forge.PRInfo{Number: 43, Title: "feat: add files", MergeCommit: merge}
+	ctx := context.Background()
+	branch := BranchName(43, "release-1.x")
+
+	prCommits := []*forge.CommitInfo{{SHA: commits[0], Parents: []string{"p"}}, {SHA: commits[1], Parents: []string{commits[0]}}}
+	tests := []struct {
+		name     string
+		strategy string
+		forge    *createPRForge
+		want     []string // Backported-from trailers of the backport commits, oldest first
+	}{
+		{name: "commits", forge: &createPRForge{commits: prCommits}, want: commits},
+		{name: "commits without forge listing", forge: &createPRForge{commitsErr: errors.New("boom")}, want: commits},
+		{name: "mainline", strategy: backport.StrategyMainline, forge: &createPRForge{}, want: []string{merge}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(tt.forge, LocalGit{})
+			r.Strategy = tt.strategy
+			result := r.process(ctx, prInfo, nil, "release-1.x", false)
+			require.True(t, result.Success, result.Message)
+
+			out, err := exec.Command("git", "log", "--reverse", "--format=%(trailers:key="+version.TrailerBackportedFrom+",valueonly)", "origin/release-1.x..origin/"+branch).Output()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, strings.Fields(string(out)))
+
+			require.NoError(t, git.DeleteBranch(branch))
+			require.NoError(t, exec.Command("git", "push", "-q", "origin", "--delete", branch).Run())
+		})
+	}
+
+	// The squash strategy refuses PRs merged via a merge commit.
+	r := newTestRunner(&createPRForge{}, LocalGit{})
+	r.Strategy = backport.StrategySquash
+	result := r.process(ctx, prInfo, nil, "release-1.x", false)
+	assert.True(t, result.Failed())
+	require.ErrorIs(t, result.Error, backport.ErrNotSquashed)
+	assert.Error(t, exec.Command("git", "rev-parse", "--verify", "-q", branch).Run())
+}
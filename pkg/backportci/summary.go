@@ -0,0 +1,57 @@
+package backportci
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+const summaryLineWidth = 40
+
+// WriteSummary writes a summary of backport operations across target branches to w.
+func WriteSummary(w io.Writer, results []Result, title string) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, strings.Repeat("=", summaryLineWidth))
+
+	var succeeded, failed, skipped, deferred int
+	for _, r := range results {
+		var status string
+		switch {
+		case r.Deferred:
+			status = "⏸️  DEFERRED"
+			deferred++
+		case r.Skipped:
+			status = "⏭️  SKIPPED"
+			skipped++
+		case r.Success:
+			status = "✓  SUCCESS"
+			succeeded++
+		default:
+			status = "✗  FAILED"
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s  %s", status, r.TargetBranch)
+		if r.PRNumber > 0 {
+			fmt.Fprintf(w, " → PR #%d", r.PRNumber)
+		}
+		if r.Diffstat != "" {
+			fmt.Fprintf(w, " [%s]", r.Diffstat)
+		}
+		if r.Error != nil {
+			fmt.Fprintf(w, " (%s)", logger.RedactError(r.Error))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, strings.Repeat("-", summaryLineWidth))
+	fmt.Fprintf(w, "Total: %d succeeded, %d failed, %d skipped", succeeded, failed, skipped)
+	if deferred > 0 {
+		fmt.Fprintf(w, ", %d deferred", deferred)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+}
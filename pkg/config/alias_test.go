@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: nil},
+		{in: "  backport pr   --base v1.x ", want: []string{"backport", "pr", "--base", "v1.x"}},
+		{in: `list --query "is:open label:backport"`, want: []string{"list", "--query", "is:open label:backport"}},
+		{in: `a 'b "c"' d\ e "f\"g" ''`, want: []string{"a", `b "c"`, "d e", `f"g`, ""}},
+	} {
+		args, err := SplitCommandLine(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, args, tc.in)
+	}
+
+	for _, in := range []string{`pr "v1.x`, `pr 'v1.x`, `pr v1.x\`} {
+		_, err := SplitCommandLine(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Aliases = map[string]string{"bp1": "backport pr --base v1.x"}
+	cfg.DefaultCommand = "list"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Aliases = map[string]string{"-x": "list"}
+	assert.ErrorContains(t, cfg.Validate(), `invalid alias name "-x"`)
+
+	cfg.Aliases = map[string]string{"bp1": "  "}
+	assert.ErrorContains(t, cfg.Validate(), "invalid aliases.bp1")
+
+	cfg.Aliases = nil
+	cfg.DefaultCommand = `list "open`
+	assert.ErrorContains(t, cfg.Validate(), "invalid default_command: unterminated quote")
+}
+
+func TestMergeAliases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Merge(&Config{Aliases: map[string]string{"a": "list", "b": "graph"}, DefaultCommand: "list"})
+	cfg.Merge(&Config{Aliases: map[string]string{"b": "releases"}})
+
+	assert.Equal(t, map[string]string{"a": "list", "b": "releases"}, cfg.Aliases)
+	assert.Equal(t, "list", cfg.DefaultCommand)
+}
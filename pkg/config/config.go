@@ -3,10 +3,18 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/goccy/go-yaml"
+
+	"codefloe.com/pat-s/backporter/pkg/fileutil"
+	"codefloe.com/pat-s/backporter/pkg/selfupdate"
 )
 
 // DefaultRecentPRCount is the default number of recent PRs to show in interactive mode.
@@ -14,38 +22,154 @@ const DefaultRecentPRCount = 10
 
 // Config represents the backporter configuration.
 type Config struct {
-	// Forge type: "github" or "forgejo".
+	// Minimum backporter version the config requires, e.g. "1.4.0".
+	MinVersion string `yaml:"min_version,omitempty"`
+
+	// Forge type: "github", "forgejo", "bitbucket" or "gerrit".
 	ForgeType string `yaml:"forge_type"`
 
 	// Forgejo/Gitea instance URL (only for forgejo forge type).
 	ForgejoURL string `yaml:"forgejo_url,omitempty"`
 
+	// Gerrit server URL (only for gerrit forge type).
+	GerritURL string `yaml:"gerrit_url,omitempty"`
+
+	// Fetch GitHub PRs with their merge commit, labels and files in a single GraphQL query
+	// instead of several REST calls (only for github forge type, requires a token).
+	GitHubGraphQL bool `yaml:"github_graphql,omitempty"`
+
+	// Environment variable holding the forge token, defaults to the one of the forge type
+	// (e.g. GITHUB_TOKEN).
+	TokenEnv string `yaml:"token_env,omitempty"`
+
+	// Shell command printing the forge token, e.g. "pass show github", used if the
+	// environment variable is unset.
+	TokenCommand string `yaml:"token_command,omitempty"`
+
+	// File containing the forge token, used if the environment variable is unset and there is no token command.
+	TokenFile string `yaml:"token_file,omitempty"`
+
+	// Named forge profiles, selected with --profile.
+	Profiles map[string]ProfileConfig `yaml:"profiles,omitempty"`
+
+	// Forge settings per remote host, e.g. "git.mycorp.com", applied to all repositories on it.
+	Hosts map[string]HostConfig `yaml:"hosts,omitempty"`
+
 	// Default target branches for backporting (supports regex).
 	TargetBranches []string `yaml:"target_branches"`
 
 	// Default commit message template.
 	CommitMessage string `yaml:"commit_message"`
 
-	// Default author name for commits.
+	// Default author name for commits, used by the committer and reauthor commit identities.
 	AuthorName string `yaml:"author_name"`
 
-	// Default author email for commits.
+	// Default author email for commits, used by the committer and reauthor commit identities.
 	AuthorEmail string `yaml:"author_email"`
 
+	// Who backport commits are attributed to: "keep" (default), "committer" or "reauthor",
+	// see the Identity constants.
+	CommitIdentity string `yaml:"commit_identity,omitempty"`
+
 	// Default branch to work from.
 	DefaultBranch string `yaml:"default_branch"`
 
 	// Remote name.
 	Remote string `yaml:"remote"`
 
+	// Remote backport branches are pushed to and backport PRs are opened against, e.g. a fork
+	// when PRs are read from an upstream remote. Defaults to Remote.
+	PushRemote string `yaml:"push_remote,omitempty"`
+
+	// Rewrite the remote URL when the forge reports the repository as renamed or transferred.
+	UpdateRemoteURL bool `yaml:"update_remote_url,omitempty"`
+
+	// Repositories ("owner/repo", supports regex) backporter may act on. Empty allows all.
+	ReposAllow []string `yaml:"repos_allow,omitempty"`
+
+	// Repositories ("owner/repo", supports regex) backporter must not act on, takes precedence over ReposAllow.
+	ReposDeny []string `yaml:"repos_deny,omitempty"`
+
 	// Number of recent PRs to show in interactive mode.
 	RecentPRCount int `yaml:"recent_pr_count"`
 
 	// Cache settings.
 	Cache CacheConfig `yaml:"cache"`
 
+	// Branch protection applied to target branches created from the interactive wizard.
+	BranchProtection BranchProtectionConfig `yaml:"branch_protection,omitempty"`
+
+	// Reviewer checklists appended to the bodies of backport PRs, per target branch.
+	ReviewChecklists []ReviewChecklistConfig `yaml:"review_checklists,omitempty"`
+
+	// Path mappings for target branches that predate a restructure of the repository.
+	PathMappings []PathMappingConfig `yaml:"path_mappings,omitempty"`
+
+	// Reuse recorded conflict resolutions (git rerere) when cherry-picking, so conflicts
+	// resolved once, e.g. on another target branch, are resolved automatically.
+	Rerere bool `yaml:"rerere,omitempty"`
+
+	// Interactive settings, overriding shared values outside of CI mode.
+	Interactive InteractiveConfig `yaml:"interactive,omitempty"`
+
+	// Command aliases, e.g. "bp1: backport pr --base v1.x", expanded when used as the command.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+
+	// Command line run instead of the interactive wizard when no command is given, e.g. "list".
+	DefaultCommand string `yaml:"default_command,omitempty"`
+
 	// CI settings for automated backporting.
 	CI CIConfig `yaml:"ci"`
+
+	// Anonymous usage statistics, strictly opt-in.
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty"`
+
+	// Append-only log of all backport attempts, e.g. for compliance reviews.
+	Audit AuditConfig `yaml:"audit,omitempty"`
+}
+
+// Scope identifies the execution context a config is resolved for.
+type Scope string
+
+const (
+	// ScopeInteractive is used for local invocations (wizard and subcommands).
+	ScopeInteractive Scope = "interactive"
+	// ScopeCI is used for automated backporting via --ci.
+	ScopeCI Scope = "ci"
+)
+
+// ScopeConfig holds settings that can be overridden per scope.
+// Empty values fall back to the shared top-level settings, the PR settings of the interactive
+// scope fall back to the ones of the CI scope.
+type ScopeConfig struct {
+	// Target branches for this scope.
+	TargetBranches []string `yaml:"target_branches,omitempty"`
+
+	// Commit message template for this scope.
+	CommitMessage string `yaml:"commit_message,omitempty"`
+
+	// Author name for commits in this scope.
+	AuthorName string `yaml:"author_name,omitempty"`
+
+	// Author email for commits in this scope.
+	AuthorEmail string `yaml:"author_email,omitempty"`
+
+	// Reuse recorded conflict resolutions in this scope, even if the shared rerere is off.
+	Rerere bool `yaml:"rerere,omitempty"`
+
+	// Additional labels for backport PRs.
+	Labels []string `yaml:"labels,omitempty"`
+
+	// Users to request a review of backport PRs from.
+	Reviewers []string `yaml:"reviewers,omitempty"`
+
+	// Open backport PRs as drafts, e.g. until CI passes.
+	DraftPRs bool `yaml:"draft_prs,omitempty"`
+}
+
+// InteractiveConfig holds settings specific to interactive (non-CI) usage.
+type InteractiveConfig struct {
+	ScopeConfig `yaml:",inline"`
 }
 
 // CacheConfig holds cache-related settings.
@@ -55,13 +179,175 @@ type CacheConfig struct {
 
 	// Path to cache file.
 	Path string `yaml:"path"`
+
+	// Additional read-only history files, e.g. a team-shared ledger checked into the repository.
+	// Their entries are merged with the local cache, new backports are only written to Path.
+	ExtraPaths []string `yaml:"extra_paths,omitempty"`
+
+	// Age up to which cached forge responses (PRs, commits, recent PRs) are used without asking
+	// the forge, e.g. "10m". Older responses are revalidated with their ETag. Defaults to 0.
+	ForgeTTL string `yaml:"forge_ttl,omitempty"`
+}
+
+// ForgeCacheTTL returns the parsed forge_ttl, 0 if it is unset or invalid.
+func (c CacheConfig) ForgeCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(c.ForgeTTL)
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// TelemetryConfig holds the opt-in for anonymous usage statistics.
+type TelemetryConfig struct {
+	// Report anonymous, aggregate usage events (command, forge type, outcome, duration).
+	Enabled bool `yaml:"enabled"`
+
+	// URL the usage events are posted to as JSON.
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+// AuditConfig configures the audit log of backport attempts.
+type AuditConfig struct {
+	// Record every backport attempt in the audit log.
+	Enabled bool `yaml:"enabled"`
+
+	// Path to the audit log, a JSON Lines file.
+	// Default: ~/.local/share/backporter/audit.jsonl
+	Path string `yaml:"path,omitempty"`
+}
+
+// BranchProtectionConfig is the template for protecting newly created target branches.
+type BranchProtectionConfig struct {
+	// Apply branch protection to pushed target branches.
+	Enabled bool `yaml:"enabled"`
+
+	// Number of approvals required to merge into the branch.
+	RequiredApprovals int `yaml:"required_approvals,omitempty"`
+
+	// Status checks that must pass before merging into the branch.
+	RequiredStatusChecks []string `yaml:"required_status_checks,omitempty"`
+}
+
+// ReviewChecklistConfig is a reviewer checklist for backport PRs against matching target branches.
+type ReviewChecklistConfig struct {
+	// Target branches the checklist applies to (supports regex).
+	Branches []string `yaml:"branches"`
+
+	// Checklist items, rendered as unchecked task list entries.
+	Items []string `yaml:"items"`
+}
+
+// AutoMergeConfig schedules backport PRs against matching target branches to merge once their
+// checks pass, and optionally approves them.
+type AutoMergeConfig struct {
+	// Target branches the settings apply to (supports regex).
+	Branches []string `yaml:"branches"`
+
+	// Merge method once checks pass: "merge", "squash" or "rebase". Empty only approves.
+	Method string `yaml:"method,omitempty"`
+
+	// Approve backport PRs as the user of the token, for branches that require approvals.
+	Approve bool `yaml:"approve,omitempty"`
+}
+
+// PathMappingConfig maps paths that were moved on the source branch back to their location
+// on matching target branches, e.g. "src/new/" to "src/old/".
+type PathMappingConfig struct {
+	// Target branches the mapping applies to (supports regex).
+	Branches []string `yaml:"branches"`
+
+	// Path prefixes on the source branch mapped to their prefix on the target branches.
+	Paths map[string]string `yaml:"paths"`
 }
 
 // CIConfig holds CI-specific settings for automated backporting.
 type CIConfig struct {
+	ScopeConfig `yaml:",inline"`
+
 	// Default conventional commit prefix when original PR title doesn't have one.
 	// Default: "fix"
 	DefaultPrefix string `yaml:"default_prefix"`
+
+	// Copy the labels of the original PR to backport PRs, except for backport labels.
+	CopyLabels bool `yaml:"copy_labels,omitempty"`
+
+	// Copy the assignees of the original PR to backport PRs.
+	CopyAssignees bool `yaml:"copy_assignees,omitempty"`
+
+	// Copy the milestone of the original PR to backport PRs.
+	CopyMilestone bool `yaml:"copy_milestone,omitempty"`
+
+	// Request a review of backport PRs from the author of the original PR.
+	RequestReviewFromAuthor bool `yaml:"request_review_from_author,omitempty"`
+
+	// Request a review of backport PRs from the user that merged the original PR.
+	RequestReviewFromMerger bool `yaml:"request_review_from_merger,omitempty"`
+
+	// Commit conflicting cherry-picks with their conflict markers and open a draft PR
+	// labeled "backport-conflict", instead of failing the backport.
+	CreateConflictPR bool `yaml:"create_conflict_pr,omitempty"`
+
+	// Show the short diffstat of the backport (files changed, insertions, deletions) in the
+	// backport PR body and the CI summary.
+	IncludeDiffstat bool `yaml:"include_diffstat,omitempty"`
+
+	// Label added to the original PR for every target branch it was backported to,
+	// "{target}" is replaced by the target branch, e.g. "backported-to/{target}".
+	BackportedLabel string `yaml:"backported_label,omitempty"`
+
+	// Remove the backport labels that triggered the backport from the original PR
+	// once all backports succeeded.
+	RemoveTriggerLabel bool `yaml:"remove_trigger_label,omitempty"`
+
+	// Windows in which backports are deferred instead of pushed, e.g. during a release freeze.
+	QuietHours []QuietWindow `yaml:"quiet_hours,omitempty"`
+
+	// Auto-merge and approval of backport PRs, per target branch.
+	AutoMerge []AutoMergeConfig `yaml:"auto_merge,omitempty"`
+
+	// Rebuild the backport branch of a previous run from the target branch, force-push it and
+	// update its open backport PR, instead of skipping target branches that have one.
+	UpdateExisting bool `yaml:"update_existing,omitempty"`
+
+	// Fork "owner/repo" backport branches are pushed to when the bot cannot push to the
+	// repository, e.g. because of branch protection. Backport PRs are opened from the fork.
+	PushFork string `yaml:"push_fork,omitempty"`
+
+	// Notifications about the backport results, posted as comments on the original PR.
+	Notify NotifyConfig `yaml:"notify,omitempty"`
+}
+
+// Commit identities of backport commits.
+const (
+	// IdentityKeep keeps the original author, the committer is the git user running backporter.
+	IdentityKeep = "keep"
+	// IdentityCommitter keeps the original author and commits as author_name/author_email.
+	IdentityCommitter = "committer"
+	// IdentityReauthor makes author_name/author_email both author and committer.
+	IdentityReauthor = "reauthor"
+)
+
+// Notification modes for CI backport results.
+const (
+	// NotifyOff posts no comments.
+	NotifyOff = "off"
+	// NotifyBranch posts one comment per target branch.
+	NotifyBranch = "branch"
+	// NotifyDigest posts one consolidated comment for all target branches.
+	NotifyDigest = "digest"
+)
+
+// NotifyConfig controls the comments posted on the original PR after a CI run.
+type NotifyConfig struct {
+	// Notification mode: "off" (default), "branch" or "digest".
+	Mode string `yaml:"mode"`
+
+	// Only notify if at least one backport failed.
+	OnlyFailures bool `yaml:"only_failures,omitempty"`
+
+	// Users to mention in the comments; "author" mentions the author of the original PR.
+	Mentions []string `yaml:"mentions,omitempty"`
 }
 
 // DefaultConfig returns a new Config with default values.
@@ -85,13 +371,34 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadFromFile loads configuration from a YAML file.
+// LoadOptions controls how config files are loaded.
+type LoadOptions struct {
+	// Strict rejects files with unknown keys, e.g. typos like "targed_branches", with an UnknownKeysError.
+	Strict bool
+}
+
+// LoadFromFile loads configuration from a YAML file, ignoring unknown keys.
 func LoadFromFile(path string) (*Config, error) {
+	return LoadFromFileWithOptions(path, LoadOptions{})
+}
+
+// LoadFromFileWithOptions loads configuration from a YAML file.
+func LoadFromFileWithOptions(path string, opts LoadOptions) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	if opts.Strict {
+		unknown, err := UnknownKeys(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(unknown) > 0 {
+			return nil, &UnknownKeysError{Path: path, Keys: unknown}
+		}
+	}
+
 	cfg := DefaultConfig()
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -106,12 +413,48 @@ func (c *Config) Merge(other *Config) {
 		return
 	}
 
+	if other.MinVersion != "" {
+		c.MinVersion = other.MinVersion
+	}
 	if other.ForgeType != "" {
 		c.ForgeType = other.ForgeType
 	}
 	if other.ForgejoURL != "" {
 		c.ForgejoURL = other.ForgejoURL
 	}
+	if other.GerritURL != "" {
+		c.GerritURL = other.GerritURL
+	}
+	if other.TokenEnv != "" {
+		c.TokenEnv = other.TokenEnv
+	}
+	if other.TokenCommand != "" {
+		c.TokenCommand = other.TokenCommand
+	}
+	if other.TokenFile != "" {
+		c.TokenFile = other.TokenFile
+	}
+	for name, profile := range other.Profiles {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]ProfileConfig)
+		}
+		c.Profiles[name] = profile
+	}
+	for host, settings := range other.Hosts {
+		if c.Hosts == nil {
+			c.Hosts = make(map[string]HostConfig)
+		}
+		c.Hosts[host] = settings
+	}
+	for name, command := range other.Aliases {
+		if c.Aliases == nil {
+			c.Aliases = make(map[string]string)
+		}
+		c.Aliases[name] = command
+	}
+	if other.DefaultCommand != "" {
+		c.DefaultCommand = other.DefaultCommand
+	}
 	if len(other.TargetBranches) > 0 {
 		c.TargetBranches = other.TargetBranches
 	}
@@ -124,25 +467,213 @@ func (c *Config) Merge(other *Config) {
 	if other.AuthorEmail != "" {
 		c.AuthorEmail = other.AuthorEmail
 	}
+	if other.CommitIdentity != "" {
+		c.CommitIdentity = other.CommitIdentity
+	}
 	if other.DefaultBranch != "" {
 		c.DefaultBranch = other.DefaultBranch
 	}
 	if other.Remote != "" {
 		c.Remote = other.Remote
 	}
+	if other.PushRemote != "" {
+		c.PushRemote = other.PushRemote
+	}
+	if other.UpdateRemoteURL {
+		c.UpdateRemoteURL = true
+	}
+	if other.GitHubGraphQL {
+		c.GitHubGraphQL = true
+	}
+	if other.Rerere {
+		c.Rerere = true
+	}
+	if len(other.ReposAllow) > 0 {
+		c.ReposAllow = other.ReposAllow
+	}
+	if len(other.ReposDeny) > 0 {
+		c.ReposDeny = other.ReposDeny
+	}
 	if other.RecentPRCount > 0 {
 		c.RecentPRCount = other.RecentPRCount
 	}
 	if other.Cache.Path != "" {
 		c.Cache.Path = other.Cache.Path
 	}
+	if len(other.Cache.ExtraPaths) > 0 {
+		c.Cache.ExtraPaths = other.Cache.ExtraPaths
+	}
+	if other.Cache.ForgeTTL != "" {
+		c.Cache.ForgeTTL = other.Cache.ForgeTTL
+	}
 	// Always take explicit boolean settings.
 	c.Cache.Enabled = other.Cache.Enabled
 
+	// The telemetry opt-in is replaced as a whole. Repository configs cannot set it, see CheckRepoLocal.
+	if other.Telemetry.Enabled || other.Telemetry.Endpoint != "" {
+		c.Telemetry = other.Telemetry
+	}
+	if other.Audit.Enabled || other.Audit.Path != "" {
+		c.Audit = other.Audit
+	}
+
+	// The branch protection template is replaced as a whole.
+	if other.BranchProtection.Enabled {
+		c.BranchProtection = other.BranchProtection
+	}
+
+	// Review checklists are replaced as a whole.
+	if len(other.ReviewChecklists) > 0 {
+		c.ReviewChecklists = other.ReviewChecklists
+	}
+
+	// Path mappings are replaced as a whole.
+	if len(other.PathMappings) > 0 {
+		c.PathMappings = other.PathMappings
+	}
+
+	// Scoped settings.
+	c.Interactive.merge(other.Interactive.ScopeConfig)
+	c.CI.merge(other.CI.ScopeConfig)
+
 	// CI settings.
 	if other.CI.DefaultPrefix != "" {
 		c.CI.DefaultPrefix = other.CI.DefaultPrefix
 	}
+	if other.CI.CopyLabels {
+		c.CI.CopyLabels = true
+	}
+	if other.CI.CopyAssignees {
+		c.CI.CopyAssignees = true
+	}
+	if other.CI.CopyMilestone {
+		c.CI.CopyMilestone = true
+	}
+	if other.CI.RequestReviewFromAuthor {
+		c.CI.RequestReviewFromAuthor = true
+	}
+	if other.CI.RequestReviewFromMerger {
+		c.CI.RequestReviewFromMerger = true
+	}
+	if other.CI.CreateConflictPR {
+		c.CI.CreateConflictPR = true
+	}
+	if other.CI.IncludeDiffstat {
+		c.CI.IncludeDiffstat = true
+	}
+	if other.CI.BackportedLabel != "" {
+		c.CI.BackportedLabel = other.CI.BackportedLabel
+	}
+	if other.CI.RemoveTriggerLabel {
+		c.CI.RemoveTriggerLabel = true
+	}
+	// Quiet hours are replaced as a whole.
+	if len(other.CI.QuietHours) > 0 {
+		c.CI.QuietHours = other.CI.QuietHours
+	}
+	// The notification settings are replaced as a whole.
+	if len(other.CI.AutoMerge) > 0 {
+		c.CI.AutoMerge = other.CI.AutoMerge
+	}
+	if other.CI.UpdateExisting {
+		c.CI.UpdateExisting = true
+	}
+	if other.CI.PushFork != "" {
+		c.CI.PushFork = other.CI.PushFork
+	}
+	if other.CI.Notify.Mode != "" {
+		c.CI.Notify = other.CI.Notify
+	}
+}
+
+// merge merges another scope config into this one. Values from other take precedence if non-empty.
+func (s *ScopeConfig) merge(other ScopeConfig) {
+	if len(other.TargetBranches) > 0 {
+		s.TargetBranches = other.TargetBranches
+	}
+	if other.CommitMessage != "" {
+		s.CommitMessage = other.CommitMessage
+	}
+	if other.AuthorName != "" {
+		s.AuthorName = other.AuthorName
+	}
+	if other.AuthorEmail != "" {
+		s.AuthorEmail = other.AuthorEmail
+	}
+	if other.Rerere {
+		s.Rerere = true
+	}
+	if len(other.Labels) > 0 {
+		s.Labels = other.Labels
+	}
+	if len(other.Reviewers) > 0 {
+		s.Reviewers = other.Reviewers
+	}
+	if other.DraftPRs {
+		s.DraftPRs = true
+	}
+}
+
+// ForScope returns a copy of the config with the overrides of the given scope applied
+// on top of the shared settings. PRs are opened with the PR settings of the CI scope, which
+// the interactive scope overrides for the PRs it opens.
+func (c *Config) ForScope(scope Scope) *Config {
+	resolved := *c
+
+	var overrides ScopeConfig
+	switch scope {
+	case ScopeCI:
+		overrides = c.CI.ScopeConfig
+	case ScopeInteractive:
+		overrides = c.Interactive.ScopeConfig
+	}
+
+	if len(overrides.TargetBranches) > 0 {
+		resolved.TargetBranches = overrides.TargetBranches
+	}
+	if overrides.CommitMessage != "" {
+		resolved.CommitMessage = overrides.CommitMessage
+	}
+	if overrides.AuthorName != "" {
+		resolved.AuthorName = overrides.AuthorName
+	}
+	if overrides.AuthorEmail != "" {
+		resolved.AuthorEmail = overrides.AuthorEmail
+	}
+	if overrides.Rerere {
+		resolved.Rerere = true
+	}
+
+	if scope == ScopeInteractive {
+		if len(overrides.Labels) > 0 {
+			resolved.CI.Labels = overrides.Labels
+		}
+		if len(overrides.Reviewers) > 0 {
+			resolved.CI.Reviewers = overrides.Reviewers
+		}
+		if overrides.DraftPRs {
+			resolved.CI.DraftPRs = true
+		}
+	}
+
+	return &resolved
+}
+
+// WriteRemote returns the remote backport branches are pushed to, PushRemote or else Remote.
+func (c *Config) WriteRemote() string {
+	if c.PushRemote != "" {
+		return c.PushRemote
+	}
+	return c.Remote
+}
+
+// ForgeURL returns the server URL of the configured self-hosted forge, GerritURL for Gerrit
+// and ForgejoURL otherwise.
+func (c *Config) ForgeURL() string {
+	if c.ForgeType == "gerrit" {
+		return c.GerritURL
+	}
+	return c.ForgejoURL
 }
 
 // GlobalConfigPath returns the path to the global config file.
@@ -159,27 +690,174 @@ func RepoConfigPath() string {
 	return ".backporter.yaml"
 }
 
+// InvalidError marks the errors of loading, validating or checking the version of a config,
+// so that they can be told apart from errors of the commands using it.
+type InvalidError struct {
+	Err error
+}
+
+// Error implements error.
+func (e *InvalidError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *InvalidError) Unwrap() error {
+	return e.Err
+}
+
 // Validate checks if the configuration is valid.
 func (c *Config) Validate() error {
-	if c.ForgeType != "" && c.ForgeType != "github" && c.ForgeType != "forgejo" {
-		return fmt.Errorf("invalid forge_type: %s (must be 'github' or 'forgejo')", c.ForgeType)
+	switch c.ForgeType {
+	case "", "github", "forgejo", "bitbucket", "gerrit":
+	default:
+		return fmt.Errorf("invalid forge_type: %s (must be 'github', 'forgejo', 'bitbucket' or 'gerrit')", c.ForgeType)
+	}
+	for name, profile := range c.Profiles {
+		switch profile.ForgeType {
+		case "", "github", "forgejo", "bitbucket", "gerrit":
+		default:
+			return fmt.Errorf("invalid profiles.%s.forge_type: %s (must be 'github', 'forgejo', 'bitbucket' or 'gerrit')", name, profile.ForgeType)
+		}
+	}
+	for host, settings := range c.Hosts {
+		switch settings.Type {
+		case "", "github", "forgejo", "bitbucket", "gerrit":
+		default:
+			return fmt.Errorf("invalid hosts.%s.type: %s (must be 'github', 'forgejo', 'bitbucket' or 'gerrit')", host, settings.Type)
+		}
+		if settings.URL != "" {
+			if u, err := url.Parse(settings.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid hosts.%s.url: %q (must be an http(s) URL)", host, settings.URL)
+			}
+		}
+	}
+	if c.MinVersion != "" {
+		if _, err := selfupdate.IsNewer(c.MinVersion, c.MinVersion); err != nil {
+			return fmt.Errorf("invalid min_version: %w", err)
+		}
+	}
+	switch c.CommitIdentity {
+	case "", IdentityKeep:
+	case IdentityCommitter, IdentityReauthor:
+		// The identity applies in both scopes, each may set its own author.
+		for _, scope := range []Scope{ScopeInteractive, ScopeCI} {
+			if resolved := c.ForScope(scope); resolved.AuthorName == "" || resolved.AuthorEmail == "" {
+				return fmt.Errorf("commit_identity %s requires author_name and author_email (missing for %s)", c.CommitIdentity, scope)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid commit_identity: %s (must be 'keep', 'committer' or 'reauthor')", c.CommitIdentity)
+	}
+	switch c.CI.Notify.Mode {
+	case "", NotifyOff, NotifyBranch, NotifyDigest:
+	default:
+		return fmt.Errorf("invalid ci.notify.mode: %s (must be 'off', 'branch' or 'digest')", c.CI.Notify.Mode)
+	}
+	if c.Telemetry.Enabled {
+		if u, err := url.Parse(c.Telemetry.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid telemetry.endpoint: %q (must be an http(s) URL)", c.Telemetry.Endpoint)
+		}
+	}
+	if c.Cache.ForgeTTL != "" {
+		if ttl, err := time.ParseDuration(c.Cache.ForgeTTL); err != nil || ttl < 0 {
+			return fmt.Errorf("invalid cache.forge_ttl: %q (must be a duration like '10m')", c.Cache.ForgeTTL)
+		}
+	}
+	for i, checklist := range c.ReviewChecklists {
+		if len(checklist.Branches) == 0 {
+			return fmt.Errorf("invalid review_checklists[%d]: no branches", i)
+		}
+	}
+	for i, window := range c.CI.QuietHours {
+		if err := window.validate(); err != nil {
+			return fmt.Errorf("invalid ci.quiet_hours[%d]: %w", i, err)
+		}
+	}
+	for i, autoMerge := range c.CI.AutoMerge {
+		if len(autoMerge.Branches) == 0 {
+			return fmt.Errorf("invalid ci.auto_merge[%d]: no branches", i)
+		}
+		switch autoMerge.Method {
+		case "", "merge", "squash", "rebase":
+		default:
+			return fmt.Errorf("invalid ci.auto_merge[%d].method: %s (must be 'merge', 'squash' or 'rebase')", i, autoMerge.Method)
+		}
+		if autoMerge.Method == "" && !autoMerge.Approve {
+			return fmt.Errorf("invalid ci.auto_merge[%d]: neither method nor approve set", i)
+		}
+	}
+	if c.CI.PushFork != "" {
+		if owner, repo, ok := strings.Cut(c.CI.PushFork, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return fmt.Errorf("invalid ci.push_fork: %q (must be 'owner/repo')", c.CI.PushFork)
+		}
+	}
+	for i, mapping := range c.PathMappings {
+		if len(mapping.Branches) == 0 {
+			return fmt.Errorf("invalid path_mappings[%d]: no branches", i)
+		}
+		if len(mapping.Paths) == 0 {
+			return fmt.Errorf("invalid path_mappings[%d]: no paths", i)
+		}
+		for from, to := range mapping.Paths {
+			if from == "" || to == "" {
+				return fmt.Errorf("invalid path_mappings[%d]: empty path in %q: %q", i, from, to)
+			}
+		}
+	}
+	for name, command := range c.Aliases {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsFunc(name, unicode.IsSpace) {
+			return fmt.Errorf("invalid alias name %q (must be a single word not starting with '-')", name)
+		}
+		if args, err := SplitCommandLine(command); err != nil || len(args) == 0 {
+			return fmt.Errorf("invalid aliases.%s: %q (must be a non-empty command line)", name, command)
+		}
+	}
+	if c.DefaultCommand != "" {
+		if _, err := SplitCommandLine(c.DefaultCommand); err != nil {
+			return fmt.Errorf("invalid default_command: %w", err)
+		}
+	}
+	for _, pattern := range append(slices.Clone(c.ReposAllow), c.ReposDeny...) {
+		if _, err := compileRepoPattern(pattern); err != nil {
+			return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
 	}
 	return nil
 }
 
-// SaveToFile saves the configuration to a YAML file.
+// SaveToFile saves the configuration to a YAML file. The file is replaced atomically while
+// holding a lock, so concurrent invocations never see or produce a truncated file. Comments
+// and the key order of an existing file are preserved.
 func (c *Config) SaveToFile(path string) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	unlock, err := fileutil.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if existing, err := os.ReadFile(path); err == nil {
+		// A file that cannot be round-tripped is replaced as a whole.
+		if merged, err := mergeYAML(existing, data); err == nil {
+			data = merged
+		}
+	}
+
+	if err := fileutil.WriteAtomic(path, data, mode); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
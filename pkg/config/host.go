@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+)
+
+// HostConfig are the forge settings of the repositories whose remote is on a host, e.g. a
+// corporate Forgejo instance, so they need no repo-local config.
+type HostConfig struct {
+	// Forge type: "github", "forgejo", "bitbucket" or "gerrit". Detected if unset.
+	Type string `yaml:"type,omitempty"`
+
+	// Forgejo or Gerrit server URL, defaults to https://<host>.
+	URL string `yaml:"url,omitempty"`
+
+	// Environment variable holding the forge token, e.g. "CORP_TOKEN".
+	TokenEnv string `yaml:"token_env,omitempty"`
+
+	// Shell command printing the forge token.
+	TokenCommand string `yaml:"token_command,omitempty"`
+
+	// File containing the forge token.
+	TokenFile string `yaml:"token_file,omitempty"`
+}
+
+// Host returns the settings of a remote host, matched case-insensitively.
+func (c *Config) Host(host string) (HostConfig, bool) {
+	for name, settings := range c.Hosts {
+		if strings.EqualFold(name, host) {
+			return settings, true
+		}
+	}
+	return HostConfig{}, false
+}
+
+// UseHost applies the forge settings configured for the host of the remote, if any.
+// Returns whether the host is configured.
+func (c *Config) UseHost(host string) bool {
+	settings, ok := c.Host(host)
+	if !ok {
+		return false
+	}
+
+	if settings.Type != "" {
+		c.ForgeType = settings.Type
+	}
+	serverURL := settings.URL
+	if serverURL == "" {
+		serverURL = "https://" + host
+	}
+	switch settings.Type {
+	case "forgejo":
+		c.ForgejoURL = serverURL
+	case "gerrit":
+		c.GerritURL = serverURL
+	}
+	if settings.TokenEnv != "" {
+		c.TokenEnv = settings.TokenEnv
+	}
+	if settings.TokenCommand != "" {
+		c.TokenCommand = settings.TokenCommand
+	}
+	if settings.TokenFile != "" {
+		c.TokenFile = settings.TokenFile
+	}
+
+	return true
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// KeyValue is a setting of a config with its dotted key, e.g. "ci.notify.mode".
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// UnknownKey is a key of a config file that backporter does not know, e.g. a misspelled one.
+type UnknownKey struct {
+	// Dotted key, e.g. "ci.notfy".
+	Key  string
+	Line int
+
+	// Closest known key at the same level, empty if none is similar.
+	Suggestion string
+}
+
+// String implements fmt.Stringer.
+func (k UnknownKey) String() string {
+	s := fmt.Sprintf("unknown key %s at line %d", k.Key, k.Line)
+	if k.Suggestion != "" {
+		s += fmt.Sprintf(", did you mean %s?", k.Suggestion)
+	}
+	return s
+}
+
+// UnknownKeysError is returned by strict loading if a config file has unknown keys.
+type UnknownKeysError struct {
+	Path string
+	Keys []UnknownKey
+}
+
+// Error implements error.
+func (e *UnknownKeysError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		keys[i] = key.String()
+	}
+	return fmt.Sprintf("invalid config file %s: %s", e.Path, strings.Join(keys, "; "))
+}
+
+// UnknownKeys returns the keys of a YAML config file that backporter does not know, in file order.
+func UnknownKeys(data []byte) ([]UnknownKey, error) {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var unknown []UnknownKey
+	for _, doc := range file.Docs {
+		unknown = append(unknown, unknownKeys("", doc.Body, reflect.TypeFor[Config]())...)
+	}
+	slices.SortStableFunc(unknown, func(a, b UnknownKey) int {
+		return a.Line - b.Line
+	})
+	return unknown, nil
+}
+
+// unknownKeys collects the keys below node that have no field in t.
+func unknownKeys(prefix string, node ast.Node, t reflect.Type) []UnknownKey {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	node = unwrapNode(node)
+
+	var unknown []UnknownKey
+	switch t.Kind() {
+	case reflect.Struct:
+		mapping, ok := node.(*ast.MappingNode)
+		if !ok {
+			return nil
+		}
+		fields := yamlFields(t)
+		for _, value := range mapping.Values {
+			key := value.Key.GetToken().Value
+			field, ok := fields[key]
+			if !ok {
+				unknown = append(unknown, UnknownKey{
+					Key:        joinKey(prefix, key),
+					Line:       value.Key.GetToken().Position.Line,
+					Suggestion: closestKey(key, fields),
+				})
+				continue
+			}
+			unknown = append(unknown, unknownKeys(joinKey(prefix, key), value.Value, field)...)
+		}
+	case reflect.Map:
+		if mapping, ok := node.(*ast.MappingNode); ok {
+			for _, value := range mapping.Values {
+				unknown = append(unknown, unknownKeys(joinKey(prefix, value.Key.GetToken().Value), value.Value, t.Elem())...)
+			}
+		}
+	case reflect.Slice:
+		if sequence, ok := node.(*ast.SequenceNode); ok {
+			for i, value := range sequence.Values {
+				unknown = append(unknown, unknownKeys(fmt.Sprintf("%s[%d]", prefix, i), value, t.Elem())...)
+			}
+		}
+	}
+	return unknown
+}
+
+// unwrapNode returns the value of anchored and tagged nodes.
+func unwrapNode(node ast.Node) ast.Node {
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			node = n.Value
+		case *ast.TagNode:
+			node = n.Value
+		default:
+			return node
+		}
+	}
+}
+
+// maxKeyDistance is the largest edit distance at which a known key is suggested for an unknown one.
+const maxKeyDistance = 3
+
+// closestKey returns the known key most similar to key, or "" if none is similar enough.
+func closestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", maxKeyDistance+1
+	for _, known := range slices.Sorted(maps.Keys(fields)) {
+		if d := editDistance(key, known); d < bestDistance && d < len(key) {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// yamlFields maps the YAML keys of a struct to the types of their fields, including inlined structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for key, ft := range yamlFields(field.Type) {
+				fields[key] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// SetKeys returns the dotted keys a YAML config file sets. Lists are a single key,
+// they are replaced as a whole when merging configs.
+func SetKeys(data []byte) ([]string, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var keys []string
+	flattenKeys("", raw, func(key string, _ any) {
+		keys = append(keys, key)
+	})
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Values returns the non-empty settings of the config, sorted by key. Lists are rendered as JSON.
+func (c *Config) Values() ([]KeyValue, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var values []KeyValue
+	var flattenErr error
+	flattenKeys("", raw, func(key string, value any) {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			if v != "" {
+				values = append(values, KeyValue{Key: key, Value: v})
+			}
+		case []any:
+			if len(v) == 0 {
+				return
+			}
+			out, err := json.Marshal(v)
+			if err != nil {
+				flattenErr = fmt.Errorf("failed to render %s: %w", key, err)
+				return
+			}
+			values = append(values, KeyValue{Key: key, Value: string(out)})
+		default:
+			values = append(values, KeyValue{Key: key, Value: fmt.Sprint(v)})
+		}
+	})
+	if flattenErr != nil {
+		return nil, flattenErr
+	}
+
+	slices.SortFunc(values, func(a, b KeyValue) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return values, nil
+}
+
+// flattenKeys calls fn for every leaf of a parsed YAML document. Mappings are descended into,
+// everything else (including lists) is a leaf.
+func flattenKeys(prefix string, value any, fn func(key string, value any)) {
+	mapping, ok := value.(map[string]any)
+	if !ok {
+		if prefix != "" {
+			fn(prefix, value)
+		}
+		return
+	}
+	for key, v := range mapping {
+		flattenKeys(joinKey(prefix, key), v, fn)
+	}
+}
+
+// joinKey appends key to the dotted prefix.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ProfileConfig is a named set of forge settings, e.g. for a corporate Forgejo instance
+// next to GitHub. Set values override the forge settings of the config files.
+type ProfileConfig struct {
+	// Forge type: "github", "forgejo", "bitbucket" or "gerrit".
+	ForgeType string `yaml:"forge_type,omitempty"`
+
+	// Forgejo/Gitea instance URL (only for forgejo forge type).
+	ForgejoURL string `yaml:"forgejo_url,omitempty"`
+
+	// Gerrit server URL (only for gerrit forge type).
+	GerritURL string `yaml:"gerrit_url,omitempty"`
+
+	// Environment variable holding the forge token, e.g. "WORK_FORGEJO_TOKEN".
+	TokenEnv string `yaml:"token_env,omitempty"`
+
+	// Shell command printing the forge token.
+	TokenCommand string `yaml:"token_command,omitempty"`
+
+	// File containing the forge token.
+	TokenFile string `yaml:"token_file,omitempty"`
+}
+
+// UseProfile applies the forge settings of the named profile.
+func (c *Config) UseProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok && looksLikePath(name) {
+		// --profile and BACKPORTER_PROFILE used to write pprof profiles to a directory.
+		return fmt.Errorf("unknown profile %q: --profile and BACKPORTER_PROFILE select a config profile, use --pprof or BACKPORTER_PPROF to write pprof profiles to a directory", name)
+	}
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q, no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	if profile.ForgeType != "" {
+		c.ForgeType = profile.ForgeType
+	}
+	if profile.ForgejoURL != "" {
+		c.ForgejoURL = profile.ForgejoURL
+	}
+	if profile.GerritURL != "" {
+		c.GerritURL = profile.GerritURL
+	}
+	if profile.TokenEnv != "" {
+		c.TokenEnv = profile.TokenEnv
+	}
+	if profile.TokenCommand != "" {
+		c.TokenCommand = profile.TokenCommand
+	}
+	if profile.TokenFile != "" {
+		c.TokenFile = profile.TokenFile
+	}
+
+	return nil
+}
+
+// looksLikePath reports whether a profile name is rather a path, i.e. it contains a path
+// separator, starts like a relative or home path, or is an existing directory.
+func looksLikePath(name string) bool {
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return true
+	}
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
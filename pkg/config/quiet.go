@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietWindow is a period in which CI mode must not push backports or open PRs,
+// e.g. nights, weekends or a release freeze. Unset fields don't restrict the window,
+// so a window with only From and Until covers these days entirely.
+type QuietWindow struct {
+	// Weekdays the window applies to, e.g. ["sat", "sun"]. Empty means every day.
+	Days []string `yaml:"days,omitempty"`
+
+	// Daily start and end time ("15:04"). The window spans midnight if end is before start.
+	Start string `yaml:"start,omitempty"`
+	End   string `yaml:"end,omitempty"`
+
+	// First and last day of the window ("2006-01-02"), e.g. for a release freeze.
+	From  string `yaml:"from,omitempty"`
+	Until string `yaml:"until,omitempty"`
+
+	// IANA time zone of the times and days, defaults to UTC.
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// Layouts of the quiet window times and days.
+const (
+	quietTimeLayout = "15:04"
+	quietDayLayout  = time.DateOnly
+)
+
+// weekdays maps the weekday names accepted in quiet windows.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// validate checks that the window can be evaluated.
+func (w QuietWindow) validate() error {
+	if _, err := w.location(); err != nil {
+		return err
+	}
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day %q (must be one of mon, tue, wed, thu, fri, sat, sun)", day)
+		}
+	}
+	if (w.Start == "") != (w.End == "") {
+		return fmt.Errorf("start and end must be set together")
+	}
+	for _, value := range []string{w.Start, w.End} {
+		if _, err := time.Parse(quietTimeLayout, value); value != "" && err != nil {
+			return fmt.Errorf("invalid time %q (must be HH:MM)", value)
+		}
+	}
+	for _, value := range []string{w.From, w.Until} {
+		if _, err := time.Parse(quietDayLayout, value); value != "" && err != nil {
+			return fmt.Errorf("invalid date %q (must be YYYY-MM-DD)", value)
+		}
+	}
+	return nil
+}
+
+// location returns the time zone of the window.
+func (w QuietWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Contains reports whether t falls into the window. Invalid windows never match,
+// they are rejected when the config is validated.
+func (w QuietWindow) Contains(t time.Time) bool {
+	loc, err := w.location()
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+	day := t.Format(quietDayLayout)
+	clock := t.Format(quietTimeLayout)
+
+	if w.From != "" && day < w.From {
+		return false
+	}
+	if w.Until != "" && day > w.Until {
+		return false
+	}
+
+	// Times after midnight of a window spanning midnight belong to the day it started.
+	weekday := t.Weekday()
+	if w.Start != "" {
+		switch {
+		case w.Start <= w.End:
+			if clock < w.Start || clock >= w.End {
+				return false
+			}
+		case clock < w.End:
+			weekday = (weekday + 6) % 7 //nolint:mnd
+		case clock < w.Start:
+			return false
+		}
+	}
+
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdays[strings.ToLower(name)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietWindowAt returns the first quiet window containing t, or nil if pushing is allowed.
+func (c CIConfig) QuietWindowAt(t time.Time) *QuietWindow {
+	for i := range c.QuietHours {
+		if c.QuietHours[i].Contains(t) {
+			return &c.QuietHours[i]
+		}
+	}
+	return nil
+}
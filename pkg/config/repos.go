@@ -0,0 +1,38 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compileRepoPattern compiles a repository pattern into a fully anchored, case-insensitive regex,
+// since forges treat owner and repository names case-insensitively.
+func compileRepoPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)^(?:" + pattern + ")$")
+}
+
+// matchesRepo checks if "owner/repo" matches any of the patterns, literally or as a regex.
+// Invalid patterns never match.
+func matchesRepo(fullName string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, fullName) {
+			return true
+		}
+		re, err := compileRepoPattern(pattern)
+		if err == nil && re.MatchString(fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoAllowed checks if backporter may act on a repository according to repos_allow and
+// repos_deny. Denied repositories are never allowed, and with an allow list only listed
+// repositories are.
+func (c *Config) RepoAllowed(owner, repo string) bool {
+	fullName := owner + "/" + repo
+	if matchesRepo(fullName, c.ReposDeny) {
+		return false
+	}
+	return len(c.ReposAllow) == 0 || matchesRepo(fullName, c.ReposAllow)
+}
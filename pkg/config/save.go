@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// mergeYAML applies the values of updated to the YAML document existing, keeping the comments
+// and key order of existing. Keys missing from updated are removed, new keys are appended.
+func mergeYAML(existing, updated []byte) ([]byte, error) {
+	oldFile, err := parser.ParseBytes(existing, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	newFile, err := parser.ParseBytes(updated, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(oldFile.Docs) != 1 || len(newFile.Docs) != 1 {
+		return nil, fmt.Errorf("expected a single YAML document")
+	}
+	oldRoot, ok := oldFile.Docs[0].Body.(*ast.MappingNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a YAML mapping")
+	}
+	newRoot, ok := newFile.Docs[0].Body.(*ast.MappingNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a YAML mapping")
+	}
+
+	mergeMapping(oldRoot, newRoot)
+	return []byte(strings.TrimRight(oldFile.String(), "\n") + "\n"), nil
+}
+
+// mergeMapping updates the values of dst in place with those of src, see mergeYAML.
+func mergeMapping(dst, src *ast.MappingNode) {
+	updated := make(map[string]*ast.MappingValueNode, len(src.Values))
+	for _, v := range src.Values {
+		updated[v.Key.String()] = v
+	}
+
+	kept := dst.Values[:0]
+	existing := make(map[string]bool, len(dst.Values))
+	for _, old := range dst.Values {
+		v, ok := updated[old.Key.String()]
+		if !ok {
+			continue
+		}
+		existing[old.Key.String()] = true
+		kept = append(kept, old)
+
+		oldMapping, oldIsMapping := old.Value.(*ast.MappingNode)
+		newMapping, newIsMapping := v.Value.(*ast.MappingNode)
+		if oldIsMapping && newIsMapping {
+			mergeMapping(oldMapping, newMapping)
+			continue
+		}
+
+		// Keep trailing comments such as "remote: upstream # the fork".
+		if comment := old.Value.GetComment(); comment != nil && v.Value.GetComment() == nil {
+			_ = v.Value.SetComment(comment)
+		}
+		old.Value = v.Value
+	}
+	dst.Values = kept
+
+	for _, v := range src.Values {
+		if !existing[v.Key.String()] {
+			dst.Values = append(dst.Values, v)
+		}
+	}
+}
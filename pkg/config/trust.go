@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CheckRepoLocal returns an error if the config of a repository sets forge credential settings
+// or telemetry. token_command runs a shell command and token_file reads a local file sent as the
+// token, and telemetry reports usage to any endpoint, so a cloned repository must not choose
+// them. They are only accepted in the global config.
+func (c *Config) CheckRepoLocal() error {
+	if c.Telemetry != (TelemetryConfig{}) {
+		return fmt.Errorf("telemetry is only allowed in the global config, not in the repository config")
+	}
+	if err := checkCredentials("", c.TokenEnv, c.TokenCommand, c.TokenFile); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(c.Profiles) {
+		p := c.Profiles[name]
+		if err := checkCredentials("profiles."+name+".", p.TokenEnv, p.TokenCommand, p.TokenFile); err != nil {
+			return err
+		}
+	}
+	for _, host := range sortedKeys(c.Hosts) {
+		h := c.Hosts[host]
+		if err := checkCredentials("hosts."+host+".", h.TokenEnv, h.TokenCommand, h.TokenFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCredentials returns an error for the first credential setting that is set.
+func checkCredentials(prefix, tokenEnv, tokenCommand, tokenFile string) error {
+	for _, setting := range []struct{ key, value string }{
+		{"token_env", tokenEnv},
+		{"token_command", tokenCommand},
+		{"token_file", tokenFile},
+	} {
+		if setting.value != "" {
+			return fmt.Errorf("%s%s is only allowed in the global config, not in the repository config", prefix, setting.key)
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in order, for deterministic errors.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
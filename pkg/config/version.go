@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"codefloe.com/pat-s/backporter/pkg/selfupdate"
+)
+
+// VersionTooOldError is returned when the running backporter is older than the min_version of the config.
+type VersionTooOldError struct {
+	Required string
+	Current  string
+}
+
+// Error implements error.
+func (e *VersionTooOldError) Error() string {
+	return fmt.Sprintf("config requires backporter %s or newer, running %s", e.Required, e.Current)
+}
+
+// CheckMinVersion checks that the running version satisfies min_version. Development builds,
+// whose version cannot be compared, always pass.
+func (c *Config) CheckMinVersion(current string) error {
+	if c.MinVersion == "" {
+		return nil
+	}
+
+	tooOld, err := selfupdate.IsNewer(c.MinVersion, current)
+	if err != nil || !tooOld {
+		return nil
+	}
+	return &VersionTooOldError{Required: c.MinVersion, Current: current}
+}
@@ -0,0 +1,214 @@
+// Package credentials looks up forge tokens from environment variables, a token helper
+// command, a token file or the OS keyring.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/zalando/go-keyring"
+
+	"codefloe.com/pat-s/backporter/pkg/forge"
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// keyringService is the service tokens are stored under in the OS keyring.
+const keyringService = "backporter"
+
+// commandTimeout bounds the runtime of a token helper command.
+const commandTimeout = 30 * time.Second
+
+// Sources of a token.
+const (
+	SourceEnv     = "env"
+	SourceCommand = "command"
+	SourceFile    = "file"
+	SourceKeyring = "keyring"
+)
+
+// ErrNoToken is returned if no source has a token.
+var ErrNoToken = errors.New("no forge token found")
+
+// Options configure where the token of a forge is looked up.
+type Options struct {
+	ForgeType string
+
+	// Server URL of self-hosted forge types (Forgejo, Gerrit).
+	ForgeURL string
+
+	// Environment variable holding the token, defaults to the one of the forge type.
+	TokenEnv string
+
+	// Shell command printing the token, e.g. "pass show forgejo".
+	TokenCommand string
+
+	// File containing the token.
+	TokenFile string
+}
+
+// Token is a forge token and where it was found.
+type Token struct {
+	Value  string
+	Source string
+
+	// Environment variable, command, file or keyring entry the token was read from.
+	Location string
+}
+
+// Lookup returns the forge token from the first source that has one: the environment variable,
+// the token command, the token file and finally the OS keyring. The token is registered as a
+// secret, so it is redacted from all output.
+func Lookup(ctx context.Context, opts Options) (*Token, error) {
+	token, err := lookup(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	logger.RegisterSecret(token.Value)
+	// Gerrit tokens are "<username>:<http-password>", the password alone is a secret too.
+	if _, password, ok := strings.Cut(token.Value, ":"); ok {
+		logger.RegisterSecret(password)
+	}
+	return token, nil
+}
+
+// lookup looks up the forge token, see Lookup.
+func lookup(ctx context.Context, opts Options) (*Token, error) {
+	envVar := opts.TokenEnv
+	if envVar == "" {
+		envVar = forge.TokenEnvVar(opts.ForgeType)
+	}
+	if envVar != "" {
+		if value := os.Getenv(envVar); value != "" {
+			return &Token{Value: value, Source: SourceEnv, Location: envVar}, nil
+		}
+	}
+
+	if opts.TokenCommand != "" {
+		value, err := runCommand(ctx, opts.TokenCommand)
+		if err != nil {
+			return nil, err
+		}
+		return &Token{Value: value, Source: SourceCommand, Location: opts.TokenCommand}, nil
+	}
+
+	if opts.TokenFile != "" {
+		path := expandHome(opts.TokenFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %s - %w", path, err)
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return nil, fmt.Errorf("token file %s is empty", path)
+		}
+		return &Token{Value: value, Source: SourceFile, Location: path}, nil
+	}
+
+	user := KeyringUser(opts.ForgeType, opts.ForgeURL)
+	if user == "" {
+		return nil, ErrNoToken
+	}
+	value, err := keyring.Get(keyringService, user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		// The keyring is often unavailable, e.g. in CI, which is not worth more than no token.
+		return nil, fmt.Errorf("%w, the keyring is unavailable: %w", ErrNoToken, err)
+	}
+	return &Token{Value: value, Source: SourceKeyring, Location: keyringService + "/" + user}, nil
+}
+
+// Store saves the token of a forge in the OS keyring.
+func Store(forgeType, forgeURL, token string) error {
+	user := KeyringUser(forgeType, forgeURL)
+	if user == "" {
+		return fmt.Errorf("forge not configured, cannot store token")
+	}
+	if err := keyring.Set(keyringService, user, token); err != nil {
+		return fmt.Errorf("failed to store token in keyring: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the token of a forge from the OS keyring. Returns false if there was none.
+func Delete(forgeType, forgeURL string) (bool, error) {
+	user := KeyringUser(forgeType, forgeURL)
+	if user == "" {
+		return false, fmt.Errorf("forge not configured, cannot remove token")
+	}
+	err := keyring.Delete(keyringService, user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove token from keyring: %w", err)
+	}
+	return true, nil
+}
+
+// KeyringUser returns the keyring entry of a forge, e.g. "forgejo@codeberg.org",
+// or "" if the forge type is unknown. Self-hosted forges are identified by the host of forgeURL.
+func KeyringUser(forgeType, forgeURL string) string {
+	var host string
+	switch forgeType {
+	case "github":
+		host = "github.com"
+	case "bitbucket":
+		host = "bitbucket.org"
+	case "forgejo", "gerrit":
+		u, err := url.Parse(forgeURL)
+		if err != nil || u.Host == "" {
+			return ""
+		}
+		host = u.Host
+	default:
+		return ""
+	}
+	return forgeType + "@" + host
+}
+
+// runCommand runs a token helper command in the shell and returns its trimmed output.
+func runCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run token command: %s - %w", command, err)
+	}
+
+	value := strings.TrimSpace(string(output))
+	if value == "" {
+		return "", fmt.Errorf("token command %s printed no token", command)
+	}
+	return value, nil
+}
+
+// expandHome replaces a leading "~/" with the home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
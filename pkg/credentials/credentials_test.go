@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+func TestLookup(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("GITHUB_TOKEN", "")
+	ctx := context.Background()
+
+	_, err := Lookup(ctx, Options{ForgeType: "github"})
+	require.ErrorIs(t, err, ErrNoToken)
+
+	require.NoError(t, Store("github", "", "keyring-token"))
+	token, err := Lookup(ctx, Options{ForgeType: "github"})
+	require.NoError(t, err)
+	assert.Equal(t, &Token{Value: "keyring-token", Source: SourceKeyring, Location: "backporter/github@github.com"}, token)
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("file-token\n"), 0o600))
+	token, err = Lookup(ctx, Options{ForgeType: "github", TokenFile: path})
+	require.NoError(t, err)
+	assert.Equal(t, &Token{Value: "file-token", Source: SourceFile, Location: path}, token)
+
+	token, err = Lookup(ctx, Options{ForgeType: "github", TokenFile: path, TokenCommand: "echo command-token"})
+	require.NoError(t, err)
+	assert.Equal(t, &Token{Value: "command-token", Source: SourceCommand, Location: "echo command-token"}, token)
+
+	t.Setenv("GITHUB_TOKEN", "env-token")
+	token, err = Lookup(ctx, Options{ForgeType: "github", TokenFile: path, TokenCommand: "echo command-token"})
+	require.NoError(t, err)
+	assert.Equal(t, &Token{Value: "env-token", Source: SourceEnv, Location: "GITHUB_TOKEN"}, token)
+
+	t.Setenv("WORK_TOKEN", "work-token")
+	token, err = Lookup(ctx, Options{ForgeType: "github", TokenEnv: "WORK_TOKEN"})
+	require.NoError(t, err)
+	assert.Equal(t, "work-token", token.Value)
+}
+
+func TestLookupFailingSources(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("FORGEJO_TOKEN", "")
+	ctx := context.Background()
+
+	_, err := Lookup(ctx, Options{ForgeType: "forgejo", TokenCommand: "exit 1"})
+	assert.ErrorContains(t, err, "failed to run token command")
+
+	_, err = Lookup(ctx, Options{ForgeType: "forgejo", TokenCommand: "true"})
+	assert.ErrorContains(t, err, "printed no token")
+
+	_, err = Lookup(ctx, Options{ForgeType: "forgejo", TokenFile: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorContains(t, err, "failed to read token file")
+
+	keyring.MockInitWithError(assert.AnError)
+	_, err = Lookup(ctx, Options{ForgeType: "forgejo", ForgeURL: "https://codeberg.org"})
+	assert.ErrorIs(t, err, ErrNoToken)
+}
+
+func TestStoreAndDelete(t *testing.T) {
+	keyring.MockInit()
+
+	removed, err := Delete("forgejo", "https://codeberg.org")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	require.NoError(t, Store("forgejo", "https://codeberg.org", "token"))
+	removed, err = Delete("forgejo", "https://codeberg.org")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	assert.Error(t, Store("", "", "token"))
+}
+
+func TestKeyringUser(t *testing.T) {
+	assert.Equal(t, "github@github.com", KeyringUser("github", ""))
+	assert.Equal(t, "bitbucket@bitbucket.org", KeyringUser("bitbucket", ""))
+	assert.Equal(t, "forgejo@codeberg.org", KeyringUser("forgejo", "https://codeberg.org/"))
+	assert.Empty(t, KeyringUser("forgejo", ""))
+	assert.Equal(t, "gerrit@review.example.com", KeyringUser("gerrit", "https://review.example.com/r"))
+	assert.Empty(t, KeyringUser("", ""))
+}
+
+func TestLookupRegistersSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("admin:file-secret-token\n"), 0o600))
+	t.Setenv("GERRIT_TOKEN", "")
+
+	_, err := Lookup(context.Background(), Options{ForgeType: "gerrit", TokenFile: path})
+	require.NoError(t, err)
+	assert.NotContains(t, logger.Redact("token admin:file-secret-token"), "file-secret-token")
+	assert.NotContains(t, logger.Redact("password file-secret-token"), "file-secret-token")
+}
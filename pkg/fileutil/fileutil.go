@@ -0,0 +1,72 @@
+// Package fileutil writes files that concurrent backporter invocations share, such as the
+// config files and the backport history.
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Locking of files against concurrent writes.
+var (
+	LockTimeout       = 5 * time.Second // How long to wait for another invocation to finish writing
+	lockRetryInterval = 50 * time.Millisecond
+	staleLockAge      = 30 * time.Second // Older locks are left over from crashed invocations
+)
+
+// Lock takes the lock of a file and returns the function releasing it. The lock is a
+// "<path>.lock" file created exclusively, which works the same on every platform.
+func Lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another backporter process (remove %s if it is stale)", path, lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// WriteAtomic writes data to a temporary file next to path and renames it over path,
+// so readers see either the old or the new content.
+func WriteAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
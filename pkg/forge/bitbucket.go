@@ -0,0 +1,752 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// BitbucketAPIURL is the base URL of the Bitbucket Cloud REST API.
+const BitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// bitbucketMaxPageLen is the maximum page size accepted by the Bitbucket API.
+const bitbucketMaxPageLen = 50
+
+// Bitbucket implements the Forge interface for Bitbucket Cloud.
+type Bitbucket struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewBitbucket creates a new Bitbucket Cloud forge client.
+// An empty baseURL defaults to BitbucketAPIURL.
+func NewBitbucket(baseURL, token string) *Bitbucket {
+	return newBitbucket(baseURL, token, nil)
+}
+
+// newBitbucket creates a new Bitbucket Cloud forge client with an optional response cache.
+func newBitbucket(baseURL, token string, cache *responseCache) *Bitbucket {
+	if baseURL == "" {
+		baseURL = BitbucketAPIURL
+	}
+
+	return &Bitbucket{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  newHTTPClient("bitbucket", cache),
+	}
+}
+
+// Name returns the name of the forge.
+func (b *Bitbucket) Name() string {
+	return "bitbucket"
+}
+
+// Capabilities returns the optional features supported by Bitbucket Cloud.
+// Bitbucket has no PR labels, see bitbucketLabelsFromTitle.
+func (b *Bitbucket) Capabilities() Capabilities {
+	return Capabilities{
+		DraftPRs:         true,
+		Reviews:          true,
+		BranchProtection: true,
+	}
+}
+
+// bitbucketPR is the API response for a pull request.
+type bitbucketPR struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	UpdatedOn   string `json:"updated_on"`
+	MergeCommit *struct {
+		Hash string `json:"hash"`
+	} `json:"merge_commit"`
+	Author   bitbucketAccount  `json:"author"`
+	ClosedBy *bitbucketAccount `json:"closed_by"`
+	Source   struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+	} `json:"source"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+}
+
+// bitbucketAccount is a user in API responses and requests.
+type bitbucketAccount struct {
+	DisplayName string `json:"display_name,omitempty"`
+	Nickname    string `json:"nickname,omitempty"`
+	AccountID   string `json:"account_id,omitempty"`
+	UUID        string `json:"uuid,omitempty"`
+}
+
+// bitbucketPRList is the paginated API response for a pull request list.
+type bitbucketPRList struct {
+	Values []bitbucketPR `json:"values"`
+	Next   string        `json:"next"`
+}
+
+// bitbucketCommit is the API response for a commit.
+type bitbucketCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+	Date    string `json:"date"`
+	Author  struct {
+		Raw  string `json:"raw"`
+		User struct {
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+	} `json:"author"`
+	Parents []struct {
+		Hash string `json:"hash"`
+	} `json:"parents"`
+}
+
+// toCommitInfo converts the API response for a commit.
+func (c bitbucketCommit) toCommitInfo() *CommitInfo {
+	parents := make([]string, len(c.Parents))
+	for i, parent := range c.Parents {
+		parents[i] = parent.Hash
+	}
+
+	name := c.Author.User.DisplayName
+	email := ""
+	if m := rawEmailPattern.FindStringSubmatch(c.Author.Raw); m != nil {
+		if name == "" {
+			name = m[1]
+		}
+		email = m[2]
+	}
+
+	timestamp, _ := time.Parse(time.RFC3339, c.Date)
+
+	return &CommitInfo{
+		SHA:       c.Hash,
+		Message:   c.Message,
+		Author:    name,
+		Email:     email,
+		Timestamp: timestamp,
+		Parents:   parents,
+	}
+}
+
+// bitbucketError is the API error response.
+type bitbucketError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// parseBitbucketError extracts a clean error message from API response.
+func parseBitbucketError(body []byte) string {
+	var errResp bitbucketError
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		return logger.Redact(errResp.Error.Message)
+	}
+	return logger.Redact(strings.TrimSpace(string(body)))
+}
+
+// bitbucketTagPattern matches bracketed tags like "[backport]" in PR titles.
+var bitbucketTagPattern = regexp.MustCompile(`\[([^\]]+)\]`)
+
+// bitbucketLabelsFromTitle approximates labels from bracketed title tags,
+// since Bitbucket Cloud pull requests have no labels.
+func bitbucketLabelsFromTitle(title string) []string {
+	matches := bitbucketTagPattern.FindAllStringSubmatch(title, -1)
+	labels := make([]string, 0, len(matches))
+	for _, m := range matches {
+		labels = append(labels, strings.TrimSpace(m[1]))
+	}
+	return labels
+}
+
+// rawEmailPattern extracts name and email from a raw author string ("Name <email>").
+var rawEmailPattern = regexp.MustCompile(`^(.*?)\s*<([^>]*)>$`)
+
+// toPRInfo converts a Bitbucket pull request to PRInfo.
+func (pr *bitbucketPR) toPRInfo() *PRInfo {
+	mergeCommit := ""
+	if pr.MergeCommit != nil {
+		mergeCommit = pr.MergeCommit.Hash
+	}
+
+	author := pr.Author.Nickname
+	if author == "" {
+		author = pr.Author.DisplayName
+	}
+
+	merged := pr.State == "MERGED"
+
+	mergedBy := ""
+	if merged && pr.ClosedBy != nil {
+		mergedBy = pr.ClosedBy.Nickname
+	}
+
+	var mergedAt time.Time
+	if merged {
+		// Bitbucket has no dedicated merge timestamp, the last update is the merge.
+		mergedAt, _ = time.Parse(time.RFC3339Nano, pr.UpdatedOn)
+	}
+
+	return &PRInfo{
+		Number:      pr.ID,
+		Title:       pr.Title,
+		Body:        pr.Description,
+		State:       strings.ToLower(pr.State),
+		MergeCommit: mergeCommit,
+		HeadSHA:     pr.Source.Commit.Hash,
+		BaseBranch:  pr.Destination.Branch.Name,
+		HeadBranch:  pr.Source.Branch.Name,
+		Merged:      merged,
+		Author:      author,
+		MergedBy:    mergedBy,
+		MergedAt:    mergedAt,
+		Labels:      bitbucketLabelsFromTitle(pr.Title),
+	}
+}
+
+// do performs an API request and decodes the response into v.
+func (b *Bitbucket) do(ctx context.Context, method, path string, body io.Reader, expected int, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		respBody, _ := io.ReadAll(resp.Body)
+		return newAPIError("bitbucket", resp, parseBitbucketError(respBody), b.token)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// GetPR retrieves information about a pull request by number.
+func (b *Bitbucket) GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	var pr bitbucketPR
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", owner, repo, number)
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, err)
+	}
+
+	if pr.State != "MERGED" {
+		return nil, fmt.Errorf("PR #%d is %w", number, ErrNotMerged)
+	}
+
+	info := pr.toPRInfo()
+
+	// Get merge commit to check if squashed and to resolve the full SHA.
+	mergeCommit, err := b.GetCommit(ctx, owner, repo, info.MergeCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge commit: %w", err)
+	}
+
+	info.MergeCommit = mergeCommit.SHA
+	info.Squashed = len(mergeCommit.Parents) == 1
+
+	return info, nil
+}
+
+// ListMilestonePRs is not supported, Bitbucket has no milestones for pull requests.
+func (b *Bitbucket) ListMilestonePRs(_ context.Context, _, _, milestone string) ([]*PRInfo, error) {
+	return nil, fmt.Errorf("failed to list milestone %s: Bitbucket has no milestones for pull requests", milestone)
+}
+
+// bitbucketDiffstat is the API response for the files changed by a pull request.
+type bitbucketDiffstat struct {
+	Values []struct {
+		Status       string `json:"status"`
+		LinesAdded   int    `json:"lines_added"`
+		LinesRemoved int    `json:"lines_removed"`
+		Old          *struct {
+			Path string `json:"path"`
+		} `json:"old"`
+		New *struct {
+			Path string `json:"path"`
+		} `json:"new"`
+	} `json:"values"`
+}
+
+// ListPRFiles lists the files changed by a pull request.
+func (b *Bitbucket) ListPRFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error) {
+	var diffstat bitbucketDiffstat
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/diffstat?pagelen=%d", owner, repo, number, bitbucketMaxPageLen)
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &diffstat); err != nil {
+		return nil, fmt.Errorf("failed to list files of PR #%d: %w", number, err)
+	}
+
+	result := make([]ChangedFile, 0, len(diffstat.Values))
+	for _, v := range diffstat.Values {
+		file := ChangedFile{Status: v.Status, Additions: v.LinesAdded, Deletions: v.LinesRemoved}
+		if v.New != nil {
+			file.Path = v.New.Path
+		} else if v.Old != nil {
+			file.Path = v.Old.Path
+		}
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// GetRepo retrieves the current workspace and slug of a repository.
+func (b *Bitbucket) GetRepo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	var r struct {
+		Slug      string `json:"slug"`
+		Workspace struct {
+			Slug string `json:"slug"`
+		} `json:"workspace"`
+	}
+	path := fmt.Sprintf("/repositories/%s/%s", owner, repo)
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &r); err != nil {
+		return nil, fmt.Errorf("failed to get repository %s/%s: %w", owner, repo, err)
+	}
+
+	return &RepoInfo{Owner: r.Workspace.Slug, Name: r.Slug}, nil
+}
+
+// GetCommit retrieves information about a commit by SHA.
+func (b *Bitbucket) GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error) {
+	ctx = cacheable(ctx)
+
+	var commit bitbucketCommit
+	path := fmt.Sprintf("/repositories/%s/%s/commit/%s", owner, repo, sha)
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &commit); err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return commit.toCommitInfo(), nil
+}
+
+// ListPRCommits lists the commits of a pull request, oldest first.
+func (b *Bitbucket) ListPRCommits(ctx context.Context, owner, repo string, number int) ([]*CommitInfo, error) {
+	var result []*CommitInfo
+	for page := 1; ; page++ {
+		var list struct {
+			Values []bitbucketCommit `json:"values"`
+			Next   string            `json:"next"`
+		}
+		path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/commits?pagelen=%d&page=%d", owner, repo, number, bitbucketMaxPageLen, page)
+		if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &list); err != nil {
+			return nil, fmt.Errorf("failed to list commits of PR #%d: %w", number, err)
+		}
+
+		for _, commit := range list.Values {
+			result = append(result, commit.toCommitInfo())
+		}
+		if list.Next == "" {
+			break
+		}
+	}
+
+	// Bitbucket lists the newest commit first.
+	slices.Reverse(result)
+	return result, nil
+}
+
+// ListRecentPRs lists recently merged PRs.
+func (b *Bitbucket) ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	pageLen := min(limit, bitbucketMaxPageLen)
+
+	query := url.Values{}
+	query.Set("state", "MERGED")
+	query.Set("sort", "-updated_on")
+	query.Set("pagelen", fmt.Sprintf("%d", pageLen))
+
+	var result []*PRInfo
+	for page := 1; page <= maxListPages; page++ {
+		query.Set("page", fmt.Sprintf("%d", page))
+
+		var list bitbucketPRList
+		path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", owner, repo, query.Encode())
+		if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &list); err != nil {
+			return nil, fmt.Errorf("failed to list PRs: %w", err)
+		}
+
+		for i := range list.Values {
+			result = append(result, list.Values[i].toPRInfo())
+
+			if len(result) >= limit {
+				return result, nil
+			}
+		}
+		if list.Next == "" {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// bitbucketCreatePRRequest is the request body for creating a PR.
+type bitbucketCreatePRRequest struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Source      bitbucketBranch `json:"source"`
+	Destination bitbucketBranch `json:"destination"`
+	Draft       bool            `json:"draft,omitempty"`
+}
+
+// bitbucketBranch references a branch in a PR request, of another repository for forks.
+type bitbucketBranch struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Repository *bitbucketRepositoryRef `json:"repository,omitempty"`
+}
+
+// bitbucketRepositoryRef references a repository by its full name in a PR request.
+type bitbucketRepositoryRef struct {
+	FullName string `json:"full_name"`
+}
+
+// CreatePR creates a new pull request and returns its number.
+// Labels, assignees, reviewers and milestones are not supported and ignored.
+// A fork head "owner:branch" refers to the owner's fork of the same name as repo.
+func (b *Bitbucket) CreatePR(ctx context.Context, owner, repo string, opts CreatePROptions) (int, error) {
+	reqBody := bitbucketCreatePRRequest{
+		Title:       opts.Title,
+		Description: opts.Body,
+		Draft:       opts.Draft,
+	}
+	headOwner, headBranch := SplitHead(opts.Head)
+	reqBody.Source.Branch.Name = headBranch
+	if headOwner != "" {
+		reqBody.Source.Repository = &bitbucketRepositoryRef{FullName: headOwner + "/" + repo}
+	}
+	reqBody.Destination.Branch.Name = opts.Base
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal PR request: %w", err)
+	}
+
+	var pr bitbucketPR
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests", owner, repo)
+	if err := b.do(ctx, http.MethodPost, path, strings.NewReader(string(jsonBody)), http.StatusCreated, &pr); err != nil {
+		return 0, fmt.Errorf("failed to create PR: %w", err)
+	}
+
+	return pr.ID, nil
+}
+
+// bitbucketUpdatePRRequest is the request body for editing a PR.
+type bitbucketUpdatePRRequest struct {
+	Title       *string          `json:"title,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	Destination *bitbucketBranch `json:"destination,omitempty"`
+}
+
+// UpdatePR changes the metadata of an existing pull request.
+// Labels are ignored since Bitbucket Cloud pull requests have no labels.
+func (b *Bitbucket) UpdatePR(ctx context.Context, owner, repo string, number int, opts UpdatePROptions) error {
+	if opts.Title == nil && opts.Body == nil && opts.Base == nil {
+		return nil
+	}
+
+	reqBody := bitbucketUpdatePRRequest{
+		Title:       opts.Title,
+		Description: opts.Body,
+	}
+	if opts.Base != nil {
+		reqBody.Destination = &bitbucketBranch{}
+		reqBody.Destination.Branch.Name = *opts.Base
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal PR request: %w", err)
+	}
+
+	var pr bitbucketPR
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", owner, repo, number)
+	if err := b.do(ctx, http.MethodPut, path, strings.NewReader(string(jsonBody)), http.StatusOK, &pr); err != nil {
+		return fmt.Errorf("failed to update PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// EnableAutoMerge is not supported, Bitbucket Cloud cannot schedule merges through the API.
+func (b *Bitbucket) EnableAutoMerge(_ context.Context, _, _ string, number int, _ string) error {
+	return fmt.Errorf("bitbucket does not support auto-merge, cannot schedule merge of PR #%d", number)
+}
+
+// ApprovePR approves a pull request as the authenticated user.
+func (b *Bitbucket) ApprovePR(ctx context.Context, owner, repo string, number int) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/approve", owner, repo, number)
+	if err := b.do(ctx, http.MethodPost, path, nil, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("failed to approve PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// RequestReview adds reviewers to a pull request, keeping the existing ones.
+// Bitbucket Cloud identifies users by account ID or {UUID}, nicknames are not accepted.
+func (b *Bitbucket) RequestReview(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", owner, repo, number)
+
+	var pr struct {
+		Reviewers []bitbucketAccount `json:"reviewers"`
+	}
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &pr); err != nil {
+		return fmt.Errorf("failed to get reviewers of PR #%d: %w", number, err)
+	}
+
+	update := make([]bitbucketAccount, 0, len(pr.Reviewers)+len(reviewers))
+	for _, r := range pr.Reviewers {
+		update = append(update, bitbucketAccount{UUID: r.UUID})
+	}
+	for _, r := range reviewers {
+		if strings.HasPrefix(r, "{") {
+			update = append(update, bitbucketAccount{UUID: r})
+		} else {
+			update = append(update, bitbucketAccount{AccountID: r})
+		}
+	}
+
+	jsonBody, err := json.Marshal(map[string][]bitbucketAccount{"reviewers": update})
+	if err != nil {
+		return fmt.Errorf("failed to marshal reviewers request: %w", err)
+	}
+
+	var updated bitbucketPR
+	if err := b.do(ctx, http.MethodPut, path, strings.NewReader(string(jsonBody)), http.StatusOK, &updated); err != nil {
+		return fmt.Errorf("failed to request reviewers on PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// ListOpenPRs lists open PRs, optionally filtered by head branch.
+func (b *Bitbucket) ListOpenPRs(ctx context.Context, owner, repo string, opts ListPROptions) ([]*PRInfo, error) {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("pagelen", fmt.Sprintf("%d", bitbucketMaxPageLen))
+	if opts.Head != "" {
+		headOwner, headBranch := SplitHead(opts.Head)
+		q := fmt.Sprintf(`source.branch.name=%q`, headBranch)
+		if headOwner != "" {
+			q += fmt.Sprintf(` AND source.repository.full_name=%q`, headOwner+"/"+repo)
+		}
+		query.Set("q", q)
+	}
+
+	var result []*PRInfo
+	for page := 1; page <= maxListPages; page++ {
+		query.Set("page", fmt.Sprintf("%d", page))
+
+		var list bitbucketPRList
+		path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", owner, repo, query.Encode())
+		if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &list); err != nil {
+			return nil, fmt.Errorf("failed to list open PRs: %w", err)
+		}
+
+		for i := range list.Values {
+			result = append(result, list.Values[i].toPRInfo())
+		}
+		if list.Next == "" {
+			return result, nil
+		}
+	}
+
+	logListTruncated("bitbucket", len(result))
+	return result, nil
+}
+
+// SearchMergedPRs lists merged PRs whose title or description contain text.
+func (b *Bitbucket) SearchMergedPRs(ctx context.Context, owner, repo, text string) ([]*PRInfo, error) {
+	query := url.Values{}
+	query.Set("state", "MERGED")
+	query.Set("sort", "-updated_on")
+	query.Set("pagelen", fmt.Sprintf("%d", bitbucketMaxPageLen))
+	query.Set("q", fmt.Sprintf(`title ~ %q OR description ~ %q`, text, text))
+
+	var result []*PRInfo
+	for page := 1; len(result) < maxSearchResults; page++ {
+		query.Set("page", fmt.Sprintf("%d", page))
+
+		var list bitbucketPRList
+		path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", owner, repo, query.Encode())
+		if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &list); err != nil {
+			return nil, fmt.Errorf("failed to search PRs: %w", err)
+		}
+
+		for i := range list.Values {
+			result = append(result, list.Values[i].toPRInfo())
+		}
+		if list.Next == "" {
+			break
+		}
+	}
+
+	return result[:min(len(result), maxSearchResults)], nil
+}
+
+// bitbucketComment is the request body for creating a PR comment.
+type bitbucketComment struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+}
+
+// CreateComment adds a comment to a pull request.
+func (b *Bitbucket) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	var comment bitbucketComment
+	comment.Content.Raw = body
+
+	jsonBody, err := json.Marshal(comment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment request: %w", err)
+	}
+
+	var created map[string]any
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments", owner, repo, number)
+	if err := b.do(ctx, http.MethodPost, path, strings.NewReader(string(jsonBody)), http.StatusCreated, &created); err != nil {
+		return fmt.Errorf("failed to comment on PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// ListComments lists the comments of a pull request, oldest first.
+func (b *Bitbucket) ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	var result []Comment
+	for page := 1; ; page++ {
+		var list struct {
+			Values []struct {
+				ID      int64 `json:"id"`
+				Content struct {
+					Raw string `json:"raw"`
+				} `json:"content"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments?pagelen=%d&page=%d", owner, repo, number, bitbucketMaxPageLen, page)
+		if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &list); err != nil {
+			return nil, fmt.Errorf("failed to list comments of PR #%d: %w", number, err)
+		}
+
+		for _, v := range list.Values {
+			result = append(result, Comment{ID: v.ID, Body: v.Content.Raw})
+		}
+		if list.Next == "" {
+			return result, nil
+		}
+	}
+}
+
+// UpdateComment replaces the body of a comment on a pull request.
+func (b *Bitbucket) UpdateComment(ctx context.Context, owner, repo string, number int, id int64, body string) error {
+	var comment bitbucketComment
+	comment.Content.Raw = body
+
+	jsonBody, err := json.Marshal(comment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment request: %w", err)
+	}
+
+	var updated map[string]any
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments/%d", owner, repo, number, id)
+	if err := b.do(ctx, http.MethodPut, path, strings.NewReader(string(jsonBody)), http.StatusOK, &updated); err != nil {
+		return fmt.Errorf("failed to update comment on PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// HasWriteAccess checks if a user has write (or admin) access to a repository.
+// The user is identified by its account ID or UUID.
+func (b *Bitbucket) HasWriteAccess(ctx context.Context, owner, repo, user string) (bool, error) {
+	var permission struct {
+		Permission string `json:"permission"`
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/permissions-config/users/%s", owner, repo, url.PathEscape(user))
+	if err := b.do(ctx, http.MethodGet, path, nil, http.StatusOK, &permission); err != nil {
+		return false, fmt.Errorf("failed to get permission of %s: %w", user, err)
+	}
+
+	return hasWritePermission(permission.Permission), nil
+}
+
+// bitbucketBranchRestriction is the request body for creating a branch restriction.
+type bitbucketBranchRestriction struct {
+	Kind            string `json:"kind"`
+	BranchMatchKind string `json:"branch_match_kind"`
+	Pattern         string `json:"pattern"`
+	Value           int    `json:"value,omitempty"`
+}
+
+// ProtectBranch applies branch protection rules to a branch.
+// Bitbucket has no named status checks, any required check requires all builds to pass.
+func (b *Bitbucket) ProtectBranch(ctx context.Context, owner, repo, branch string, rules BranchProtection) error {
+	restrictions := []bitbucketBranchRestriction{
+		{Kind: "force"},
+		{Kind: "delete"},
+	}
+	if rules.RequiredApprovals > 0 {
+		restrictions = append(restrictions, bitbucketBranchRestriction{Kind: "require_approvals_to_merge", Value: rules.RequiredApprovals})
+	}
+	if len(rules.RequiredStatusChecks) > 0 {
+		restrictions = append(restrictions, bitbucketBranchRestriction{Kind: "require_passing_builds_to_merge", Value: 1})
+	}
+
+	path := fmt.Sprintf("/repositories/%s/%s/branch-restrictions", owner, repo)
+	for _, restriction := range restrictions {
+		restriction.BranchMatchKind = "glob"
+		restriction.Pattern = branch
+
+		jsonBody, err := json.Marshal(restriction)
+		if err != nil {
+			return fmt.Errorf("failed to marshal branch restriction: %w", err)
+		}
+
+		var created map[string]any
+		if err := b.do(ctx, http.MethodPost, path, strings.NewReader(string(jsonBody)), http.StatusCreated, &created); err != nil {
+			return fmt.Errorf("failed to protect branch %s (%s): %w", branch, restriction.Kind, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,377 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitbucketName(t *testing.T) {
+	bb := NewBitbucket("", "test-token")
+	assert.Equal(t, "bitbucket", bb.Name())
+	assert.Equal(t, BitbucketAPIURL, bb.baseURL)
+}
+
+func TestBitbucketLabelsFromTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		expected []string
+	}{
+		{
+			name:     "no tags",
+			title:    "fix: resolve bug",
+			expected: []string{},
+		},
+		{
+			name:     "single tag",
+			title:    "[backport] fix: resolve bug",
+			expected: []string{"backport"},
+		},
+		{
+			name:     "multiple tags",
+			title:    "[backport] [security] fix: resolve bug",
+			expected: []string{"backport", "security"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, bitbucketLabelsFromTitle(tt.title))
+		})
+	}
+}
+
+func TestBitbucketGetPR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/owner/repo/pullrequests/7", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{
+			"id": 7,
+			"title": "[backport] fix: resolve bug",
+			"description": "details",
+			"state": "MERGED",
+			"updated_on": "2024-01-15T10:30:00.000000+00:00",
+			"merge_commit": {"hash": "abc123def456"},
+			"author": {"display_name": "Test User", "nickname": "testuser"},
+			"source": {"branch": {"name": "feature"}, "commit": {"hash": "fff000"}},
+			"destination": {"branch": {"name": "main"}}
+		}`))
+	})
+	mux.HandleFunc("/repositories/owner/repo/commit/abc123def456", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"hash": "abc123def4567890abc123def4567890abc123de",
+			"message": "fix: resolve bug",
+			"date": "2024-01-15T10:30:00+00:00",
+			"author": {"raw": "Test User <test@example.com>"},
+			"parents": [{"hash": "parent1"}]
+		}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	pr, err := bb.GetPR(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, pr.Number)
+	assert.Equal(t, "abc123def4567890abc123def4567890abc123de", pr.MergeCommit)
+	assert.Equal(t, "main", pr.BaseBranch)
+	assert.Equal(t, "feature", pr.HeadBranch)
+	assert.Equal(t, "testuser", pr.Author)
+	assert.True(t, pr.Merged)
+	assert.True(t, pr.IsSquashMerge())
+	assert.True(t, pr.HasBackportLabel())
+	assert.Equal(t, 2024, pr.MergedAt.Year())
+}
+
+func TestBitbucketGetPRNotMerged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 8, "state": "OPEN"}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "")
+	_, err := bb.GetPR(context.Background(), "owner", "repo", 8)
+	assert.ErrorIs(t, err, ErrNotMerged)
+}
+
+func TestBitbucketAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"type": "error", "error": {"message": "Repository not found"}}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "")
+	_, err := bb.GetPR(context.Background(), "owner", "repo", 1)
+	assert.ErrorContains(t, err, "Repository not found")
+}
+
+func TestBitbucketProtectBranch(t *testing.T) {
+	var kinds []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repositories/owner/repo/branch-restrictions", r.URL.Path)
+
+		var restriction bitbucketBranchRestriction
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&restriction))
+		assert.Equal(t, "release-1.x", restriction.Pattern)
+		assert.Equal(t, "glob", restriction.BranchMatchKind)
+		kinds = append(kinds, restriction.Kind)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	err := bb.ProtectBranch(context.Background(), "owner", "repo", "release-1.x", BranchProtection{
+		RequiredApprovals:    2,
+		RequiredStatusChecks: []string{"build"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"force", "delete", "require_approvals_to_merge", "require_passing_builds_to_merge"}, kinds)
+}
+
+func TestBitbucketCreateComment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7/comments", r.URL.Path)
+
+		var comment bitbucketComment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+		assert.Equal(t, "Backport summary", comment.Content.Raw)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	require.NoError(t, bb.CreateComment(context.Background(), "owner", "repo", 7, "Backport summary"))
+}
+
+func TestBitbucketComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "/repositories/owner/repo/pullrequests/7/comments", r.URL.Path)
+			if r.URL.Query().Get("page") == "1" {
+				_, _ = w.Write([]byte(`{"values": [{"id": 3, "content": {"raw": "LGTM"}}], "next": "page-2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"values": [{"id": 5, "content": {"raw": "Backport summary"}}]}`))
+		case http.MethodPut:
+			assert.Equal(t, "/repositories/owner/repo/pullrequests/7/comments/5", r.URL.Path)
+
+			var comment bitbucketComment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+			assert.Equal(t, "Updated summary", comment.Content.Raw)
+			_, _ = w.Write([]byte(`{"id": 5}`))
+		}
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	comments, err := bb.ListComments(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []Comment{{ID: 3, Body: "LGTM"}, {ID: 5, Body: "Backport summary"}}, comments)
+
+	require.NoError(t, bb.UpdateComment(context.Background(), "owner", "repo", 7, 5, "Updated summary"))
+}
+
+func TestBitbucketListOpenPRsPaginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/owner/repo/pullrequests", r.URL.Path)
+		assert.Equal(t, "OPEN", r.URL.Query().Get("state"))
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = w.Write([]byte(`{"values": [{"id": 3, "state": "OPEN"}], "next": "page-2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"values": [{"id": 5, "state": "OPEN"}]}`))
+	}))
+	defer server.Close()
+
+	prs, err := NewBitbucket(server.URL, "test-token").ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{})
+	require.NoError(t, err)
+	require.Len(t, prs, 2)
+	assert.Equal(t, 3, prs[0].Number)
+	assert.Equal(t, 5, prs[1].Number)
+}
+
+func TestBitbucketForkHead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/owner/repo/pullrequests", r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, `source.branch.name="backport-7-to-main" AND source.repository.full_name="bot/repo"`, r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`{"values": []}`))
+		case http.MethodPost:
+			var req bitbucketCreatePRRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "backport-7-to-main", req.Source.Branch.Name)
+			require.NotNil(t, req.Source.Repository)
+			assert.Equal(t, "bot/repo", req.Source.Repository.FullName)
+			assert.Nil(t, req.Destination.Repository)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 9}`))
+		}
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	prs, err := bb.ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{Head: "bot:backport-7-to-main"})
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+
+	number, err := bb.CreatePR(context.Background(), "owner", "repo", CreatePROptions{Title: "fix", Head: "bot:backport-7-to-main", Base: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, 9, number)
+}
+
+func TestBitbucketListPRFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7/diffstat", r.URL.Path)
+		_, _ = w.Write([]byte(`{"values": [
+			{"status": "modified", "lines_added": 3, "lines_removed": 1, "old": {"path": "main.go"}, "new": {"path": "main.go"}},
+			{"status": "removed", "lines_added": 0, "lines_removed": 10, "old": {"path": "old.go"}, "new": null}
+		]}`))
+	}))
+	defer server.Close()
+
+	files, err := NewBitbucket(server.URL, "test-token").ListPRFiles(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []ChangedFile{
+		{Path: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "old.go", Status: "removed", Deletions: 10},
+	}, files)
+}
+
+func TestBitbucketListPRCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7/commits", r.URL.Path)
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = w.Write([]byte(`{"values": [
+				{"hash": "ccc", "message": "third", "author": {"raw": "Carol <carol@example.com>"}, "parents": [{"hash": "bbb"}]}
+			], "next": "page2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"values": [
+			{"hash": "bbb", "message": "second", "author": {"raw": "Bob <bob@example.com>", "user": {"display_name": "Bob B."}}, "parents": [{"hash": "aaa"}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	commits, err := NewBitbucket(server.URL, "test-token").ListPRCommits(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	// Bitbucket lists the newest commit first.
+	assert.Equal(t, "bbb", commits[0].SHA)
+	assert.Equal(t, "Bob B.", commits[0].Author)
+	assert.Equal(t, "bob@example.com", commits[0].Email)
+	assert.Equal(t, "ccc", commits[1].SHA)
+	assert.Equal(t, "Carol", commits[1].Author)
+}
+
+func TestBitbucketRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type": "error", "error": {"message": "Rate limit exceeded"}}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "")
+	_, err := bb.GetPR(context.Background(), "owner", "repo", 1)
+	require.ErrorIs(t, err, ErrRateLimited)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.True(t, apiErr.NoToken)
+	assert.Equal(t, "bitbucket", apiErr.Forge)
+	assert.ErrorContains(t, err, "Rate limit exceeded")
+}
+
+func TestBitbucketHasWriteAccess(t *testing.T) {
+	permissions := map[string]string{"admin-user": "admin", "writer": "write", "reader": "read"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := strings.TrimPrefix(r.URL.Path, "/repositories/owner/repo/permissions-config/users/")
+		_, _ = w.Write([]byte(`{"permission": "` + permissions[user] + `"}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	for user, expected := range map[string]bool{"admin-user": true, "writer": true, "reader": false} {
+		allowed, err := bb.HasWriteAccess(context.Background(), "owner", "repo", user)
+		require.NoError(t, err)
+		assert.Equal(t, expected, allowed, user)
+	}
+}
+
+func TestBitbucketUpdatePR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Updated body", body["description"])
+		assert.Equal(t, map[string]any{"branch": map[string]any{"name": "release-2.x"}}, body["destination"])
+		assert.NotContains(t, body, "title")
+
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	body := "Updated body"
+	base := "release-2.x"
+	bb := NewBitbucket(server.URL, "test-token")
+	require.NoError(t, bb.UpdatePR(context.Background(), "owner", "repo", 7, UpdatePROptions{Body: &body, Base: &base, Labels: []string{"backport"}}))
+}
+
+func TestBitbucketRequestReview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7", r.URL.Path)
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"id": 7, "reviewers": [{"uuid": "{existing}", "nickname": "carol"}]}`))
+			return
+		}
+
+		assert.Equal(t, http.MethodPut, r.Method)
+		var body map[string][]bitbucketAccount
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []bitbucketAccount{
+			{UUID: "{existing}"},
+			{UUID: "{new-uuid}"},
+			{AccountID: "557058:abc"},
+		}, body["reviewers"])
+
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	require.NoError(t, bb.RequestReview(context.Background(), "owner", "repo", 7, []string{"{new-uuid}", "557058:abc"}))
+}
+
+func TestBitbucketApprovePR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repositories/owner/repo/pullrequests/7/approve", r.URL.Path)
+		_, _ = w.Write([]byte(`{"approved": true, "role": "REVIEWER"}`))
+	}))
+	defer server.Close()
+
+	bb := NewBitbucket(server.URL, "test-token")
+	require.NoError(t, bb.ApprovePR(context.Background(), "owner", "repo", 7))
+}
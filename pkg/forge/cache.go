@@ -0,0 +1,186 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// cacheableKey marks a request context whose GET responses may be served from the response cache.
+type cacheableKey struct{}
+
+// cacheable marks ctx so the GET responses of requests made with it are cached, used for
+// PR and commit metadata that rarely changes.
+func cacheable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheableKey{}, true)
+}
+
+// responseCache is an on-disk cache of forge API responses. Responses younger than ttl are
+// served without a request, older ones are revalidated with their ETag or Last-Modified date.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cachedResponse is a cached forge API response.
+type cachedResponse struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// newResponseCache returns the response cache in dir, or nil if dir is empty.
+func newResponseCache(dir string, ttl time.Duration) *responseCache {
+	if dir == "" {
+		return nil
+	}
+	return &responseCache{dir: dir, ttl: ttl}
+}
+
+// path returns the file of the cached response to req. Requests with different credentials
+// are cached separately, so responses are never shared between tokens.
+func (c *responseCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\x00" + req.Header.Get("Authorization")))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached response to req, or nil if there is none.
+func (c *responseCache) load(req *http.Request) *cachedResponse {
+	data, err := os.ReadFile(c.path(req))
+	if err != nil {
+		return nil
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != req.URL.String() {
+		return nil
+	}
+	return &entry
+}
+
+// store caches the response to req.
+func (c *responseCache) store(req *http.Request, entry *cachedResponse) {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		log.Debug().Err(err).Msg("failed to create forge response cache directory")
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(c.path(req), data, 0o600); err != nil {
+		log.Debug().Err(err).Msg("failed to cache forge response")
+	}
+}
+
+// response returns the cached response as an answer to req.
+func (e *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheTransport serves GET requests of cacheable contexts from the response cache.
+type cacheTransport struct {
+	cache *responseCache
+	base  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Context().Value(cacheableKey{}) == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	entry := t.cache.load(req)
+	if entry != nil && time.Since(entry.StoredAt) < t.cache.ttl {
+		log.Debug().Str("url", req.URL.Redacted()).Msg("forge response served from cache")
+		return entry.response(req), nil
+	}
+
+	outReq := req
+	if entry != nil && (entry.ETag != "" || entry.LastModified != "") {
+		outReq = req.Clone(req.Context())
+		if entry.ETag != "" {
+			outReq.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			outReq.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		log.Debug().Str("url", req.URL.Redacted()).Msg("forge response revalidated from cache")
+
+		entry.StoredAt = time.Now()
+		t.cache.store(req, entry)
+		return entry.response(req), nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "" && t.cache.ttl <= 0) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.store(req, &cachedResponse{
+		URL:          req.URL.String(),
+		ETag:         etag,
+		LastModified: lastModified,
+		Header:       cacheableHeader(resp.Header),
+		Body:         body,
+		StoredAt:     time.Now(),
+	})
+
+	return resp, nil
+}
+
+// cacheableHeader returns the response headers worth caching. Rate limit headers describe the
+// time of the original response and would mislead clients when replayed.
+func cacheableHeader(header http.Header) http.Header {
+	cached := http.Header{}
+	for key, values := range header {
+		canonical := http.CanonicalHeaderKey(key)
+		if strings.HasPrefix(canonical, "X-Ratelimit-") || canonical == "Date" || canonical == "Set-Cookie" {
+			continue
+		}
+		cached[canonical] = values
+	}
+	return cached
+}
@@ -0,0 +1,110 @@
+package forge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagServer answers with a fixed body and ETag, and with 304 to matching conditional requests.
+func etagServer(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		_, _ = w.Write([]byte(`{"number": 1, "auth": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &full, &notModified
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context, url, auth string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestResponseCacheRevalidates(t *testing.T) {
+	server, full, notModified := etagServer(t)
+	client := newHTTPClient("test", newResponseCache(t.TempDir(), 0))
+	ctx := cacheable(context.Background())
+
+	_, body := get(t, client, ctx, server.URL, "")
+	assert.JSONEq(t, `{"number": 1, "auth": ""}`, body)
+
+	resp, body := get(t, client, ctx, server.URL, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"number": 1, "auth": ""}`, body)
+	assert.Empty(t, resp.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+
+	// Responses for other tokens are not shared.
+	_, body = get(t, client, ctx, server.URL, "token secret")
+	assert.JSONEq(t, `{"number": 1, "auth": "token secret"}`, body)
+	assert.Equal(t, int32(2), full.Load())
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	server, full, notModified := etagServer(t)
+	client := newHTTPClient("test", newResponseCache(t.TempDir(), time.Hour))
+	ctx := cacheable(context.Background())
+
+	get(t, client, ctx, server.URL, "")
+	_, body := get(t, client, ctx, server.URL, "")
+	assert.JSONEq(t, `{"number": 1, "auth": ""}`, body)
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(0), notModified.Load())
+}
+
+func TestResponseCacheOnlyCacheableRequests(t *testing.T) {
+	server, full, notModified := etagServer(t)
+	client := newHTTPClient("test", newResponseCache(t.TempDir(), time.Hour))
+
+	get(t, client, context.Background(), server.URL, "")
+	get(t, client, context.Background(), server.URL, "")
+	assert.Equal(t, int32(2), full.Load())
+	assert.Equal(t, int32(0), notModified.Load())
+}
+
+func TestNewWithOptionsCachesPRs(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"sha": "abc123", "commit": {"message": "fix: crash"}, "parents": [{"sha": "def456"}]}`))
+	}))
+	defer server.Close()
+
+	f, err := NewWithOptions("forgejo", "", NewOptions{ForgejoURL: server.URL, CacheDir: t.TempDir(), CacheTTL: time.Hour})
+	require.NoError(t, err)
+
+	for range 2 {
+		commit, err := f.GetCommit(context.Background(), "owner", "repo", "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", commit.SHA)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
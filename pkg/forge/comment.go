@@ -0,0 +1,36 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CommentMarker returns the hidden marker identifying a status comment of backporter,
+// e.g. "<!-- backporter:summary -->". Comments carrying it are updated instead of posted again.
+func CommentMarker(key string) string {
+	return fmt.Sprintf("<!-- backporter:%s -->", key)
+}
+
+// UpsertComment posts a comment marked with marker to a pull request, or updates the latest
+// comment with that marker so repeated runs don't stack up comments. If the comments cannot
+// be listed, a new comment is posted.
+func UpsertComment(ctx context.Context, f Forge, owner, repo string, number int, marker, body string) error {
+	body = strings.TrimRight(body, "\n") + "\n\n" + marker + "\n"
+
+	comments, err := f.ListComments(ctx, owner, repo, number)
+	if err != nil {
+		return f.CreateComment(ctx, owner, repo, number, body)
+	}
+
+	for i := len(comments) - 1; i >= 0; i-- {
+		if strings.Contains(comments[i].Body, marker) {
+			if comments[i].Body == body {
+				return nil
+			}
+			return f.UpdateComment(ctx, owner, repo, number, comments[i].ID, body)
+		}
+	}
+
+	return f.CreateComment(ctx, owner, repo, number, body)
+}
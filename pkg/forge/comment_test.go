@@ -0,0 +1,68 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertComment(t *testing.T) {
+	marker := CommentMarker("summary")
+	assert.Equal(t, "<!-- backporter:summary -->", marker)
+
+	type comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	comments := []comment{{ID: 1, Body: "LGTM"}}
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			var body comment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			comments = append(comments, comment{ID: int64(len(comments) + 1), Body: body.Body})
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case http.MethodPatch:
+			var body comment
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			for i := range comments {
+				if r.URL.Path == fmt.Sprintf("/api/v1/repos/owner/repo/issues/comments/%d", comments[i].ID) {
+					comments[i].Body = body.Body
+				}
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	fj := NewForgejo(server.URL, "test-token")
+	ctx := context.Background()
+
+	require.NoError(t, UpsertComment(ctx, fj, "owner", "repo", 7, marker, "first run\n"))
+	require.NoError(t, UpsertComment(ctx, fj, "owner", "repo", 7, marker, "second run\n"))
+	require.NoError(t, UpsertComment(ctx, fj, "owner", "repo", 7, marker, "second run\n"))
+
+	assert.Equal(t, []comment{
+		{ID: 1, Body: "LGTM"},
+		{ID: 2, Body: "second run\n\n<!-- backporter:summary -->\n"},
+	}, comments)
+	assert.Equal(t, []string{
+		"GET /api/v1/repos/owner/repo/issues/7/comments",
+		"POST /api/v1/repos/owner/repo/issues/7/comments",
+		"GET /api/v1/repos/owner/repo/issues/7/comments",
+		"PATCH /api/v1/repos/owner/repo/issues/comments/2",
+		"GET /api/v1/repos/owner/repo/issues/7/comments",
+	}, requests)
+}
@@ -0,0 +1,99 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotDetected is returned if the forge type of a host cannot be detected.
+var ErrNotDetected = errors.New("cannot detect the forge type")
+
+// detectTimeout bounds the probes of a self-hosted forge.
+const detectTimeout = 5 * time.Second
+
+// maxProbeBody bounds the response read by a probe.
+const maxProbeBody = 1 << 16
+
+// knownHosts are the forge types of public hosts, which need no probe.
+var knownHosts = map[string]string{
+	"github.com":    "github",
+	"bitbucket.org": "bitbucket",
+	"codeberg.org":  "forgejo",
+	"gitea.com":     "forgejo",
+	"gitlab.com":    "gitlab",
+}
+
+// Detection is a forge detected from the host of a remote.
+type Detection struct {
+	Type string // Forge type, e.g. "forgejo"
+	URL  string // Server URL of self-hosted forge types, Forgejo and Gerrit
+}
+
+// Detect infers the forge type of a git remote host. Public hosts are known, other hosts are
+// probed for the Forgejo/Gitea and Gerrit APIs. Returns ErrNotDetected if the host answers
+// neither, e.g. for a self-hosted GitHub Enterprise, and an error for GitLab, which is not supported.
+func Detect(ctx context.Context, host string) (*Detection, error) {
+	detection, err := detect(ctx, host, "https://"+host)
+	if err != nil {
+		return nil, err
+	}
+	if detection.Type == "gitlab" {
+		return nil, fmt.Errorf("%s is a GitLab host, which backporter does not support", host)
+	}
+	return detection, nil
+}
+
+// detect returns the forge type of host, probing the server at baseURL if the host is not known.
+func detect(ctx context.Context, host, baseURL string) (*Detection, error) {
+	switch forgeType := knownHosts[host]; forgeType {
+	case "":
+	case "forgejo":
+		return &Detection{Type: forgeType, URL: baseURL}, nil
+	default:
+		return &Detection{Type: forgeType}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, detectTimeout)
+	defer cancel()
+	client := &http.Client{}
+
+	// Forgejo and Gitea answer {"version": "..."}.
+	var version struct {
+		Version string `json:"version"`
+	}
+	if body, ok := probe(ctx, client, baseURL+forgejoAPIPath+"/version"); ok && json.Unmarshal(body, &version) == nil && version.Version != "" {
+		return &Detection{Type: "forgejo", URL: baseURL}, nil
+	}
+
+	// Gerrit answers a JSON string behind its XSSI prefix.
+	if body, ok := probe(ctx, client, baseURL+"/config/server/version"); ok && strings.HasPrefix(string(body), gerritXSSIPrefix) {
+		return &Detection{Type: "gerrit", URL: baseURL}, nil
+	}
+
+	return nil, fmt.Errorf("%w of %s, set forge_type in the config", ErrNotDetected, host)
+}
+
+// probe requests url anonymously and returns the body of a successful response.
+func probe(ctx context.Context, client *http.Client, url string) ([]byte, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	return body, err == nil
+}
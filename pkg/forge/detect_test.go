@@ -0,0 +1,64 @@
+package forge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectKnownHosts(t *testing.T) {
+	for host, want := range map[string]Detection{
+		"github.com":    {Type: "github"},
+		"bitbucket.org": {Type: "bitbucket"},
+		"codeberg.org":  {Type: "forgejo", URL: "https://codeberg.org"},
+	} {
+		detection, err := Detect(context.Background(), host)
+		require.NoError(t, err, host)
+		assert.Equal(t, want, *detection, host)
+	}
+
+	_, err := Detect(context.Background(), "gitlab.com")
+	assert.ErrorContains(t, err, "GitLab")
+}
+
+func TestDetectSelfHosted(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{name: "forgejo", path: "/api/v1/version", body: `{"version": "11.0.1+gitea-1.22.0"}`, want: "forgejo"},
+		{name: "gerrit", path: "/config/server/version", body: ")]}'\n\"3.10.2\"", want: "gerrit"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.path {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			detection, err := detect(context.Background(), "git.example.com", server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, Detection{Type: tc.want, URL: server.URL}, *detection)
+		})
+	}
+}
+
+func TestDetectAmbiguous(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Login pages answer every path.
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	_, err := detect(context.Background(), "git.example.com", server.URL)
+	assert.ErrorIs(t, err, ErrNotDetected)
+}
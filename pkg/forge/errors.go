@@ -0,0 +1,105 @@
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v80/github"
+)
+
+var (
+	// ErrNotMerged is returned when a PR that is not merged is requested for backporting.
+	ErrNotMerged = errors.New("not merged")
+
+	// ErrRateLimited matches API errors caused by the forge rate limit.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrUnauthorized matches API errors caused by a missing or insufficient token.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNotFound matches API errors for resources that do not exist or are hidden from the token.
+	ErrNotFound = errors.New("not found")
+)
+
+// APIError is an error response of a forge API.
+// Use errors.Is with ErrRateLimited, ErrUnauthorized or ErrNotFound to classify it.
+type APIError struct {
+	Forge      string // Name of the forge, see Forge.Name
+	StatusCode int    // HTTP status code
+	Status     string // HTTP status line, e.g. "404 Not Found"
+	Message    string // Error message returned by the forge
+	NoToken    bool   // True if the request was sent without a token
+
+	rateLimited bool // Set for rate limit errors not reported as 429
+}
+
+// Error implements error.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return fmt.Sprintf("%s (%s)", e.Status, e.Message)
+}
+
+// Is reports whether the API error matches ErrRateLimited, ErrUnauthorized or ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.rateLimited || e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return !e.rateLimited && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	default:
+		return false
+	}
+}
+
+// newAPIError creates an API error from an unexpected HTTP response.
+func newAPIError(forge string, resp *http.Response, message, token string) *APIError {
+	return &APIError{
+		Forge:      forge,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Message:    message,
+		NoToken:    token == "",
+	}
+}
+
+// githubAPIError converts go-github error responses to API errors.
+// Other errors are returned unchanged.
+func githubAPIError(err error, token string) error {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return &APIError{Forge: "github", StatusCode: http.StatusForbidden, Status: "403 Forbidden", Message: rateErr.Message, NoToken: token == "", rateLimited: true}
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return &APIError{Forge: "github", StatusCode: http.StatusForbidden, Status: "403 Forbidden", Message: abuseErr.Message, NoToken: token == "", rateLimited: true}
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return newAPIError("github", respErr.Response, respErr.Message, token)
+	}
+
+	return err
+}
+
+// TokenEnvVar returns the environment variable holding the token of a forge type.
+func TokenEnvVar(forgeType string) string {
+	switch forgeType {
+	case "github":
+		return "GITHUB_TOKEN"
+	case "forgejo":
+		return "FORGEJO_TOKEN"
+	case "bitbucket":
+		return "BITBUCKET_TOKEN"
+	case "gerrit":
+		return "GERRIT_TOKEN"
+	default:
+		return ""
+	}
+}
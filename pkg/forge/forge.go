@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 // Forge is the interface for interacting with git forges.
@@ -11,6 +15,18 @@ type Forge interface {
 	// GetPR retrieves information about a pull request by number.
 	GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error)
 
+	// ListMilestonePRs lists the merged PRs of a milestone, identified by its name.
+	ListMilestonePRs(ctx context.Context, owner, repo, milestone string) ([]*PRInfo, error)
+
+	// ListPRFiles lists the files changed by a pull request.
+	ListPRFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error)
+
+	// ListPRCommits lists the commits of a pull request, oldest first.
+	ListPRCommits(ctx context.Context, owner, repo string, number int) ([]*CommitInfo, error)
+
+	// GetRepo retrieves the current owner and name of a repository, following renames and transfers.
+	GetRepo(ctx context.Context, owner, repo string) (*RepoInfo, error)
+
 	// GetCommit retrieves information about a commit by SHA.
 	GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error)
 
@@ -18,31 +34,133 @@ type Forge interface {
 	ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error)
 
 	// CreatePR creates a new pull request and returns its number.
+	// If the PR was created but its metadata could not be set, the number is returned with the error.
 	CreatePR(ctx context.Context, owner, repo string, opts CreatePROptions) (int, error)
 
+	// UpdatePR changes the metadata of an existing pull request.
+	UpdatePR(ctx context.Context, owner, repo string, number int, opts UpdatePROptions) error
+
+	// RequestReview requests a review of a pull request from the given users.
+	RequestReview(ctx context.Context, owner, repo string, number int, reviewers []string) error
+
+	// EnableAutoMerge schedules a pull request to be merged with method once its required checks
+	// and approvals pass. Forges without Capabilities.AutoMerge return an error.
+	EnableAutoMerge(ctx context.Context, owner, repo string, number int, method string) error
+
+	// ApprovePR approves a pull request as the authenticated user.
+	ApprovePR(ctx context.Context, owner, repo string, number int) error
+
 	// ListOpenPRs lists open PRs, optionally filtered by head branch.
 	ListOpenPRs(ctx context.Context, owner, repo string, opts ListPROptions) ([]*PRInfo, error)
 
+	// SearchMergedPRs lists up to maxSearchResults merged PRs whose title or body match text in
+	// the forge's search, most recently updated first. Searches are fuzzy, callers check the matches.
+	SearchMergedPRs(ctx context.Context, owner, repo, text string) ([]*PRInfo, error)
+
+	// CreateComment adds a comment to a pull request.
+	CreateComment(ctx context.Context, owner, repo string, number int, body string) error
+
+	// ListComments lists the comments of a pull request, oldest first.
+	ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error)
+
+	// UpdateComment replaces the body of a comment on a pull request.
+	UpdateComment(ctx context.Context, owner, repo string, number int, id int64, body string) error
+
+	// HasWriteAccess checks if a user has write (or admin) access to a repository.
+	HasWriteAccess(ctx context.Context, owner, repo, user string) (bool, error)
+
+	// ProtectBranch applies branch protection rules to a branch.
+	ProtectBranch(ctx context.Context, owner, repo, branch string, rules BranchProtection) error
+
 	// Name returns the name of the forge.
 	Name() string
+
+	// Capabilities returns the optional features supported by the forge.
+	Capabilities() Capabilities
 }
 
 // CreatePROptions contains options for creating a pull request.
 type CreatePROptions struct {
 	Title string // PR title
 	Body  string // PR description/body
-	Head  string // Source branch name
+	Head  string // Source branch name, "owner:branch" for a branch of the owner's fork
 	Base  string // Target branch name
+
+	Labels    []string // Labels to add (optional)
+	Assignees []string // Users to assign (optional)
+	Reviewers []string // Users to request a review from (optional)
+	Milestone int      // Milestone number (GitHub) or ID (Forgejo), see PRInfo.Milestone (optional)
+	Draft     bool     // Open as a draft, Forgejo marks the title with "WIP:" instead (optional)
+}
+
+// Merge methods of EnableAutoMerge.
+const (
+	MergeMethodMerge  = "merge"  // Merge commit
+	MergeMethodSquash = "squash" // Squash the commits into one
+	MergeMethodRebase = "rebase" // Rebase the commits onto the base branch
+)
+
+// UpdatePROptions contains the changes to an existing pull request.
+// Nil fields are left unchanged.
+type UpdatePROptions struct {
+	Title  *string  // New PR title
+	Body   *string  // New PR description/body
+	Base   *string  // New target branch name
+	Labels []string // Replaces all labels, an empty non-nil slice removes them
+}
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	ID   int64
+	Body string
+}
+
+// BranchProtection contains the rules applied when protecting a branch.
+// Force pushes and deletions are always blocked on protected branches.
+type BranchProtection struct {
+	RequiredApprovals    int      // Number of approvals required to merge (0 disables)
+	RequiredStatusChecks []string // Status check contexts that must pass before merging
+}
+
+// maxListPages caps the pages fetched when listing PRs, so repositories with thousands
+// of PRs do not exhaust the rate limit.
+const maxListPages = 10
+
+// maxSearchResults caps the PRs returned by a search, searches have a lower rate limit than other requests.
+const maxSearchResults = 100
+
+// logListTruncated warns that a list of open PRs was cut off at maxListPages, so a PR may be missed.
+func logListTruncated(forge string, count int) {
+	log.Warn().Str("forge", forge).Int("prs", count).Int("pages", maxListPages).Msg("stopped listing open PRs at the page limit, some PRs were not checked")
 }
 
 // ListPROptions contains options for listing pull requests.
 type ListPROptions struct {
-	Head string // Filter by head branch (optional)
+	Head string // Filter by head branch, "owner:branch" for a branch of the owner's fork (optional)
+}
+
+// SplitHead splits the head of a pull request into the owner of the fork and the branch name.
+// The owner is empty for branches of the repository itself.
+func SplitHead(head string) (owner, branch string) {
+	if owner, branch, ok := strings.Cut(head, ":"); ok {
+		return owner, branch
+	}
+	return "", head
 }
 
 // NewOptions holds options for creating a forge client.
 type NewOptions struct {
 	ForgejoURL string // Required for Forgejo forge type
+	GerritURL  string // Required for Gerrit forge type
+
+	// Fetch GitHub PRs through the GraphQL API, one query per PR instead of several REST calls.
+	GitHubGraphQL bool
+
+	// Directory of the on-disk cache of PR and commit responses, empty disables it.
+	CacheDir string
+	// Age up to which cached responses are used without asking the forge, older ones are
+	// revalidated with their ETag.
+	CacheTTL time.Duration
 }
 
 // New creates a new forge client based on the forge type.
@@ -52,9 +170,13 @@ func New(forgeType, token string) (Forge, error) {
 
 // NewWithOptions creates a new forge client with additional options.
 func NewWithOptions(forgeType, token string, opts NewOptions) (Forge, error) {
+	cache := newResponseCache(opts.CacheDir, opts.CacheTTL)
+
 	switch forgeType {
 	case "github":
-		return NewGitHub(token), nil
+		gh := newGitHub(token, cache)
+		gh.graphql = opts.GitHubGraphQL && token != ""
+		return gh, nil
 	case "forgejo":
 		// Forgejo requires a base URL - check options first, then environment.
 		baseURL := opts.ForgejoURL
@@ -64,7 +186,18 @@ func NewWithOptions(forgeType, token string, opts NewOptions) (Forge, error) {
 		if baseURL == "" {
 			return nil, fmt.Errorf("FORGEJO_URL not configured (set in config file or FORGEJO_URL environment variable)")
 		}
-		return NewForgejo(baseURL, token), nil
+		return newForgejo(baseURL, token, cache), nil
+	case "bitbucket":
+		return newBitbucket(BitbucketAPIURL, token, cache), nil
+	case "gerrit":
+		baseURL := opts.GerritURL
+		if baseURL == "" {
+			baseURL = os.Getenv("GERRIT_URL")
+		}
+		if baseURL == "" {
+			return nil, fmt.Errorf("GERRIT_URL not configured (set gerrit_url in config file or GERRIT_URL environment variable)")
+		}
+		return newGerrit(baseURL, token, cache), nil
 	default:
 		return nil, fmt.Errorf("unknown forge type: %s", forgeType)
 	}
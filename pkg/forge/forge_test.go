@@ -35,6 +35,20 @@ func TestNew(t *testing.T) {
 			wantError: true,
 			wantName:  "",
 		},
+		{
+			name:      "bitbucket forge",
+			forgeType: "bitbucket",
+			token:     "test-token",
+			wantError: false,
+			wantName:  "bitbucket",
+		},
+		{
+			name:      "gerrit forge without URL",
+			forgeType: "gerrit",
+			token:     "test-token",
+			wantError: true,
+			wantName:  "",
+		},
 		{
 			name:      "unknown forge type",
 			forgeType: "gitlab",
@@ -107,6 +121,35 @@ func TestForgejoName(t *testing.T) {
 	assert.Equal(t, "forgejo", fg.Name())
 }
 
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		forge    Forge
+		expected Capabilities
+	}{
+		{
+			forge:    NewGitHub("test-token"),
+			expected: Capabilities{DraftPRs: true, AutoMerge: true, Reviews: true, BranchProtection: true, Milestones: true},
+		},
+		{
+			forge:    NewForgejo("https://codeberg.org", "test-token"),
+			expected: Capabilities{AutoMerge: true, LabelsOnCreate: true, Reviews: true, BranchProtection: true, Milestones: true},
+		},
+		{
+			forge:    NewBitbucket("", "test-token"),
+			expected: Capabilities{DraftPRs: true, Reviews: true, BranchProtection: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.forge.Name(), func(t *testing.T) {
+			caps := tt.forge.Capabilities()
+			assert.Equal(t, tt.expected, caps)
+			// No supported forge can cherry-pick server-side, backports always run locally.
+			assert.False(t, caps.ServerSideCherryPick)
+		})
+	}
+}
+
 func TestPRInfoHasBackportLabel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -168,3 +211,13 @@ func TestPRInfoHasBackportLabel(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitHead(t *testing.T) {
+	owner, branch := SplitHead("bot:backport-7-to-main")
+	assert.Equal(t, "bot", owner)
+	assert.Equal(t, "backport-7-to-main", branch)
+
+	owner, branch = SplitHead("backport-7-to-main")
+	assert.Empty(t, owner)
+	assert.Equal(t, "backport-7-to-main", branch)
+}
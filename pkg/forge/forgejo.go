@@ -1,15 +1,25 @@
 package forge
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"codefloe.com/pat-s/backporter/shared/logger"
 )
 
+// forgejoAPIPath is the path of the API below the base URL of a Forgejo or Gitea instance.
+const forgejoAPIPath = "/api/v1"
+
 // Forgejo implements the Forge interface for Forgejo/Gitea.
 type Forgejo struct {
 	baseURL string
@@ -25,10 +35,15 @@ type ForgejoConfig struct {
 
 // NewForgejo creates a new Forgejo forge client.
 func NewForgejo(baseURL, token string) *Forgejo {
+	return newForgejo(baseURL, token, nil)
+}
+
+// newForgejo creates a new Forgejo forge client with an optional response cache.
+func newForgejo(baseURL, token string, cache *responseCache) *Forgejo {
 	return &Forgejo{
-		baseURL: baseURL,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 		token:   token,
-		client:  &http.Client{Timeout: 30 * time.Second}, //nolint:mnd
+		client:  newHTTPClient("forgejo", cache),
 	}
 }
 
@@ -37,11 +52,30 @@ func (f *Forgejo) Name() string {
 	return "forgejo"
 }
 
+// Capabilities returns the optional features supported by Forgejo.
+// Auto-merge schedules the merge with merge_when_checks_succeed, see EnableAutoMerge.
+// Drafts are not reported, Forgejo only marks them with a "WIP:" title prefix.
+func (f *Forgejo) Capabilities() Capabilities {
+	return Capabilities{
+		AutoMerge:        true,
+		LabelsOnCreate:   true,
+		Reviews:          true,
+		BranchProtection: true,
+		Milestones:       true,
+	}
+}
+
 // forgejoLabel is the API response for a label.
 type forgejoLabel struct {
+	ID   int64  `json:"id"`
 	Name string `json:"name"`
 }
 
+// forgejoUser is a user in API responses.
+type forgejoUser struct {
+	Login string `json:"login"`
+}
+
 // forgejoPR is the API response for a pull request.
 type forgejoPR struct {
 	Number    int            `json:"number"`
@@ -53,12 +87,18 @@ type forgejoPR struct {
 	MergedAt  string         `json:"merged_at"`
 	MergeSHA  string         `json:"merge_commit_sha"`
 	Labels    []forgejoLabel `json:"labels"`
-	User      struct {
-		Login string `json:"login"`
-	} `json:"user"`
+	User      forgejoUser    `json:"user"`
+	MergedBy  *forgejoUser   `json:"merged_by"`
+	Assignees []forgejoUser  `json:"assignees"`
+	Milestone *struct {
+		ID int `json:"id"`
+	} `json:"milestone"`
 	Head struct {
-		SHA string `json:"sha"`
-		Ref string `json:"ref"`
+		SHA  string `json:"sha"`
+		Ref  string `json:"ref"`
+		Repo *struct {
+			Owner forgejoUser `json:"owner"`
+		} `json:"repo"`
 	} `json:"head"`
 	Base struct {
 		Ref string `json:"ref"`
@@ -81,70 +121,81 @@ type forgejoCommit struct {
 	} `json:"parents"`
 }
 
-// forgejoError is the API error response.
+// toCommitInfo converts the API response for a commit.
+func (c forgejoCommit) toCommitInfo() *CommitInfo {
+	parents := make([]string, len(c.Parents))
+	for i, parent := range c.Parents {
+		parents[i] = parent.SHA
+	}
+
+	timestamp, _ := time.Parse(time.RFC3339, c.Commit.Author.Date)
+
+	return &CommitInfo{
+		SHA:       c.SHA,
+		Message:   c.Commit.Message,
+		Author:    c.Commit.Author.Name,
+		Email:     c.Commit.Author.Email,
+		Timestamp: timestamp,
+		Parents:   parents,
+	}
+}
+
+// forgejoIssue is the API response for an issue, or a pull request in issue listings.
+type forgejoIssue struct {
+	Number      int            `json:"number"`
+	Title       string         `json:"title"`
+	Body        string         `json:"body"`
+	State       string         `json:"state"`
+	User        forgejoUser    `json:"user"`
+	Labels      []forgejoLabel `json:"labels"`
+	PullRequest *struct {
+		Merged   bool   `json:"merged"`
+		MergedAt string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// forgejoChangedFile is the API response for a file changed by a pull request.
+type forgejoChangedFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// forgejoError is the API error response. Validation errors list the invalid fields in Errors.
 type forgejoError struct {
-	Message string `json:"message"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
 }
 
 // parseForgejoError extracts a clean error message from API response.
 func parseForgejoError(body []byte) string {
 	var errResp forgejoError
 	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
-		return errResp.Message
+		message := errResp.Message
+		if len(errResp.Errors) > 0 {
+			message += ": " + strings.Join(errResp.Errors, ", ")
+		}
+		return logger.Redact(message)
 	}
 	// Fallback to raw body, but clean it up
-	return strings.TrimSpace(string(body))
+	return logger.Redact(strings.TrimSpace(string(body)))
 }
 
-// GetPR retrieves information about a pull request by number.
-func (f *Forgejo) GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
-	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/pulls/%d", f.baseURL, owner, repo, number)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if f.token != "" {
-		req.Header.Set("Authorization", "token "+f.token)
-	}
-
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get PR #%d: %w", number, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get PR #%d: %s (%s)", number, resp.Status, parseForgejoError(body))
-	}
-
-	var pr forgejoPR
-	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return nil, fmt.Errorf("failed to decode PR response: %w", err)
-	}
-
-	if !pr.Merged {
-		return nil, fmt.Errorf("PR #%d is not merged", number)
+// toPRInfo converts the API response for a pull request. Squashed is not known from the PR alone.
+func (pr *forgejoPR) toPRInfo() *PRInfo {
+	labels := make([]string, len(pr.Labels))
+	for i, label := range pr.Labels {
+		labels[i] = label.Name
 	}
 
-	// Get merge commit to check if squashed.
-	mergeCommit, err := f.GetCommit(ctx, owner, repo, pr.MergeSHA)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get merge commit: %w", err)
+	assignees := make([]string, len(pr.Assignees))
+	for i, assignee := range pr.Assignees {
+		assignees[i] = assignee.Login
 	}
 
-	squashed := len(mergeCommit.Parents) == 1
-
 	mergedAt, _ := time.Parse(time.RFC3339, pr.MergedAt)
 
-	// Extract labels.
-	labels := make([]string, len(pr.Labels))
-	for i, label := range pr.Labels {
-		labels[i] = label.Name
-	}
-
 	info := &PRInfo{
 		Number:      pr.Number,
 		Title:       pr.Title,
@@ -155,226 +206,535 @@ func (f *Forgejo) GetPR(ctx context.Context, owner, repo string, number int) (*P
 		BaseBranch:  pr.Base.Ref,
 		HeadBranch:  pr.Head.Ref,
 		Merged:      pr.Merged,
-		Squashed:    squashed,
 		Author:      pr.User.Login,
 		MergedAt:    mergedAt,
 		Labels:      labels,
+		Assignees:   assignees,
+	}
+	if pr.Milestone != nil {
+		info.Milestone = pr.Milestone.ID
+	}
+	if pr.MergedBy != nil {
+		info.MergedBy = pr.MergedBy.Login
 	}
+	return info
+}
 
-	return info, nil
+// repoPath returns the API path of a repository, followed by the optional path elements.
+func repoPath(owner, repo string, elems ...any) string {
+	path := fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(repo))
+	for _, elem := range elems {
+		path += fmt.Sprintf("/%v", elem)
+	}
+	return path
 }
 
-// GetCommit retrieves information about a commit by SHA.
-func (f *Forgejo) GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error) {
-	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/git/commits/%s", f.baseURL, owner, repo, sha)
+// do sends a request to the API and decodes the JSON response into v, unless v is nil.
+// reqBody is sent as JSON unless nil. Responses other than expected are returned as API errors.
+func (f *Forgejo) do(ctx context.Context, method, path string, reqBody any, expected int, v any) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, f.baseURL+forgejoAPIPath+path, body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	if f.token != "" {
 		req.Header.Set("Authorization", "token "+f.token)
 	}
 
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get commit %s: %s (%s)", sha, resp.Status, parseForgejoError(body))
+	if resp.StatusCode != expected {
+		respBody, _ := io.ReadAll(resp.Body)
+		return newAPIError("forgejo", resp, parseForgejoError(respBody), f.token)
 	}
 
-	var commit forgejoCommit
-	if err := json.NewDecoder(resp.Body).Decode(&commit); err != nil {
-		return nil, fmt.Errorf("failed to decode commit response: %w", err)
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
+	return nil
+}
 
-	parents := make([]string, len(commit.Parents))
-	for i, parent := range commit.Parents {
-		parents[i] = parent.SHA
+// get sends a GET request to the API and decodes the JSON response into v.
+func (f *Forgejo) get(ctx context.Context, path string, v any) error {
+	return f.do(ctx, http.MethodGet, path, nil, http.StatusOK, v)
+}
+
+// forgejoPageSize is the page size of paginated listings.
+const forgejoPageSize = 50
+
+// forgejoList calls yield for the items of a paginated listing until it returns false, the
+// listing ends or maxPages pages were fetched (0 fetches all). It reports whether the listing
+// was cut off at maxPages. query holds the filters of the listing, without page and limit.
+func forgejoList[T any](ctx context.Context, f *Forgejo, path string, query url.Values, maxPages, pageSize int, yield func(T) bool) (bool, error) {
+	if query == nil {
+		query = url.Values{}
 	}
+	query.Set("limit", fmt.Sprint(pageSize))
 
-	timestamp, _ := time.Parse(time.RFC3339, commit.Commit.Author.Date)
+	for page := 1; maxPages == 0 || page <= maxPages; page++ {
+		query.Set("page", fmt.Sprint(page))
 
-	info := &CommitInfo{
-		SHA:       commit.SHA,
-		Message:   commit.Commit.Message,
-		Author:    commit.Commit.Author.Name,
-		Email:     commit.Commit.Author.Email,
-		Timestamp: timestamp,
-		Parents:   parents,
+		var items []T
+		if err := f.get(ctx, path+"?"+query.Encode(), &items); err != nil {
+			return false, err
+		}
+
+		// The server may cap the page size, so only an empty page ends the listing.
+		if len(items) == 0 {
+			return false, nil
+		}
+		for _, item := range items {
+			if !yield(item) {
+				return false, nil
+			}
+		}
 	}
 
-	return info, nil
+	return true, nil
 }
 
-// ListRecentPRs lists recently merged PRs.
-func (f *Forgejo) ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error) {
-	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/pulls?state=closed&sort=recentupdate&limit=%d", f.baseURL, owner, repo, limit)
+// GetPR retrieves information about a pull request by number.
+func (f *Forgejo) GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
+	ctx = cacheable(ctx)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+	var pr forgejoPR
+	if err := f.get(ctx, repoPath(owner, repo, "pulls", number), &pr); err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, err)
 	}
 
-	if f.token != "" {
-		req.Header.Set("Authorization", "token "+f.token)
+	if !pr.Merged {
+		return nil, fmt.Errorf("PR #%d is %w", number, ErrNotMerged)
 	}
 
-	resp, err := f.client.Do(req)
+	// Get merge commit to check if squashed.
+	mergeCommit, err := f.GetCommit(ctx, owner, repo, pr.MergeSHA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list PRs: %w", err)
+		return nil, fmt.Errorf("failed to get merge commit: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list PRs: %s (%s)", resp.Status, parseForgejoError(body))
+	info := pr.toPRInfo()
+	info.Squashed = len(mergeCommit.Parents) == 1
+	return info, nil
+}
+
+// ListMilestonePRs lists the merged PRs of a milestone, identified by its name.
+func (f *Forgejo) ListMilestonePRs(ctx context.Context, owner, repo, milestone string) ([]*PRInfo, error) {
+	// The milestone endpoint accepts names as well as IDs.
+	var m struct {
+		ID int64 `json:"id"`
+	}
+	if err := f.get(ctx, repoPath(owner, repo, "milestones", url.PathEscape(milestone)), &m); err != nil {
+		return nil, fmt.Errorf("failed to get milestone %s: %w", milestone, err)
 	}
 
-	var prs []forgejoPR
-	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
-		return nil, fmt.Errorf("failed to decode PR list response: %w", err)
+	var numbers []int
+	query := url.Values{"state": {"closed"}, "type": {"pulls"}, "milestones": {fmt.Sprint(m.ID)}}
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "issues"), query, 0, forgejoPageSize, func(issue forgejoIssue) bool {
+		numbers = append(numbers, issue.Number)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list milestone %s: %w", milestone, err)
 	}
 
 	var result []*PRInfo
-	for _, pr := range prs {
-		if !pr.Merged {
+	for _, number := range numbers {
+		pr, err := f.GetPR(ctx, owner, repo, number)
+		if errors.Is(err, ErrNotMerged) {
 			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pr)
+	}
+	return result, nil
+}
 
-		mergedAt, _ := time.Parse(time.RFC3339, pr.MergedAt)
-
-		info := &PRInfo{
-			Number:      pr.Number,
-			Title:       pr.Title,
-			State:       pr.State,
-			MergeCommit: pr.MergeSHA,
-			HeadSHA:     pr.Head.SHA,
-			BaseBranch:  pr.Base.Ref,
-			HeadBranch:  pr.Head.Ref,
-			Merged:      pr.Merged,
-			Author:      pr.User.Login,
-			MergedAt:    mergedAt,
+// forgejoFileStatus maps the file statuses of the Forgejo API to the ones of ChangedFile.
+var forgejoFileStatus = map[string]string{
+	"deleted": "removed",
+	"changed": "modified",
+}
+
+// ListPRFiles lists the files changed by a pull request.
+func (f *Forgejo) ListPRFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error) {
+	var result []ChangedFile
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "pulls", number, "files"), nil, 0, forgejoPageSize, func(file forgejoChangedFile) bool {
+		status := file.Status
+		if mapped, ok := forgejoFileStatus[status]; ok {
+			status = mapped
 		}
-		result = append(result, info)
+		result = append(result, ChangedFile{
+			Path:      file.Filename,
+			Status:    status,
+			Additions: file.Additions,
+			Deletions: file.Deletions,
+		})
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list files of PR #%d: %w", number, err)
+	}
+
+	return result, nil
+}
+
+// GetRepo retrieves the current owner and name of a repository.
+// Forgejo redirects requests for renamed and transferred repositories.
+func (f *Forgejo) GetRepo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	var r struct {
+		Name  string      `json:"name"`
+		Owner forgejoUser `json:"owner"`
+	}
+	if err := f.get(ctx, repoPath(owner, repo), &r); err != nil {
+		return nil, fmt.Errorf("failed to get repository %s/%s: %w", owner, repo, err)
+	}
+
+	return &RepoInfo{Owner: r.Owner.Login, Name: r.Name}, nil
+}
+
+// GetCommit retrieves information about a commit by SHA.
+func (f *Forgejo) GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error) {
+	ctx = cacheable(ctx)
+
+	var commit forgejoCommit
+	if err := f.get(ctx, repoPath(owner, repo, "git", "commits", sha), &commit); err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return commit.toCommitInfo(), nil
+}
+
+// ListPRCommits lists the commits of a pull request, oldest first.
+func (f *Forgejo) ListPRCommits(ctx context.Context, owner, repo string, number int) ([]*CommitInfo, error) {
+	var result []*CommitInfo
+	// Skip the per-commit file lists and verification, they are not needed.
+	query := url.Values{"stat": {"false"}, "verification": {"false"}, "files": {"false"}}
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "pulls", number, "commits"), query, 0, forgejoPageSize, func(commit forgejoCommit) bool {
+		result = append(result, commit.toCommitInfo())
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list commits of PR #%d: %w", number, err)
+	}
 
-		if len(result) >= limit {
-			break
+	return result, nil
+}
+
+// ListRecentPRs lists recently merged PRs.
+func (f *Forgejo) ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	// Closed PRs include unmerged ones, so more pages may be needed to reach the limit.
+	var result []*PRInfo
+	query := url.Values{"state": {"closed"}, "sort": {"recentupdate"}}
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "pulls"), query, maxListPages, limit, func(pr forgejoPR) bool {
+		if pr.Merged {
+			result = append(result, pr.toPRInfo())
 		}
+		return len(result) < limit
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list PRs: %w", err)
 	}
 
 	return result, nil
 }
 
+// forgejoDraftPrefix marks a PR as work in progress, which blocks merging.
+const forgejoDraftPrefix = "WIP: "
+
 // forgejoCreatePRRequest is the request body for creating a PR.
 type forgejoCreatePRRequest struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
-	Head  string `json:"head"`
-	Base  string `json:"base"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Head      string   `json:"head"`
+	Base      string   `json:"base"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+	Milestone int      `json:"milestone,omitempty"`
 }
 
 // CreatePR creates a new pull request and returns its number.
 func (f *Forgejo) CreatePR(ctx context.Context, owner, repo string, opts CreatePROptions) (int, error) {
-	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/pulls", f.baseURL, owner, repo)
+	// Forgejo has no draft flag, PRs with a "WIP:" title are treated as work in progress.
+	title := opts.Title
+	if opts.Draft {
+		title = forgejoDraftPrefix + title
+	}
 
-	reqBody := forgejoCreatePRRequest(opts)
+	// Labels on creation are label IDs, labels not found in the repository (e.g. the ones of
+	// its organization) are added by name afterwards.
+	labelIDs, otherLabels := f.labelIDs(ctx, owner, repo, opts.Labels)
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return 0, fmt.Errorf("failed to marshal PR request: %w", err)
+	reqBody := forgejoCreatePRRequest{
+		Title:     title,
+		Body:      opts.Body,
+		Head:      opts.Head,
+		Base:      opts.Base,
+		Assignees: opts.Assignees,
+		Labels:    labelIDs,
+		Milestone: opts.Milestone,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(jsonBody)))
-	if err != nil {
-		return 0, err
+	var pr forgejoPR
+	if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "pulls"), reqBody, http.StatusCreated, &pr); err != nil {
+		return 0, fmt.Errorf("failed to create PR: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if f.token != "" {
-		req.Header.Set("Authorization", "token "+f.token)
+	// The issue labels endpoint also accepts names.
+	if len(otherLabels) > 0 {
+		reqBody := map[string][]string{"labels": otherLabels}
+		if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "issues", pr.Number, "labels"), reqBody, http.StatusOK, nil); err != nil {
+			return pr.Number, fmt.Errorf("failed to add labels to PR #%d: %w", pr.Number, err)
+		}
 	}
 
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create PR: %w", err)
+	if len(opts.Reviewers) > 0 {
+		if err := f.RequestReview(ctx, owner, repo, pr.Number, opts.Reviewers); err != nil {
+			return pr.Number, err
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to create PR: %s (%s)", resp.Status, parseForgejoError(body))
+	return pr.Number, nil
+}
+
+// labelIDs resolves label names to the IDs of the repository's labels. Names without a label
+// in the repository are returned separately, all of them if the labels cannot be listed.
+func (f *Forgejo) labelIDs(ctx context.Context, owner, repo string, names []string) ([]int64, []string) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	byName := make(map[string]int64)
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "labels"), nil, 0, forgejoPageSize, func(label forgejoLabel) bool {
+		byName[label.Name] = label.ID
+		return true
+	}); err != nil {
+		log.Debug().Err(err).Msg("failed to list labels, adding them by name")
+		return nil, names
+	}
+
+	var ids []int64
+	var other []string
+	for _, name := range names {
+		if id, ok := byName[name]; ok {
+			ids = append(ids, id)
+		} else {
+			other = append(other, name)
+		}
 	}
+	return ids, other
+}
 
-	var pr forgejoPR
-	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return 0, fmt.Errorf("failed to decode PR response: %w", err)
+// RequestReview requests a review of a pull request from the given users.
+func (f *Forgejo) RequestReview(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	reqBody := map[string][]string{"reviewers": reviewers}
+	if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "pulls", number, "requested_reviewers"), reqBody, http.StatusCreated, nil); err != nil {
+		return fmt.Errorf("failed to request reviewers on PR #%d: %w", number, err)
 	}
 
-	return pr.Number, nil
+	return nil
 }
 
-// ListOpenPRs lists open PRs, optionally filtered by head branch.
-func (f *Forgejo) ListOpenPRs(ctx context.Context, owner, repo string, opts ListPROptions) ([]*PRInfo, error) {
-	url := fmt.Sprintf("%s/api/v1/repos/%s/%s/pulls?state=open", f.baseURL, owner, repo)
+// forgejoMergePRRequest is the request body for merging a PR.
+type forgejoMergePRRequest struct {
+	Do                     string `json:"Do"`
+	MergeWhenChecksSucceed bool   `json:"merge_when_checks_succeed"`
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// EnableAutoMerge schedules a PR to be merged when its checks succeed. A PR whose checks
+// already succeeded is merged right away, which is answered with 200 instead of 201.
+func (f *Forgejo) EnableAutoMerge(ctx context.Context, owner, repo string, number int, method string) error {
+	reqBody := forgejoMergePRRequest{Do: method, MergeWhenChecksSucceed: true}
+	err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "pulls", number, "merge"), reqBody, http.StatusCreated, nil)
+	var apiErr *APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusOK) {
+		return fmt.Errorf("failed to schedule merge of PR #%d: %w", number, err)
 	}
 
-	if f.token != "" {
-		req.Header.Set("Authorization", "token "+f.token)
+	return nil
+}
+
+// ApprovePR approves a pull request as the authenticated user.
+func (f *Forgejo) ApprovePR(ctx context.Context, owner, repo string, number int) error {
+	reqBody := map[string]string{"event": "APPROVED"}
+	if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "pulls", number, "reviews"), reqBody, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("failed to approve PR #%d: %w", number, err)
 	}
 
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list open PRs: %w", err)
+	return nil
+}
+
+// forgejoUpdatePRRequest is the request body for editing a PR.
+type forgejoUpdatePRRequest struct {
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+	Base  *string `json:"base,omitempty"`
+}
+
+// UpdatePR changes the metadata of an existing pull request.
+func (f *Forgejo) UpdatePR(ctx context.Context, owner, repo string, number int, opts UpdatePROptions) error {
+	if opts.Title != nil || opts.Body != nil || opts.Base != nil {
+		reqBody := forgejoUpdatePRRequest{Title: opts.Title, Body: opts.Body, Base: opts.Base}
+		if err := f.do(ctx, http.MethodPatch, repoPath(owner, repo, "pulls", number), reqBody, http.StatusCreated, nil); err != nil {
+			return fmt.Errorf("failed to update PR #%d: %w", number, err)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list open PRs: %s (%s)", resp.Status, parseForgejoError(body))
+	// The PR edit endpoint only takes label IDs, the issue labels endpoint also accepts names.
+	if opts.Labels != nil {
+		reqBody := map[string][]string{"labels": opts.Labels}
+		if err := f.do(ctx, http.MethodPut, repoPath(owner, repo, "issues", number, "labels"), reqBody, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("failed to update labels of PR #%d: %w", number, err)
+		}
+	}
+
+	return nil
+}
+
+// ListOpenPRs lists open PRs, optionally filtered by head branch.
+func (f *Forgejo) ListOpenPRs(ctx context.Context, owner, repo string, opts ListPROptions) ([]*PRInfo, error) {
+	headOwner, headBranch := SplitHead(opts.Head)
+
+	var result []*PRInfo
+	truncated, err := forgejoList(ctx, f, repoPath(owner, repo, "pulls"), url.Values{"state": {"open"}}, maxListPages, forgejoPageSize, func(pr forgejoPR) bool {
+		// Filter by head branch and the owner of its fork if specified.
+		if opts.Head == "" || pr.Head.Ref == headBranch && (headOwner == "" || pr.Head.Repo != nil && pr.Head.Repo.Owner.Login == headOwner) {
+			result = append(result, pr.toPRInfo())
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open PRs: %w", err)
 	}
 
-	var prs []forgejoPR
-	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
-		return nil, fmt.Errorf("failed to decode PR list response: %w", err)
+	if truncated {
+		logListTruncated("forgejo", len(result))
 	}
+	return result, nil
+}
 
+// SearchMergedPRs lists merged PRs whose title or body match text in the issue search.
+func (f *Forgejo) SearchMergedPRs(ctx context.Context, owner, repo, text string) ([]*PRInfo, error) {
 	var result []*PRInfo
-	for _, pr := range prs {
-		// Filter by head branch if specified.
-		if opts.Head != "" && pr.Head.Ref != opts.Head {
-			continue
+	query := url.Values{"state": {"closed"}, "type": {"pulls"}, "q": {text}}
+	if _, err := forgejoList(ctx, f, repoPath(owner, repo, "issues"), query, maxSearchResults/forgejoPageSize, forgejoPageSize, func(issue forgejoIssue) bool {
+		if issue.PullRequest == nil || !issue.PullRequest.Merged {
+			return true
 		}
 
-		// Extract labels.
-		labels := make([]string, len(pr.Labels))
-		for i, label := range pr.Labels {
+		labels := make([]string, len(issue.Labels))
+		for i, label := range issue.Labels {
 			labels[i] = label.Name
 		}
+		mergedAt, _ := time.Parse(time.RFC3339, issue.PullRequest.MergedAt)
+
+		result = append(result, &PRInfo{
+			Number:   issue.Number,
+			Title:    issue.Title,
+			Body:     issue.Body,
+			State:    issue.State,
+			Merged:   true,
+			Author:   issue.User.Login,
+			MergedAt: mergedAt,
+			Labels:   labels,
+		})
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to search PRs: %w", err)
+	}
 
-		info := &PRInfo{
-			Number:     pr.Number,
-			Title:      pr.Title,
-			Body:       pr.Body,
-			State:      pr.State,
-			HeadSHA:    pr.Head.SHA,
-			BaseBranch: pr.Base.Ref,
-			HeadBranch: pr.Head.Ref,
-			Merged:     pr.Merged,
-			Author:     pr.User.Login,
-			Labels:     labels,
-		}
-		result = append(result, info)
+	return result, nil
+}
+
+// CreateComment adds a comment to a pull request.
+func (f *Forgejo) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	reqBody := map[string]string{"body": body}
+	if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "issues", number, "comments"), reqBody, http.StatusCreated, nil); err != nil {
+		return fmt.Errorf("failed to comment on PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// ListComments lists the comments of a pull request, oldest first.
+// The endpoint is not paginated, it returns all comments at once.
+func (f *Forgejo) ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	var comments []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	if err := f.get(ctx, repoPath(owner, repo, "issues", number, "comments"), &comments); err != nil {
+		return nil, fmt.Errorf("failed to list comments of PR #%d: %w", number, err)
+	}
+
+	result := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		result = append(result, Comment{ID: comment.ID, Body: comment.Body})
 	}
 
 	return result, nil
 }
+
+// UpdateComment replaces the body of a comment on a pull request.
+func (f *Forgejo) UpdateComment(ctx context.Context, owner, repo string, number int, id int64, body string) error {
+	reqBody := map[string]string{"body": body}
+	if err := f.do(ctx, http.MethodPatch, repoPath(owner, repo, "issues", "comments", id), reqBody, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("failed to update comment on PR #%d: %w", number, err)
+	}
+
+	return nil
+}
+
+// HasWriteAccess checks if a user has write (or admin) access to a repository.
+func (f *Forgejo) HasWriteAccess(ctx context.Context, owner, repo, user string) (bool, error) {
+	var permission struct {
+		Permission string `json:"permission"`
+	}
+	if err := f.get(ctx, repoPath(owner, repo, "collaborators", url.PathEscape(user), "permission"), &permission); err != nil {
+		return false, fmt.Errorf("failed to get permission of %s: %w", user, err)
+	}
+
+	return hasWritePermission(permission.Permission), nil
+}
+
+// forgejoBranchProtectionRequest is the request body for creating a branch protection.
+type forgejoBranchProtectionRequest struct {
+	RuleName            string   `json:"rule_name"`
+	RequiredApprovals   int      `json:"required_approvals"`
+	EnableStatusCheck   bool     `json:"enable_status_check"`
+	StatusCheckContexts []string `json:"status_check_contexts,omitempty"`
+}
+
+// ProtectBranch applies branch protection rules to a branch.
+// Forgejo never allows force pushes to protected branches.
+func (f *Forgejo) ProtectBranch(ctx context.Context, owner, repo, branch string, rules BranchProtection) error {
+	reqBody := forgejoBranchProtectionRequest{
+		RuleName:            branch,
+		RequiredApprovals:   rules.RequiredApprovals,
+		EnableStatusCheck:   len(rules.RequiredStatusChecks) > 0,
+		StatusCheckContexts: rules.RequiredStatusChecks,
+	}
+	if err := f.do(ctx, http.MethodPost, repoPath(owner, repo, "branch_protections"), reqBody, http.StatusCreated, nil); err != nil {
+		return fmt.Errorf("failed to protect branch %s: %w", branch, err)
+	}
+
+	return nil
+}
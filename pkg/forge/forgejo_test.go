@@ -0,0 +1,343 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForgejoUpdatePR(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "token test-token", r.Header.Get("Authorization"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/pulls/7":
+			assert.Equal(t, map[string]any{"title": "New title"}, body)
+			w.WriteHeader(http.StatusCreated)
+		case "/api/v1/repos/owner/repo/issues/7/labels":
+			assert.Equal(t, map[string]any{"labels": []any{"backport"}}, body)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	title := "New title"
+	fj := NewForgejo(server.URL, "test-token")
+	require.NoError(t, fj.UpdatePR(context.Background(), "owner", "repo", 7, UpdatePROptions{Title: &title, Labels: []string{"backport"}}))
+	assert.Equal(t, []string{
+		"PATCH /api/v1/repos/owner/repo/pulls/7",
+		"PUT /api/v1/repos/owner/repo/issues/7/labels",
+	}, requests)
+
+	// Nothing to change, nothing sent.
+	requests = nil
+	require.NoError(t, fj.UpdatePR(context.Background(), "owner", "repo", 7, UpdatePROptions{}))
+	assert.Empty(t, requests)
+}
+
+func TestForgejoCreatePRMetadata(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			if r.URL.Query().Get("page") == "1" {
+				_, _ = w.Write([]byte(`[{"id": 5, "name": "backport"}, {"id": 6, "name": "bug"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/pulls":
+			assert.Equal(t, "WIP: fix: backport #1 to release-1.x", body["title"])
+			assert.Equal(t, []any{"alice"}, body["assignees"])
+			assert.Equal(t, []any{float64(6)}, body["labels"])
+			assert.InDelta(t, 3, body["milestone"], 0)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": 12}`))
+			return
+		case "/api/v1/repos/owner/repo/issues/12/labels":
+			// Labels of the organization are not listed for the repository.
+			assert.Equal(t, []any{"org-label"}, body["labels"])
+		case "/api/v1/repos/owner/repo/pulls/12/requested_reviewers":
+			assert.Equal(t, []any{"bob"}, body["reviewers"])
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	fj := NewForgejo(server.URL, "test-token")
+	number, err := fj.CreatePR(context.Background(), "owner", "repo", CreatePROptions{
+		Title:     "fix: backport #1 to release-1.x",
+		Head:      "backport-1-to-release-1.x",
+		Base:      "release-1.x",
+		Labels:    []string{"bug", "org-label"},
+		Assignees: []string{"alice"},
+		Reviewers: []string{"bob"},
+		Milestone: 3,
+		Draft:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 12, number)
+	assert.Equal(t, []string{
+		"GET /api/v1/repos/owner/repo/labels",
+		"GET /api/v1/repos/owner/repo/labels",
+		"POST /api/v1/repos/owner/repo/pulls",
+		"POST /api/v1/repos/owner/repo/issues/12/labels",
+		"POST /api/v1/repos/owner/repo/pulls/12/requested_reviewers",
+	}, requests)
+}
+
+func TestForgejoListPRFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo/pulls/7/files", r.URL.Path)
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"filename": "main.go", "status": "changed", "additions": 3, "deletions": 1},
+			{"filename": "old.go", "status": "deleted", "additions": 0, "deletions": 10}
+		]`))
+	}))
+	defer server.Close()
+
+	files, err := NewForgejo(server.URL, "test-token").ListPRFiles(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []ChangedFile{
+		{Path: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "old.go", Status: "removed", Deletions: 10},
+	}, files)
+}
+
+func TestForgejoListPRCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo/pulls/7/commits", r.URL.Path)
+		assert.Equal(t, "false", r.URL.Query().Get("files"))
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"sha": "aaa", "commit": {"message": "first", "author": {"name": "Alice", "email": "alice@example.com", "date": "2024-01-15T10:30:00Z"}}, "parents": [{"sha": "base"}]},
+			{"sha": "bbb", "commit": {"message": "second", "author": {"name": "Bob", "email": "bob@example.com"}}, "parents": [{"sha": "aaa"}]}
+		]`))
+	}))
+	defer server.Close()
+
+	commits, err := NewForgejo(server.URL, "test-token").ListPRCommits(context.Background(), "owner", "repo", 7)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "aaa", commits[0].SHA)
+	assert.Equal(t, "Alice", commits[0].Author)
+	assert.Equal(t, "alice@example.com", commits[0].Email)
+	assert.Equal(t, 2024, commits[0].Timestamp.Year())
+	assert.Equal(t, []string{"aaa"}, commits[1].Parents)
+}
+
+func TestForgejoGetRepo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo", r.URL.Path)
+		_, _ = w.Write([]byte(`{"name": "renamed", "owner": {"login": "new-owner"}}`))
+	}))
+	defer server.Close()
+
+	info, err := NewForgejo(server.URL, "test-token").GetRepo(context.Background(), "owner", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, &RepoInfo{Owner: "new-owner", Name: "renamed"}, info)
+}
+
+func TestForgejoListMilestonePRs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/milestones/v1.2":
+			_, _ = w.Write([]byte(`{"id": 42}`))
+		case "/api/v1/repos/owner/repo/issues":
+			assert.Equal(t, "42", r.URL.Query().Get("milestones"))
+			if r.URL.Query().Get("page") != "1" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"number": 1}, {"number": 2}]`))
+		case "/api/v1/repos/owner/repo/pulls/1":
+			_, _ = w.Write([]byte(`{"number": 1, "title": "Fix", "merged": true, "merge_commit_sha": "abc", "labels": [{"name": "backport"}]}`))
+		case "/api/v1/repos/owner/repo/pulls/2":
+			_, _ = w.Write([]byte(`{"number": 2, "title": "Closed", "merged": false}`))
+		case "/api/v1/repos/owner/repo/git/commits/abc":
+			_, _ = w.Write([]byte(`{"sha": "abc", "parents": [{"sha": "def"}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	prs, err := NewForgejo(server.URL, "test-token").ListMilestonePRs(context.Background(), "owner", "repo", "v1.2")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 1, prs[0].Number)
+	assert.True(t, prs[0].HasBackportLabel())
+}
+
+func TestForgejoListPRsPaginates(t *testing.T) {
+	var pages int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo/pulls", r.URL.Path)
+		pages++
+		switch r.URL.Query().Get("page") {
+		case "1":
+			_, _ = w.Write([]byte(`[{"number": 1, "merged": true, "head": {"ref": "feature"}}, {"number": 2, "merged": false, "head": {"ref": "backport-7-to-release-1.x"}}]`))
+		case "2":
+			_, _ = w.Write([]byte(`[{"number": 3, "merged": true, "head": {"ref": "backport-8-to-release-1.x"}}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	fj := NewForgejo(server.URL, "test-token")
+
+	// Unmerged PRs do not count towards the limit.
+	prs, err := fj.ListRecentPRs(context.Background(), "owner", "repo", 2)
+	require.NoError(t, err)
+	require.Len(t, prs, 2)
+	assert.Equal(t, 3, prs[1].Number)
+	assert.Equal(t, 2, pages)
+
+	// PRs beyond the first page are found.
+	prs, err = fj.ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{Head: "backport-8-to-release-1.x"})
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 3, prs[0].Number)
+}
+
+func TestForgejoListOpenPRsForkHead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"number": 1, "head": {"ref": "backport-8-to-release-1.x", "repo": {"owner": {"login": "owner"}}}},
+			{"number": 2, "head": {"ref": "backport-8-to-release-1.x", "repo": {"owner": {"login": "bot"}}}}
+		]`))
+	}))
+	defer server.Close()
+
+	prs, err := NewForgejo(server.URL, "test-token").ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{Head: "bot:backport-8-to-release-1.x"})
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 2, prs[0].Number)
+}
+
+func TestForgejoAutoMergeAndApprove(t *testing.T) {
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/pulls/7/merge":
+			assert.Equal(t, map[string]any{"Do": "rebase", "merge_when_checks_succeed": true}, body)
+			w.WriteHeader(status)
+		case "/api/v1/repos/owner/repo/pulls/7/reviews":
+			assert.Equal(t, map[string]any{"event": "APPROVED"}, body)
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fj := NewForgejo(server.URL, "test-token")
+	require.NoError(t, fj.ApprovePR(context.Background(), "owner", "repo", 7))
+	require.NoError(t, fj.EnableAutoMerge(context.Background(), "owner", "repo", 7, MergeMethodRebase))
+
+	// PRs with passing checks are merged right away.
+	status = http.StatusOK
+	require.NoError(t, fj.EnableAutoMerge(context.Background(), "owner", "repo", 7, MergeMethodRebase))
+
+	status = http.StatusMethodNotAllowed
+	assert.Error(t, fj.EnableAutoMerge(context.Background(), "owner", "repo", 7, MergeMethodRebase))
+}
+
+func TestForgejoListOpenPRsPageLimit(t *testing.T) {
+	var pages int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		pages++
+		_, _ = w.Write([]byte(`[{"number": 1}]`))
+	}))
+	defer server.Close()
+
+	prs, err := NewForgejo(server.URL, "test-token").ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{})
+	require.NoError(t, err)
+	assert.Len(t, prs, maxListPages)
+	assert.Equal(t, maxListPages, pages)
+}
+
+func TestForgejoErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/repo/pulls/404":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "The target couldn't be found."}`))
+		case "/api/v1/repos/owner/repo/pulls":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message": "Validation failed", "errors": ["head: branch does not exist"]}`))
+		}
+	}))
+	defer server.Close()
+
+	fj := NewForgejo(server.URL+"/", "")
+
+	_, err := fj.GetPR(context.Background(), "owner", "repo", 404)
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.True(t, apiErr.NoToken)
+	assert.ErrorContains(t, err, "The target couldn't be found.")
+
+	_, err = fj.CreatePR(context.Background(), "owner", "repo", CreatePROptions{Title: "fix", Head: "missing", Base: "main"})
+	assert.ErrorContains(t, err, "Validation failed: head: branch does not exist")
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestForgejoSearchMergedPRs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo/issues", r.URL.Path)
+		assert.Equal(t, "#123", r.URL.Query().Get("q"))
+		assert.Equal(t, "pulls", r.URL.Query().Get("type"))
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"number": 130, "title": "fix: regression from #123", "user": {"login": "alice"}, "pull_request": {"merged": true, "merged_at": "2024-03-02T12:00:00Z"}},
+			{"number": 131, "title": "fix: closed attempt for #123", "pull_request": {"merged": false}}
+		]`))
+	}))
+	defer server.Close()
+
+	prs, err := NewForgejo(server.URL, "test-token").SearchMergedPRs(context.Background(), "owner", "repo", "#123")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 130, prs[0].Number)
+	assert.Equal(t, "alice", prs[0].Author)
+	assert.True(t, prs[0].Merged)
+	assert.Equal(t, 2024, prs[0].MergedAt.Year())
+}
@@ -0,0 +1,587 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"codefloe.com/pat-s/backporter/shared/logger"
+)
+
+// gerritXSSIPrefix precedes all JSON responses of the Gerrit REST API.
+const gerritXSSIPrefix = ")]}'"
+
+// gerritTimeLayout is the layout of timestamps in Gerrit responses, always in UTC.
+const gerritTimeLayout = "2006-01-02 15:04:05.000000000"
+
+// gerritPageSize is the page size of change queries.
+const gerritPageSize = 50
+
+// gerritChangeOptions are the fields requested for changes: the current patch set with its
+// commit and the usernames of accounts.
+var gerritChangeOptions = []string{"CURRENT_REVISION", "CURRENT_COMMIT", "DETAILED_ACCOUNTS"}
+
+// Gerrit implements the Forge interface for Gerrit Code Review. Changes take the place of PRs:
+// a backport is pushed to refs/for/<target> with the backport branch as topic, see
+// Capabilities.PushForReview, and CreatePR then finds the change the push created.
+//
+// Projects are identified by owner/repo as parsed from the remote URL. The "a/" prefix of
+// authenticated HTTP URLs is not part of the project name.
+type Gerrit struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGerrit creates a new Gerrit forge client. The token is "<username>:<HTTP password>",
+// without a token the API is used anonymously.
+func NewGerrit(baseURL, token string) *Gerrit {
+	return newGerrit(baseURL, token, nil)
+}
+
+// newGerrit creates a new Gerrit forge client with an optional response cache.
+func newGerrit(baseURL, token string, cache *responseCache) *Gerrit {
+	return &Gerrit{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  newHTTPClient("gerrit", cache),
+	}
+}
+
+// Name returns the name of the forge.
+func (g *Gerrit) Name() string {
+	return "gerrit"
+}
+
+// Capabilities returns the optional features supported by Gerrit.
+// Changes are created by pushes, work-in-progress changes stand in for drafts.
+func (g *Gerrit) Capabilities() Capabilities {
+	return Capabilities{
+		DraftPRs:      true,
+		Reviews:       true,
+		PushForReview: true,
+	}
+}
+
+// gerritAccount is an account in API responses.
+type gerritAccount struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+// login returns the username of the account, or its name for accounts without one.
+func (a *gerritAccount) login() string {
+	if a.Username != "" {
+		return a.Username
+	}
+	return a.Name
+}
+
+// gerritCommit is the commit of a patch set or project in API responses.
+type gerritCommit struct {
+	Commit  string `json:"commit"`
+	Parents []struct {
+		Commit string `json:"commit"`
+	} `json:"parents"`
+	Author struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Date  string `json:"date"`
+	} `json:"author"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// toCommitInfo converts the API response for a commit.
+func (c gerritCommit) toCommitInfo() *CommitInfo {
+	parents := make([]string, len(c.Parents))
+	for i, parent := range c.Parents {
+		parents[i] = parent.Commit
+	}
+
+	timestamp, _ := time.Parse(gerritTimeLayout, c.Author.Date)
+
+	return &CommitInfo{
+		SHA:       c.Commit,
+		Message:   c.Message,
+		Author:    c.Author.Name,
+		Email:     c.Author.Email,
+		Timestamp: timestamp,
+		Parents:   parents,
+	}
+}
+
+// gerritChange is the API response for a change.
+type gerritChange struct {
+	Number          int            `json:"_number"`
+	Project         string         `json:"project"`
+	Branch          string         `json:"branch"`
+	Topic           string         `json:"topic"`
+	Hashtags        []string       `json:"hashtags"`
+	Subject         string         `json:"subject"`
+	Status          string         `json:"status"`
+	Submitted       string         `json:"submitted"`
+	Owner           gerritAccount  `json:"owner"`
+	Submitter       *gerritAccount `json:"submitter"`
+	CurrentRevision string         `json:"current_revision"`
+	Revisions       map[string]struct {
+		Commit gerritCommit `json:"commit"`
+	} `json:"revisions"`
+	MoreChanges bool `json:"_more_changes"`
+}
+
+// toPRInfo converts a change. The current patch set is the commit that was submitted, it is a
+// single commit like the merge commit of a squashed PR. The topic stands in for the head branch.
+func (c *gerritChange) toPRInfo() *PRInfo {
+	info := &PRInfo{
+		Number:      c.Number,
+		Title:       c.Subject,
+		State:       strings.ToLower(c.Status),
+		MergeCommit: c.CurrentRevision,
+		HeadSHA:     c.CurrentRevision,
+		BaseBranch:  c.Branch,
+		HeadBranch:  c.Topic,
+		Merged:      c.Status == "MERGED",
+		Squashed:    true,
+		Author:      c.Owner.login(),
+		Labels:      c.Hashtags,
+	}
+	if info.State == "new" {
+		info.State = "open"
+	}
+	if revision, ok := c.Revisions[c.CurrentRevision]; ok {
+		// The subject line is the title, the rest of the commit message the description.
+		_, body, _ := strings.Cut(revision.Commit.Message, "\n")
+		info.Body = strings.TrimSpace(body)
+	}
+	if c.Submitter != nil {
+		info.MergedBy = c.Submitter.login()
+	}
+	if c.Submitted != "" {
+		info.MergedAt, _ = time.Parse(gerritTimeLayout, c.Submitted)
+	}
+	return info
+}
+
+// gerritProject returns the name of the project of owner/repo.
+func gerritProject(owner, repo string) string {
+	if owner == "a" {
+		return repo
+	}
+	return owner + "/" + repo
+}
+
+// gerritChangeID returns the identifier of a change in API paths, "<project>~<number>".
+func gerritChangeID(owner, repo string, number int) string {
+	return url.PathEscape(gerritProject(owner, repo)) + "~" + strconv.Itoa(number)
+}
+
+// do sends a request to the API and decodes the JSON response into v, unless v is nil.
+// Requests with a token go to the authenticated /a/ endpoints.
+func (g *Gerrit) do(ctx context.Context, method, path string, reqBody any, expected int, v any) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	endpoint := g.baseURL + path
+	if g.token != "" {
+		endpoint = g.baseURL + "/a" + path
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user, password, ok := strings.Cut(g.token, ":"); ok {
+		req.SetBasicAuth(user, password)
+	} else if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Gerrit answers errors in plain text.
+	if resp.StatusCode != expected {
+		return newAPIError("gerrit", resp, logger.Redact(strings.TrimSpace(string(respBody))), g.token)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(bytes.TrimPrefix(respBody, []byte(gerritXSSIPrefix)), v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// queryChanges returns the changes matching a search query, most recently updated first,
+// fetching up to maxPages pages. It reports whether more changes match.
+func (g *Gerrit) queryChanges(ctx context.Context, query string, pageSize, maxPages int) ([]*gerritChange, bool, error) {
+	params := url.Values{"q": {query}, "n": {strconv.Itoa(pageSize)}, "o": gerritChangeOptions}
+
+	var result []*gerritChange
+	for page := range maxPages {
+		params.Set("S", strconv.Itoa(page*pageSize))
+
+		var changes []*gerritChange
+		if err := g.do(ctx, http.MethodGet, "/changes/?"+params.Encode(), nil, http.StatusOK, &changes); err != nil {
+			return nil, false, err
+		}
+		result = append(result, changes...)
+
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			return result, false, nil
+		}
+	}
+	return result, true, nil
+}
+
+// gerritQuery returns a change search query in a project, joining the given operators.
+func gerritQuery(owner, repo string, operators ...string) string {
+	return strings.Join(append([]string{fmt.Sprintf("project:%q", gerritProject(owner, repo))}, operators...), " ")
+}
+
+// getChange retrieves a change with its current patch set.
+func (g *Gerrit) getChange(ctx context.Context, owner, repo string, number int) (*gerritChange, error) {
+	params := url.Values{"o": gerritChangeOptions}
+	var change gerritChange
+	if err := g.do(ctx, http.MethodGet, "/changes/"+gerritChangeID(owner, repo, number)+"?"+params.Encode(), nil, http.StatusOK, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetPR retrieves information about a change by number.
+func (g *Gerrit) GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	change, err := g.getChange(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change %d: %w", number, err)
+	}
+
+	if change.Status != "MERGED" {
+		return nil, fmt.Errorf("change %d is %w", number, ErrNotMerged)
+	}
+
+	return change.toPRInfo(), nil
+}
+
+// ListMilestonePRs is not supported, Gerrit has no milestones.
+func (g *Gerrit) ListMilestonePRs(_ context.Context, _, _, milestone string) ([]*PRInfo, error) {
+	return nil, fmt.Errorf("gerrit does not support milestones, cannot list milestone %s", milestone)
+}
+
+// gerritFileStatus maps the file statuses of the Gerrit API to the ones of ChangedFile.
+// Files without a status are modified.
+var gerritFileStatus = map[string]string{
+	"A": "added",
+	"D": "removed",
+	"R": "renamed",
+	"C": "copied",
+	"W": "modified",
+}
+
+// ListPRFiles lists the files changed by the current patch set of a change.
+func (g *Gerrit) ListPRFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error) {
+	var files map[string]struct {
+		Status        string `json:"status"`
+		LinesInserted int    `json:"lines_inserted"`
+		LinesDeleted  int    `json:"lines_deleted"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/changes/"+gerritChangeID(owner, repo, number)+"/revisions/current/files", nil, http.StatusOK, &files); err != nil {
+		return nil, fmt.Errorf("failed to list files of change %d: %w", number, err)
+	}
+
+	result := make([]ChangedFile, 0, len(files))
+	for path, file := range files {
+		// The commit message is listed like a file.
+		if strings.HasPrefix(path, "/") {
+			continue
+		}
+		status, ok := gerritFileStatus[file.Status]
+		if !ok {
+			status = "modified"
+		}
+		result = append(result, ChangedFile{Path: path, Status: status, Additions: file.LinesInserted, Deletions: file.LinesDeleted})
+	}
+	slices.SortFunc(result, func(a, b ChangedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return result, nil
+}
+
+// GetRepo checks that a project exists. Gerrit projects cannot be renamed or transferred.
+func (g *Gerrit) GetRepo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	if err := g.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(gerritProject(owner, repo)), nil, http.StatusOK, nil); err != nil {
+		return nil, fmt.Errorf("failed to get project %s: %w", gerritProject(owner, repo), err)
+	}
+
+	return &RepoInfo{Owner: owner, Name: repo}, nil
+}
+
+// GetCommit retrieves information about a commit by SHA.
+func (g *Gerrit) GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error) {
+	ctx = cacheable(ctx)
+
+	var commit gerritCommit
+	if err := g.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(gerritProject(owner, repo))+"/commits/"+sha, nil, http.StatusOK, &commit); err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return commit.toCommitInfo(), nil
+}
+
+// ListPRCommits lists the commit of the current patch set of a change, changes have a single commit.
+func (g *Gerrit) ListPRCommits(ctx context.Context, owner, repo string, number int) ([]*CommitInfo, error) {
+	var commit gerritCommit
+	if err := g.do(ctx, http.MethodGet, "/changes/"+gerritChangeID(owner, repo, number)+"/revisions/current/commit", nil, http.StatusOK, &commit); err != nil {
+		return nil, fmt.Errorf("failed to list commits of change %d: %w", number, err)
+	}
+
+	return []*CommitInfo{commit.toCommitInfo()}, nil
+}
+
+// ListRecentPRs lists recently merged changes.
+func (g *Gerrit) ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	changes, _, err := g.queryChanges(ctx, gerritQuery(owner, repo, "status:merged"), limit, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	result := make([]*PRInfo, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change.toPRInfo())
+	}
+	return result, nil
+}
+
+// CreatePR finds the change created by pushing the backport for review and sets its metadata.
+// The push sets the topic to opts.Head and the commit message sets the title, opts.Body is
+// posted as a message on the change. Labels are added as hashtags, assignees and milestones
+// do not exist in Gerrit. Changes are pushed to the project itself, there are no fork heads.
+func (g *Gerrit) CreatePR(ctx context.Context, owner, repo string, opts CreatePROptions) (int, error) {
+	if headOwner, _ := SplitHead(opts.Head); headOwner != "" {
+		return 0, fmt.Errorf("gerrit does not support forks, cannot open a change from %s", opts.Head)
+	}
+	changes, _, err := g.queryChanges(ctx, gerritQuery(owner, repo, "status:open", fmt.Sprintf("branch:%q", opts.Base), fmt.Sprintf("topic:%q", opts.Head)), 1, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find change: %w", err)
+	}
+	if len(changes) == 0 {
+		return 0, fmt.Errorf("no open change with topic %s on %s, push it to refs/for/%s first", opts.Head, opts.Base, opts.Base)
+	}
+	number := changes[0].Number
+	id := gerritChangeID(owner, repo, number)
+
+	if opts.Body != "" {
+		if err := g.CreateComment(ctx, owner, repo, number, opts.Body); err != nil {
+			return number, err
+		}
+	}
+
+	if len(opts.Labels) > 0 {
+		if err := g.do(ctx, http.MethodPost, "/changes/"+id+"/hashtags", map[string][]string{"add": opts.Labels}, http.StatusOK, nil); err != nil {
+			return number, fmt.Errorf("failed to add hashtags to change %d: %w", number, err)
+		}
+	}
+
+	if opts.Draft {
+		if err := g.do(ctx, http.MethodPost, "/changes/"+id+"/wip", struct{}{}, http.StatusOK, nil); err != nil {
+			return number, fmt.Errorf("failed to mark change %d as work in progress: %w", number, err)
+		}
+	}
+
+	if len(opts.Reviewers) > 0 {
+		if err := g.RequestReview(ctx, owner, repo, number, opts.Reviewers); err != nil {
+			return number, err
+		}
+	}
+
+	return number, nil
+}
+
+// RequestReview adds the given users as reviewers of a change.
+func (g *Gerrit) RequestReview(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	for _, reviewer := range reviewers {
+		if err := g.do(ctx, http.MethodPost, "/changes/"+gerritChangeID(owner, repo, number)+"/reviewers", map[string]string{"reviewer": reviewer}, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("failed to add reviewer %s to change %d: %w", reviewer, number, err)
+		}
+	}
+
+	return nil
+}
+
+// EnableAutoMerge is not supported, Gerrit submits changes explicitly.
+func (g *Gerrit) EnableAutoMerge(_ context.Context, _, _ string, number int, _ string) error {
+	return fmt.Errorf("gerrit does not support auto-merge, cannot schedule submission of change %d", number)
+}
+
+// ApprovePR votes Code-Review+2 on the current patch set of a change.
+func (g *Gerrit) ApprovePR(ctx context.Context, owner, repo string, number int) error {
+	reqBody := map[string]map[string]int{"labels": {"Code-Review": 2}}
+	if err := g.do(ctx, http.MethodPost, "/changes/"+gerritChangeID(owner, repo, number)+"/revisions/current/review", reqBody, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("failed to approve change %d: %w", number, err)
+	}
+
+	return nil
+}
+
+// UpdatePR changes the metadata of an existing change. The title is the subject of the commit
+// message and cannot be changed, a new body is posted as a message.
+func (g *Gerrit) UpdatePR(ctx context.Context, owner, repo string, number int, opts UpdatePROptions) error {
+	id := gerritChangeID(owner, repo, number)
+
+	if opts.Title != nil {
+		return fmt.Errorf("failed to update change %d: gerrit takes the title from the commit message", number)
+	}
+	if opts.Body != nil {
+		if err := g.CreateComment(ctx, owner, repo, number, *opts.Body); err != nil {
+			return err
+		}
+	}
+	if opts.Base != nil {
+		if err := g.do(ctx, http.MethodPost, "/changes/"+id+"/move", map[string]string{"destination_branch": *opts.Base}, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("failed to move change %d: %w", number, err)
+		}
+	}
+
+	// Hashtags are added and removed, there is no request replacing them.
+	if opts.Labels != nil {
+		var current []string
+		if err := g.do(ctx, http.MethodGet, "/changes/"+id+"/hashtags", nil, http.StatusOK, &current); err != nil {
+			return fmt.Errorf("failed to get hashtags of change %d: %w", number, err)
+		}
+		var remove []string
+		for _, hashtag := range current {
+			if !slices.Contains(opts.Labels, hashtag) {
+				remove = append(remove, hashtag)
+			}
+		}
+		reqBody := map[string][]string{"add": opts.Labels, "remove": remove}
+		if err := g.do(ctx, http.MethodPost, "/changes/"+id+"/hashtags", reqBody, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("failed to update hashtags of change %d: %w", number, err)
+		}
+	}
+
+	return nil
+}
+
+// ListOpenPRs lists open changes, optionally filtered by topic, which stands in for the head branch.
+func (g *Gerrit) ListOpenPRs(ctx context.Context, owner, repo string, opts ListPROptions) ([]*PRInfo, error) {
+	operators := []string{"status:open"}
+	if opts.Head != "" {
+		operators = append(operators, fmt.Sprintf("topic:%q", opts.Head))
+	}
+
+	changes, truncated, err := g.queryChanges(ctx, gerritQuery(owner, repo, operators...), gerritPageSize, maxListPages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open changes: %w", err)
+	}
+
+	result := make([]*PRInfo, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change.toPRInfo())
+	}
+	if truncated {
+		logListTruncated("gerrit", len(result))
+	}
+	return result, nil
+}
+
+// SearchMergedPRs lists merged changes whose commit message contains text.
+func (g *Gerrit) SearchMergedPRs(ctx context.Context, owner, repo, text string) ([]*PRInfo, error) {
+	changes, _, err := g.queryChanges(ctx, gerritQuery(owner, repo, "status:merged", fmt.Sprintf("message:%q", text)), maxSearchResults, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search changes: %w", err)
+	}
+
+	result := make([]*PRInfo, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, change.toPRInfo())
+	}
+	return result, nil
+}
+
+// CreateComment posts a message on the current patch set of a change.
+func (g *Gerrit) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	path := "/changes/" + gerritChangeID(owner, repo, number) + "/revisions/current/review"
+	if err := g.do(ctx, http.MethodPost, path, map[string]string{"message": body}, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("failed to comment on change %d: %w", number, err)
+	}
+
+	return nil
+}
+
+// ListComments lists the messages of a change, oldest first. Gerrit message IDs are no
+// numbers, the messages are numbered by position instead.
+func (g *Gerrit) ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	var messages []struct {
+		Message string `json:"message"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/changes/"+gerritChangeID(owner, repo, number)+"/messages", nil, http.StatusOK, &messages); err != nil {
+		return nil, fmt.Errorf("failed to list messages of change %d: %w", number, err)
+	}
+
+	result := make([]Comment, 0, len(messages))
+	for i, message := range messages {
+		result = append(result, Comment{ID: int64(i + 1), Body: message.Message})
+	}
+
+	return result, nil
+}
+
+// UpdateComment posts body as a new message, messages on Gerrit changes cannot be edited.
+func (g *Gerrit) UpdateComment(ctx context.Context, owner, repo string, number int, _ int64, body string) error {
+	return g.CreateComment(ctx, owner, repo, number, body)
+}
+
+// HasWriteAccess checks if a user may push to the branches of a project, which requires
+// the permission to view the project's access rights.
+func (g *Gerrit) HasWriteAccess(ctx context.Context, owner, repo, user string) (bool, error) {
+	params := url.Values{"account": {user}, "ref": {"refs/heads/*"}, "perm": {"push"}}
+	var check struct {
+		Status int `json:"status"`
+	}
+	path := "/projects/" + url.PathEscape(gerritProject(owner, repo)) + "/check.access?" + params.Encode()
+	if err := g.do(ctx, http.MethodGet, path, nil, http.StatusOK, &check); err != nil {
+		return false, fmt.Errorf("failed to get permission of %s: %w", user, err)
+	}
+
+	return check.Status == http.StatusOK, nil
+}
+
+// ProtectBranch is not supported, Gerrit protects branches through project access rights.
+func (g *Gerrit) ProtectBranch(_ context.Context, _, _, branch string, _ BranchProtection) error {
+	return fmt.Errorf("gerrit does not support branch protection rules, cannot protect branch %s", branch)
+}
@@ -0,0 +1,252 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gerritTestChange = `)]}'
+{
+	"_number": 42,
+	"project": "owner/repo",
+	"branch": "main",
+	"topic": "fix-bug",
+	"hashtags": ["backport"],
+	"subject": "fix: resolve bug",
+	"status": "MERGED",
+	"submitted": "2024-01-15 10:30:00.000000000",
+	"owner": {"username": "alice", "name": "Alice"},
+	"submitter": {"username": "bob"},
+	"current_revision": "abc123",
+	"revisions": {"abc123": {"commit": {"message": "fix: resolve bug\n\nDetails.\n\nChange-Id: I0123\n"}}}
+}`
+
+func TestGerritGetPR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/a/changes/owner%2Frepo~42", r.URL.RawPath)
+		assert.Equal(t, []string{"CURRENT_REVISION", "CURRENT_COMMIT", "DETAILED_ACCOUNTS"}, r.URL.Query()["o"])
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "alice", user)
+		assert.Equal(t, "secret", password)
+		_, _ = w.Write([]byte(gerritTestChange))
+	}))
+	defer server.Close()
+
+	pr, err := NewGerrit(server.URL+"/", "alice:secret").GetPR(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	assert.Equal(t, 42, pr.Number)
+	assert.Equal(t, "fix: resolve bug", pr.Title)
+	assert.Equal(t, "Details.\n\nChange-Id: I0123", pr.Body)
+	assert.Equal(t, "abc123", pr.MergeCommit)
+	assert.Equal(t, "main", pr.BaseBranch)
+	assert.Equal(t, "fix-bug", pr.HeadBranch)
+	assert.Equal(t, "alice", pr.Author)
+	assert.Equal(t, "bob", pr.MergedBy)
+	assert.Equal(t, []string{"backport"}, pr.Labels)
+	assert.True(t, pr.Merged)
+	assert.True(t, pr.Squashed)
+	assert.Equal(t, 2024, pr.MergedAt.Year())
+}
+
+func TestGerritGetPRNotMerged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`)]}'
+{"_number": 42, "status": "NEW"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewGerrit(server.URL, "").GetPR(context.Background(), "owner", "repo", 42)
+	assert.ErrorIs(t, err, ErrNotMerged)
+}
+
+func TestGerritErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Anonymous requests do not use the /a/ prefix.
+		assert.Equal(t, "/changes/owner%2Frepo~42", r.URL.RawPath)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Not found: owner/repo~42\n"))
+	}))
+	defer server.Close()
+
+	_, err := NewGerrit(server.URL, "").GetPR(context.Background(), "owner", "repo", 42)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "gerrit", apiErr.Forge)
+	assert.Equal(t, "Not found: owner/repo~42", apiErr.Message)
+}
+
+func TestGerritListPRFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/changes/owner%2Frepo~42/revisions/current/files", r.URL.RawPath)
+		_, _ = w.Write([]byte(`)]}'
+{
+	"/COMMIT_MSG": {"status": "A", "lines_inserted": 7},
+	"src/new.go": {"status": "A", "lines_inserted": 10},
+	"src/main.go": {"lines_inserted": 2, "lines_deleted": 1},
+	"src/old.go": {"status": "D", "lines_deleted": 4}
+}`))
+	}))
+	defer server.Close()
+
+	files, err := NewGerrit(server.URL, "").ListPRFiles(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []ChangedFile{
+		{Path: "src/main.go", Status: "modified", Additions: 2, Deletions: 1},
+		{Path: "src/new.go", Status: "added", Additions: 10},
+		{Path: "src/old.go", Status: "removed", Deletions: 4},
+	}, files)
+}
+
+func TestGerritListPRCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/changes/owner%2Frepo~42/revisions/current/commit", r.URL.RawPath)
+		_, _ = w.Write([]byte(`)]}'
+{"commit": "abc123", "parents": [{"commit": "base"}], "author": {"name": "Alice", "email": "alice@example.com", "date": "2024-01-15 10:30:00.000000000"}, "message": "fix: resolve bug\n"}`))
+	}))
+	defer server.Close()
+
+	commits, err := NewGerrit(server.URL, "").ListPRCommits(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "abc123", commits[0].SHA)
+	assert.Equal(t, "Alice", commits[0].Author)
+	assert.Equal(t, []string{"base"}, commits[0].Parents)
+}
+
+func TestGerritCreatePR(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	bodies := make(map[string]map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			assert.Equal(t, `project:"repo" status:open branch:"release-1.x" topic:"backport-42-to-release-1.x"`, r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`)]}'
+[{"_number": 50, "status": "NEW"}]`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies[r.URL.Path] = body
+		_, _ = w.Write([]byte(`)]}'
+{}`))
+	}))
+	defer server.Close()
+
+	// Projects of authenticated HTTP remotes are parsed with the owner "a".
+	number, err := NewGerrit(server.URL, "token").CreatePR(context.Background(), "a", "repo", CreatePROptions{
+		Title:     "[release-1.x] fix: resolve bug",
+		Body:      "Backport of #42",
+		Head:      "backport-42-to-release-1.x",
+		Base:      "release-1.x",
+		Labels:    []string{"backport"},
+		Reviewers: []string{"bob", "carol"},
+		Draft:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 50, number)
+
+	assert.Equal(t, []string{
+		"GET /a/changes/",
+		"POST /a/changes/repo~50/revisions/current/review",
+		"POST /a/changes/repo~50/hashtags",
+		"POST /a/changes/repo~50/wip",
+		"POST /a/changes/repo~50/reviewers",
+		"POST /a/changes/repo~50/reviewers",
+	}, requests)
+	assert.Equal(t, "Backport of #42", bodies["/a/changes/repo~50/revisions/current/review"]["message"])
+	assert.Equal(t, []any{"backport"}, bodies["/a/changes/repo~50/hashtags"]["add"])
+}
+
+func TestGerritCreatePRWithoutChange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`)]}'
+[]`))
+	}))
+	defer server.Close()
+
+	_, err := NewGerrit(server.URL, "").CreatePR(context.Background(), "owner", "repo", CreatePROptions{
+		Head: "backport-42-to-release-1.x",
+		Base: "release-1.x",
+	})
+	assert.ErrorContains(t, err, "push it to refs/for/release-1.x first")
+}
+
+func TestGerritCreatePRFromFork(t *testing.T) {
+	_, err := NewGerrit("http://gerrit.invalid", "").CreatePR(context.Background(), "owner", "repo", CreatePROptions{
+		Head: "bot:backport-42-to-release-1.x",
+		Base: "release-1.x",
+	})
+	assert.ErrorContains(t, err, "does not support forks")
+}
+
+func TestGerritListOpenPRsPaginates(t *testing.T) {
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `project:"owner/repo" status:open topic:"backport-42-to-release-1.x"`, r.URL.Query().Get("q"))
+		starts = append(starts, r.URL.Query().Get("S"))
+		if r.URL.Query().Get("S") == "0" {
+			_, _ = w.Write([]byte(`)]}'
+[{"_number": 1, "status": "NEW"}, {"_number": 2, "status": "NEW", "_more_changes": true}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`)]}'
+[{"_number": 3, "status": "NEW"}]`))
+	}))
+	defer server.Close()
+
+	prs, err := NewGerrit(server.URL, "").ListOpenPRs(context.Background(), "owner", "repo", ListPROptions{Head: "backport-42-to-release-1.x"})
+	require.NoError(t, err)
+	require.Len(t, prs, 3)
+	assert.Equal(t, "open", prs[0].State)
+	assert.Equal(t, []string{"0", "50"}, starts)
+}
+
+func TestGerritUpdatePR(t *testing.T) {
+	var hashtags map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`)]}'
+["backport", "stale"]`))
+			return
+		}
+		assert.Equal(t, "/changes/owner%2Frepo~50/hashtags", r.URL.RawPath)
+		_ = json.NewDecoder(r.Body).Decode(&hashtags)
+		_, _ = w.Write([]byte(`)]}'
+[]`))
+	}))
+	defer server.Close()
+
+	g := NewGerrit(server.URL, "")
+	require.NoError(t, g.UpdatePR(context.Background(), "owner", "repo", 50, UpdatePROptions{Labels: []string{"backport", "conflict"}}))
+	assert.Equal(t, map[string][]string{"add": {"backport", "conflict"}, "remove": {"stale"}}, hashtags)
+
+	title := "new title"
+	assert.Error(t, g.UpdatePR(context.Background(), "owner", "repo", 50, UpdatePROptions{Title: &title}))
+}
+
+func TestGerritListComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`)]}'
+[{"id": "a1b2", "message": "Uploaded patch set 1."}, {"id": "c3d4", "message": "Backport status"}]`))
+	}))
+	defer server.Close()
+
+	comments, err := NewGerrit(server.URL, "").ListComments(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []Comment{{ID: 1, Body: "Uploaded patch set 1."}, {ID: 2, Body: "Backport status"}}, comments)
+}
@@ -2,7 +2,10 @@ package forge
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/google/go-github/v80/github"
 )
@@ -10,19 +13,27 @@ import (
 // GitHub implements the Forge interface for GitHub.
 type GitHub struct {
 	client *github.Client
+	token  string
+
+	// graphql fetches PRs with a single GraphQL query, see getPRGraphQL.
+	graphql bool
+	// files holds the changed files fetched along with PRs via GraphQL, by PR.
+	files sync.Map
 }
 
 // NewGitHub creates a new GitHub forge client.
 func NewGitHub(token string) *GitHub {
-	var client *github.Client
+	return newGitHub(token, nil)
+}
 
+// newGitHub creates a new GitHub forge client with an optional response cache.
+func newGitHub(token string, cache *responseCache) *GitHub {
+	client := github.NewClient(newHTTPClient("github", cache))
 	if token != "" {
-		client = github.NewClient(nil).WithAuthToken(token)
-	} else {
-		client = github.NewClient(nil)
+		client = client.WithAuthToken(token)
 	}
 
-	return &GitHub{client: client}
+	return &GitHub{client: client, token: token}
 }
 
 // Name returns the name of the forge.
@@ -30,21 +41,39 @@ func (g *GitHub) Name() string {
 	return "github"
 }
 
+// Capabilities returns the optional features supported by GitHub.
+// Labels need a separate issues API call after the PR was created.
+func (g *GitHub) Capabilities() Capabilities {
+	return Capabilities{
+		DraftPRs:         true,
+		AutoMerge:        true,
+		Reviews:          true,
+		BranchProtection: true,
+		Milestones:       true,
+	}
+}
+
 // GetPR retrieves information about a pull request by number.
 func (g *GitHub) GetPR(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	if g.graphql {
+		return g.getPRGraphQL(ctx, owner, repo, number)
+	}
+
 	pr, _, err := g.client.PullRequests.Get(ctx, owner, repo, number)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get PR #%d: %w", number, err)
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, githubAPIError(err, g.token))
 	}
 
 	if !pr.GetMerged() {
-		return nil, fmt.Errorf("PR #%d is not merged", number)
+		return nil, fmt.Errorf("PR #%d is %w", number, ErrNotMerged)
 	}
 
 	// Check if it was squash merged by looking at the merge commit.
 	mergeCommit, _, err := g.client.Repositories.GetCommit(ctx, owner, repo, pr.GetMergeCommitSHA(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get merge commit: %w", err)
+		return nil, fmt.Errorf("failed to get merge commit: %w", githubAPIError(err, g.token))
 	}
 
 	squashed := len(mergeCommit.Parents) == 1
@@ -67,26 +96,164 @@ func (g *GitHub) GetPR(ctx context.Context, owner, repo string, number int) (*PR
 		Merged:      pr.GetMerged(),
 		Squashed:    squashed,
 		Author:      pr.GetUser().GetLogin(),
+		MergedBy:    pr.GetMergedBy().GetLogin(),
 		MergedAt:    pr.GetMergedAt().Time,
 		Labels:      labels,
+		Assignees:   githubLogins(pr.Assignees),
+		Milestone:   pr.GetMilestone().GetNumber(),
 	}
 
 	return info, nil
 }
 
+// ListMilestonePRs lists the merged PRs of a milestone, identified by its name.
+func (g *GitHub) ListMilestonePRs(ctx context.Context, owner, repo, milestone string) ([]*PRInfo, error) {
+	number, err := g.findMilestone(ctx, owner, repo, milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	const maxIssuesPerPage = 100
+	opts := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(number),
+		State:       "closed",
+		ListOptions: github.ListOptions{PerPage: maxIssuesPerPage},
+	}
+
+	var result []*PRInfo
+	for {
+		issues, resp, err := g.client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestone %s: %w", milestone, githubAPIError(err, g.token))
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				continue
+			}
+			pr, err := g.GetPR(ctx, owner, repo, issue.GetNumber())
+			if errors.Is(err, ErrNotMerged) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, pr)
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.ListOptions.Page = resp.NextPage
+	}
+}
+
+// findMilestone returns the number of the milestone with the given title.
+func (g *GitHub) findMilestone(ctx context.Context, owner, repo, title string) (int, error) {
+	const maxMilestonesPerPage = 100
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: maxMilestonesPerPage},
+	}
+
+	for {
+		milestones, resp, err := g.client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list milestones: %w", githubAPIError(err, g.token))
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				return m.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("milestone %s not found", title)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListPRFiles lists the files changed by a pull request.
+func (g *GitHub) ListPRFiles(ctx context.Context, owner, repo string, number int) ([]ChangedFile, error) {
+	if files, ok := g.files.Load(prKey(owner, repo, number)); ok {
+		return files.([]ChangedFile), nil
+	}
+
+	const maxFilesPerPage = 100
+	opts := &github.ListOptions{PerPage: maxFilesPerPage}
+
+	var result []ChangedFile
+	for {
+		files, resp, err := g.client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+		for _, file := range files {
+			result = append(result, ChangedFile{
+				Path:      file.GetFilename(),
+				Status:    file.GetStatus(),
+				Additions: file.GetAdditions(),
+				Deletions: file.GetDeletions(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetRepo retrieves the current owner and name of a repository.
+// GitHub redirects requests for renamed and transferred repositories.
+func (g *GitHub) GetRepo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	r, _, err := g.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository %s/%s: %w", owner, repo, githubAPIError(err, g.token))
+	}
+
+	return &RepoInfo{Owner: r.GetOwner().GetLogin(), Name: r.GetName()}, nil
+}
+
 // GetCommit retrieves information about a commit by SHA.
 func (g *GitHub) GetCommit(ctx context.Context, owner, repo, sha string) (*CommitInfo, error) {
+	ctx = cacheable(ctx)
+
 	commit, _, err := g.client.Repositories.GetCommit(ctx, owner, repo, sha, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, githubAPIError(err, g.token))
 	}
 
+	return githubCommitInfo(commit), nil
+}
+
+// ListPRCommits lists the commits of a pull request, oldest first.
+// GitHub returns at most 250 commits per pull request.
+func (g *GitHub) ListPRCommits(ctx context.Context, owner, repo string, number int) ([]*CommitInfo, error) {
+	const maxCommitsPerPage = 100
+	opts := &github.ListOptions{PerPage: maxCommitsPerPage}
+
+	var result []*CommitInfo
+	for {
+		commits, resp, err := g.client.PullRequests.ListCommits(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits of PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+		for _, commit := range commits {
+			result = append(result, githubCommitInfo(commit))
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// githubCommitInfo converts the API response for a commit.
+func githubCommitInfo(commit *github.RepositoryCommit) *CommitInfo {
 	parents := make([]string, len(commit.Parents))
 	for i, parent := range commit.Parents {
 		parents[i] = parent.GetSHA()
 	}
 
-	info := &CommitInfo{
+	return &CommitInfo{
 		SHA:       commit.GetSHA(),
 		Message:   commit.GetCommit().GetMessage(),
 		Author:    commit.GetCommit().GetAuthor().GetName(),
@@ -94,49 +261,89 @@ func (g *GitHub) GetCommit(ctx context.Context, owner, repo, sha string) (*Commi
 		Timestamp: commit.GetCommit().GetAuthor().GetDate().Time,
 		Parents:   parents,
 	}
-
-	return info, nil
 }
 
 // ListRecentPRs lists recently merged PRs.
 func (g *GitHub) ListRecentPRs(ctx context.Context, owner, repo string, limit int) ([]*PRInfo, error) {
+	ctx = cacheable(ctx)
+
+	const maxPRsPerPage = 100
 	opts := &github.PullRequestListOptions{
 		State:     "closed",
 		Sort:      "updated",
 		Direction: "desc",
 		ListOptions: github.ListOptions{
-			PerPage: limit,
+			PerPage: min(limit, maxPRsPerPage),
 		},
 	}
 
-	prs, _, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+	// Closed PRs include unmerged ones, so more pages may be needed to reach the limit.
+	var result []*PRInfo
+	for range maxListPages {
+		prs, resp, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PRs: %w", githubAPIError(err, g.token))
+		}
+
+		for _, pr := range prs {
+			if !pr.GetMerged() {
+				continue
+			}
+
+			info := &PRInfo{
+				Number:      pr.GetNumber(),
+				Title:       pr.GetTitle(),
+				State:       pr.GetState(),
+				MergeCommit: pr.GetMergeCommitSHA(),
+				HeadSHA:     pr.GetHead().GetSHA(),
+				BaseBranch:  pr.GetBase().GetRef(),
+				HeadBranch:  pr.GetHead().GetRef(),
+				Merged:      pr.GetMerged(),
+				Author:      pr.GetUser().GetLogin(),
+				MergedAt:    pr.GetMergedAt().Time,
+			}
+			result = append(result, info)
+
+			if len(result) >= limit {
+				return result, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return result, nil
+}
+
+// SearchMergedPRs lists merged PRs whose title or body match text.
+func (g *GitHub) SearchMergedPRs(ctx context.Context, owner, repo, text string) ([]*PRInfo, error) {
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged %q", owner, repo, text)
+	opts := &github.SearchOptions{Sort: "updated", Order: "desc", ListOptions: github.ListOptions{PerPage: maxSearchResults}}
+
+	found, _, err := g.client.Search.Issues(ctx, query, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list PRs: %w", err)
+		return nil, fmt.Errorf("failed to search PRs: %w", githubAPIError(err, g.token))
 	}
 
-	var result []*PRInfo
-	for _, pr := range prs {
-		if !pr.GetMerged() {
-			continue
-		}
-
-		info := &PRInfo{
-			Number:      pr.GetNumber(),
-			Title:       pr.GetTitle(),
-			State:       pr.GetState(),
-			MergeCommit: pr.GetMergeCommitSHA(),
-			HeadSHA:     pr.GetHead().GetSHA(),
-			BaseBranch:  pr.GetBase().GetRef(),
-			HeadBranch:  pr.GetHead().GetRef(),
-			Merged:      pr.GetMerged(),
-			Author:      pr.GetUser().GetLogin(),
-			MergedAt:    pr.GetMergedAt().Time,
-		}
-		result = append(result, info)
-
-		if len(result) >= limit {
-			break
+	result := make([]*PRInfo, 0, len(found.Issues))
+	for _, issue := range found.Issues {
+		labels := make([]string, len(issue.Labels))
+		for i, label := range issue.Labels {
+			labels[i] = label.GetName()
 		}
+		result = append(result, &PRInfo{
+			Number:   issue.GetNumber(),
+			Title:    issue.GetTitle(),
+			Body:     issue.GetBody(),
+			State:    issue.GetState(),
+			Merged:   true,
+			Author:   issue.GetUser().GetLogin(),
+			MergedAt: issue.GetPullRequestLinks().GetMergedAt().Time,
+			Labels:   labels,
+		})
 	}
 
 	return result, nil
@@ -149,14 +356,94 @@ func (g *GitHub) CreatePR(ctx context.Context, owner, repo string, opts CreatePR
 		Body:  github.Ptr(opts.Body),
 		Head:  github.Ptr(opts.Head),
 		Base:  github.Ptr(opts.Base),
+		Draft: github.Ptr(opts.Draft),
 	}
 
 	pr, _, err := g.client.PullRequests.Create(ctx, owner, repo, newPR)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create PR: %w", err)
+		return 0, fmt.Errorf("failed to create PR: %w", githubAPIError(err, g.token))
+	}
+	number := pr.GetNumber()
+
+	// Labels, assignees and the milestone are managed through the issues API.
+	if len(opts.Labels) > 0 || len(opts.Assignees) > 0 || opts.Milestone > 0 {
+		issue := &github.IssueRequest{}
+		if len(opts.Labels) > 0 {
+			issue.Labels = &opts.Labels
+		}
+		if len(opts.Assignees) > 0 {
+			issue.Assignees = &opts.Assignees
+		}
+		if opts.Milestone > 0 {
+			issue.Milestone = github.Ptr(opts.Milestone)
+		}
+		if _, _, err := g.client.Issues.Edit(ctx, owner, repo, number, issue); err != nil {
+			return number, fmt.Errorf("failed to set metadata of PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+	}
+
+	if len(opts.Reviewers) > 0 {
+		if err := g.RequestReview(ctx, owner, repo, number, opts.Reviewers); err != nil {
+			return number, err
+		}
 	}
 
-	return pr.GetNumber(), nil
+	return number, nil
+}
+
+// RequestReview requests a review of a pull request from the given users.
+func (g *GitHub) RequestReview(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	req := github.ReviewersRequest{Reviewers: reviewers}
+	if _, _, err := g.client.PullRequests.RequestReviewers(ctx, owner, repo, number, req); err != nil {
+		return fmt.Errorf("failed to request reviewers on PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+
+	return nil
+}
+
+// ApprovePR approves a pull request as the authenticated user.
+func (g *GitHub) ApprovePR(ctx context.Context, owner, repo string, number int) error {
+	req := &github.PullRequestReviewRequest{Event: github.Ptr("APPROVE")}
+	if _, _, err := g.client.PullRequests.CreateReview(ctx, owner, repo, number, req); err != nil {
+		return fmt.Errorf("failed to approve PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+
+	return nil
+}
+
+// githubLogins returns the logins of GitHub users.
+func githubLogins(users []*github.User) []string {
+	logins := make([]string, len(users))
+	for i, user := range users {
+		logins[i] = user.GetLogin()
+	}
+	return logins
+}
+
+// UpdatePR changes the metadata of an existing pull request.
+func (g *GitHub) UpdatePR(ctx context.Context, owner, repo string, number int, opts UpdatePROptions) error {
+	if opts.Title != nil || opts.Body != nil || opts.Base != nil {
+		pr := &github.PullRequest{
+			Title: opts.Title,
+			Body:  opts.Body,
+		}
+		if opts.Base != nil {
+			pr.Base = &github.PullRequestBranch{Ref: opts.Base}
+		}
+
+		if _, _, err := g.client.PullRequests.Edit(ctx, owner, repo, number, pr); err != nil {
+			return fmt.Errorf("failed to update PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+	}
+
+	// Labels are managed through the issues API.
+	if opts.Labels != nil {
+		if _, _, err := g.client.Issues.ReplaceLabelsForIssue(ctx, owner, repo, number, opts.Labels); err != nil {
+			return fmt.Errorf("failed to update labels of PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+	}
+
+	return nil
 }
 
 // ListOpenPRs lists open PRs, optionally filtered by head branch.
@@ -174,33 +461,117 @@ func (g *GitHub) ListOpenPRs(ctx context.Context, owner, repo string, opts ListP
 		listOpts.Head = opts.Head
 	}
 
-	prs, _, err := g.client.PullRequests.List(ctx, owner, repo, listOpts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list open PRs: %w", err)
-	}
-
 	var result []*PRInfo
-	for _, pr := range prs {
-		// Extract labels.
-		labels := make([]string, len(pr.Labels))
-		for i, label := range pr.Labels {
-			labels[i] = label.GetName()
+	for range maxListPages {
+		prs, resp, err := g.client.PullRequests.List(ctx, owner, repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open PRs: %w", githubAPIError(err, g.token))
 		}
 
-		info := &PRInfo{
-			Number:     pr.GetNumber(),
-			Title:      pr.GetTitle(),
-			Body:       pr.GetBody(),
-			State:      pr.GetState(),
-			HeadSHA:    pr.GetHead().GetSHA(),
-			BaseBranch: pr.GetBase().GetRef(),
-			HeadBranch: pr.GetHead().GetRef(),
-			Merged:     pr.GetMerged(),
-			Author:     pr.GetUser().GetLogin(),
-			Labels:     labels,
+		for _, pr := range prs {
+			// Extract labels.
+			labels := make([]string, len(pr.Labels))
+			for i, label := range pr.Labels {
+				labels[i] = label.GetName()
+			}
+
+			info := &PRInfo{
+				Number:     pr.GetNumber(),
+				Title:      pr.GetTitle(),
+				Body:       pr.GetBody(),
+				State:      pr.GetState(),
+				HeadSHA:    pr.GetHead().GetSHA(),
+				BaseBranch: pr.GetBase().GetRef(),
+				HeadBranch: pr.GetHead().GetRef(),
+				Merged:     pr.GetMerged(),
+				Author:     pr.GetUser().GetLogin(),
+				Labels:     labels,
+			}
+			result = append(result, info)
 		}
-		result = append(result, info)
+
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		listOpts.Page = resp.NextPage
 	}
 
+	logListTruncated("github", len(result))
 	return result, nil
 }
+
+// CreateComment adds a comment to a pull request.
+func (g *GitHub) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	comment := &github.IssueComment{Body: github.Ptr(body)}
+	if _, _, err := g.client.Issues.CreateComment(ctx, owner, repo, number, comment); err != nil {
+		return fmt.Errorf("failed to comment on PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+
+	return nil
+}
+
+// ListComments lists the comments of a pull request, oldest first.
+func (g *GitHub) ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	const maxCommentsPerPage = 100
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: maxCommentsPerPage}}
+
+	var result []Comment
+	for {
+		comments, resp, err := g.client.Issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments of PR #%d: %w", number, githubAPIError(err, g.token))
+		}
+		for _, comment := range comments {
+			result = append(result, Comment{ID: comment.GetID(), Body: comment.GetBody()})
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpdateComment replaces the body of a comment on a pull request.
+func (g *GitHub) UpdateComment(ctx context.Context, owner, repo string, number int, id int64, body string) error {
+	comment := &github.IssueComment{Body: github.Ptr(body)}
+	if _, _, err := g.client.Issues.EditComment(ctx, owner, repo, id, comment); err != nil {
+		return fmt.Errorf("failed to update comment on PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+
+	return nil
+}
+
+// HasWriteAccess checks if a user has write (or admin) access to a repository.
+func (g *GitHub) HasWriteAccess(ctx context.Context, owner, repo, user string) (bool, error) {
+	level, _, err := g.client.Repositories.GetPermissionLevel(ctx, owner, repo, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to get permission of %s: %w", user, githubAPIError(err, g.token))
+	}
+
+	return hasWritePermission(level.GetPermission()), nil
+}
+
+// ProtectBranch applies branch protection rules to a branch.
+func (g *GitHub) ProtectBranch(ctx context.Context, owner, repo, branch string, rules BranchProtection) error {
+	req := &github.ProtectionRequest{
+		AllowForcePushes: github.Ptr(false),
+		AllowDeletions:   github.Ptr(false),
+	}
+	if rules.RequiredApprovals > 0 {
+		req.RequiredPullRequestReviews = &github.PullRequestReviewsEnforcementRequest{
+			RequiredApprovingReviewCount: rules.RequiredApprovals,
+		}
+	}
+	if len(rules.RequiredStatusChecks) > 0 {
+		checks := rules.RequiredStatusChecks
+		req.RequiredStatusChecks = &github.RequiredStatusChecks{
+			Contexts: &checks,
+		}
+	}
+
+	if _, _, err := g.client.Repositories.UpdateBranchProtection(ctx, owner, repo, branch, req); err != nil {
+		return fmt.Errorf("failed to protect branch %s: %w", branch, githubAPIError(err, g.token))
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// githubPRQuery fetches a PR with its merge commit, labels and changed files, which takes
+// two REST calls for the PR and another one per 100 files.
+const githubPRQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      number
+      title
+      body
+      state
+      merged
+      mergedAt
+      headRefName
+      headRefOid
+      baseRefName
+      author { login }
+      mergedBy { login }
+      milestone { number }
+      mergeCommit { oid parents { totalCount } }
+      labels(first: 100) { nodes { name } }
+      assignees(first: 100) { nodes { login } }
+      files(first: 100) { totalCount nodes { path additions deletions changeType } }
+    }
+  }
+}`
+
+// githubAutoMergeMutation enables auto-merge of a PR, which has no REST endpoint.
+const githubAutoMergeMutation = `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) {
+    clientMutationId
+  }
+}`
+
+// githubGraphQLRequest is the request body of a GraphQL query.
+type githubGraphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// githubGraphQLError is an error of a GraphQL response, which is sent with status 200.
+type githubGraphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// githubLogin is a user or bot in GraphQL responses.
+type githubLogin struct {
+	Login string `json:"login"`
+}
+
+// githubGraphQLPR is the PR of a githubPRQuery response.
+type githubGraphQLPR struct {
+	Number      int          `json:"number"`
+	Title       string       `json:"title"`
+	Body        string       `json:"body"`
+	State       string       `json:"state"`
+	Merged      bool         `json:"merged"`
+	MergedAt    time.Time    `json:"mergedAt"`
+	HeadRefName string       `json:"headRefName"`
+	HeadRefOid  string       `json:"headRefOid"`
+	BaseRefName string       `json:"baseRefName"`
+	Author      *githubLogin `json:"author"`
+	MergedBy    *githubLogin `json:"mergedBy"`
+	Milestone   *struct {
+		Number int `json:"number"`
+	} `json:"milestone"`
+	MergeCommit *struct {
+		OID     string `json:"oid"`
+		Parents struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"parents"`
+	} `json:"mergeCommit"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignees struct {
+		Nodes []githubLogin `json:"nodes"`
+	} `json:"assignees"`
+	Files struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Path       string `json:"path"`
+			Additions  int    `json:"additions"`
+			Deletions  int    `json:"deletions"`
+			ChangeType string `json:"changeType"`
+		} `json:"nodes"`
+	} `json:"files"`
+}
+
+// githubChangeTypes maps the file change types of the GraphQL API to the statuses of ChangedFile.
+var githubChangeTypes = map[string]string{
+	"ADDED":    "added",
+	"DELETED":  "removed",
+	"MODIFIED": "modified",
+	"RENAMED":  "renamed",
+	"COPIED":   "copied",
+	"CHANGED":  "changed",
+}
+
+// prKey identifies a PR across repositories.
+func prKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// getPRGraphQL retrieves a PR through the GraphQL API in a single request. Its changed files
+// are kept for ListPRFiles unless the PR changes more files than one query returns.
+func (g *GitHub) getPRGraphQL(ctx context.Context, owner, repo string, number int) (*PRInfo, error) {
+	body := githubGraphQLRequest{
+		Query:     githubPRQuery,
+		Variables: map[string]any{"owner": owner, "repo": repo, "number": number},
+	}
+	req, err := g.client.NewRequest(http.MethodPost, "graphql", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Repository *struct {
+				PullRequest *githubGraphQLPR `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []githubGraphQLError `json:"errors"`
+	}
+	if _, err := g.client.Do(ctx, req, &result); err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, githubGraphQLAPIError(result.Errors, g.token))
+	}
+	if result.Data.Repository == nil || result.Data.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %w", number, &APIError{Forge: "github", StatusCode: http.StatusNotFound, Status: "404 Not Found"})
+	}
+
+	pr := result.Data.Repository.PullRequest
+	if !pr.Merged {
+		return nil, fmt.Errorf("PR #%d is %w", number, ErrNotMerged)
+	}
+
+	if pr.Files.TotalCount == len(pr.Files.Nodes) {
+		files := make([]ChangedFile, 0, len(pr.Files.Nodes))
+		for _, file := range pr.Files.Nodes {
+			status, ok := githubChangeTypes[file.ChangeType]
+			if !ok {
+				status = strings.ToLower(file.ChangeType)
+			}
+			files = append(files, ChangedFile{Path: file.Path, Status: status, Additions: file.Additions, Deletions: file.Deletions})
+		}
+		g.files.Store(prKey(owner, repo, number), files)
+	}
+
+	return pr.info(), nil
+}
+
+// info converts the PR of a GraphQL response like GetPR converts the REST ones.
+func (pr *githubGraphQLPR) info() *PRInfo {
+	labels := make([]string, len(pr.Labels.Nodes))
+	for i, label := range pr.Labels.Nodes {
+		labels[i] = label.Name
+	}
+	var assignees []string
+	for _, assignee := range pr.Assignees.Nodes {
+		assignees = append(assignees, assignee.Login)
+	}
+
+	info := &PRInfo{
+		Number:     pr.Number,
+		Title:      pr.Title,
+		Body:       pr.Body,
+		State:      "closed",
+		HeadSHA:    pr.HeadRefOid,
+		BaseBranch: pr.BaseRefName,
+		HeadBranch: pr.HeadRefName,
+		Merged:     pr.Merged,
+		MergedAt:   pr.MergedAt,
+		Labels:     labels,
+		Assignees:  assignees,
+	}
+	if pr.State == "OPEN" {
+		info.State = "open"
+	}
+	if pr.MergeCommit != nil {
+		info.MergeCommit = pr.MergeCommit.OID
+		info.Squashed = pr.MergeCommit.Parents.TotalCount == 1
+	}
+	if pr.Author != nil {
+		info.Author = pr.Author.Login
+	}
+	if pr.MergedBy != nil {
+		info.MergedBy = pr.MergedBy.Login
+	}
+	if pr.Milestone != nil {
+		info.Milestone = pr.Milestone.Number
+	}
+	return info
+}
+
+// githubGraphQLAPIError converts the errors of a GraphQL response to an API error.
+func githubGraphQLAPIError(errs []githubGraphQLError, token string) *APIError {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+	}
+	apiErr := &APIError{Forge: "github", StatusCode: http.StatusOK, Status: "200 OK", Message: strings.Join(messages, "; "), NoToken: token == ""}
+
+	switch errs[0].Type {
+	case "NOT_FOUND":
+		apiErr.StatusCode, apiErr.Status = http.StatusNotFound, "404 Not Found"
+	case "FORBIDDEN":
+		apiErr.StatusCode, apiErr.Status = http.StatusForbidden, "403 Forbidden"
+	case "RATE_LIMITED":
+		apiErr.StatusCode, apiErr.Status = http.StatusForbidden, "403 Forbidden"
+		apiErr.rateLimited = true
+	}
+	return apiErr
+}
+
+// EnableAutoMerge enables auto-merge of a PR, which requires auto-merge to be allowed in the
+// repository settings and the base branch to have required checks or approvals.
+func (g *GitHub) EnableAutoMerge(ctx context.Context, owner, repo string, number int, method string) error {
+	pr, _, err := g.client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+
+	body := githubGraphQLRequest{
+		Query:     githubAutoMergeMutation,
+		Variables: map[string]any{"id": pr.GetNodeID(), "method": strings.ToUpper(method)},
+	}
+	req, err := g.client.NewRequest(http.MethodPost, "graphql", body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Errors []githubGraphQLError `json:"errors"`
+	}
+	if _, err := g.client.Do(ctx, req, &result); err != nil {
+		return fmt.Errorf("failed to enable auto-merge on PR #%d: %w", number, githubAPIError(err, g.token))
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("failed to enable auto-merge on PR #%d: %w", number, githubGraphQLAPIError(result.Errors, g.token))
+	}
+	return nil
+}
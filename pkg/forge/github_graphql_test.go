@@ -0,0 +1,160 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGraphQLTestGitHub returns a GitHub client using GraphQL against handler.
+func newGraphQLTestGitHub(t *testing.T, handler http.HandlerFunc) *GitHub {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	gh := newGitHub("test-token", nil)
+	gh.graphql = true
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	gh.client.BaseURL = baseURL
+	return gh
+}
+
+func TestGitHubGetPRGraphQL(t *testing.T) {
+	var requests int
+	gh := newGraphQLTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		var req githubGraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, map[string]any{"owner": "owner", "repo": "repo", "number": float64(42)}, req.Variables)
+
+		_, _ = w.Write([]byte(`{"data": {"repository": {"pullRequest": {
+			"number": 42, "title": "fix: crash", "body": "details", "state": "MERGED", "merged": true,
+			"mergedAt": "2024-01-15T10:30:00Z", "headRefName": "fix-crash", "headRefOid": "abc", "baseRefName": "main",
+			"author": {"login": "alice"}, "mergedBy": {"login": "bob"}, "milestone": {"number": 3},
+			"mergeCommit": {"oid": "def", "parents": {"totalCount": 1}},
+			"labels": {"nodes": [{"name": "backport"}]},
+			"assignees": {"nodes": [{"login": "carol"}]},
+			"files": {"totalCount": 2, "nodes": [
+				{"path": "main.go", "additions": 3, "deletions": 1, "changeType": "MODIFIED"},
+				{"path": "old.go", "additions": 0, "deletions": 10, "changeType": "DELETED"}
+			]}
+		}}}}`))
+	})
+
+	pr, err := gh.GetPR(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	assert.Equal(t, &PRInfo{
+		Number:      42,
+		Title:       "fix: crash",
+		Body:        "details",
+		State:       "closed",
+		MergeCommit: "def",
+		HeadSHA:     "abc",
+		BaseBranch:  "main",
+		HeadBranch:  "fix-crash",
+		Merged:      true,
+		Squashed:    true,
+		Author:      "alice",
+		MergedBy:    "bob",
+		MergedAt:    time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Labels:      []string{"backport"},
+		Assignees:   []string{"carol"},
+		Milestone:   3,
+	}, pr)
+
+	// The files came with the PR.
+	files, err := gh.ListPRFiles(context.Background(), "owner", "repo", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []ChangedFile{
+		{Path: "main.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "old.go", Status: "removed", Deletions: 10},
+	}, files)
+	assert.Equal(t, 1, requests)
+}
+
+func TestGitHubGetPRGraphQLErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name:     "not merged",
+			response: `{"data": {"repository": {"pullRequest": {"number": 42, "state": "OPEN", "merged": false}}}}`,
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrNotMerged)
+			},
+		},
+		{
+			name:     "not found",
+			response: `{"data": {"repository": {"pullRequest": null}}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a PullRequest with the number of 42."}]}`,
+			check: func(t *testing.T, err error) {
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+				assert.Contains(t, err.Error(), "Could not resolve")
+			},
+		},
+		{
+			name:     "rate limited",
+			response: `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`,
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrRateLimited)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := newGraphQLTestGitHub(t, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(tt.response))
+			})
+			_, err := gh.GetPR(context.Background(), "owner", "repo", 42)
+			require.Error(t, err)
+			tt.check(t, err)
+		})
+	}
+}
+
+func TestNewWithOptionsGitHubGraphQL(t *testing.T) {
+	f, err := NewWithOptions("github", "token", NewOptions{GitHubGraphQL: true})
+	require.NoError(t, err)
+	assert.True(t, f.(*GitHub).graphql)
+
+	// GraphQL requires a token.
+	f, err = NewWithOptions("github", "", NewOptions{GitHubGraphQL: true})
+	require.NoError(t, err)
+	assert.False(t, f.(*GitHub).graphql)
+}
+
+func TestGitHubEnableAutoMerge(t *testing.T) {
+	gh := newGraphQLTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			assert.Equal(t, "/repos/owner/repo/pulls/42", r.URL.Path)
+			_, _ = w.Write([]byte(`{"number": 42, "node_id": "PR_kwDO"}`))
+			return
+		}
+		assert.Equal(t, "/graphql", r.URL.Path)
+
+		var req githubGraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Contains(t, req.Query, "enablePullRequestAutoMerge")
+		assert.Equal(t, map[string]any{"id": "PR_kwDO", "method": "SQUASH"}, req.Variables)
+		_, _ = w.Write([]byte(`{"errors": [{"type": "UNPROCESSABLE", "message": "Pull request is in clean status"}]}`))
+	})
+
+	err := gh.EnableAutoMerge(context.Background(), "owner", "repo", 42, MergeMethodSquash)
+	assert.ErrorContains(t, err, "Pull request is in clean status")
+}
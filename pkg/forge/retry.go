@@ -0,0 +1,190 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// requestTimeout bounds the wait for the response headers of a single attempt.
+const requestTimeout = 30 * time.Second
+
+// retryPolicy controls how forge API requests are retried.
+type retryPolicy struct {
+	maxRetries int           // Retries after the first attempt
+	baseDelay  time.Duration // Backoff before the first retry, doubled for each further one
+	maxDelay   time.Duration // Upper bound of the backoff
+	maxWait    time.Duration // Longest Retry-After or rate limit reset that is waited for
+}
+
+// defaultRetryPolicy is the retry policy of all forge clients.
+var defaultRetryPolicy = retryPolicy{
+	maxRetries: 3,                //nolint:mnd
+	baseDelay:  time.Second,      //nolint:mnd
+	maxDelay:   30 * time.Second, //nolint:mnd
+	maxWait:    time.Minute,      //nolint:mnd
+}
+
+// retryTransport retries forge API requests that failed transiently: rate limited requests
+// after the time the forge asks for, and network errors and 5xx responses of idempotent
+// requests with jittered exponential backoff.
+type retryTransport struct {
+	forge  string
+	base   http.RoundTripper
+	policy retryPolicy
+}
+
+// newHTTPClient returns the HTTP client of a forge, retrying transient failures and serving
+// cacheable requests from cache, if it is not nil.
+func newHTTPClient(forge string, cache *responseCache) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = requestTimeout
+
+	var transport http.RoundTripper = &retryTransport{forge: forge, base: base, policy: defaultRetryPolicy}
+	if cache != nil {
+		transport = &cacheTransport{cache: cache, base: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("cannot retry request with a body that cannot be rewound")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if resp != nil {
+			logRateLimit(t.forge, resp)
+		}
+
+		delay, retry := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		log.Debug().Err(err).Str("forge", t.forge).Str("method", req.Method).Str("url", req.URL.Redacted()).
+			Int("attempt", attempt+1).Dur("delay", delay).Msg("retrying forge API request")
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying a request, and whether to retry it at all.
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.policy.maxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+
+	if err != nil {
+		return t.backoff(attempt), idempotent(req.Method)
+	}
+
+	// Rate limited requests were rejected, they can be retried regardless of the method.
+	if wait, ok := rateLimitWait(resp); ok {
+		return wait, wait <= t.policy.maxWait
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return t.backoff(attempt), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.backoff(attempt), idempotent(req.Method)
+	}
+	return 0, false
+}
+
+// backoff returns the jittered exponential backoff before the given retry, between half and
+// the full doubled base delay.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := min(t.policy.baseDelay<<attempt, t.policy.maxDelay)
+	half := delay / 2 //nolint:mnd
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half) //nolint:gosec // Jitter needs no cryptographic randomness
+}
+
+// rateLimitWait returns the time a rate limited response asks to wait before retrying,
+// from its Retry-After header or from the reset time of an exhausted rate limit.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(max(seconds, 0)) * time.Second, true
+		}
+		if when, err := http.ParseTime(value); err == nil {
+			return max(time.Until(when), 0), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0)), 0), true
+		}
+	}
+
+	return 0, false
+}
+
+// logRateLimit logs the remaining rate limit reported by a response at debug level.
+func logRateLimit(forge string, resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+
+	event := log.Debug().Str("forge", forge).Str("remaining", remaining).Str("limit", resp.Header.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		event = event.Time("reset", time.Unix(reset, 0))
+	}
+	event.Msg("forge API rate limit")
+}
+
+// idempotent checks if requests with the method can be repeated without side effects,
+// e.g. creating a PR twice.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package forge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the retries of failing test servers fast.
+	defaultRetryPolicy.baseDelay = time.Millisecond
+	defaultRetryPolicy.maxDelay = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+// flakyServer fails the first failures requests with status and answers the rest with 200.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := newHTTPClient("test", nil).Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	server, calls := flakyServer(t, 2, http.StatusBadGateway, nil)
+
+	resp := doRequest(t, http.MethodPut, server.URL, "payload")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The body is sent again with every attempt.
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+
+	resp := doRequest(t, http.MethodGet, server.URL, "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(defaultRetryPolicy.maxRetries+1), calls.Load())
+}
+
+func TestNoRetryOfNonIdempotentRequests(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusInternalServerError, nil)
+
+	resp := doRequest(t, http.MethodPost, server.URL, "create")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	t.Run("Retry-After", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}})
+
+		resp := doRequest(t, http.MethodPost, server.URL, "create")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		header := http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(time.Now().Unix(), 10)}}
+		server, calls := flakyServer(t, 1, http.StatusForbidden, header)
+
+		resp := doRequest(t, http.MethodGet, server.URL, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("reset too far away", func(t *testing.T) {
+		header := http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}}
+		server, calls := flakyServer(t, 1, http.StatusForbidden, header)
+
+		resp := doRequest(t, http.MethodGet, server.URL, "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		server, calls := flakyServer(t, 1, http.StatusForbidden, nil)
+
+		resp := doRequest(t, http.MethodGet, server.URL, "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	transport := &retryTransport{policy: retryPolicy{baseDelay: time.Second, maxDelay: 5 * time.Second}}
+
+	for attempt, limit := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		delay := transport.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, limit/2)
+		assert.Less(t, delay, limit)
+	}
+}
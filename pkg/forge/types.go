@@ -19,8 +19,25 @@ type PRInfo struct {
 	Merged      bool
 	Squashed    bool
 	Author      string
+	MergedBy    string
 	MergedAt    time.Time
 	Labels      []string
+	Assignees   []string
+	Milestone   int // Milestone number (GitHub) or ID (Forgejo), 0 if none
+}
+
+// ChangedFile is a file changed by a pull request.
+type ChangedFile struct {
+	Path      string
+	Status    string // "added", "modified", "removed" or "renamed"
+	Additions int
+	Deletions int
+}
+
+// RepoInfo identifies a repository by its current owner and name.
+type RepoInfo struct {
+	Owner string
+	Name  string
 }
 
 // HasBackportLabel checks if the PR has any label containing "backport".
@@ -33,6 +50,18 @@ func (p *PRInfo) HasBackportLabel() bool {
 	return false
 }
 
+// Capabilities describes optional forge features, so callers can skip calls a forge does not support.
+type Capabilities struct {
+	DraftPRs             bool // PRs can be opened as drafts
+	AutoMerge            bool // PRs can be scheduled to merge once checks pass
+	LabelsOnCreate       bool // Labels can be set in the same call that creates a PR
+	ServerSideCherryPick bool // Commits can be cherry-picked by the forge without a local clone
+	Reviews              bool // Reviewers can be requested on PRs and PRs can be approved
+	BranchProtection     bool // Branches can be protected via the API
+	Milestones           bool // PRs can be grouped in milestones
+	PushForReview        bool // PRs are created by pushing to refs/for/<target> instead of from branches (Gerrit)
+}
+
 // CommitInfo contains information about a commit.
 type CommitInfo struct {
 	SHA       string
@@ -47,3 +76,13 @@ type CommitInfo struct {
 func (p *PRInfo) IsSquashMerge() bool {
 	return p.Squashed
 }
+
+// hasWritePermission checks if a repository permission level allows pushing.
+func hasWritePermission(permission string) bool {
+	switch strings.ToLower(permission) {
+	case "admin", "owner", "maintain", "write":
+		return true
+	default:
+		return false
+	}
+}
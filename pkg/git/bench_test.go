@@ -0,0 +1,138 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Performance budgets per operation. They are far above the timings on a developer machine
+// and only fail the benchmarks on order-of-magnitude regressions.
+const (
+	budgetLookup     = 50 * time.Millisecond
+	budgetPatchIDs   = 2 * time.Second
+	budgetCherryPick = 500 * time.Millisecond
+)
+
+// benchFixtureDepth is the number of commits in the benchmark fixture repository.
+const benchFixtureDepth = 200
+
+// checkBudget fails the benchmark if an iteration took longer than budget on average.
+func checkBudget(b *testing.B, budget time.Duration) {
+	b.Helper()
+
+	if perOp := b.Elapsed() / time.Duration(b.N); perOp > budget {
+		b.Errorf("%s/op exceeds the performance budget of %s", perOp, budget)
+	}
+}
+
+// setupBenchRepo creates a repository with a release branch and depth commits on the
+// default branch, each touching its own file, and changes into it.
+func setupBenchRepo(b *testing.B, depth int) (string, []string) {
+	b.Helper()
+
+	repoPath, cleanup := setupTestRepo(b)
+	b.Cleanup(cleanup)
+	b.Chdir(repoPath)
+
+	require.NoError(b, exec.Command("git", "branch", "release").Run())
+
+	shas := make([]string, 0, depth)
+	for i := range depth {
+		name := fmt.Sprintf("file-%d.txt", i)
+		require.NoError(b, os.WriteFile(filepath.Join(repoPath, name), []byte(strings.Repeat(name+"\n", 100)), 0o644))
+		require.NoError(b, exec.Command("git", "add", name).Run())
+		require.NoError(b, exec.Command("git", "commit", "-q", "-m", "Add "+name).Run())
+
+		sha, err := GetCurrentCommitSHA()
+		require.NoError(b, err)
+		shas = append(shas, sha)
+	}
+
+	return repoPath, shas
+}
+
+// BenchmarkCommitMessage compares reading a commit message through git with go-git.
+func BenchmarkCommitMessage(b *testing.B) {
+	repoPath, shas := setupBenchRepo(b, benchFixtureDepth)
+	sha := shas[0]
+
+	b.Run("exec", func(b *testing.B) {
+		for b.Loop() {
+			_, err := GetCommitMessage(sha)
+			require.NoError(b, err)
+		}
+		checkBudget(b, budgetLookup)
+	})
+
+	b.Run("go-git", func(b *testing.B) {
+		repo, err := Open(repoPath)
+		require.NoError(b, err)
+
+		for b.Loop() {
+			_, err := repo.GetCommitMessage(sha)
+			require.NoError(b, err)
+		}
+		checkBudget(b, budgetLookup)
+	})
+}
+
+// BenchmarkResolveHead compares resolving HEAD through git with go-git.
+func BenchmarkResolveHead(b *testing.B) {
+	repoPath, _ := setupBenchRepo(b, benchFixtureDepth)
+
+	b.Run("exec", func(b *testing.B) {
+		for b.Loop() {
+			_, err := GetCurrentCommitSHA()
+			require.NoError(b, err)
+		}
+		checkBudget(b, budgetLookup)
+	})
+
+	b.Run("go-git", func(b *testing.B) {
+		repo, err := Open(repoPath)
+		require.NoError(b, err)
+
+		for b.Loop() {
+			_, err := repo.GetCommitSHA("HEAD")
+			require.NoError(b, err)
+		}
+		checkBudget(b, budgetLookup)
+	})
+}
+
+// BenchmarkRangePatchIDs measures the patch ID scan used to detect existing backports.
+func BenchmarkRangePatchIDs(b *testing.B) {
+	setupBenchRepo(b, benchFixtureDepth)
+
+	for b.Loop() {
+		ids, err := RangePatchIDs("release", "HEAD")
+		require.NoError(b, err)
+		require.Len(b, ids, benchFixtureDepth)
+	}
+	checkBudget(b, budgetPatchIDs)
+}
+
+// BenchmarkCherryPick measures cherry-picking a commit onto the release branch.
+func BenchmarkCherryPick(b *testing.B) {
+	_, shas := setupBenchRepo(b, benchFixtureDepth)
+	require.NoError(b, CheckoutBranch("release"))
+	sha := shas[len(shas)-1]
+
+	for b.Loop() {
+		result, err := CherryPick(sha)
+		require.NoError(b, err)
+		require.False(b, result.HasConflict)
+
+		b.StopTimer()
+		require.NoError(b, exec.Command("git", "reset", "-q", "--hard", "HEAD~1").Run())
+		b.StartTimer()
+	}
+	checkBudget(b, budgetCherryPick)
+}
@@ -2,7 +2,10 @@ package git
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -10,22 +13,174 @@ import (
 type CherryPickResult struct {
 	Success     bool
 	HasConflict bool
+	Empty       bool     // True if the commit was dropped because it is already present on the target
+	Conflicts   []string // Files with conflicts, set with HasConflict
+	Resolved    []string // Files whose conflicts were resolved from recorded resolutions (rerere)
 	Message     string
 }
 
+// Empty commit handling modes, mirroring git cherry-pick --empty.
+const (
+	EmptyDrop = "drop" // Drop commits that become empty on the target
+	EmptyKeep = "keep" // Keep commits that become empty on the target
+	EmptyStop = "stop" // Stop and let the user decide (git default)
+)
+
+// CherryPickOptions contains options for cherry-pick operations.
+type CherryPickOptions struct {
+	// Empty controls how commits that become empty are handled ("drop", "keep" or "stop").
+	// Requires git >= 2.45.
+	Empty string
+
+	// KeepRedundantCommits keeps commits that become empty as empty commits.
+	KeepRedundantCommits bool
+
+	// Mainline is the parent number used when cherry-picking a merge commit (see git cherry-pick -m).
+	// Zero means the commit is not picked as a merge.
+	Mainline int
+
+	// Strategy is the merge strategy of the cherry-pick ("ort", "recursive" or "resolve",
+	// see git cherry-pick --strategy). Empty uses the git default.
+	Strategy string
+
+	// StrategyOptions are passed to the merge strategy, e.g. "theirs" or "ignore-space-change"
+	// (see git cherry-pick -X). Path mapped commits are applied without them.
+	StrategyOptions []string
+
+	// Paths limits the commit to its changes of the files matching these glob patterns,
+	// e.g. "src/server/**". The commit is then applied as a patch with git am.
+	Paths []string
+
+	// PathMappings move the paths of the commit before it is applied, for target branches
+	// that predate a restructure. The commit is then applied as a patch with git am.
+	PathMappings []PathMapping
+
+	// Rerere reuses recorded conflict resolutions (git rerere) and records new ones. If all
+	// conflicts are resolved that way, the cherry-pick is continued.
+	Rerere bool
+
+	// Identity overrides the committer, and optionally the author, of the picked commit.
+	// Nil keeps the original author and git's default committer.
+	Identity *Identity
+
+	// Trailers ("<key>: <value>") are added to the message of the picked commit, e.g. the
+	// provenance of a backport.
+	Trailers []string
+}
+
+// ValidateEmptyMode checks if the given empty commit handling mode is supported.
+func ValidateEmptyMode(mode string) error {
+	switch mode {
+	case "", EmptyDrop, EmptyKeep, EmptyStop:
+		return nil
+	default:
+		return fmt.Errorf("invalid empty mode: %s (must be 'drop', 'keep' or 'stop')", mode)
+	}
+}
+
+// Merge strategies supported for cherry-picks.
+const (
+	StrategyOrt       = "ort"
+	StrategyRecursive = "recursive"
+	StrategyResolve   = "resolve"
+)
+
+// ValidateMergeStrategy checks if the given cherry-pick merge strategy is supported.
+func ValidateMergeStrategy(strategy string) error {
+	switch strategy {
+	case "", StrategyOrt, StrategyRecursive, StrategyResolve:
+		return nil
+	default:
+		return fmt.Errorf("invalid merge strategy: %s (must be 'ort', 'recursive' or 'resolve')", strategy)
+	}
+}
+
+// cherryPickArgs builds the git arguments for a cherry-pick.
+func cherryPickArgs(sha string, opts CherryPickOptions) []string {
+	var args []string
+	if opts.Rerere {
+		args = append(args, "-c", "rerere.enabled=true", "-c", "rerere.autoUpdate=true")
+	}
+	args = append(args, "cherry-pick")
+	if opts.Empty != "" {
+		args = append(args, "--empty="+opts.Empty)
+	}
+	if opts.KeepRedundantCommits {
+		args = append(args, "--keep-redundant-commits")
+	}
+	if opts.Mainline > 0 {
+		args = append(args, "-m", strconv.Itoa(opts.Mainline))
+	}
+	if opts.Strategy != "" {
+		args = append(args, "--strategy="+opts.Strategy)
+	}
+	for _, option := range opts.StrategyOptions {
+		args = append(args, "--strategy-option="+option)
+	}
+	return append(args, sha)
+}
+
 // CherryPick performs a git cherry-pick operation.
 // Note: go-git doesn't support cherry-pick natively, so we use git command.
 func CherryPick(sha string) (*CherryPickResult, error) {
-	cmd := exec.Command("git", "cherry-pick", sha)
+	return CherryPickWithOptions(sha, CherryPickOptions{})
+}
+
+// CherryPickWithOptions performs a git cherry-pick operation with additional options.
+func CherryPickWithOptions(sha string, opts CherryPickOptions) (*CherryPickResult, error) {
+	return cherryPickIn("", sha, opts)
+}
+
+// cherryPickIn performs a git cherry-pick in dir, or the current directory if dir is empty.
+func cherryPickIn(dir, sha string, opts CherryPickOptions) (*CherryPickResult, error) {
+	if err := ValidateEmptyMode(opts.Empty); err != nil {
+		return nil, err
+	}
+	if err := ValidateMergeStrategy(opts.Strategy); err != nil {
+		return nil, err
+	}
+
+	headBefore, err := headSHAIn(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.PathMappings) > 0 || len(opts.Paths) > 0 {
+		return applyPatchIn(dir, sha, headBefore, opts)
+	}
+
+	cmd := exec.Command("git", cherryPickArgs(sha, opts)...)
+	cmd.Dir = dir
+	cmd.Env = opts.Identity.env()
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		outputStr := string(output)
 
 		// Check if it's a conflict.
 		if strings.Contains(outputStr, "CONFLICT") || strings.Contains(outputStr, "after resolving the conflicts") {
+			conflicts, resolved := conflictedFilesIn(dir), rerereResolved(outputStr)
+			if len(resolved) > 0 && len(conflicts) == 0 {
+				// Recorded resolutions were staged for all conflicts.
+				if err := continueCherryPickIn(dir); err != nil {
+					return nil, err
+				}
+				if err := applyIdentityIn(dir, opts.Identity); err != nil {
+					return nil, err
+				}
+				if err := addTrailersIn(dir, opts.Trailers, opts.Identity); err != nil {
+					return nil, err
+				}
+				return &CherryPickResult{
+					Success:  true,
+					Resolved: resolved,
+					Message:  outputStr,
+				}, nil
+			}
 			return &CherryPickResult{
 				Success:     false,
 				HasConflict: true,
+				Conflicts:   conflicts,
+				Resolved:    resolved,
 				Message:     outputStr,
 			}, nil
 		}
@@ -33,31 +188,158 @@ func CherryPick(sha string) (*CherryPickResult, error) {
 		return nil, fmt.Errorf("cherry-pick failed: %s - %w", outputStr, err)
 	}
 
+	// With --empty=drop git succeeds without creating a commit.
+	headAfter, err := headSHAIn(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := headBefore == headAfter
+	if !empty && opts.Identity != nil && opts.Identity.Reauthor {
+		if err := applyIdentityIn(dir, opts.Identity); err != nil {
+			return nil, err
+		}
+	}
+	if !empty {
+		if err := addTrailersIn(dir, opts.Trailers, opts.Identity); err != nil {
+			return nil, err
+		}
+	}
+
 	return &CherryPickResult{
 		Success:     true,
 		HasConflict: false,
+		Empty:       empty,
 		Message:     string(output),
 	}, nil
 }
 
 // AbortCherryPick aborts an in-progress cherry-pick.
 func AbortCherryPick() error {
+	return abortCherryPickIn("")
+}
+
+// abortCherryPickIn aborts an in-progress cherry-pick or path mapped patch in dir,
+// or the current directory if dir is empty.
+func abortCherryPickIn(dir string) error {
 	cmd := exec.Command("git", "cherry-pick", "--abort")
+	if amInProgressIn(dir) {
+		cmd = exec.Command("git", "am", "--abort")
+	}
+	cmd.Dir = dir
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to abort cherry-pick: %w", err)
 	}
 	return nil
 }
 
+// conflictedFilesIn returns the unmerged files in dir, or the current directory if dir is empty.
+func conflictedFilesIn(dir string) []string {
+	// NUL-separated, so paths with spaces or newlines are kept whole.
+	cmd := exec.Command("git", "diff", "--name-only", "-z", "--diff-filter=U")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for file := range strings.SplitSeq(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// CommitConflicts concludes a conflicting cherry-pick by committing the files as they are,
+// conflict markers included, so the conflicts can be resolved elsewhere. The commit is committed
+// with identity, like the cherry-pick would have been.
+func CommitConflicts(message string, identity *Identity) error {
+	return commitConflictsIn("", message, identity)
+}
+
+// commitConflictsIn commits a conflicting cherry-pick in dir, or the current directory if dir is empty.
+func commitConflictsIn(dir, message string, identity *Identity) error {
+	add := exec.Command("git", "add", "-A")
+	add.Dir = dir
+	if output, err := add.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage conflicts: %s - %w", string(output), err)
+	}
+
+	args := []string{"commit", "--no-verify", "-m", message}
+	if identity != nil && identity.Reauthor {
+		// The author of the cherry-picked commit is kept otherwise, the environment does not override it.
+		args = append(args, "--reset-author")
+	}
+	commit := exec.Command("git", args...)
+	commit.Dir = dir
+	commit.Env = identity.env()
+	if output, err := commit.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to commit conflicts: %s - %w", string(output), err)
+	}
+
+	// A path mapped patch is done once committed, keep the commit and end the git am session.
+	if amInProgressIn(dir) {
+		quit := exec.Command("git", "am", "--quit")
+		quit.Dir = dir
+		if output, err := quit.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to finish patch: %s - %w", string(output), err)
+		}
+	}
+
+	return nil
+}
+
+// rerereResolvedPattern matches the files git rerere resolved from a recorded resolution.
+var rerereResolvedPattern = regexp.MustCompile(`(?m)^(?:Resolved|Staged) '(.+)' using previous resolution\.$`)
+
+// rerereResolved returns the files git rerere resolved, from the output of a cherry-pick.
+func rerereResolved(output string) []string {
+	var files []string
+	for _, m := range rerereResolvedPattern.FindAllStringSubmatch(output, -1) {
+		files = append(files, m[1])
+	}
+	return files
+}
+
 // ContinueCherryPick continues a cherry-pick after conflicts are resolved.
+// The original commit message is kept without opening an editor.
 func ContinueCherryPick() error {
+	return continueCherryPickIn("")
+}
+
+// continueCherryPickIn continues a cherry-pick or path mapped patch in dir, or the current
+// directory if dir is empty.
+func continueCherryPickIn(dir string) error {
 	cmd := exec.Command("git", "cherry-pick", "--continue")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to continue cherry-pick: %w", err)
+	if amInProgressIn(dir) {
+		cmd = exec.Command("git", "am", "--continue")
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to continue cherry-pick: %s - %w", string(output), err)
 	}
 	return nil
 }
 
+// CherryPickInProgress checks if a cherry-pick or path mapped patch is waiting for conflicts to be resolved.
+func CherryPickInProgress() bool {
+	cmd := exec.Command("git", "rev-parse", "-q", "--verify", "CHERRY_PICK_HEAD")
+	return cmd.Run() == nil || amInProgressIn("")
+}
+
+// GitPath returns the path of a file inside the git directory of the current repository.
+func GitPath(name string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-path", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git path %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // CheckoutBranch switches to the specified branch.
 // Note: We don't use "--" separator here because it would treat the branch as a file path.
 // Branch existence is validated by the caller using go-git before calling this function.
@@ -100,9 +382,25 @@ func DeleteBranch(name string) error {
 	return nil
 }
 
+// ResetBranch moves an existing branch that is not checked out to the given ref.
+func ResetBranch(name, ref string) error {
+	cmd := exec.Command("git", "branch", "-f", "--", name, ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to reset branch %s to %s: %s - %w", name, ref, string(output), err)
+	}
+	return nil
+}
+
 // AmendCommitMessage amends the last commit message.
 func AmendCommitMessage(message string) error {
-	cmd := exec.Command("git", "commit", "--amend", "-m", message)
+	return AmendCommitMessageAs(message, nil)
+}
+
+// AmendCommitMessageAs amends the last commit message, committing it with the given identity.
+func AmendCommitMessageAs(message string, identity *Identity) error {
+	cmd := exec.Command("git", append(identity.amendArgs(), "-m", message)...)
+	cmd.Env = identity.env()
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to amend commit: %s - %w", string(output), err)
@@ -112,7 +410,13 @@ func AmendCommitMessage(message string) error {
 
 // GetCurrentCommitSHA returns the SHA of the current HEAD.
 func GetCurrentCommitSHA() (string, error) {
+	return headSHAIn("")
+}
+
+// headSHAIn returns the SHA of HEAD in dir, or the current directory if dir is empty.
+func headSHAIn(dir string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current commit SHA: %w", err)
@@ -130,16 +434,35 @@ func Fetch(remote string) error {
 	return nil
 }
 
-// Push pushes a branch to the specified remote.
+// Push pushes a branch to the specified remote. Returns ErrPushRejected if the remote branch
+// has commits the local one lacks.
 func Push(remote, branch string) error {
-	cmd := exec.Command("git", "push", remote, branch)
+	return pushIn("", remote, branch)
+}
+
+// PushForReview pushes a branch for review on Gerrit, to refs/for/<target> with the branch
+// name as topic. Gerrit creates a change, or a new patch set of the change with the same Change-Id.
+func PushForReview(remote, branch, target string) error {
+	refspec := fmt.Sprintf("refs/heads/%s:refs/for/%s%%topic=%s", branch, target, branch)
+	cmd := exec.Command("git", "push", remote, refspec)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to push %s to %s: %s - %w", branch, remote, string(output), err)
+		return fmt.Errorf("failed to push %s for review on %s to %s: %s - %w", branch, target, remote, string(output), err)
 	}
 	return nil
 }
 
+// DiffStat returns the short diffstat between two revisions,
+// e.g. "3 files changed, 10 insertions(+), 2 deletions(-)".
+func DiffStat(from, to string) (string, error) {
+	cmd := exec.Command("git", "diff", "--shortstat", from, to)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diffstat of %s..%s: %s - %w", from, to, string(output), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // GetHeadCommitMessage returns the commit message of HEAD.
 func GetHeadCommitMessage() (string, error) {
 	return GetCommitMessage("HEAD")
@@ -186,6 +509,8 @@ func ConfigureUserForCI(forgeType string) (bool, error) {
 		switch forgeType {
 		case "forgejo":
 			name = "forgejo-actions[bot]"
+		case "bitbucket":
+			name = "bitbucket-pipelines[bot]"
 		default:
 			name = "github-actions[bot]"
 		}
@@ -201,6 +526,8 @@ func ConfigureUserForCI(forgeType string) (bool, error) {
 		switch forgeType {
 		case "forgejo":
 			email = "forgejo-actions[bot]@noreply.forgejo.org"
+		case "bitbucket":
+			email = "bitbucket-pipelines[bot]@noreply.bitbucket.org"
 		default:
 			email = "github-actions[bot]@users.noreply.github.com"
 		}
@@ -212,3 +539,60 @@ func ConfigureUserForCI(forgeType string) (bool, error) {
 
 	return configured, nil
 }
+
+// fullSHALength is the length of a full (SHA-1) commit hash.
+const fullSHALength = 40
+
+// CommitExists checks if a commit object is available in the local repository.
+func CommitExists(sha string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", sha+"^{commit}")
+	return cmd.Run() == nil
+}
+
+// FetchCommit fetches a single commit object from the specified remote. Shallow clones only
+// fetch the last commits of its history, EnsureMergeBase fetches more when it is needed.
+func FetchCommit(remote, sha string) error {
+	args := []string{"fetch", "--no-tags"}
+	if IsShallow() {
+		args = append(args, "--depth="+strconv.Itoa(deepenStep))
+	}
+	cmd := exec.Command("git", append(args, remote, sha)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %s - %w", sha, remote, string(output), err)
+	}
+	return nil
+}
+
+// EnsureCommit makes sure a commit is available locally, fetching exactly that object
+// from the remote if it is missing. Only full SHAs can be fetched.
+// Returns true if the commit was fetched.
+func EnsureCommit(remote, sha string) (bool, error) {
+	if CommitExists(sha) {
+		return false, nil
+	}
+
+	if len(sha) != fullSHALength {
+		return false, fmt.Errorf("commit %s not found locally, only full SHAs can be fetched", sha)
+	}
+	if remote == "" {
+		return false, fmt.Errorf("commit %s not found locally and no remote configured", sha)
+	}
+
+	if err := FetchCommit(remote, sha); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListMergedCommits returns the non-merge commits a merge commit brought in from its
+// second parent, oldest first.
+func ListMergedCommits(mergeSHA string) ([]string, error) {
+	cmd := exec.Command("git", "rev-list", "--reverse", "--no-merges", mergeSHA+"^1.."+mergeSHA+"^2")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits of merge %s: %s - %w", mergeSHA, string(output), err)
+	}
+	return strings.Fields(string(output)), nil
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// conflictMarkerPattern matches the lines git marks the start and end of a conflict with.
+var conflictMarkerPattern = regexp.MustCompile(`(?m)^(<{7}|>{7})( |$)`)
+
+// ConflictedFiles returns the files of the current repository that still have unresolved conflicts.
+func ConflictedFiles() []string {
+	return conflictedFilesIn("")
+}
+
+// HasConflictMarkers reports whether a file still contains conflict markers. Files that
+// were deleted have none.
+func HasConflictMarkers(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return conflictMarkerPattern.Match(data), nil
+}
+
+// MarkResolved stages a conflicted file as resolved, or its removal if it was deleted.
+func MarkResolved(path string) error {
+	return runGit("failed to mark "+path+" as resolved", "add", "-A", "--", path)
+}
+
+// EditFile opens a file in the editor git uses (GIT_EDITOR, core.editor, VISUAL or EDITOR)
+// and waits for it to be closed.
+func EditFile(path string) error {
+	output, err := exec.Command("git", "var", "GIT_EDITOR").Output()
+	if err != nil {
+		return fmt.Errorf("failed to determine editor: %w", err)
+	}
+	editor := strings.TrimSpace(string(output))
+
+	// The editor may come with arguments, so it is run through the shell like git does.
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
+
+// MergetoolConfigured reports whether a merge tool is configured (merge.tool).
+func MergetoolConfigured() bool {
+	return GetConfigValue("merge.tool") != ""
+}
+
+// RunMergetool resolves the conflicts of a file with the configured merge tool, like
+// git mergetool. The file is staged if the merge tool reports it as resolved.
+func RunMergetool(path string) error {
+	cmd := exec.Command("git", "mergetool", "--no-prompt", "--", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("merge tool failed for %s: %w", path, err)
+	}
+	return nil
+}
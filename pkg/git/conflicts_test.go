@@ -0,0 +1,39 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasConflictMarkers(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "conflict", content: "a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> 1234567 (Fix bug)\n", want: true},
+		{name: "diff3 conflict", content: "<<<<<<< HEAD\nb\n||||||| base\n=======\nc\n>>>>>>>\n", want: true},
+		{name: "resolved", content: "a\nb\nc\n", want: false},
+		{name: "longer markers", content: "<<<<<<<<<< heading\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			got, err := HasConflictMarkers(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := HasConflictMarkers(filepath.Join(dir, "deleted"))
+	require.NoError(t, err)
+	assert.False(t, got)
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// SelectHunks lets the user pick the hunks of the HEAD commit to keep, like git add -p, and
+// rewrites the commit to contain only those, keeping its message and author. The other hunks
+// are discarded. Returns false if no hunk was selected, in which case the commit is dropped.
+func SelectHunks() (bool, error) {
+	return selectHunks(os.Stdin, os.Stdout)
+}
+
+// selectHunks runs the hunk selection of SelectHunks with the given terminal.
+func selectHunks(in io.Reader, out io.Writer) (bool, error) {
+	picked, err := GetCurrentCommitSHA()
+	if err != nil {
+		return false, err
+	}
+
+	// Files added by the commit are only offered by git add -p as intent-to-add entries.
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=A", "HEAD^", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list added files: %w", err)
+	}
+	added := strings.Fields(string(output))
+
+	if err := runGit("failed to unstage commit", "reset", "-q", "HEAD^"); err != nil {
+		return false, err
+	}
+	if len(added) > 0 {
+		args := append([]string{"add", "-N", "--"}, added...)
+		if err := runGit("failed to track added files", args...); err != nil {
+			return false, err
+		}
+	}
+
+	add := exec.Command("git", "add", "-p")
+	add.Stdin = in
+	add.Stdout = out
+	add.Stderr = out
+	if err := add.Run(); err != nil {
+		_ = runGit("", "reset", "-q", "--hard", picked)
+		return false, fmt.Errorf("failed to select hunks: %w", err)
+	}
+
+	// Nothing staged means no hunk was selected.
+	staged := exec.Command("git", "diff", "--cached", "--quiet")
+	if staged.Run() == nil {
+		return false, runGit("failed to discard hunks", "reset", "-q", "--hard", "HEAD")
+	}
+
+	if err := runGit("failed to commit selected hunks", "commit", "-q", "--no-verify", "-C", picked); err != nil {
+		return false, err
+	}
+	if err := runGit("failed to discard unselected hunks", "reset", "-q", "--hard", "HEAD"); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// runGit runs a git command in the current directory, wrapping failures with msg.
+func runGit(msg string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s - %w", msg, string(output), err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Identity overrides who commits, and optionally authors, the commits created by cherry-picks
+// and amends. Empty fields fall back to the git user configuration. A nil *Identity keeps the
+// original author and git's default committer.
+type Identity struct {
+	Name  string
+	Email string
+
+	// Reauthor also makes Name and Email the author, instead of keeping the original author.
+	Reauthor bool
+}
+
+// env returns the environment of git commands creating commits with the identity.
+func (i *Identity) env() []string {
+	env := os.Environ()
+	if i == nil {
+		return env
+	}
+	vars := []string{"COMMITTER"}
+	if i.Reauthor {
+		vars = append(vars, "AUTHOR")
+	}
+	for _, v := range vars {
+		if i.Name != "" {
+			env = append(env, "GIT_"+v+"_NAME="+i.Name)
+		}
+		if i.Email != "" {
+			env = append(env, "GIT_"+v+"_EMAIL="+i.Email)
+		}
+	}
+	return env
+}
+
+// amendArgs returns the git commit --amend arguments applying the identity.
+func (i *Identity) amendArgs() []string {
+	args := []string{"commit", "--amend"}
+	if i != nil && i.Reauthor {
+		args = append(args, "--reset-author")
+	}
+	return args
+}
+
+// applyIdentityIn amends the HEAD commit in dir, or the current directory if dir is empty, to
+// the identity. Cherry-picks and patches keep the original author, so it is reset here.
+func applyIdentityIn(dir string, identity *Identity) error {
+	if identity == nil {
+		return nil
+	}
+	cmd := exec.Command("git", append(identity.amendArgs(), "--no-edit", "--allow-empty")...)
+	cmd.Dir = dir
+	cmd.Env = identity.env()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to apply commit identity: %s - %w", string(output), err)
+	}
+	return nil
+}
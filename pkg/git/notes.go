@@ -0,0 +1,121 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// AddNote attaches message as the note of a commit in the notes ref, replacing an existing note.
+func AddNote(ref, sha, message string) error {
+	cmd := exec.Command("git", "notes", "--ref", ref, "add", "--force", "-m", message, sha)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to add note to %s: %s - %w", sha, string(output), err)
+	}
+	return nil
+}
+
+// ReadNotes returns all notes of the notes ref by the commit they are attached to.
+// A missing notes ref has no notes.
+func ReadNotes(ref string) (map[string]string, error) {
+	output, err := exec.Command("git", "notes", "--ref", ref, "list").Output()
+	if err != nil {
+		// git notes list fails if the ref does not exist.
+		if exec.Command("git", "rev-parse", "-q", "--verify", qualifyNotesRef(ref)).Run() != nil {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to list notes of %s: %w", ref, err)
+	}
+
+	// Each line is "<note blob> <commit>", the blobs are read in one batch.
+	var blobs, commits []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		blob, commit, ok := strings.Cut(line, " ")
+		if ok {
+			blobs = append(blobs, blob)
+			commits = append(commits, commit)
+		}
+	}
+
+	contents, err := readBlobs(blobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes of %s: %w", ref, err)
+	}
+	notes := make(map[string]string, len(commits))
+	for i, commit := range commits {
+		notes[commit] = contents[i]
+	}
+	return notes, nil
+}
+
+// qualifyNotesRef returns the full name of a notes ref, git notes accepts "name" for "refs/notes/name".
+func qualifyNotesRef(ref string) string {
+	if strings.HasPrefix(ref, "refs/") {
+		return ref
+	}
+	return "refs/notes/" + ref
+}
+
+// readBlobs returns the contents of the blobs in order, using a single git cat-file --batch.
+func readBlobs(blobs []string) ([]string, error) {
+	if len(blobs) == 0 {
+		return nil, nil
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(strings.Join(blobs, "\n") + "\n")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(stdout)
+	contents := make([]string, 0, len(blobs))
+	for range blobs {
+		// Each object is "<sha> <type> <size>\n<content>\n".
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			_ = cmd.Wait()
+			return nil, err
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 { //nolint:mnd
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("unexpected object header %q", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("unexpected object header %q", strings.TrimSpace(header))
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			_ = cmd.Wait()
+			return nil, err
+		}
+		contents = append(contents, string(content[:size]))
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// PushNotes pushes the notes ref to the remote.
+func PushNotes(remote, ref string) error {
+	ref = qualifyNotesRef(ref)
+	cmd := exec.Command("git", "push", remote, ref+":"+ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to push %s to %s: %s - %w", ref, remote, string(output), err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package git
+
+// FormatPatch returns a commit as a patch in mbox format, like git format-patch, limited to
+// the files matching the glob patterns in paths if any. The patch is empty if the commit
+// changes none of them.
+func FormatPatch(sha string, paths []string) ([]byte, error) {
+	return formatPatchIn("", sha, paths)
+}
+
+// ApplyPatch applies a patch in mbox format holding a single commit with git am, keeping its
+// message and author. The paths are mapped and the empty commit handling follows opts.
+// Conflicts are left to be resolved and continued like those of a cherry-pick.
+func ApplyPatch(patch []byte, opts CherryPickOptions) (*CherryPickResult, error) {
+	if err := ValidateEmptyMode(opts.Empty); err != nil {
+		return nil, err
+	}
+
+	headBefore, err := headSHAIn("")
+	if err != nil {
+		return nil, err
+	}
+	return amIn("", patch, headBefore, opts)
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PatchID returns the stable patch-id of a commit.
+// Commits with the same patch-id introduce the same change, regardless of their parents.
+func PatchID(sha string) (string, error) {
+	show := exec.Command("git", "show", "--no-color", "--pretty=format:", sha)
+	diff, err := show.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff of %s: %w", sha, err)
+	}
+
+	ids, err := patchIDs(diff)
+	if err != nil {
+		return "", err
+	}
+
+	for id := range ids {
+		return id, nil
+	}
+	return "", fmt.Errorf("commit %s has no diff", sha)
+}
+
+// RangePatchIDs returns the patch-ids of all non-merge commits reachable from ref but not from base,
+// mapped to the commit SHA introducing them.
+func RangePatchIDs(base, ref string) (map[string]string, error) {
+	log := exec.Command("git", "log", "--no-merges", "--no-color", "-p", base+".."+ref)
+	diff, err := log.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log of %s..%s: %w", base, ref, err)
+	}
+
+	return patchIDs(diff)
+}
+
+// patchIDs runs git patch-id on the given diff output.
+func patchIDs(diff []byte) (map[string]string, error) {
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Stdin = bytes.NewReader(diff)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch-id: %w", err)
+	}
+
+	ids := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 { //nolint:mnd
+			continue
+		}
+		ids[fields[0]] = fields[1]
+	}
+
+	return ids, scanner.Err()
+}
+
+// IsAncestor checks if the commit sha is an ancestor of (or equal to) ref.
+func IsAncestor(sha, ref string) (bool, error) {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", sha, ref)
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+
+	// Exit code 1 means "not an ancestor", anything else is an error.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check if %s is an ancestor of %s: %w", sha, ref, err)
+}
+
+// MergeBase returns the best common ancestor of two refs.
+func MergeBase(a, b string) (string, error) {
+	cmd := exec.Command("git", "merge-base", a, b)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get merge base of %s and %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// FindCommitByMessage returns the most recent commit on ref whose message contains text,
+// or an empty string if there is none.
+func FindCommitByMessage(ref, text string) (string, error) {
+	cmd := exec.Command("git", "log", "-n", "1", "--format=%H", "--fixed-strings", "--grep", text, ref, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to search commits on %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CountCommitsByMessage returns the number of commits on ref whose message contains any of texts.
+func CountCommitsByMessage(ref string, texts ...string) (int, error) {
+	args := []string{"rev-list", "--count", "--fixed-strings"}
+	for _, text := range texts {
+		args = append(args, "--grep", text)
+	}
+	cmd := exec.Command("git", append(args, ref, "--")...)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits on %s: %w", ref, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
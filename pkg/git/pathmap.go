@@ -0,0 +1,203 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PathMapping moves the files below a path prefix of a patch to another prefix,
+// e.g. from "src/new/" on the source branch to "src/old/" on the target.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// mapPath returns path with the first matching mapping applied.
+func mapPath(path string, mappings []PathMapping) string {
+	for _, m := range mappings {
+		if strings.HasPrefix(path, m.From) {
+			return m.To + strings.TrimPrefix(path, m.From)
+		}
+	}
+	return path
+}
+
+// patchPathHeaders are the extended diff header lines naming a single path.
+var patchPathHeaders = []string{"--- a/", "+++ b/", "rename from ", "rename to ", "copy from ", "copy to "}
+
+// rewritePatchPaths applies the path mappings to the file names in the diff headers of a patch.
+// The commit message and the hunks are left untouched.
+func rewritePatchPaths(patch []byte, mappings []PathMapping) []byte {
+	lines := bytes.SplitAfter(patch, []byte("\n"))
+	inHeader := false
+	for i, line := range lines {
+		text := string(line)
+		if strings.HasPrefix(text, "diff --git a/") {
+			inHeader = true
+			lines[i] = []byte(rewriteDiffGitLine(text, mappings))
+			continue
+		}
+		if strings.HasPrefix(text, "@@") {
+			inHeader = false
+		}
+		if !inHeader {
+			continue
+		}
+		for _, header := range patchPathHeaders {
+			if strings.HasPrefix(text, header) {
+				path := strings.TrimPrefix(text, header)
+				eol := path[len(strings.TrimRight(path, "\r\n")):]
+				lines[i] = []byte(header + mapPath(strings.TrimSuffix(path, eol), mappings) + eol)
+				break
+			}
+		}
+	}
+	return bytes.Join(lines, nil)
+}
+
+// rewriteDiffGitLine applies the path mappings to a "diff --git a/<path> b/<path>" line.
+func rewriteDiffGitLine(line string, mappings []PathMapping) string {
+	body := strings.TrimPrefix(line, "diff --git a/")
+	eol := body[len(strings.TrimRight(body, "\r\n")):]
+	body = strings.TrimSuffix(body, eol)
+
+	// Without a rename both paths are equal, which also splits paths containing " b/".
+	idx := strings.Index(body, " b/")
+	if half := (len(body) - len(" b/")) / 2; half > 0 && body[half:half+len(" b/")] == " b/" && body[:half] == body[half+len(" b/"):] {
+		idx = half
+	}
+	if idx < 0 {
+		return line
+	}
+
+	from, to := body[:idx], body[idx+len(" b/"):]
+	return "diff --git a/" + mapPath(from, mappings) + " b/" + mapPath(to, mappings) + eol
+}
+
+// pathspecs returns the git pathspecs matching the glob patterns, e.g. "src/server/**".
+// Patterns naming a directory match all files below it.
+func pathspecs(patterns []string) []string {
+	specs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		specs = append(specs, ":(glob)"+strings.TrimSuffix(pattern, "/"))
+	}
+	return specs
+}
+
+// applyPatchIn applies a commit as a patch, limited to opts.Paths and with its paths mapped.
+// See amIn.
+func applyPatchIn(dir, sha, headBefore string, opts CherryPickOptions) (*CherryPickResult, error) {
+	if opts.Mainline > 0 {
+		return nil, fmt.Errorf("path mappings and path filters cannot be applied to merge commits, backport the individual commits instead")
+	}
+
+	patch, err := formatPatchIn(dir, sha, opts.Paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(patch) == 0 {
+		// The commit changes none of the paths.
+		return &CherryPickResult{
+			Success: true,
+			Empty:   true,
+			Message: fmt.Sprintf("%s does not change %s", sha, strings.Join(opts.Paths, ", ")),
+		}, nil
+	}
+
+	return amIn(dir, patch, headBefore, opts)
+}
+
+// formatPatchIn returns a commit of the repository in dir, or the current directory if dir is empty,
+// as a patch in mbox format, limited to the files matching paths if any. The patch is empty if
+// the commit changes none of them.
+func formatPatchIn(dir, sha string, paths []string) ([]byte, error) {
+	args := []string{"format-patch", "-1", "--stdout", "--binary", "--keep-subject", "--no-signature", sha}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), pathspecs(paths)...)
+	}
+	formatPatch := exec.Command("git", args...)
+	formatPatch.Dir = dir
+	patch, err := formatPatch.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patch: %s - %w", sha, err)
+	}
+	return patch, nil
+}
+
+// amIn applies a patch in mbox format with its paths mapped, using git am with a three-way
+// fallback so changes that do not apply cleanly end in regular conflicts.
+func amIn(dir string, patch []byte, headBefore string, opts CherryPickOptions) (*CherryPickResult, error) {
+	args := []string{"am", "--3way", "--keep"}
+	switch {
+	case opts.Empty != "":
+		args = append(args, "--empty="+opts.Empty)
+	case opts.KeepRedundantCommits:
+		args = append(args, "--empty="+EmptyKeep)
+	}
+
+	am := exec.Command("git", args...)
+	am.Dir = dir
+	am.Env = opts.Identity.env()
+	am.Stdin = bytes.NewReader(rewritePatchPaths(patch, opts.PathMappings))
+	output, err := am.CombinedOutput()
+	if err != nil {
+		outputStr := string(output)
+		if strings.Contains(outputStr, "CONFLICT") || strings.Contains(outputStr, "Patch failed at") {
+			return &CherryPickResult{
+				Success:     false,
+				HasConflict: true,
+				Conflicts:   conflictedFilesIn(dir),
+				Message:     outputStr,
+			}, nil
+		}
+		if amInProgressIn(dir) {
+			abort := exec.Command("git", "am", "--abort")
+			abort.Dir = dir
+			_ = abort.Run()
+		}
+		return nil, fmt.Errorf("failed to apply patch: %s - %w", outputStr, err)
+	}
+
+	headAfter, err := headSHAIn(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := headBefore == headAfter
+	if !empty && opts.Identity != nil && opts.Identity.Reauthor {
+		if err := applyIdentityIn(dir, opts.Identity); err != nil {
+			return nil, err
+		}
+	}
+	if !empty {
+		if err := addTrailersIn(dir, opts.Trailers, opts.Identity); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CherryPickResult{
+		Success: true,
+		Empty:   empty,
+		Message: string(output),
+	}, nil
+}
+
+// amInProgressIn checks if git am stopped on a patch in dir, or the current directory if dir is empty.
+func amInProgressIn(dir string) bool {
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-path", "rebase-apply/applying")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	path := strings.TrimSpace(string(output))
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewritePatchPaths(t *testing.T) {
+	mappings := []PathMapping{{From: "src/new/", To: "src/old/"}}
+	patch := "Subject: [PATCH] Touch src/new/main.go\n\n" +
+		"--- a/src/new/main.go in the message\n" +
+		"---\n" +
+		"diff --git a/src/new/main.go b/src/new/main.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/src/new/main.go\n" +
+		"+++ b/src/new/main.go\n" +
+		"@@ -1 +1 @@\n" +
+		"--- a/src/new/removed line\n" +
+		"diff --git a/docs/a b/x.md b/docs/a b/x.md\n" +
+		"--- a/docs/a b/x.md\n" +
+		"diff --git a/src/new/a.go b/src/new/b.go\n" +
+		"rename from src/new/a.go\n" +
+		"rename to src/new/b.go\n"
+
+	want := "Subject: [PATCH] Touch src/new/main.go\n\n" +
+		"--- a/src/new/main.go in the message\n" +
+		"---\n" +
+		"diff --git a/src/old/main.go b/src/old/main.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/src/old/main.go\n" +
+		"+++ b/src/old/main.go\n" +
+		"@@ -1 +1 @@\n" +
+		"--- a/src/new/removed line\n" +
+		"diff --git a/docs/a b/x.md b/docs/a b/x.md\n" +
+		"--- a/docs/a b/x.md\n" +
+		"diff --git a/src/old/a.go b/src/old/b.go\n" +
+		"rename from src/old/a.go\n" +
+		"rename to src/old/b.go\n"
+
+	assert.Equal(t, want, string(rewritePatchPaths([]byte(patch), mappings)))
+}
+
+// setupMovedRepo creates a repository whose main branch moved old/ to new/ and changed
+// new/file.txt afterwards, with a target branch from before the move.
+func setupMovedRepo(t *testing.T) string {
+	t.Helper()
+
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Chdir(repoPath)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repoPath, "old"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "old", "file.txt"), []byte("one\ntwo\nthree\n"), 0o644))
+	require.NoError(t, exec.Command("git", "add", "-A").Run())
+	require.NoError(t, exec.Command("git", "commit", "-q", "-m", "Add file").Run())
+	require.NoError(t, exec.Command("git", "branch", "target-branch").Run())
+
+	require.NoError(t, exec.Command("git", "mv", "old", "new").Run())
+	require.NoError(t, exec.Command("git", "commit", "-q", "-m", "Move old to new").Run())
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "new", "file.txt"), []byte("one\n2\nthree\n"), 0o644))
+	require.NoError(t, exec.Command("git", "commit", "-q", "-am", "Change file").Run())
+
+	return repoPath
+}
+
+func TestCherryPick_PathMappings(t *testing.T) {
+	repoPath := setupMovedRepo(t)
+	sha, err := GetCurrentCommitSHA()
+	require.NoError(t, err)
+	require.NoError(t, CheckoutBranch("target-branch"))
+
+	result, err := CherryPickWithOptions(sha, CherryPickOptions{PathMappings: []PathMapping{{From: "new/", To: "old/"}}})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.False(t, result.Empty)
+	assert.False(t, CherryPickInProgress())
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "old", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\n2\nthree\n", string(content))
+	assert.NoDirExists(t, filepath.Join(repoPath, "new"))
+
+	message, err := GetHeadCommitMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "Change file", message)
+
+	// Applying it again leaves nothing to commit.
+	result, err = CherryPickWithOptions(sha, CherryPickOptions{Empty: EmptyDrop, PathMappings: []PathMapping{{From: "new/", To: "old/"}}})
+	require.NoError(t, err)
+	assert.True(t, result.Empty)
+}
+
+func TestCherryPick_PathMappingsConflict(t *testing.T) {
+	repoPath := setupMovedRepo(t)
+	sha, err := GetCurrentCommitSHA()
+	require.NoError(t, err)
+
+	require.NoError(t, CheckoutBranch("target-branch"))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "old", "file.txt"), []byte("one\nzwei\nthree\n"), 0o644))
+	require.NoError(t, exec.Command("git", "commit", "-q", "-am", "Target branch change").Run())
+
+	result, err := CherryPickWithOptions(sha, CherryPickOptions{PathMappings: []PathMapping{{From: "new/", To: "old/"}}})
+	require.NoError(t, err)
+	require.True(t, result.HasConflict)
+	assert.Equal(t, []string{"old/file.txt"}, result.Conflicts)
+	assert.True(t, CherryPickInProgress())
+
+	require.NoError(t, CommitConflicts("Backport with conflicts", nil))
+	assert.False(t, CherryPickInProgress())
+}
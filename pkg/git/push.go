@@ -0,0 +1,84 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrPushRejected is returned if a push is rejected because the remote branch has commits the
+// local branch lacks, e.g. because the branch moved since it was fetched.
+var ErrPushRejected = errors.New("push rejected, the remote branch has new commits")
+
+// pushIn pushes a branch to the branch of the same name on remote, in dir or the current
+// directory if dir is empty.
+func pushIn(dir, remote, branch string) error {
+	cmd := exec.Command("git", "push", remote, "refs/heads/"+branch+":refs/heads/"+branch)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// Rejections by hooks or branch protection are reported as "remote rejected" and not retried.
+		if strings.Contains(string(output), "(fetch first)") || strings.Contains(string(output), "(non-fast-forward)") {
+			return fmt.Errorf("failed to push %s to %s: %w: %s - %w", branch, remote, ErrPushRejected, string(output), err)
+		}
+		return fmt.Errorf("failed to push %s to %s: %s - %w", branch, remote, string(output), err)
+	}
+	return nil
+}
+
+// ForcePushWithLease pushes a branch to the branch of the same name on remote, replacing its
+// commits. The lease is the remote branch as it was before the push, so commits pushed in
+// between are not lost but reported as ErrPushRejected. A missing remote branch is created.
+func ForcePushWithLease(remote, branch string) error {
+	ref := "refs/heads/" + branch
+	current, err := gitOutputIn("", "failed to look up "+branch+" on "+remote, "ls-remote", remote, ref)
+	if err != nil {
+		return err
+	}
+	expected, _, _ := strings.Cut(current, "\t")
+
+	cmd := exec.Command("git", "push", "--force-with-lease="+ref+":"+expected, remote, ref+":"+ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "(stale info)") {
+			return fmt.Errorf("failed to force-push %s to %s: %w: %s - %w", branch, remote, ErrPushRejected, string(output), err)
+		}
+		return fmt.Errorf("failed to force-push %s to %s: %s - %w", branch, remote, string(output), err)
+	}
+	return nil
+}
+
+// RebaseOnRemote fetches a branch from remote and rebases the local commits of the branch onto
+// it, so a push rejected with ErrPushRejected can be retried. A branch that is not checked out
+// is rebased in a temporary worktree. A conflicting rebase is aborted and leaves the branch unchanged.
+func RebaseOnRemote(remote, branch string) error {
+	return rebaseOnRemoteIn("", remote, branch)
+}
+
+// rebaseOnRemoteIn rebases branch onto the branch of remote in dir, or the current directory if dir is empty.
+func rebaseOnRemoteIn(dir, remote, branch string) error {
+	if _, err := gitOutputIn(dir, "failed to fetch "+branch+" from "+remote, "fetch", "--no-tags", remote, "refs/heads/"+branch); err != nil {
+		return err
+	}
+	tip, err := gitOutputIn(dir, "failed to resolve fetched "+branch, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+
+	workDir := dir
+	if head, _ := gitOutputIn(dir, "", "symbolic-ref", "--quiet", "--short", "HEAD"); head != branch {
+		worktree, err := addWorktreeIn(dir, branch)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = worktree.Remove() }()
+		workDir = worktree.Path
+	}
+
+	if _, err := gitOutputIn(workDir, "failed to rebase "+branch+" onto "+remote+"/"+branch, "rebase", tip); err != nil {
+		_, _ = gitOutputIn(workDir, "", "rebase", "--abort")
+		return err
+	}
+	return nil
+}
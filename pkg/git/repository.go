@@ -6,11 +6,16 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
+// ErrDetachedHead is returned by CurrentBranch if HEAD points to a commit instead of a branch,
+// e.g. in CI checkouts of a specific SHA.
+var ErrDetachedHead = errors.New("HEAD is not pointing to a branch")
+
 // Repository wraps go-git repository operations.
 type Repository struct {
 	repo *gogit.Repository
@@ -69,6 +74,16 @@ func ParseRemoteURL(url string) (owner, repo string, err error) {
 		return pathParts[0], pathParts[1], nil
 	}
 
+	// Handle SSH URLs with a scheme, as used by Gerrit: ssh://user@review.example.com:29418/owner/repo
+	if strings.HasPrefix(url, "ssh://") {
+		re := regexp.MustCompile(`^ssh://[^/]+/([^/]+)/([^/]+?)(?:\.git)?$`)
+		matches := re.FindStringSubmatch(url)
+		if len(matches) != 3 { //nolint:mnd
+			return "", "", fmt.Errorf("invalid SSH URL path: %s", url)
+		}
+		return matches[1], matches[2], nil
+	}
+
 	// Handle HTTPS URLs: https://github.com/owner/repo.git
 	re := regexp.MustCompile(`https?://[^/]+/([^/]+)/([^/]+?)(?:\.git)?$`)
 	matches := re.FindStringSubmatch(url)
@@ -79,6 +94,54 @@ func ParseRemoteURL(url string) (owner, repo string, err error) {
 	return matches[1], matches[2], nil
 }
 
+// ParseRemoteHost extracts the host of a git remote URL, without user and port,
+// e.g. "github.com" for git@github.com:owner/repo.git.
+func ParseRemoteHost(url string) (string, error) {
+	re := regexp.MustCompile(`^(?:(?:https?|ssh)://)?(?:[^@/]+@)?([^:/]+)`)
+	if strings.HasPrefix(url, "git@") || strings.Contains(url, "://") {
+		if matches := re.FindStringSubmatch(url); len(matches) == 2 { //nolint:mnd
+			return strings.ToLower(matches[1]), nil
+		}
+	}
+	return "", fmt.Errorf("remote URL has no host: %s", url)
+}
+
+// RewriteRemoteURL replaces owner and repo in a git remote URL, keeping its host and format.
+func RewriteRemoteURL(url, owner, repo string) (string, error) {
+	oldOwner, oldRepo, err := ParseRemoteURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	oldPath := oldOwner + "/" + oldRepo
+	idx := strings.LastIndex(url, oldPath)
+	if idx < 0 {
+		return "", fmt.Errorf("invalid remote URL: %s", url)
+	}
+
+	return url[:idx] + owner + "/" + repo + url[idx+len(oldPath):], nil
+}
+
+// SetRemoteURL replaces the URLs of a remote.
+func (r *Repository) SetRemoteURL(name, url string) error {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read repository config: %w", err)
+	}
+
+	remote, ok := cfg.Remotes[name]
+	if !ok {
+		return fmt.Errorf("remote %s not found", name)
+	}
+	remote.URLs = []string{url}
+
+	if err := r.repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to update remote %s: %w", name, err)
+	}
+
+	return nil
+}
+
 // CurrentBranch returns the name of the current branch.
 func (r *Repository) CurrentBranch() (string, error) {
 	head, err := r.repo.Head()
@@ -87,7 +150,22 @@ func (r *Repository) CurrentBranch() (string, error) {
 	}
 
 	if !head.Name().IsBranch() {
-		return "", fmt.Errorf("HEAD is not pointing to a branch")
+		return "", ErrDetachedHead
+	}
+
+	return head.Name().Short(), nil
+}
+
+// CurrentRef returns the current branch, or the commit SHA of a detached HEAD.
+// Either can be checked out again to return to the current state.
+func (r *Repository) CurrentRef() (string, error) {
+	head, err := r.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	if !head.Name().IsBranch() {
+		return head.Hash().String(), nil
 	}
 
 	return head.Name().Short(), nil
@@ -147,7 +225,32 @@ func (r *Repository) ListBranches() ([]string, error) {
 	return branches, err
 }
 
-// GetCommitSHA returns the SHA of a commit reference (branch name, tag, or SHA).
+// ListRemoteBranches returns the names of the remote-tracking branches of a remote,
+// without the remote prefix.
+func (r *Repository) ListRemoteBranches(remote string) ([]string, error) {
+	iter, err := r.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote branches: %w", err)
+	}
+
+	prefix := remote + "/"
+	var branches []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() {
+			return nil
+		}
+		name, ok := strings.CutPrefix(ref.Name().Short(), prefix)
+		if ok && name != "HEAD" {
+			branches = append(branches, name)
+		}
+		return nil
+	})
+
+	return branches, err
+}
+
+// GetCommitSHA returns the SHA of a commit reference (branch name, remote ref, tag, SHA
+// or a revision expression such as origin/main~2).
 func (r *Repository) GetCommitSHA(ref string) (string, error) {
 	hash, err := r.repo.ResolveRevision(plumbing.Revision(ref))
 	if err != nil {
@@ -168,6 +271,26 @@ func (r *Repository) GetCommitMessage(sha string) (string, error) {
 	return commit.Message, nil
 }
 
+// CommitTime returns the committer date of a commit.
+func (r *Repository) CommitTime(sha string) (time.Time, error) {
+	commit, err := r.repo.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return commit.Committer.When, nil
+}
+
+// ParentCount returns the number of parents of a commit, more than one for merge commits.
+func (r *Repository) ParentCount(sha string) (int, error) {
+	commit, err := r.repo.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	return commit.NumParents(), nil
+}
+
 // Inner returns the underlying go-git repository.
 func (r *Repository) Inner() *gogit.Repository {
 	return r.repo
@@ -4,8 +4,26 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestParseRemoteHost(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/owner/repo.git":                 "github.com",
+		"http://Codeberg.org/owner/repo":                    "codeberg.org",
+		"git@bitbucket.org:owner/repo.git":                  "bitbucket.org",
+		"ssh://user@review.example.com:29418/owner/repo":    "review.example.com",
+		"https://token@git.example.com:8443/owner/repo.git": "git.example.com",
+	} {
+		host, err := ParseRemoteHost(url)
+		require.NoError(t, err, url)
+		assert.Equal(t, want, host, url)
+	}
+
+	_, err := ParseRemoteHost("/srv/git/repo.git")
+	assert.Error(t, err)
+}
+
 func TestParseRemoteURL(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -56,6 +74,27 @@ func TestParseRemoteURL(t *testing.T) {
 			wantRepo:  "myrepo",
 			wantError: false,
 		},
+		{
+			name:      "Gerrit SSH URL",
+			url:       "ssh://user@review.example.com:29418/myorg/myrepo",
+			wantOwner: "myorg",
+			wantRepo:  "myrepo",
+			wantError: false,
+		},
+		{
+			name:      "Gerrit authenticated HTTPS URL",
+			url:       "https://review.example.com/a/myrepo",
+			wantOwner: "a",
+			wantRepo:  "myrepo",
+			wantError: false,
+		},
+		{
+			name:      "Invalid SSH URL with scheme too few parts",
+			url:       "ssh://review.example.com:29418/myrepo",
+			wantOwner: "",
+			wantRepo:  "",
+			wantError: true,
+		},
 		{
 			name:      "Invalid SSH URL missing colon",
 			url:       "git@github.com/owner/repo.git",
@@ -94,6 +133,28 @@ func TestParseRemoteURL(t *testing.T) {
 	}
 }
 
+func TestRewriteRemoteURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/owner/repo.git", "https://github.com/new-owner/new-repo.git"},
+		{"https://github.com/owner/repo", "https://github.com/new-owner/new-repo"},
+		{"git@github.com:owner/repo.git", "git@github.com:new-owner/new-repo.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := RewriteRemoteURL(tt.url, "new-owner", "new-repo")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := RewriteRemoteURL("not-a-url", "new-owner", "new-repo")
+	assert.Error(t, err)
+}
+
 func TestLooksLikeSHA(t *testing.T) {
 	tests := []struct {
 		input    string
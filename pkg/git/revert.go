@@ -0,0 +1,36 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// ResetHard moves the checked out branch to ref, discarding changes in the working copy.
+func ResetHard(ref string) error {
+	cmd := exec.Command("git", "reset", "--hard", ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to reset to %s: %s - %w", ref, string(output), err)
+	}
+	return nil
+}
+
+// Revert commits the revert of sha on the current branch and returns the SHA of the revert commit.
+// A conflicting revert is aborted.
+func Revert(sha string) (string, error) {
+	return revertIn("", sha)
+}
+
+// revertIn reverts sha in dir, or the current directory if dir is empty.
+func revertIn(dir, sha string) (string, error) {
+	cmd := exec.Command("git", "revert", "--no-edit", sha)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		abort := exec.Command("git", "revert", "--abort")
+		abort.Dir = dir
+		_ = abort.Run()
+		return "", fmt.Errorf("failed to revert %s: %s - %w", sha, string(output), err)
+	}
+	return headSHAIn(dir)
+}
@@ -0,0 +1,284 @@
+package git
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sandboxNoPush is the push URL of all sandbox remotes, it is no valid repository so pushes fail.
+const sandboxNoPush = "backporter-sandbox-no-push"
+
+// Sandbox is a throwaway clone of the current repository. It shares the objects of the
+// original through hard links and, unless created with SandboxOptions.Push, cannot push, so nothing
+// done in it reaches the repository or its remotes. Sandboxes made with CloneRemote are fresh
+// clones of a remote repository instead, see CloneOptions.
+type Sandbox struct {
+	Path string
+
+	prevDir string
+}
+
+// SandboxOptions configure a sandbox.
+type SandboxOptions struct {
+	// Allow pushing to the remotes of the original repository, e.g. backport branches for PRs.
+	Push bool
+}
+
+// NewSandbox clones the current repository into a temporary directory. The clone has the
+// branches, remote branches, remotes and commit identity of the original and the same HEAD checked out.
+func NewSandbox() (*Sandbox, error) {
+	return NewSandboxWithOptions(SandboxOptions{})
+}
+
+// NewSandboxWithOptions clones the current repository into a temporary directory, see NewSandbox.
+func NewSandboxWithOptions(opts SandboxOptions) (*Sandbox, error) {
+	top, err := gitOutputIn("", "failed to find repository root", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := os.MkdirTemp("", "backporter-sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	sandbox := &Sandbox{Path: path}
+
+	if err := sandbox.clone(top, opts); err != nil {
+		_ = os.RemoveAll(path)
+		return nil, err
+	}
+
+	return sandbox, nil
+}
+
+// CloneOptions configure CloneRemote.
+type CloneOptions struct {
+	// Name of the remote in the clone, defaults to origin.
+	Remote string
+
+	// Number of commits of history to fetch of each branch, 0 for the full history.
+	// Missing history is fetched later on, see EnsureCommit and EnsureMergeBase.
+	Depth int
+
+	// Token authenticating HTTPS fetches and pushes, sent as password of TokenUser. Without
+	// TokenUser the token is sent as is, for tokens that are "<user>:<password>" already.
+	Token     string
+	TokenUser string
+}
+
+// authHeader returns the HTTP basic authentication header of the token of opts.
+func (opts CloneOptions) authHeader() string {
+	credentials := opts.Token
+	if opts.TokenUser != "" {
+		credentials = opts.TokenUser + ":" + opts.Token
+	}
+	return "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
+// CloneRemote clones the repository at url with all its branches into a temporary directory,
+// for running without a checkout, e.g. in CI. The clone can fetch from and push to the remote.
+// The token is stored in the clone's config as an HTTP header for the host of url only.
+func CloneRemote(url string, opts CloneOptions) (*Sandbox, error) {
+	remote := opts.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+
+	path, err := os.MkdirTemp("", "backporter-clone-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	sandbox := &Sandbox{Path: path}
+
+	args := []string{"clone", "--quiet", "--origin", remote, "--no-single-branch"}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	var header, scope string
+	if opts.Token != "" {
+		scope, err = headerScope(url)
+		if err != nil {
+			_ = os.RemoveAll(path)
+			return nil, err
+		}
+		header = opts.authHeader()
+		args = append([]string{"-c", "http." + scope + ".extraHeader=" + header}, args...)
+	}
+	args = append(args, url, path)
+
+	if _, err := gitOutputIn("", "failed to clone "+url, args...); err != nil {
+		_ = os.RemoveAll(path)
+		return nil, err
+	}
+	if header != "" {
+		if _, err := gitOutputIn(path, "failed to configure clone", "config", "http."+scope+".extraHeader", header); err != nil {
+			_ = os.RemoveAll(path)
+			return nil, err
+		}
+	}
+
+	return sandbox, nil
+}
+
+// headerScope returns the URL prefix the authorization header of a clone applies to: the
+// scheme and host of url. Tokens are only sent over HTTPS.
+func headerScope(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("cannot authenticate clone of %s with a token, only HTTPS URLs are supported", rawURL)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("refusing to send a token over %s to %s, use an HTTPS URL", u.Scheme, u.Host)
+	}
+	return u.Scheme + "://" + u.Host + "/", nil
+}
+
+// clone fills the sandbox directory with a local clone of the repository at top. Objects are
+// hard-linked rather than borrowed with --shared, go-git does not read alternates.
+func (s *Sandbox) clone(top string, opts SandboxOptions) error {
+	if _, err := gitOutputIn("", "failed to clone repository into sandbox", "clone", "--quiet", "--local", "--no-checkout", top, s.Path); err != nil {
+		return err
+	}
+
+	// Replace the clone's origin (the local repository) with the remotes of the original,
+	// so missing commits are fetched from the forge like in the repository itself.
+	if _, err := gitOutputIn(s.Path, "failed to configure sandbox remotes", "remote", "remove", "origin"); err != nil {
+		return err
+	}
+	remotes, err := gitOutputIn("", "failed to list remotes", "remote")
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Fields(remotes) {
+		url, err := gitOutputIn("", "failed to get remote URL", "remote", "get-url", name)
+		if err != nil {
+			return err
+		}
+		if _, err := gitOutputIn(s.Path, "failed to configure sandbox remotes", "remote", "add", name, url); err != nil {
+			return err
+		}
+		if opts.Push {
+			continue
+		}
+		if _, err := gitOutputIn(s.Path, "failed to configure sandbox remotes", "remote", "set-url", "--push", name, sandboxNoPush); err != nil {
+			return err
+		}
+	}
+
+	if _, err := gitOutputIn(s.Path, "failed to copy refs into sandbox", "fetch", "--quiet", "--update-head-ok", top,
+		"+refs/heads/*:refs/heads/*", "+refs/remotes/*:refs/remotes/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		return err
+	}
+
+	// Commits made in the sandbox need the identity configured for the repository.
+	for _, key := range []string{"user.name", "user.email"} {
+		if value := GetConfigValue(key); value != "" {
+			if _, err := gitOutputIn(s.Path, "failed to configure sandbox", "config", key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	head, err := GetCurrentCommitSHA()
+	if err != nil {
+		return err
+	}
+	_, err = gitOutputIn(s.Path, "failed to check out sandbox", "reset", "--quiet", "--hard", head)
+	return err
+}
+
+// Enter changes the working directory to the sandbox, so subsequent git commands run in it.
+func (s *Sandbox) Enter() error {
+	prevDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if err := os.Chdir(s.Path); err != nil {
+		return fmt.Errorf("failed to enter sandbox %s: %w", s.Path, err)
+	}
+	s.prevDir = prevDir
+
+	return nil
+}
+
+// Log returns the commits between from and to, oldest first, with their full message, stats and diff.
+func (s *Sandbox) Log(from, to string) (string, error) {
+	return gitOutputIn(s.Path, "failed to show sandbox commits", "log", "--reverse", "--format=fuller", "--stat", "--patch", from+".."+to)
+}
+
+// Heads returns the commits of the local branches in the sandbox by branch name.
+func (s *Sandbox) Heads() (map[string]string, error) {
+	output, err := gitOutputIn(s.Path, "failed to list sandbox branches", "for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if branch, sha, ok := strings.Cut(line, " "); ok {
+			heads[branch] = sha
+		}
+	}
+	return heads, nil
+}
+
+// FormatPatch returns the commits reachable from tip but not from any of the excluded commits
+// as a patch series in mbox format, which `git am` applies.
+func (s *Sandbox) FormatPatch(tip string, exclude []string) (string, error) {
+	args := append([]string{"format-patch", "--stdout", tip, "--not"}, exclude...)
+	return gitOutputIn(s.Path, "failed to create patch", args...)
+}
+
+// Push pushes a branch of the sandbox to a remote of the original repository.
+// Requires a sandbox created with SandboxOptions.Push.
+func (s *Sandbox) Push(remote, branch string) error {
+	return pushIn(s.Path, remote, branch)
+}
+
+// RebaseOnRemote rebases a branch of the sandbox onto the branch of the remote, see RebaseOnRemote.
+func (s *Sandbox) RebaseOnRemote(remote, branch string) error {
+	return rebaseOnRemoteIn(s.Path, remote, branch)
+}
+
+// Leave returns to the working directory the sandbox was entered from, keeping the sandbox.
+func (s *Sandbox) Leave() error {
+	if s.prevDir == "" {
+		return nil
+	}
+	if err := os.Chdir(s.prevDir); err != nil {
+		return fmt.Errorf("failed to leave sandbox %s: %w", s.Path, err)
+	}
+	s.prevDir = ""
+	return nil
+}
+
+// Remove returns to the previous working directory and deletes the sandbox.
+func (s *Sandbox) Remove() error {
+	if err := s.Leave(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(s.Path); err != nil {
+		return fmt.Errorf("failed to remove sandbox %s: %w", s.Path, err)
+	}
+
+	return nil
+}
+
+// gitOutputIn runs a git command in dir, or the current directory if dir is empty, and returns
+// its output. Failures are wrapped with msg.
+func gitOutputIn(dir, msg string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s - %w", msg, string(output), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
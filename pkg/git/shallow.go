@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// deepenStep is the initial number of commits to deepen a shallow clone by.
+	deepenStep = 50
+	// maxDeepenAttempts is the number of (doubling) deepen attempts before unshallowing.
+	maxDeepenAttempts = 4
+)
+
+// DeepenResult describes what was fetched to make a merge base available.
+type DeepenResult struct {
+	Shallow     bool // True if the repository is a shallow clone
+	Deepened    int  // Total number of commits the history was deepened by
+	Unshallowed bool // True if the full history had to be fetched
+}
+
+// IsShallow checks if the current repository is a shallow clone.
+func IsShallow() bool {
+	cmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "true"
+}
+
+// HasMergeBase checks if two refs have a common ancestor in the local history.
+func HasMergeBase(a, b string) bool {
+	cmd := exec.Command("git", "merge-base", a, b)
+	return cmd.Run() == nil
+}
+
+// Deepen deepens the history of a shallow clone by the given number of commits.
+func Deepen(remote string, depth int) error {
+	cmd := exec.Command("git", "fetch", "--no-tags", "--deepen="+strconv.Itoa(depth), remote)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to deepen history from %s: %s - %w", remote, string(output), err)
+	}
+	return nil
+}
+
+// Unshallow fetches the complete history of a shallow clone.
+func Unshallow(remote string) error {
+	cmd := exec.Command("git", "fetch", "--no-tags", "--unshallow", remote)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to unshallow history from %s: %s - %w", remote, string(output), err)
+	}
+	return nil
+}
+
+// IsShallowCommit reports whether the parents of a commit are cut off by the shallow boundary
+// of the repository. Cherry-picking such a commit would apply its whole tree instead of its changes.
+func IsShallowCommit(sha string) bool {
+	path, err := exec.Command("git", "rev-parse", "--git-path", "shallow").Output()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(strings.TrimSpace(string(path)))
+	if err != nil {
+		return false
+	}
+	full, err := exec.Command("git", "rev-parse", "--verify", "-q", sha+"^{commit}").Output()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(strings.Fields(string(data)), strings.TrimSpace(string(full)))
+}
+
+// EnsureMergeBase makes sure the merge base of sha and target and the parents of sha are available
+// in a shallow clone, deepening the history step by step and fetching the full history as a last resort.
+// Repositories that are not shallow are left untouched.
+func EnsureMergeBase(remote, sha, target string) (*DeepenResult, error) {
+	result := &DeepenResult{Shallow: IsShallow()}
+	complete := func() bool {
+		return HasMergeBase(sha, target) && !IsShallowCommit(sha)
+	}
+	if !result.Shallow || complete() {
+		return result, nil
+	}
+
+	if remote == "" {
+		return result, fmt.Errorf("history of %s and %s incomplete in shallow clone and no remote configured", sha, target)
+	}
+
+	depth := deepenStep
+	for range maxDeepenAttempts {
+		if err := Deepen(remote, depth); err != nil {
+			return result, err
+		}
+		result.Deepened += depth
+		if complete() {
+			return result, nil
+		}
+		depth *= 2
+	}
+
+	if err := Unshallow(remote); err != nil {
+		return result, err
+	}
+	result.Unshallowed = true
+	if !HasMergeBase(sha, target) {
+		return result, fmt.Errorf("no merge base between %s and %s", sha, target)
+	}
+	if IsShallowCommit(sha) {
+		return result, fmt.Errorf("parents of %s not available from %s", sha, remote)
+	}
+
+	return result, nil
+}
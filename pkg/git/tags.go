@@ -0,0 +1,23 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// FirstTagContaining returns the oldest tag whose history contains the commit,
+// or an empty string if the commit has not been tagged yet.
+func FirstTagContaining(sha string) (string, error) {
+	cmd := exec.Command("git", "tag", "--contains", sha, "--sort=creatordate")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags containing %s: %w", sha, err)
+	}
+
+	tags := strings.Fields(string(output))
+	if len(tags) == 0 {
+		return "", nil
+	}
+	return tags[0], nil
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// AddTrailers adds trailers ("<key>: <value>") to a commit message with git interpret-trailers,
+// joining the existing trailer block if there is one.
+func AddTrailers(message string, trailers []string) (string, error) {
+	args := []string{"interpret-trailers"}
+	for _, trailer := range trailers {
+		args = append(args, "--trailer", trailer)
+	}
+	cmd := exec.Command("git", args...)
+	// Without a final newline the trailers would continue the last line of a one-line message.
+	cmd.Stdin = strings.NewReader(strings.TrimRight(message, "\n") + "\n")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to add trailers: %w", err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// addTrailersIn amends the HEAD commit in dir, or the current directory if dir is empty, adding
+// trailers to its message. The commit is committed with identity.
+func addTrailersIn(dir string, trailers []string, identity *Identity) error {
+	if len(trailers) == 0 {
+		return nil
+	}
+
+	show := exec.Command("git", "log", "-1", "--format=%B", "HEAD")
+	show.Dir = dir
+	output, err := show.Output()
+	if err != nil {
+		return fmt.Errorf("failed to get commit message: %w", err)
+	}
+	message, err := AddTrailers(strings.TrimSpace(string(output)), trailers)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", append(identity.amendArgs(), "--allow-empty", "-m", message)...)
+	cmd.Dir = dir
+	cmd.Env = identity.env()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to add trailers: %s - %w", string(output), err)
+	}
+	return nil
+}
+
+// FindCommitByTrailer returns the most recent commit on ref with a trailer key whose value is
+// value, or an empty string if there is none. Only the trailer block of the messages is parsed.
+func FindCommitByTrailer(ref, key, value string) (string, error) {
+	// Grepping for the value first narrows the commits whose trailers are parsed.
+	format := fmt.Sprintf("--format=%%H%%n%%(trailers:key=%s,valueonly)", key)
+	cmd := exec.Command("git", "log", "-z", format, "--fixed-strings", "--grep", value, ref, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to search commits on %s: %w", ref, err)
+	}
+
+	for record := range bytes.SplitSeq(output, []byte{0}) {
+		lines := strings.Split(strings.TrimSpace(string(record)), "\n")
+		if len(lines) > 1 && slices.Contains(lines[1:], value) {
+			return lines[0], nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// worktreeMu serializes adding and removing worktrees, git reads the administrative files of
+// all worktrees while adding one and fails on half-created ones.
+var worktreeMu sync.Mutex
+
+// Worktree is a temporary linked working tree of the current repository.
+type Worktree struct {
+	Path string
+
+	// Working copy of the repository the worktree was added to, "" for the current directory.
+	repoDir string
+	prevDir string
+}
+
+// AddWorktree checks out an existing branch in a new temporary worktree.
+// The branch must not be checked out anywhere else.
+func AddWorktree(branch string) (*Worktree, error) {
+	return addWorktreeIn("", branch)
+}
+
+// AddDetachedWorktree checks out ref with a detached HEAD in a new temporary worktree,
+// e.g. to try out changes that are thrown away along with the worktree.
+func AddDetachedWorktree(ref string) (*Worktree, error) {
+	return addWorktreeIn("", "--detach", ref)
+}
+
+// addWorktreeIn adds a worktree to the repository in dir, or the current directory if dir
+// is empty. The last argument is the branch or ref to check out, preceded by options.
+func addWorktreeIn(dir string, args ...string) (*Worktree, error) {
+	path, err := os.MkdirTemp("", "backporter-worktree-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	worktreeMu.Lock()
+	defer worktreeMu.Unlock()
+
+	ref := args[len(args)-1]
+	cmd := exec.Command("git", append([]string{"worktree", "add", path}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		_ = os.RemoveAll(path)
+		return nil, fmt.Errorf("failed to create worktree for %s: %s - %w", ref, string(output), err)
+	}
+
+	return &Worktree{Path: path, repoDir: dir}, nil
+}
+
+// Enter changes the working directory to the worktree, so subsequent git commands run in it.
+func (w *Worktree) Enter() error {
+	prevDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if err := os.Chdir(w.Path); err != nil {
+		return fmt.Errorf("failed to enter worktree %s: %w", w.Path, err)
+	}
+	w.prevDir = prevDir
+
+	return nil
+}
+
+// CherryPick performs a git cherry-pick in the worktree. Unlike Enter it leaves the working
+// directory of the process alone, so several worktrees can be used concurrently.
+func (w *Worktree) CherryPick(sha string, opts CherryPickOptions) (*CherryPickResult, error) {
+	return cherryPickIn(w.Path, sha, opts)
+}
+
+// AbortCherryPick aborts an in-progress cherry-pick in the worktree.
+func (w *Worktree) AbortCherryPick() error {
+	return abortCherryPickIn(w.Path)
+}
+
+// CommitConflicts commits a conflicting cherry-pick in the worktree, conflict markers included,
+// with identity.
+func (w *Worktree) CommitConflicts(message string, identity *Identity) error {
+	return commitConflictsIn(w.Path, message, identity)
+}
+
+// ChangedSince reports whether the files in the worktree differ from those of ref.
+func (w *Worktree) ChangedSince(ref string) (bool, error) {
+	cmd := exec.Command("git", "diff", "--quiet", ref, "--")
+	cmd.Dir = w.Path
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to compare worktree with %s: %w", ref, err)
+	}
+}
+
+// Revert commits the revert of sha in the worktree, see Revert.
+func (w *Worktree) Revert(sha string) (string, error) {
+	return revertIn(w.Path, sha)
+}
+
+// Remove returns to the previous working directory and deletes the worktree.
+func (w *Worktree) Remove() error {
+	if w.prevDir != "" {
+		if err := os.Chdir(w.prevDir); err != nil {
+			return fmt.Errorf("failed to leave worktree %s: %w", w.Path, err)
+		}
+		w.prevDir = ""
+	}
+
+	worktreeMu.Lock()
+	defer worktreeMu.Unlock()
+
+	cmd := exec.Command("git", "worktree", "remove", "--force", w.Path)
+	cmd.Dir = w.repoDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove worktree %s: %s - %w", w.Path, string(output), err)
+	}
+
+	return nil
+}
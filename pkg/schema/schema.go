@@ -0,0 +1,191 @@
+// Package schema provides the JSON Schemas of the files and JSON output of backporter,
+// and validates documents against them.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version is the version of the schemas, it is increased on incompatible changes.
+const Version = 1
+
+// Names of the schemas.
+const (
+	History  = "history"  // Backport cache, shared ledgers and `list --json`
+	Pending  = "pending"  // Backport stopped on conflicts
+	Deferred = "deferred" // Backports deferred during quiet hours
+	Badge    = "badge"    // `badge --format json`
+	Audit    = "audit"    // `audit --json`
+	Check    = "check"    // `backport check --json`
+)
+
+//go:embed v1/*.schema.json
+var files embed.FS
+
+// Names returns the names of all schemas, sorted.
+func Names() []string {
+	names := []string{History, Pending, Deferred, Badge, Audit, Check}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the JSON Schema with the given name.
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(fmt.Sprintf("v%d/%s.schema.json", Version, name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema: %s (must be one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
+
+// ValidationError is returned if a document does not match its schema.
+type ValidationError struct {
+	Schema   string
+	Problems []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("does not match the %s schema: %s", e.Schema, strings.Join(e.Problems, "; "))
+}
+
+// node is the subset of JSON Schema the backporter schemas use.
+type node struct {
+	Type                 any              `json:"type"` // A type or a list of types
+	Properties           map[string]*node `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties *bool            `json:"additionalProperties"`
+	Items                *node            `json:"items"`
+	Enum                 []any            `json:"enum"`
+	Minimum              *float64         `json:"minimum"`
+	Pattern              string           `json:"pattern"`
+	Format               string           `json:"format"`
+}
+
+// Validate checks that the JSON document data matches the schema with the given name.
+func Validate(name string, data []byte) error {
+	raw, err := Get(name)
+	if err != nil {
+		return err
+	}
+	var root node
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var problems []string
+	root.validate("$", doc, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Schema: name, Problems: problems}
+	}
+	return nil
+}
+
+// validate appends the problems of value at path to problems.
+func (n *node) validate(path string, value any, problems *[]string) {
+	if types := n.types(); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		*problems = append(*problems, fmt.Sprintf("%s must be %s, got %s", path, strings.Join(types, " or "), typeOf(value)))
+		return
+	}
+	if len(n.Enum) > 0 && !slices.Contains(n.Enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %v, got %v", path, n.Enum, value))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range n.Required {
+			if _, ok := v[key]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s is missing %s", path, key))
+			}
+		}
+		// Sorted, so problems are reported deterministically.
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			property, ok := n.Properties[key]
+			if !ok {
+				if n.AdditionalProperties != nil && !*n.AdditionalProperties {
+					*problems = append(*problems, fmt.Sprintf("%s has unknown property %s", path, key))
+				}
+				continue
+			}
+			property.validate(path+"."+key, v[key], problems)
+		}
+	case []any:
+		if n.Items != nil {
+			for i, item := range v {
+				n.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	case float64:
+		if n.Minimum != nil && v < *n.Minimum {
+			*problems = append(*problems, fmt.Sprintf("%s must be at least %v, got %v", path, *n.Minimum, v))
+		}
+	case string:
+		if n.Pattern != "" {
+			if re, err := regexp.Compile(n.Pattern); err == nil && !re.MatchString(v) {
+				*problems = append(*problems, fmt.Sprintf("%s must match %s, got %q", path, n.Pattern, v))
+			}
+		}
+		if n.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s must be a date-time, got %q", path, v))
+			}
+		}
+	}
+}
+
+// types returns the allowed types of the node.
+func (n *node) types() []string {
+	switch t := n.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// hasType checks if a decoded JSON value is of a JSON Schema type.
+func hasType(value any, t string) bool {
+	if t == "integer" {
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return typeOf(value) == t
+}
+
+// typeOf returns the JSON Schema type of a decoded JSON value.
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
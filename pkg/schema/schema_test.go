@@ -0,0 +1,77 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"codefloe.com/pat-s/backporter/pkg/backport"
+	"codefloe.com/pat-s/backporter/pkg/schema"
+)
+
+func TestSchemasMatchTypes(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{schema.History, []backport.CacheEntry{
+			{OriginalSHA: "aaa", BackportSHA: "bbb", TargetBranch: "release-1", Timestamp: now, Message: "fix: crash"},
+			{OriginalSHA: "ccc", BackportSHA: "ddd", TargetBranch: "release-1", PRNumber: 42, Timestamp: now, Message: "fix: leak", Release: "v1.0.1"},
+		}},
+		{schema.History, []backport.CacheEntry(nil)},
+		{schema.Pending, backport.PendingBackport{OriginalSHA: "aaa", TargetBranch: "release-1", TargetSHA: "bbb", PRNumber: 42, CreatePR: true, Base: "release-1"}},
+		{schema.Deferred, []backport.DeferredBackport{{Owner: "o", Repo: "r", PRNumber: 1, TargetBranches: []string{"release-1"}, DeferredAt: now}}},
+		{schema.Audit, []backport.AuditEntry{{
+			Time: now, Actor: "alice", Repo: "o/r", SourceSHA: "aaa", SourcePR: 42, TargetBranch: "release-1",
+			Result: backport.AuditSuccess, BackportSHA: "bbb", BackportPR: 43, Hash: strings.Repeat("a", 64),
+		}}},
+		{schema.Check, []backport.CheckResult{
+			{TargetBranch: "release-1", Outcome: backport.CheckClean},
+			{TargetBranch: "release-2", Outcome: backport.CheckConflict, ConflictSHA: "aaa", Conflicts: []string{"a.go"}},
+			{TargetBranch: "release-3", Outcome: backport.CheckError, Error: "branch not found"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			require.NoError(t, err)
+			assert.NoError(t, schema.Validate(tt.name, data))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := schema.Validate(schema.History, []byte(`[{"original_sha": "aaa", "backport_sha": 1, "timestamp": "yesterday", "message": "m", "extra": true}]`))
+
+	var validationErr *schema.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, schema.History, validationErr.Schema)
+	assert.Equal(t, []string{
+		"$[0] is missing target_branch",
+		"$[0].backport_sha must be string, got number",
+		"$[0] has unknown property extra",
+		"$[0].timestamp must be a date-time, got \"yesterday\"",
+	}, validationErr.Problems)
+
+	assert.Error(t, schema.Validate(schema.Pending, []byte(`{`)))
+	assert.Error(t, schema.Validate(schema.Pending, []byte(`{"original_sha": "a", "target_branch": "b", "target_sha": "c", "pr_number": 0}`)))
+	assert.Error(t, schema.Validate(schema.Badge, []byte(`{"schemaVersion": 1.5, "label": "l", "message": "m", "color": "brightgreen"}`)))
+	assert.Error(t, schema.Validate(schema.Check, []byte(`[{"target_branch": "release-1", "outcome": "maybe"}]`)))
+}
+
+func TestGet(t *testing.T) {
+	for _, name := range schema.Names() {
+		data, err := schema.Get(name)
+		require.NoError(t, err)
+		assert.True(t, json.Valid(data), name)
+	}
+
+	_, err := schema.Get("unknown")
+	assert.ErrorContains(t, err, "unknown schema")
+}
@@ -0,0 +1,283 @@
+// Package selfupdate replaces the running backporter binary with the latest release.
+package selfupdate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LatestReleaseURL is the release feed of the project's forge.
+const LatestReleaseURL = "https://codefloe.com/api/v1/repos/pat-s/backporter/releases/latest"
+
+// checksumsAsset is the release asset listing the SHA-256 checksums of all archives.
+const checksumsAsset = "checksums.txt"
+
+// binaryName is the name of the binary inside the release archives.
+const binaryName = "backporter"
+
+// ErrChecksumMismatch is returned if a downloaded archive does not match its published checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Release is a published release and its downloadable assets.
+type Release struct {
+	Version string            // Version without the "v" prefix of the tag
+	Assets  map[string]string // Download URLs by asset name
+}
+
+// Updater downloads and installs releases.
+type Updater struct {
+	FeedURL string
+	Client  *http.Client
+}
+
+// New creates an updater for the project's release feed.
+func New() *Updater {
+	return &Updater{FeedURL: LatestReleaseURL, Client: http.DefaultClient}
+}
+
+// forgejoRelease is the part of a Forgejo release response used for updates.
+type forgejoRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Latest returns the latest release.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	body, err := u.get(ctx, u.FeedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	defer body.Close()
+
+	var fr forgejoRelease
+	if err := json.NewDecoder(body).Decode(&fr); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+
+	release := &Release{
+		Version: strings.TrimPrefix(fr.TagName, "v"),
+		Assets:  make(map[string]string, len(fr.Assets)),
+	}
+	for _, asset := range fr.Assets {
+		release.Assets[asset.Name] = asset.URL
+	}
+
+	return release, nil
+}
+
+// AssetName returns the name of the release archive for a platform.
+func AssetName(goos, goarch string) (string, error) {
+	if goos == "windows" {
+		return "", fmt.Errorf("self-update is not supported on windows")
+	}
+	return fmt.Sprintf("%s_%s_%s.tar.gz", binaryName, goos, goarch), nil
+}
+
+// Install downloads the archive of the release for a platform, verifies its checksum and
+// replaces the binary at target with the one from the archive.
+func (u *Updater) Install(ctx context.Context, release *Release, goos, goarch, target string) error {
+	name, err := AssetName(goos, goarch)
+	if err != nil {
+		return err
+	}
+
+	archiveURL, ok := release.Assets[name]
+	if !ok {
+		return fmt.Errorf("release %s has no archive for %s/%s", release.Version, goos, goarch)
+	}
+	checksumsURL, ok := release.Assets[checksumsAsset]
+	if !ok {
+		return fmt.Errorf("release %s publishes no checksums, refusing to install it", release.Version)
+	}
+
+	want, err := u.checksum(ctx, checksumsURL, name)
+	if err != nil {
+		return err
+	}
+
+	archive, err := u.download(ctx, archiveURL)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, name, want, got)
+	}
+
+	binary, err := extractBinary(archive)
+	if err != nil {
+		return err
+	}
+
+	return replaceBinary(target, binary)
+}
+
+// checksum returns the published SHA-256 checksum of an asset.
+func (u *Updater) checksum(ctx context.Context, url, name string) (string, error) {
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums: %w", err)
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		// Lines are "<sha256>  <file>", as written by sha256sum.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+
+	return "", fmt.Errorf("no checksum published for %s", name)
+}
+
+// download returns the content at url.
+func (u *Updater) download(ctx context.Context, url string) ([]byte, error) {
+	body, err := u.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// get performs a GET request and returns the body of a successful response.
+func (u *Updater) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// extractBinary returns the backporter binary from a release archive.
+func extractBinary(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("archive contains no %s binary", binaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceBinary atomically replaces the file at target with binary.
+func replaceBinary(target string, binary []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+binaryName+"-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// IsNewer reports whether version latest is newer than current. Both are "major.minor.patch"
+// versions, optionally prefixed with "v". A release is newer than its pre-releases.
+// Versions that cannot be compared yield an error.
+func IsNewer(latest, current string) (bool, error) {
+	l, lPre, err := parseVersion(latest)
+	if err != nil {
+		return false, err
+	}
+	c, cPre, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i], nil
+		}
+	}
+	return cPre && !lPre, nil
+}
+
+// parseVersion parses a "major.minor.patch" version and reports whether it is a pre-release.
+// Build metadata is ignored.
+func parseVersion(v string) ([3]int, bool, error) {
+	var parsed [3]int
+
+	core, _, _ := strings.Cut(strings.TrimPrefix(v, "v"), "+")
+	core, pre, _ := strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != len(parsed) {
+		return parsed, false, fmt.Errorf("invalid version: %s", v)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false, fmt.Errorf("invalid version: %s", v)
+		}
+		parsed[i] = n
+	}
+
+	return parsed, pre != "", nil
+}
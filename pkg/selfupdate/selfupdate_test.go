@@ -0,0 +1,136 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchive returns a release archive containing a backporter binary with the given content.
+func testArchive(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "backporter", Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+// testReleaseServer serves a release feed with a linux/amd64 archive and its checksum.
+func testReleaseServer(t *testing.T, archive []byte, checksum string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			fmt.Fprintf(w, `{"tag_name": "v1.3.0", "assets": [
+				{"name": "backporter_linux_amd64.tar.gz", "browser_download_url": "%[1]s/dl/archive"},
+				{"name": "checksums.txt", "browser_download_url": "%[1]s/dl/checksums"}
+			]}`, server.URL)
+		case "/dl/archive":
+			_, _ = w.Write(archive)
+		case "/dl/checksums":
+			fmt.Fprintf(w, "0000  backporter_darwin_arm64.tar.gz\n%s  backporter_linux_amd64.tar.gz\n", checksum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestInstall(t *testing.T) {
+	archive := testArchive(t, "new binary")
+	sum := sha256.Sum256(archive)
+	server := testReleaseServer(t, archive, hex.EncodeToString(sum[:]))
+
+	updater := &Updater{FeedURL: server.URL + "/releases/latest", Client: server.Client()}
+	release, err := updater.Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", release.Version)
+
+	target := filepath.Join(t.TempDir(), "backporter")
+	require.NoError(t, os.WriteFile(target, []byte("old binary"), 0o755))
+
+	require.NoError(t, updater.Install(context.Background(), release, "linux", "amd64", target))
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new binary", string(content))
+
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	// Platforms without an archive are reported.
+	require.Error(t, updater.Install(context.Background(), release, "darwin", "amd64", target))
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	server := testReleaseServer(t, testArchive(t, "tampered binary"), "deadbeef")
+
+	updater := &Updater{FeedURL: server.URL + "/releases/latest", Client: server.Client()}
+	release, err := updater.Latest(context.Background())
+	require.NoError(t, err)
+
+	target := filepath.Join(t.TempDir(), "backporter")
+	require.NoError(t, os.WriteFile(target, []byte("old binary"), 0o755))
+
+	err = updater.Install(context.Background(), release, "linux", "amd64", target)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "old binary", string(content))
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"1.3.0", "1.2.9", true},
+		{"v1.10.0", "1.9.0", true},
+		{"1.3.0", "1.3.0", false},
+		{"1.2.0", "1.3.0", false},
+		{"2.0.0", "2.0.0-rc.1", true},
+		{"2.0.0-rc.2", "2.0.0", false},
+	}
+
+	for _, tt := range tests {
+		got, err := IsNewer(tt.latest, tt.current)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s > %s", tt.latest, tt.current)
+	}
+
+	_, err := IsNewer("1.3.0", "dev")
+	assert.Error(t, err)
+}
+
+func TestAssetName(t *testing.T) {
+	name, err := AssetName("linux", "arm64")
+	require.NoError(t, err)
+	assert.Equal(t, "backporter_linux_arm64.tar.gz", name)
+
+	_, err = AssetName("windows", "amd64")
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+// Package telemetry reports anonymous usage events to an opt-in endpoint.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// Outcomes of a command.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeConflict = "conflict"
+	OutcomeError    = "error"
+)
+
+// sendTimeout bounds the time spent reporting an event, telemetry must never slow down a command.
+const sendTimeout = 2 * time.Second
+
+// Event is an anonymous usage event. It deliberately carries no repository, user, branch
+// or commit information.
+type Event struct {
+	Command    string `json:"command"`
+	Forge      string `json:"forge,omitempty"`
+	Outcome    string `json:"outcome"`
+	DurationMS int64  `json:"duration_ms"`
+	CI         bool   `json:"ci"`
+	Version    string `json:"version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+}
+
+// NewEvent creates an event for a command that ran for the given duration.
+func NewEvent(command, forgeType, outcome, version string, ci bool, duration time.Duration) Event {
+	return Event{
+		Command:    command,
+		Forge:      forgeType,
+		Outcome:    outcome,
+		DurationMS: duration.Milliseconds(),
+		CI:         ci,
+		Version:    version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+	}
+}
+
+// Client posts usage events to an endpoint.
+type Client struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// New creates a client for the given endpoint.
+func New(endpoint string) *Client {
+	return &Client{Endpoint: endpoint, Client: http.DefaultClient}
+}
+
+// Send posts an event as JSON.
+func (c *Client) Send(ctx context.Context, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send usage event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to send usage event: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := NewEvent("backport pr", "github", OutcomeConflict, "1.2.3", true, 1500*time.Millisecond)
+	require.NoError(t, New(server.URL).Send(context.Background(), event))
+
+	assert.Equal(t, "backport pr", received["command"])
+	assert.Equal(t, "github", received["forge"])
+	assert.Equal(t, OutcomeConflict, received["outcome"])
+	assert.InDelta(t, 1500, received["duration_ms"], 0)
+	assert.Equal(t, true, received["ci"])
+	assert.Len(t, received, 8)
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := New(server.URL).Send(context.Background(), Event{})
+	assert.ErrorContains(t, err, "500")
+}
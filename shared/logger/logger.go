@@ -41,7 +41,9 @@ func SetupGlobalLogger(_ context.Context, c *cli.Command) error {
 	pretty := c.Bool("pretty")
 	noColor := c.Bool("nocolor")
 
-	var out io.Writer = os.Stderr
+	// Tokens must never end up in logs, including error texts from forges and git.
+	registerEnvSecrets()
+	var out io.Writer = redactWriter{out: os.Stderr}
 
 	log.Logger = zerolog.New(out).With().Timestamp().Logger()
 
@@ -64,6 +66,13 @@ func SetupGlobalLogger(_ context.Context, c *cli.Command) error {
 		log.Logger = log.With().Caller().Logger()
 	}
 
+	// Strict mode fails on warnings, they must not be hidden by the log level.
+	if c.Bool("strict") && zerolog.GlobalLevel() > zerolog.WarnLevel {
+		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	}
+	warnings.count.Store(0)
+	log.Logger = log.Logger.Hook(&warnings)
+
 	return nil
 }
 
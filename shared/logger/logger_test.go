@@ -1,8 +1,10 @@
 package logger
 
 import (
+	"io"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,3 +50,15 @@ func TestIsCI(t *testing.T) {
 		})
 	}
 }
+
+func TestWarningCounter(t *testing.T) {
+	var counter warningCounter
+	logger := zerolog.New(io.Discard).Hook(&counter)
+
+	logger.Info().Msg("backporting PR")
+	logger.Warn().Msg("failed to cache backport entry")
+	logger.Error().Msg("backport failed")
+	logger.Debug().Msg("PR labels")
+
+	assert.Equal(t, int64(2), counter.count.Load())
+}
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// warnings counts the warnings and errors logged, see Warnings.
+var warnings warningCounter
+
+// warningCounter is a zerolog hook counting the events at warning level and above.
+type warningCounter struct {
+	count atomic.Int64
+}
+
+// Run implements zerolog.Hook.
+func (w *warningCounter) Run(_ *zerolog.Event, level zerolog.Level, _ string) {
+	if level >= zerolog.WarnLevel && level < zerolog.NoLevel {
+		w.count.Add(1)
+	}
+}
+
+// Warnings returns the number of warnings and errors logged since the logger was set up.
+func Warnings() int {
+	return int(warnings.count.Load())
+}
@@ -22,7 +22,30 @@ func Full() string {
 	return fmt.Sprintf("backporter %s (%s)", Version, GitURL)
 }
 
-// SignatureMessage returns the message to append to backport commits.
+// Trailers recording the provenance of backport commits.
+const (
+	TrailerBackportedFrom = "Backported-from" // The original commit
+	TrailerBackportOf     = "Backport-of"     // The original PR, as #<number>
+	TrailerBackportTool   = "Backport-tool"   // The backporter version that made the backport
+)
+
+// SignatureTrailers returns the trailers to add to backports of a commit, as "<key>: <value>".
+// The PR is left out if prNumber is 0.
+func SignatureTrailers(originalSHA string, prNumber int) []string {
+	trailers := []string{TrailerBackportedFrom + ": " + originalSHA}
+	if prNumber > 0 {
+		trailers = append(trailers, fmt.Sprintf("%s: #%d", TrailerBackportOf, prNumber))
+	}
+	return append(trailers, fmt.Sprintf("%s: backporter %s", TrailerBackportTool, Version))
+}
+
+// SignatureMessage returns the free-text signature older versions appended to backport commits.
 func SignatureMessage(originalSHA string) string {
-	return fmt.Sprintf("Backported from %s using backporter %s (%s)", originalSHA, Version, GitURL)
+	return fmt.Sprintf("%s using backporter %s (%s)", SignatureMarker(originalSHA), Version, GitURL)
+}
+
+// SignatureMarker returns the version independent part of the free-text signature of backports
+// of a commit, still recognized for backports made by older versions.
+func SignatureMarker(originalSHA string) string {
+	return "Backported from " + originalSHA
 }
@@ -44,3 +44,20 @@ func TestSignatureMessage(t *testing.T) {
 	assert.Contains(t, msg, GitURL)
 	assert.Contains(t, msg, "Backported from")
 }
+
+func TestSignatureTrailers(t *testing.T) {
+	original := Version
+	defer func() { Version = original }()
+	Version = "2.0.0"
+
+	assert.Equal(t, []string{
+		"Backported-from: abc123def456",
+		"Backport-of: #42",
+		"Backport-tool: backporter 2.0.0",
+	}, SignatureTrailers("abc123def456", 42))
+
+	assert.Equal(t, []string{
+		"Backported-from: abc123def456",
+		"Backport-tool: backporter 2.0.0",
+	}, SignatureTrailers("abc123def456", 0))
+}